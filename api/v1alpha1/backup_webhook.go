@@ -0,0 +1,135 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// log is for logging in this package.
+var backuplog = logf.Log.WithName("backup-resource")
+
+func (r *Backup) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	webhookReader = mgr.GetAPIReader()
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+// The backups of v1alpha1 and v1beta1 share the CRD without conversion, the webhooks match the
+// version exactly so that the requests are not validated by the webhook of the other version.
+//+kubebuilder:webhook:path=/validate-mysql-radondb-com-v1alpha1-backup,mutating=false,failurePolicy=fail,sideEffects=None,groups=mysql.radondb.com,resources=backups,verbs=create;update,versions=v1alpha1,name=vbackup.v1alpha1.kb.io,matchPolicy=Exact,admissionReviewVersions=v1
+
+var _ webhook.Validator = &Backup{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *Backup) ValidateCreate() error {
+	backuplog.Info("validate create", "name", r.Name)
+
+	return r.validateBackup()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *Backup) ValidateUpdate(old runtime.Object) error {
+	backuplog.Info("validate update", "name", r.Name)
+
+	if !r.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	return r.validateBackup()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *Backup) ValidateDelete() error {
+	backuplog.Info("validate delete", "name", r.Name)
+
+	return nil
+}
+
+func (r *Backup) validateBackup() error {
+	if r.Spec.ClusterName == "" {
+		return r.forbidden(fmt.Errorf("clusterName must not be empty"))
+	}
+	if err := r.validateHostName(); err != nil {
+		return r.forbidden(err)
+	}
+	if err := r.validateNFSServerAddress(); err != nil {
+		return r.forbidden(err)
+	}
+	if r.Spec.HistoryLimit != nil && *r.Spec.HistoryLimit < 0 {
+		return r.forbidden(fmt.Errorf("historyLimit must not be negative"))
+	}
+	if webhookReader == nil {
+		return nil
+	}
+
+	cluster := &MysqlCluster{}
+	if err := webhookReader.Get(context.TODO(), client.ObjectKey{Name: r.Spec.ClusterName, Namespace: r.Namespace}, cluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return r.forbidden(fmt.Errorf("the cluster %s is not found", r.Spec.ClusterName))
+		}
+		return err
+	}
+	return nil
+}
+
+// validateHostName checks the host to take the backup is a pod of the cluster, such as sample-mysql-0.
+func (r *Backup) validateHostName() error {
+	if r.Spec.HostName == "" {
+		return nil
+	}
+	pattern := fmt.Sprintf(`^%s-mysql-\d+$`, regexp.QuoteMeta(r.Spec.ClusterName))
+	// The host may be the FQDN of the pod.
+	name := strings.SplitN(r.Spec.HostName, ".", 2)[0]
+	if !regexp.MustCompile(pattern).MatchString(name) {
+		return fmt.Errorf("hostName %s is not a pod of the cluster %s", r.Spec.HostName, r.Spec.ClusterName)
+	}
+	return nil
+}
+
+// validateNFSServerAddress checks the address of the NFS server is in the format of `host[:/path]`.
+func (r *Backup) validateNFSServerAddress() error {
+	addr := r.Spec.NFSServerAddress
+	if addr == "" {
+		return nil
+	}
+	res := strings.Split(addr, ":")
+	switch {
+	case len(res) > 2:
+		return fmt.Errorf("nfsServerAddress %s must be in the format of host[:/path]", addr)
+	case res[0] == "":
+		return fmt.Errorf("the host of nfsServerAddress %s must not be empty", addr)
+	case len(res) == 2 && !strings.HasPrefix(res[1], "/"):
+		return fmt.Errorf("the path of nfsServerAddress %s must be absolute", addr)
+	}
+	return nil
+}
+
+func (r *Backup) forbidden(err error) error {
+	return apierrors.NewForbidden(schema.GroupResource{Group: GroupVersion.Group, Resource: "backups"}, r.Name, err)
+}
@@ -55,6 +55,26 @@ type MysqlClusterSpec struct {
 	// +kubebuilder:default:={image: "radondb/xenon:v3.0.0", admitDefeatHearbeatCount: 5, electionTimeout: 10000, resources: {limits: {cpu: "100m", memory: "256Mi"}, requests: {cpu: "50m", memory: "128Mi"}}}
 	XenonOpts XenonOpts `json:"xenonOpts,omitempty"`
 
+	// ReplicationMode is the replication mode between the leader and the followers.
+	// semiSync: wait for the followers' ack, degrade to async when they are unavailable or time out.
+	// semiSyncStrict: wait for the followers' ack and never degrade.
+	// async: do not wait for the followers.
+	// +optional
+	// +kubebuilder:validation:Enum=semiSync;semiSyncStrict;async
+	// +kubebuilder:default:="semiSync"
+	ReplicationMode string `json:"replicationMode,omitempty"`
+
+	// SemiSync is the options of semi-synchronous replication, it takes effect
+	// when ReplicationMode is semiSync or semiSyncStrict.
+	// +optional
+	// +kubebuilder:default:={waitForSlaveCount: 1}
+	SemiSync SemiSyncOpts `json:"semiSync,omitempty"`
+
+	// UpdateStrategy defines how the pods are updated when the statefulset changes.
+	// +optional
+	// +kubebuilder:default:={maxUnavailable: 1}
+	UpdateStrategy UpdateStrategy `json:"updateStrategy,omitempty"`
+
 	// MetricsOpts is the options of metrics container.
 	// +optional
 	// +kubebuilder:default:={image: "prom/mysqld-exporter:v0.12.1", resources: {limits: {cpu: "100m", memory: "128Mi"}, requests: {cpu: "10m", memory: "32Mi"}}, enabled: false}
@@ -91,10 +111,36 @@ type MysqlClusterSpec struct {
 	// +optional
 	RestorePoint string `json:"restorePoint"`
 
+	// RestoreTarget is the point to restore data by GTID or binlog position, it can also skip
+	// the transactions when applying the binlogs.
+	// +optional
+	RestoreTarget *RestoreTarget `json:"restoreTarget,omitempty"`
+
 	// Represents NFS ip address where cluster restore from.
 	// +optional
 	NFSServerAddress string `json:"nfsServerAddress,omitempty"`
 
+	// Represents the name of the secret that contains the keys to decrypt
+	// the backup which the cluster restore from.
+	// +optional
+	RestoreEncryptionKeySecret string `json:"restoreEncryptionKeySecret,omitempty"`
+
+	// Represents the algorithm which compresses the backup the cluster restore from, it is
+	// detected from the backup if not set. Mysql 5.7 only supports qpress.
+	// +optional
+	// +kubebuilder:validation:Enum=qpress;lz4;zstd
+	RestoreCompression string `json:"restoreCompression,omitempty"`
+
+	// Represents the number of threads to download, decrypt and decompress the backup, defaults to 10.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	RestoreParallel int32 `json:"restoreParallel,omitempty"`
+
+	// Represents the memory used by xtrabackup to prepare the backup, defaults to 3072M.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[0-9]+[KMG]?$`
+	RestorePrepareMemory string `json:"restorePrepareMemory,omitempty"`
+
 	// Specify under crontab format interval to take backups
 	// leave it empty to deactivate the backup process
 	// Defaults to ""
@@ -114,6 +160,17 @@ type MysqlClusterSpec struct {
 	// +optional
 	TlsSecretName string `json:"tlsSecretName,omitempty"`
 
+	// AutoTLS makes the operator generate the CA and server certificate for SSL, and rotate them
+	// before they expire. It is ignored if the TLS secret is specified.
+	// +optional
+	AutoTLS *AutoTLSOpts `json:"autoTLS,omitempty"`
+
+	// EncryptInTransit encrypts the replication, the backup streaming between the nodes and the
+	// requests from the operator to xenon with the TLS certificates, it requires SSL is enabled.
+	// The custom certificate should cover the names of the services and pods, like the generated one.
+	// +optional
+	EncryptInTransit bool `json:"encryptInTransit,omitempty"`
+
 	// Bootstraping from remote data source
 	// +optional
 	SourceConfig *corev1.SecretProjection `json:"sourceConfig,omitempty"`
@@ -128,6 +185,137 @@ type MysqlClusterSpec struct {
 	// +optional
 	// +kubebuilder:default:0
 	ServerIDOffset int `json:"serverIDOffset,omitempty"`
+
+	// AllowedUserNamespaces selects the namespaces whose MysqlUsers, MysqlDatabases and MysqlRoles
+	// can be created in the cluster.
+	// All the namespaces are allowed if not set.
+	// +optional
+	AllowedUserNamespaces *AllowedNamespaces `json:"allowedUserNamespaces,omitempty"`
+
+	// RotateInternalCredentials rotates the passwords of the internal accounts periodically,
+	// the rotation can also be triggered by changing the annotation
+	// mysql.radondb.com/rotate-internal-credentials of the cluster.
+	// +optional
+	RotateInternalCredentials *CredentialsRotationOpts `json:"rotateInternalCredentials,omitempty"`
+
+	// BinlogArchive streams the binlogs from the leader and archives them to S3 continuously,
+	// the archive follows the new leader after a failover.
+	// +optional
+	BinlogArchive *BinlogArchiveOpts `json:"binlogArchive,omitempty"`
+}
+
+// CredentialsRotationOpts defines the periodic rotation of the internal credentials.
+type CredentialsRotationOpts struct {
+	// IntervalDays is the days between two rotations.
+	// +kubebuilder:validation:Minimum=1
+	IntervalDays int32 `json:"intervalDays"`
+}
+
+// RestoreTarget is the point the binlogs are applied to after the backup is restored,
+// only one of the stop GTID, the stop file and the restore point can be set.
+type RestoreTarget struct {
+	// StopGTID stops applying the binlogs before the transaction, such as
+	// "3e11fa47-71ca-11e1-9e33-c80aa9429562:23".
+	// +optional
+	// +kubebuilder:validation:Pattern=`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}:[0-9]+$`
+	StopGTID string `json:"stopGTID,omitempty"`
+
+	// Inclusive applies the transaction of StopGTID too.
+	// +optional
+	Inclusive bool `json:"inclusive,omitempty"`
+
+	// StopFile is the name of the binlog on the server which wrote it, the binlogs are applied
+	// until StopPosition of the file.
+	// +optional
+	StopFile string `json:"stopFile,omitempty"`
+
+	// StopPosition is the position in StopFile, the event at the position is not applied.
+	// +optional
+	// +kubebuilder:validation:Minimum=4
+	StopPosition int64 `json:"stopPosition,omitempty"`
+
+	// SkipGTIDs is the set of the transactions not applied, such as a bad DROP TABLE.
+	// +optional
+	SkipGTIDs string `json:"skipGTIDs,omitempty"`
+
+	// BinlogArchivePrefix applies the binlogs archived continuously under the prefix of the backup
+	// bucket, instead of the binlogs uploaded with the backup.
+	// +optional
+	BinlogArchivePrefix string `json:"binlogArchivePrefix,omitempty"`
+}
+
+// BinlogArchiveOpts defines the continuous archiving of the binlogs.
+type BinlogArchiveOpts struct {
+	// Enabled represents if start the binlog archiver.
+	// +optional
+	// +kubebuilder:default:=true
+	Enabled bool `json:"enabled,omitempty"`
+
+	// S3SecretName is the name of the secret that contains the S3 endpoint, bucket and
+	// credentials, in the same format as the backup secret.
+	S3SecretName string `json:"s3SecretName"`
+
+	// Prefix is the prefix of the archived objects in the bucket, defaults to "<cluster>-binlogs/".
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+
+	// FlushIntervalSeconds is the longest time a transaction waits in the current binlog of the
+	// leader, the archiver flushes the binlogs when it expires so that the binlog is archived.
+	// +optional
+	// +kubebuilder:default:=30
+	// +kubebuilder:validation:Minimum=10
+	FlushIntervalSeconds int32 `json:"flushIntervalSeconds,omitempty"`
+
+	// The compute resource requirements of the archiver.
+	// +optional
+	// +kubebuilder:default:={limits: {cpu: "200m", memory: "256Mi"}, requests: {cpu: "10m", memory: "32Mi"}}
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// AutoTLSOpts defines the certificates generated by the operator.
+type AutoTLSOpts struct {
+	// Enabled enables the certificates generated by the operator.
+	// +optional
+	// +kubebuilder:default:=true
+	Enabled bool `json:"enabled,omitempty"`
+
+	// CertValidityDays is the validity of the server certificate.
+	// +optional
+	// +kubebuilder:default:=90
+	// +kubebuilder:validation:Minimum=1
+	CertValidityDays int32 `json:"certValidityDays,omitempty"`
+
+	// RenewBeforeDays is the days before the expiry to renew the server certificate,
+	// it should be less than the certValidityDays.
+	// +optional
+	// +kubebuilder:default:=30
+	// +kubebuilder:validation:Minimum=1
+	RenewBeforeDays int32 `json:"renewBeforeDays,omitempty"`
+}
+
+// NamespacesFrom specifies the namespaces allowed to reference the cluster.
+type NamespacesFrom string
+
+const (
+	// NamespacesFromAll allows all the namespaces.
+	NamespacesFromAll NamespacesFrom = "All"
+	// NamespacesFromSame only allows the namespace of the cluster.
+	NamespacesFromSame NamespacesFrom = "Same"
+	// NamespacesFromSelector allows the namespace of the cluster and the namespaces selected by the selector.
+	NamespacesFromSelector NamespacesFrom = "Selector"
+)
+
+// AllowedNamespaces defines the namespaces allowed to reference the cluster.
+type AllowedNamespaces struct {
+	// From is the namespaces allowed, one of All, Same and Selector.
+	// +optional
+	// +kubebuilder:default:="Same"
+	// +kubebuilder:validation:Enum=All;Same;Selector
+	From NamespacesFrom `json:"from,omitempty"`
+
+	// Selector selects the namespaces by labels, it only works with the `Selector` from.
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
 }
 
 // ReadOnly define the ReadOnly pods
@@ -256,12 +444,79 @@ type XenonOpts struct {
 	// +kubebuilder:default:=false
 	EnableAutoRebuild bool `json:"enableAutoRebuild,omitempty"`
 
+	// The max seconds to wait for the candidate applying all the leader's transactions
+	// before promoting it to leader. The leader is read-only during the wait, and the
+	// promotion is aborted after timeout.
+	// +optional
+	// +kubebuilder:default:=30
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=300
+	PromoteCatchUpTimeout *int32 `json:"promoteCatchUpTimeout,omitempty"`
+
 	// The compute resource requirements.
 	// +optional
 	// +kubebuilder:default:={limits: {cpu: "100m", memory: "256Mi"}, requests: {cpu: "50m", memory: "128Mi"}}
 	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
 }
 
+// SemiSyncOpts defines the options of semi-synchronous replication.
+type SemiSyncOpts struct {
+	// The number of followers acks the leader must receive per transaction before proceeding,
+	// see rpl_semi_sync_master_wait_for_slave_count.
+	// +optional
+	// +kubebuilder:default:=1
+	// +kubebuilder:validation:Minimum=1
+	WaitForSlaveCount *int32 `json:"waitForSlaveCount,omitempty"`
+
+	// The milliseconds the leader waits for the followers' ack before degrading to async,
+	// see rpl_semi_sync_master_timeout. Only used in semiSync mode, if not set the leader
+	// waits until Xenon degrades it.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	Timeout *int64 `json:"timeout,omitempty"`
+}
+
+// UpdateStrategy defines the rolling update strategy of the pods.
+// The followers are updated before the leader.
+type UpdateStrategy struct {
+	// Partition indicates the ordinal at which the pods should be updated.
+	// The pods with an ordinal less than Partition keep the current revision.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	Partition *int32 `json:"partition,omitempty"`
+
+	// MaxUnavailable is the max number of followers updated at the same time.
+	// The leader is always updated alone.
+	// +optional
+	// +kubebuilder:default:=1
+	// +kubebuilder:validation:Minimum=1
+	MaxUnavailable *int32 `json:"maxUnavailable,omitempty"`
+
+	// Paused stops the rolling update, the pods not updated keep the current revision.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+
+	// Canary updates one follower first and watches it for a soak period,
+	// the others are updated only if the canary is healthy.
+	// +optional
+	Canary *CanaryOpts `json:"canary,omitempty"`
+}
+
+// CanaryOpts defines the options of the canary update.
+type CanaryOpts struct {
+	// SoakSeconds is the seconds to watch the canary before updating the others.
+	// +optional
+	// +kubebuilder:default:=300
+	// +kubebuilder:validation:Minimum=0
+	SoakSeconds int32 `json:"soakSeconds,omitempty"`
+
+	// MaxLagSeconds is the max replication lag of the canary during the soak period.
+	// +optional
+	// +kubebuilder:default:=30
+	// +kubebuilder:validation:Minimum=0
+	MaxLagSeconds int32 `json:"maxLagSeconds,omitempty"`
+}
+
 // MetricsOpts defines the options of metrics container.
 type MetricsOpts struct {
 	// To specify the image that will be used for metrics container.
@@ -409,6 +664,7 @@ const (
 	ClIndexScaleIn
 	ClIndexScaleOut
 	ClIndexRemoteSlave
+	ClIndexPromoteBlocked
 )
 const (
 	// ConditionInit indicates whether the cluster is initializing.
@@ -427,6 +683,9 @@ const (
 	ConditionScaleOut ClusterConditionType = "ScaleOut"
 	// is it a slave for Remote cluster?
 	ConditionRemoteSlave ClusterConditionType = "RemoteSlave"
+	// ConditionPromoteBlocked indicates whether the last promotion was aborted because
+	// the candidate could not catch up the leader's transactions.
+	ConditionPromoteBlocked ClusterConditionType = "PromoteBlocked"
 )
 
 // ClusterCondition defines type for cluster conditions.
@@ -450,6 +709,16 @@ type NodeStatus struct {
 	Name string `json:"name"`
 	// Full text reason for current status of the node.
 	Message string `json:"message,omitempty"`
+	// Version is the MySQL server version running on the node.
+	// +optional
+	Version string `json:"version,omitempty"`
+	// PendingRestart lists the changed static variables which take effect after the node restarts.
+	// +optional
+	PendingRestart []string `json:"pendingRestart,omitempty"`
+	// AppliedVariables are the dynamic variables whose values are ensured online by the operator,
+	// they are reset to the default values after removed from the configs.
+	// +optional
+	AppliedVariables map[string]string `json:"appliedVariables,omitempty"`
 	// RaftStatus is the raft status of the node.
 	RaftStatus RaftStatus `json:"raftStatus,omitempty"`
 	// (RO) ReadOnly Status
@@ -482,6 +751,9 @@ type NodeCondition struct {
 	Status corev1.ConditionStatus `json:"status"`
 	// The last time this Condition type changed.
 	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+	// Message explains the condition, such as the drifted variables.
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 // The index of the NodeStatus.Conditions.
@@ -518,8 +790,156 @@ const (
 	NodeConditionRoSemiClose NodeConditionType = "RoSemiClose"
 	// ReadOnly Pod Ready
 	NodeConditionRoReplicating NodeConditionType = "RoReplicating"
+	// NodeConditionConfigDrift represents if the live variables differ from the configs.
+	NodeConditionConfigDrift NodeConditionType = "ConfigDrift"
+)
+
+// CanaryPhase is the phase of the canary update.
+type CanaryPhase string
+
+const (
+	// CanarySoaking means the canary has been updated and is being watched.
+	CanarySoaking CanaryPhase = "Soaking"
+	// CanaryPassed means the canary is healthy during the soak period.
+	CanaryPassed CanaryPhase = "Passed"
+	// CanaryFailed means the canary is unhealthy, the rolling update is stopped until the
+	// statefulset changes again, the canary is retried by the annotation or disabled.
+	CanaryFailed CanaryPhase = "Failed"
+)
+
+// RollingUpdatePhase is the phase of the current rolling update step.
+type RollingUpdatePhase string
+
+const (
+	// RollingUpdateIdle means no step is in progress.
+	RollingUpdateIdle RollingUpdatePhase = ""
+	// RollingUpdateUpdating means the current pods are deleted and waiting to be healthy with the new revision.
+	RollingUpdateUpdating RollingUpdatePhase = "Updating"
+	// RollingUpdateSoaking means the canary is watched during the soak period.
+	RollingUpdateSoaking RollingUpdatePhase = "Soaking"
+	// RollingUpdateSwitching means the leader is being switched over to an updated follower.
+	RollingUpdateSwitching RollingUpdatePhase = "Switching"
+)
+
+// RollingUpdateStatus defines the observed state of the rolling update.
+// It is persisted so that the rolling update can be resumed after the operator restarts.
+type RollingUpdateStatus struct {
+	// Revision is the statefulset revision the pods are updated to.
+	Revision string `json:"revision,omitempty"`
+	// CurrentPods are the pods updated in the current step.
+	// +optional
+	CurrentPods []string `json:"currentPods,omitempty"`
+	// Phase is the phase of the current step.
+	// +optional
+	Phase RollingUpdatePhase `json:"phase,omitempty"`
+	// StartedAt is the time the current step started.
+	// +optional
+	StartedAt *metav1.Time `json:"startedAt,omitempty"`
+	// SwitchedAt is the time xenon was asked to promote the target in the Switching step,
+	// it is empty while the target is catching up the leader.
+	// +optional
+	SwitchedAt *metav1.Time `json:"switchedAt,omitempty"`
+	// CanaryPod is the follower updated first.
+	// +optional
+	CanaryPod string `json:"canaryPod,omitempty"`
+	// CanaryPhase is the phase of the canary update.
+	// +optional
+	CanaryPhase CanaryPhase `json:"canaryPhase,omitempty"`
+	// A human readable message indicating details about the canary.
+	// +optional
+	Message string `json:"message,omitempty"`
+	// RetryTrigger is the value of the canary retry annotation handled last time.
+	// +optional
+	RetryTrigger string `json:"retryTrigger,omitempty"`
+}
+
+// UpgradePhase is the phase of the MySQL major version upgrade.
+type UpgradePhase string
+
+const (
+	// UpgradePreChecking means the leader's data is being checked before upgrade.
+	UpgradePreChecking UpgradePhase = "PreChecking"
+	// UpgradePreCheckFailed means the data can't be upgraded, no pod is updated.
+	UpgradePreCheckFailed UpgradePhase = "PreCheckFailed"
+	// UpgradeUpgrading means the pods are being upgraded, followers first.
+	UpgradeUpgrading UpgradePhase = "Upgrading"
+	// UpgradeCompleted means all the pods run the new version.
+	UpgradeCompleted UpgradePhase = "Completed"
 )
 
+// UpgradeStatus defines the observed state of the MySQL major version upgrade.
+type UpgradeStatus struct {
+	// FromVersion is the MySQL version before upgrade.
+	FromVersion string `json:"fromVersion,omitempty"`
+	// ToVersion is the MySQL version to upgrade to.
+	ToVersion string `json:"toVersion,omitempty"`
+	// Phase is the phase of the upgrade.
+	Phase UpgradePhase `json:"phase,omitempty"`
+	// A human readable message of the pre-check problems or warnings.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// CredentialsRotationPhase is the phase of the internal credentials rotation in progress.
+type CredentialsRotationPhase string
+
+const (
+	// CredentialsRotationIdle means no rotation is in progress.
+	CredentialsRotationIdle CredentialsRotationPhase = ""
+	// CredentialsRotationCatchingUp means the passwords are changed on the leader, and the
+	// replicas are applying the change.
+	CredentialsRotationCatchingUp CredentialsRotationPhase = "CatchingUp"
+	// CredentialsRotationReconfiguring means the replication channels and xenon of the pods
+	// are being updated one by one.
+	CredentialsRotationReconfiguring CredentialsRotationPhase = "Reconfiguring"
+)
+
+// CredentialsRotationStatus defines the status of the internal credentials rotation.
+type CredentialsRotationStatus struct {
+	// LastRotationTime is the time when the internal credentials were rotated last time.
+	// +optional
+	LastRotationTime *metav1.Time `json:"lastRotationTime,omitempty"`
+	// Trigger is the value of the rotation annotation handled last time.
+	// +optional
+	Trigger string `json:"trigger,omitempty"`
+	// Message is the error of the rotation in progress.
+	// +optional
+	Message string `json:"message,omitempty"`
+	// Phase is the phase of the rotation in progress.
+	// +optional
+	Phase CredentialsRotationPhase `json:"phase,omitempty"`
+	// StartedAt is the time the current phase started, or the xenon of the RestartingPod
+	// was restarted.
+	// +optional
+	StartedAt *metav1.Time `json:"startedAt,omitempty"`
+	// ReconfiguredPods is the pods updated in the Reconfiguring phase.
+	// +optional
+	ReconfiguredPods []string `json:"reconfiguredPods,omitempty"`
+	// RestartingPod is the pod whose xenon is restarted to load the new replication password,
+	// waiting for the xenon to rejoin the raft.
+	// +optional
+	RestartingPod string `json:"restartingPod,omitempty"`
+	// XenonRestartCount is the restart count of the xenon container of the RestartingPod
+	// before it was restarted.
+	// +optional
+	XenonRestartCount int32 `json:"xenonRestartCount,omitempty"`
+}
+
+// TryLeaderStatus defines the status of promoting the pod labeled tryleader.
+type TryLeaderStatus struct {
+	// Pod is the candidate to promote.
+	Pod string `json:"pod,omitempty"`
+	// Leader is the leader made read-only for the candidate catching up.
+	// +optional
+	Leader string `json:"leader,omitempty"`
+	// FenceTime is the time the leader was made read-only.
+	// +optional
+	FenceTime *metav1.Time `json:"fenceTime,omitempty"`
+	// SwitchTime is the time xenon was asked to promote the candidate.
+	// +optional
+	SwitchTime *metav1.Time `json:"switchTime,omitempty"`
+}
+
 // MysqlClusterStatus defines the observed state of MysqlCluster
 type MysqlClusterStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
@@ -538,6 +958,18 @@ type MysqlClusterStatus struct {
 	Conditions []ClusterCondition `json:"conditions,omitempty"`
 	// Nodes contains the list of the node status fulfilled.
 	Nodes []NodeStatus `json:"nodes,omitempty"`
+	// RollingUpdate is the status of the rolling update.
+	// +optional
+	RollingUpdate *RollingUpdateStatus `json:"rollingUpdate,omitempty"`
+	// Upgrade is the status of the MySQL major version upgrade.
+	// +optional
+	Upgrade *UpgradeStatus `json:"upgrade,omitempty"`
+	// CredentialsRotation is the status of the internal credentials rotation.
+	// +optional
+	CredentialsRotation *CredentialsRotationStatus `json:"credentialsRotation,omitempty"`
+	// TryLeader is the status of promoting the pod labeled tryleader.
+	// +optional
+	TryLeader *TryLeaderStatus `json:"tryLeader,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -75,6 +75,15 @@ func (r *MysqlCluster) ValidateCreate() error {
 	if err := r.validateMysqlVersion(); err != nil {
 		return err
 	}
+	if err := r.validateEncryptInTransit(); err != nil {
+		return err
+	}
+	if err := r.validateRestoreTarget(); err != nil {
+		return err
+	}
+	if err := CheckCompression(r.Spec.RestoreCompression, r.Spec.MysqlVersion); err != nil {
+		return apierrors.NewForbidden(schema.GroupResource{}, "", fmt.Errorf("spec.restoreCompression: %s", err))
+	}
 	return nil
 }
 
@@ -113,6 +122,9 @@ func (r *MysqlCluster) ValidateUpdate(old runtime.Object) error {
 	if err := r.ValidataRo(); err != nil {
 		return err
 	}
+	if err := r.validateEncryptInTransit(); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -220,7 +232,55 @@ func (r *MysqlCluster) validateMysqlVersionAndImage(oldCluster *MysqlCluster) er
 				return apierrors.NewForbidden(schema.GroupResource{}, "", fmt.Errorf("spec.MysqlOpts.Image cannot downgrade"))
 			}
 			if mysqlSemVerOld.Major == 5 && mysqlSemVernew.Major == 8 {
-				return apierrors.NewForbidden(schema.GroupResource{}, "", fmt.Errorf("spec.MysqlOpts.Image do not support upgrade from 5 to 8"))
+				if err := r.validateMajorUpgrade(oldCluster); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// mysql80RemovedVariables are the variables removed in MySQL 8.0.
+var mysql80RemovedVariables = []string{
+	"query_cache_limit", "query_cache_min_res_unit", "query_cache_size", "query_cache_type",
+	"query_cache_wlock_invalidate", "innodb_file_format", "innodb_file_format_check",
+	"innodb_file_format_max", "innodb_large_prefix", "innodb_support_xa", "innodb_undo_logs",
+	"tx_isolation", "tx_read_only", "log_warnings", "secure_auth", "old_passwords", "sync_frm",
+	"show_compatibility_56", "date_format", "datetime_format", "time_format", "max_tmp_tables",
+	"multi_range_count", "ignore_builtin_innodb", "log_builtin_as_identified_by_password",
+	"have_crypt", "metadata_locks_cache_size", "metadata_locks_hash_instances",
+}
+
+// mysql80RemovedSQLModes are the sql modes removed in MySQL 8.0.
+var mysql80RemovedSQLModes = []string{
+	"NO_AUTO_CREATE_USER", "DB2", "MAXDB", "MSSQL", "MYSQL323", "MYSQL40", "ORACLE", "POSTGRESQL",
+	"NO_FIELD_OPTIONS", "NO_KEY_OPTIONS", "NO_TABLE_OPTIONS",
+}
+
+// validateMajorUpgrade checks whether the cluster can be upgraded from 5.7 to 8.0.
+func (r *MysqlCluster) validateMajorUpgrade(oldCluster *MysqlCluster) error {
+	if oldCluster.Spec.MysqlVersion != "5.7" || r.Spec.MysqlVersion != "8.0" {
+		return apierrors.NewForbidden(schema.GroupResource{}, "", fmt.Errorf("spec.MysqlOpts.Image only support major upgrade from 5.7 to 8.0"))
+	}
+	if oldCluster.Status.State != ClusterReadyState {
+		return apierrors.NewForbidden(schema.GroupResource{}, "", fmt.Errorf("the cluster must be ready before upgrading from 5.7 to 8.0"))
+	}
+	for key, val := range r.Spec.MysqlOpts.MysqlConf {
+		name := strings.ReplaceAll(strings.ToLower(key), "-", "_")
+		for _, removed := range mysql80RemovedVariables {
+			if name == removed {
+				return apierrors.NewForbidden(schema.GroupResource{}, "", fmt.Errorf("spec.MysqlOpts.MysqlConf %s is removed in 8.0", key))
+			}
+		}
+		if name != "sql_mode" {
+			continue
+		}
+		for _, mode := range strings.Split(strings.ToUpper(val), ",") {
+			for _, removed := range mysql80RemovedSQLModes {
+				if strings.TrimSpace(mode) == removed {
+					return apierrors.NewForbidden(schema.GroupResource{}, "", fmt.Errorf("spec.MysqlOpts.MysqlConf sql_mode %s is removed in 8.0", removed))
+				}
 			}
 		}
 	}
@@ -245,6 +305,53 @@ func (r *MysqlCluster) validateMysqlVersion() error {
 	return nil
 }
 
+// Validate the certificates for encryption in transit.
+func (r *MysqlCluster) validateEncryptInTransit() error {
+	if r.Spec.EncryptInTransit && r.Spec.TlsSecretName == "" && (r.Spec.AutoTLS == nil || !r.Spec.AutoTLS.Enabled) {
+		return apierrors.NewForbidden(schema.GroupResource{}, "",
+			fmt.Errorf("spec.encryptInTransit requires spec.tlsSecretName or spec.autoTLS"))
+	}
+	return nil
+}
+
+// Validate the restore target, only one of the stop points can be set.
+func (r *MysqlCluster) validateRestoreTarget() error {
+	target := r.Spec.RestoreTarget
+	if target == nil {
+		return nil
+	}
+	if len(r.Spec.RestoreFrom) == 0 {
+		return apierrors.NewForbidden(schema.GroupResource{}, "", fmt.Errorf("spec.restoreTarget requires spec.restoreFrom"))
+	}
+	stops := 0
+	for _, stop := range []string{target.StopGTID, target.StopFile, r.Spec.RestorePoint} {
+		if len(stop) != 0 {
+			stops++
+		}
+	}
+	if stops > 1 {
+		return apierrors.NewForbidden(schema.GroupResource{}, "",
+			fmt.Errorf("only one of spec.restoreTarget.stopGTID, spec.restoreTarget.stopFile and spec.restorePoint can be set"))
+	}
+	if target.Inclusive && len(target.StopGTID) == 0 {
+		return apierrors.NewForbidden(schema.GroupResource{}, "", fmt.Errorf("spec.restoreTarget.inclusive requires stopGTID"))
+	}
+	if (len(target.StopFile) == 0) != (target.StopPosition == 0) {
+		return apierrors.NewForbidden(schema.GroupResource{}, "",
+			fmt.Errorf("spec.restoreTarget.stopFile and spec.restoreTarget.stopPosition must be set together"))
+	}
+	return nil
+}
+
+// CheckCompression checks whether the xtrabackup of the mysql version supports the compression,
+// the xtrabackup 2.4 of mysql 5.7 only supports qpress.
+func CheckCompression(compression, mysqlVersion string) error {
+	if compression == "" || compression == "qpress" || strings.HasPrefix(mysqlVersion, "8.0") {
+		return nil
+	}
+	return fmt.Errorf("%s is not supported by the xtrabackup of mysql %s, only qpress is supported", compression, mysqlVersion)
+}
+
 // Validate BothS3NFS
 func (r *MysqlCluster) validBothS3NFS() error {
 	if r.Spec.BothS3NFS != nil &&
@@ -0,0 +1,130 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DatabaseSpec defines the desired state of MysqlDatabase.
+type DatabaseSpec struct {
+	// Database is the name of the database to be created, the system schemas are not allowed.
+	// This field is immutable.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern="^[A-Za-z0-9_]{1,64}$"
+	Database string `json:"database"`
+
+	// ClusterRef contains parameters about the cluster which the database belongs to.
+	// +kubebuilder:validation:Required
+	ClusterRef ClusterReference `json:"clusterRef"`
+
+	// CharacterSet is the default character set of the database.
+	// +optional
+	// +kubebuilder:default:="utf8mb4"
+	// +kubebuilder:validation:Pattern="^[A-Za-z0-9_]+$"
+	CharacterSet string `json:"characterSet,omitempty"`
+
+	// Collation is the default collation of the database, use the default collation
+	// of the character set if not set.
+	// +optional
+	// +kubebuilder:validation:Pattern="^[A-Za-z0-9_]+$"
+	Collation string `json:"collation,omitempty"`
+
+	// DeletionPolicy decides whether to drop the database when the MysqlDatabase is deleted.
+	// Retain: keep the database in the cluster.
+	// Drop: drop the database and all its data if it was created by the MysqlDatabase.
+	// +optional
+	// +kubebuilder:default:="Retain"
+	// +kubebuilder:validation:Enum=Retain;Drop
+	DeletionPolicy DatabaseDeletionPolicy `json:"deletionPolicy,omitempty"`
+}
+
+// ClusterReference is the reference to a MysqlCluster.
+type ClusterReference struct {
+	// ClusterName is the name of cluster.
+	ClusterName string `json:"clusterName,omitempty"`
+
+	// NameSpace is the nameSpace of cluster, defaults to the namespace of the resource.
+	// +optional
+	NameSpace string `json:"nameSpace,omitempty"`
+}
+
+// DatabaseDeletionPolicy is the policy of the database when the MysqlDatabase is deleted.
+type DatabaseDeletionPolicy string
+
+const (
+	// DatabaseRetain keeps the database in the cluster.
+	DatabaseRetain DatabaseDeletionPolicy = "Retain"
+	// DatabaseDrop drops the database from the cluster.
+	DatabaseDrop DatabaseDeletionPolicy = "Drop"
+)
+
+// DatabaseStatus defines the observed state of MysqlDatabase.
+type DatabaseStatus struct {
+	// Conditions represents the MysqlDatabase resource conditions list.
+	// +optional
+	Conditions []ResourceCondition `json:"conditions,omitempty"`
+
+	// CharacterSet is the character set of the database in the cluster.
+	// +optional
+	CharacterSet string `json:"characterSet,omitempty"`
+
+	// Collation is the collation of the database in the cluster.
+	// +optional
+	Collation string `json:"collation,omitempty"`
+
+	// Created is true if the database was created by the MysqlDatabase. Only the created
+	// database is dropped by the `Drop` deletion policy, an existing database is adopted
+	// and kept in the cluster.
+	// +optional
+	Created bool `json:"created,omitempty"`
+}
+
+const (
+	// MysqlDatabaseReady means the database exists in the cluster.
+	MysqlDatabaseReady ResourceConditionType = "Ready"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:subresource:finalizers
+// +kubebuilder:printcolumn:name="Database",type="string",JSONPath=".spec.database",description="The name of the database"
+// +kubebuilder:printcolumn:name="Cluster",type="string",JSONPath=".spec.clusterRef.clusterName",description="The cluster of the database"
+// +kubebuilder:printcolumn:name="CharacterSet",type="string",JSONPath=".status.characterSet",description="The character set of the database"
+// +kubebuilder:printcolumn:name="DeletionPolicy",type="string",JSONPath=".spec.deletionPolicy",description="Whether to drop the database when deleted"
+// +kubebuilder:printcolumn:name="Available",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description="The availability of the database"
+// +kubebuilder:printcolumn:name="Collation",type="string",priority=1,JSONPath=".status.collation",description="The collation of the database"
+// MysqlDatabase is the Schema for the mysqldatabases API.
+type MysqlDatabase struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   DatabaseSpec   `json:"spec,omitempty"`
+	Status DatabaseStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+// MysqlDatabaseList contains a list of MysqlDatabase.
+type MysqlDatabaseList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MysqlDatabase `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MysqlDatabase{}, &MysqlDatabaseList{})
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// log is for logging in this package.
+var mysqldatabaselog = logf.Log.WithName("mysqldatabase-resource")
+
+func (r *MysqlDatabase) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	webhookReader = mgr.GetAPIReader()
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-mysql-radondb-com-v1alpha1-mysqldatabase,mutating=false,failurePolicy=fail,sideEffects=None,groups=mysql.radondb.com,resources=mysqldatabases,verbs=create;update,versions=v1alpha1,name=vmysqldatabase.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &MysqlDatabase{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *MysqlDatabase) ValidateCreate() error {
+	mysqldatabaselog.Info("validate create", "name", r.Name)
+
+	if err := r.validateDatabase(); err != nil {
+		return err
+	}
+	return r.validateNamespace()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *MysqlDatabase) ValidateUpdate(old runtime.Object) error {
+	mysqldatabaselog.Info("validate update", "name", r.Name)
+
+	// Do not block removing the finalizer of the deleting database.
+	if !r.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	oldDatabase, ok := old.(*MysqlDatabase)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected an MysqlDatabase but got a %T", old))
+	}
+	if r.Spec.Database != oldDatabase.Spec.Database {
+		return apierrors.NewForbidden(schema.GroupResource{Group: GroupVersion.Group, Resource: "mysqldatabases"}, r.Name,
+			fmt.Errorf("spec.database is immutable"))
+	}
+	return r.validateNamespace()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *MysqlDatabase) ValidateDelete() error {
+	mysqldatabaselog.Info("validate delete", "name", r.Name)
+
+	return nil
+}
+
+// validateNamespace refuses the MysqlDatabase whose namespace is not allowed by the cluster.
+func (r *MysqlDatabase) validateNamespace() error {
+	clusterKey := r.Spec.ClusterRef.clusterKey(r.Namespace)
+	allowed, err := clusterAllowsNamespace(context.TODO(), clusterKey, r.Namespace)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return apierrors.NewForbidden(schema.GroupResource{Group: GroupVersion.Group, Resource: "mysqldatabases"}, r.Name,
+			fmt.Errorf("the namespace %s is not allowed by the allowedUserNamespaces of the cluster %s", r.Namespace, clusterKey))
+	}
+	return nil
+}
+
+// systemDatabases are the schemas of mysql itself, which cannot be managed by the MysqlDatabase.
+var systemDatabases = []string{"mysql", "sys", "performance_schema", "information_schema"}
+
+// validateDatabase refuses the MysqlDatabase which manages a system schema.
+func (r *MysqlDatabase) validateDatabase() error {
+	for _, name := range systemDatabases {
+		if strings.EqualFold(r.Spec.Database, name) {
+			return apierrors.NewForbidden(schema.GroupResource{Group: GroupVersion.Group, Resource: "mysqldatabases"}, r.Name,
+				fmt.Errorf("spec.database cannot be %s", strings.Join(systemDatabases, "|")))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// MysqlRestoreSpec defines the desired state of MysqlRestore.
+type MysqlRestoreSpec struct {
+	// ClusterName is the name of the new cluster the backup is restored into.
+	// The cluster must not exist, it is created in the same namespace as the MysqlRestore.
+	// +kubebuilder:validation:Required
+	ClusterName string `json:"clusterName"`
+
+	// Backup restores from a backup taken by a Backup resource.
+	// Only one of Backup and S3 can be set.
+	// +optional
+	Backup *RestoreBackupSource `json:"backup,omitempty"`
+
+	// S3 restores from a backup in the S3 bucket which has no Backup resource,
+	// such as a backup copied from another Kubernetes cluster.
+	// +optional
+	S3 *RestoreS3Source `json:"s3,omitempty"`
+
+	// RestorePoint is the date and time to apply the binlogs to after the backup is restored.
+	// The format is "2006-01-02 15:04:05".
+	// +optional
+	RestorePoint string `json:"restorePoint,omitempty"`
+
+	// Target is the GTID or binlog position to apply the binlogs to after the backup is restored.
+	// +optional
+	Target *RestoreTarget `json:"target,omitempty"`
+
+	// SourceCluster is the cluster whose spec the new cluster copies, the restore and backup
+	// settings are not copied. Defaults to the cluster of the Backup, the new cluster uses
+	// the default spec if the source cluster does not exist.
+	// +optional
+	SourceCluster string `json:"sourceCluster,omitempty"`
+
+	// Replicas overrides the replicas of the new cluster.
+	// +optional
+	// +kubebuilder:validation:Enum=1;2;3;5
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// MysqlVersion overrides the MySQL version of the new cluster, it must match the version
+	// of the cluster which took the backup.
+	// +optional
+	MysqlVersion string `json:"mysqlVersion,omitempty"`
+}
+
+// RestoreBackupSource selects a backup taken by a Backup resource.
+type RestoreBackupSource struct {
+	// Name is the name of the Backup resource in the same namespace.
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// BackupName is the name of the backup in the storage, it must be one of the backups
+	// recorded in the status of the Backup. Defaults to the last succeeded backup.
+	// +optional
+	BackupName string `json:"backupName,omitempty"`
+}
+
+// RestoreS3Source selects a backup in the S3 bucket.
+type RestoreS3Source struct {
+	// SecretName is the name of the secret that contains the credentials of the bucket.
+	// +kubebuilder:validation:Required
+	SecretName string `json:"secretName"`
+
+	// BackupName is the name of the backup in the bucket.
+	// +kubebuilder:validation:Required
+	BackupName string `json:"backupName"`
+
+	// EncryptionKeySecret is the name of the secret that contains the key to decrypt the backup.
+	// +optional
+	EncryptionKeySecret string `json:"encryptionKeySecret,omitempty"`
+
+	// Compression is the algorithm which compresses the backup, it is detected from the backup if not set.
+	// +optional
+	// +kubebuilder:validation:Enum=qpress;lz4;zstd
+	Compression string `json:"compression,omitempty"`
+}
+
+// RestorePhase defines the phase of a MysqlRestore.
+type RestorePhase string
+
+const (
+	// RestorePending means the restore has not been handled yet.
+	RestorePending RestorePhase = ""
+	// RestoreDownloading means the backup is being downloaded into the first pod.
+	RestoreDownloading RestorePhase = "Downloading"
+	// RestorePreparing means xtrabackup is preparing the backup and the incremental backups.
+	RestorePreparing RestorePhase = "Preparing"
+	// RestoreApplyingBinlogs means the binlogs are being applied to the restore target.
+	RestoreApplyingBinlogs RestorePhase = "ApplyingBinlogs"
+	// RestoreVerifying means the cluster is starting and the restored data is being checked.
+	RestoreVerifying RestorePhase = "Verifying"
+	// RestoreSucceeded means the cluster is ready with the restored data.
+	RestoreSucceeded RestorePhase = "Succeeded"
+	// RestoreFailed means the restore was aborted.
+	RestoreFailed RestorePhase = "Failed"
+)
+
+// MysqlRestoreStatus defines the observed state of MysqlRestore.
+type MysqlRestoreStatus struct {
+	// Phase is the current phase of the restore.
+	// +optional
+	Phase RestorePhase `json:"phase,omitempty"`
+	// Progress is the progress of the phase, such as "2/5" when preparing the third
+	// of five backups in the backup chain.
+	// +optional
+	Progress string `json:"progress,omitempty"`
+	// BackupName is the name of the backup in the storage which is restored.
+	// +optional
+	BackupName string `json:"backupName,omitempty"`
+	// BackupGtid is the GTID set of the backup which is restored.
+	// +optional
+	BackupGtid string `json:"backupGtid,omitempty"`
+	// RestoredGtid is the gtid_executed of the cluster after the restore.
+	// +optional
+	RestoredGtid string `json:"restoredGtid,omitempty"`
+	// StartTime is the time the restore was started.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// CompletionTime is the time the restore finished, whether it failed or succeeded.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// The reason for the last phase transition.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// A human readable message indicating details about the last phase transition.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Cluster",type="string",JSONPath=".spec.clusterName",description="The cluster the backup is restored into"
+// +kubebuilder:printcolumn:name="Backup",type="string",JSONPath=".status.backupName",description="The backup which is restored"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase",description="The phase of the restore"
+// +kubebuilder:printcolumn:name="Progress",type="string",JSONPath=".status.progress",description="The progress of the phase"
+// +kubebuilder:printcolumn:name="Reason",type="string",priority=1,JSONPath=".status.reason",description="The reason of the last phase transition"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// MysqlRestore is the Schema for the mysqlrestores API.
+type MysqlRestore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MysqlRestoreSpec   `json:"spec,omitempty"`
+	Status MysqlRestoreStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+// MysqlRestoreList contains a list of MysqlRestore.
+type MysqlRestoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MysqlRestore `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MysqlRestore{}, &MysqlRestoreList{})
+}
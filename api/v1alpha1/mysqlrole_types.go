@@ -0,0 +1,88 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RoleSpec defines the desired state of MysqlRole.
+type RoleSpec struct {
+	// Role is the name of the role to be created, the role is created as `role`@`%`.
+	// The accounts of the operator cannot be used as the role.
+	// This field is immutable.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern="^[A-Za-z0-9_]{2,32}$"
+	Role string `json:"role"`
+
+	// ClusterRef contains parameters about the cluster which the role belongs to.
+	// Roles require MySQL 8.0.
+	// +kubebuilder:validation:Required
+	ClusterRef ClusterReference `json:"clusterRef"`
+
+	// Permissions is the list of privileges that the role has in the specified database.
+	// +optional
+	Permissions []UserPermission `json:"permissions,omitempty"`
+}
+
+// RoleStatus defines the observed state of MysqlRole.
+type RoleStatus struct {
+	// Conditions represents the MysqlRole resource conditions list.
+	// +optional
+	Conditions []ResourceCondition `json:"conditions,omitempty"`
+
+	// Grants is the list of the grants applied to the role in mysql, reported by `SHOW GRANTS`.
+	// +optional
+	Grants []string `json:"grants,omitempty"`
+
+	// Created is true if the role was created by the MysqlRole. An existing account is not
+	// adopted, and only the created role is dropped when the MysqlRole is deleted.
+	// +optional
+	Created bool `json:"created,omitempty"`
+}
+
+const (
+	// MysqlRoleReady means the role exists in the cluster.
+	MysqlRoleReady ResourceConditionType = "Ready"
+)
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:subresource:finalizers
+// +kubebuilder:printcolumn:name="Role",type="string",JSONPath=".spec.role",description="The name of the role"
+// +kubebuilder:printcolumn:name="Cluster",type="string",JSONPath=".spec.clusterRef.clusterName",description="The cluster of the role"
+// +kubebuilder:printcolumn:name="Available",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description="The availability of the role"
+// MysqlRole is the Schema for the mysqlroles API.
+type MysqlRole struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RoleSpec   `json:"spec,omitempty"`
+	Status RoleStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+// MysqlRoleList contains a list of MysqlRole.
+type MysqlRoleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MysqlRole `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MysqlRole{}, &MysqlRoleList{})
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+// log is for logging in this package.
+var mysqlrolelog = logf.Log.WithName("mysqlrole-resource")
+
+func (r *MysqlRole) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	webhookReader = mgr.GetAPIReader()
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-mysql-radondb-com-v1alpha1-mysqlrole,mutating=false,failurePolicy=fail,sideEffects=None,groups=mysql.radondb.com,resources=mysqlroles,verbs=create;update,versions=v1alpha1,name=vmysqlrole.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &MysqlRole{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *MysqlRole) ValidateCreate() error {
+	mysqlrolelog.Info("validate create", "name", r.Name)
+
+	if err := r.validateRole(); err != nil {
+		return err
+	}
+	return r.validateNamespace()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *MysqlRole) ValidateUpdate(old runtime.Object) error {
+	mysqlrolelog.Info("validate update", "name", r.Name)
+
+	// Do not block removing the finalizer of the deleting role.
+	if !r.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	oldRole, ok := old.(*MysqlRole)
+	if !ok {
+		return apierrors.NewBadRequest(fmt.Sprintf("expected an MysqlRole but got a %T", old))
+	}
+	if r.Spec.Role != oldRole.Spec.Role {
+		return apierrors.NewForbidden(schema.GroupResource{Group: GroupVersion.Group, Resource: "mysqlroles"}, r.Name,
+			fmt.Errorf("spec.role is immutable"))
+	}
+	return r.validateNamespace()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *MysqlRole) ValidateDelete() error {
+	mysqlrolelog.Info("validate delete", "name", r.Name)
+
+	return nil
+}
+
+// validateNamespace refuses the MysqlRole whose namespace is not allowed by the cluster.
+func (r *MysqlRole) validateNamespace() error {
+	clusterKey := r.Spec.ClusterRef.clusterKey(r.Namespace)
+	allowed, err := clusterAllowsNamespace(context.TODO(), clusterKey, r.Namespace)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return apierrors.NewForbidden(schema.GroupResource{Group: GroupVersion.Group, Resource: "mysqlroles"}, r.Name,
+			fmt.Errorf("the namespace %s is not allowed by the allowedUserNamespaces of the cluster %s", r.Namespace, clusterKey))
+	}
+	return nil
+}
+
+// reservedRoles are the accounts of the operator, which cannot be managed by the MysqlRole.
+var reservedRoles = []string{utils.RootUser, utils.ReplicationUser, utils.OperatorUser, utils.MetricsUser,
+	utils.DonorCloneUser, utils.BackupUser, utils.BinlogArchiverUser}
+
+// validateRole refuses the MysqlRole which uses an account of the operator.
+func (r *MysqlRole) validateRole() error {
+	if utils.StringInArray(r.Spec.Role, reservedRoles) {
+		return apierrors.NewForbidden(schema.GroupResource{Group: GroupVersion.Group, Resource: "mysqlroles"}, r.Name,
+			fmt.Errorf("spec.role cannot be %s", strings.Join(reservedRoles, "|")))
+	}
+	return nil
+}
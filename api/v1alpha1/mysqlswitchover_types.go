@@ -0,0 +1,115 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SwitchoverSpec defines the desired state of MysqlSwitchover.
+type SwitchoverSpec struct {
+	// ClusterName is the name of the cluster whose leader will be moved.
+	// The cluster must be in the same namespace as the MysqlSwitchover.
+	// +kubebuilder:validation:Required
+	ClusterName string `json:"clusterName"`
+
+	// Target is the name of the pod that will be promoted to leader.
+	// +kubebuilder:validation:Required
+	Target string `json:"target"`
+
+	// TimeoutSeconds is the max seconds to wait for the target becoming the leader.
+	// +optional
+	// +kubebuilder:default:=60
+	// +kubebuilder:validation:Minimum=1
+	TimeoutSeconds int32 `json:"timeoutSeconds,omitempty"`
+}
+
+// SwitchoverPhase defines the phase of a MysqlSwitchover.
+type SwitchoverPhase string
+
+const (
+	// SwitchoverPending means the switchover has not been handled yet.
+	SwitchoverPending SwitchoverPhase = ""
+	// SwitchoverValidating means the target is being checked.
+	SwitchoverValidating SwitchoverPhase = "Validating"
+	// SwitchoverFencing means the leader has been made read-only, and the target is applying
+	// the leader's transactions.
+	SwitchoverFencing SwitchoverPhase = "Fencing"
+	// SwitchoverSwitching means the leader transfer has been sent to xenon.
+	SwitchoverSwitching SwitchoverPhase = "Switching"
+	// SwitchoverSucceeded means the target has become the leader.
+	SwitchoverSucceeded SwitchoverPhase = "Succeeded"
+	// SwitchoverFailed means the switchover was aborted.
+	SwitchoverFailed SwitchoverPhase = "Failed"
+)
+
+// SwitchoverStatus defines the observed state of MysqlSwitchover.
+type SwitchoverStatus struct {
+	// Phase is the current phase of the switchover.
+	// +optional
+	Phase SwitchoverPhase `json:"phase,omitempty"`
+	// FromLeader is the pod that was the leader when the switchover started.
+	// +optional
+	FromLeader string `json:"fromLeader,omitempty"`
+	// StartTime is the time the switchover was started.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// FenceTime is the time the leader was made read-only.
+	// +optional
+	FenceTime *metav1.Time `json:"fenceTime,omitempty"`
+	// SwitchTime is the time the leader transfer was sent to xenon.
+	// +optional
+	SwitchTime *metav1.Time `json:"switchTime,omitempty"`
+	// CompletionTime is the time the switchover finished, whether it failed or succeeded.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// The reason for the last phase transition.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// A human readable message indicating details about the last phase transition.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Cluster",type="string",JSONPath=".spec.clusterName",description="The cluster of the switchover"
+// +kubebuilder:printcolumn:name="Target",type="string",JSONPath=".spec.target",description="The pod to be promoted to leader"
+// +kubebuilder:printcolumn:name="From",type="string",JSONPath=".status.fromLeader",description="The leader before the switchover"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase",description="The phase of the switchover"
+// +kubebuilder:printcolumn:name="Reason",type="string",priority=1,JSONPath=".status.reason",description="The reason of the last phase transition"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// MysqlSwitchover is the Schema for the mysqlswitchovers API.
+type MysqlSwitchover struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SwitchoverSpec   `json:"spec,omitempty"`
+	Status SwitchoverStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+// MysqlSwitchoverList contains a list of MysqlSwitchover.
+type MysqlSwitchoverList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MysqlSwitchover `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&MysqlSwitchover{}, &MysqlSwitchoverList{})
+}
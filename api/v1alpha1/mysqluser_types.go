@@ -52,6 +52,16 @@ type UserSpec struct {
 	// +optional
 	Permissions []UserPermission `json:"permissions,omitempty"`
 
+	// Roles is the list of the roles granted to the user, the roles are managed by MysqlRole.
+	// Roles require MySQL 8.0.
+	// +optional
+	Roles []string `json:"roles,omitempty"`
+
+	// DefaultRoles is the list of the roles activated when the user connects to the server.
+	// They must be in the roles.
+	// +optional
+	DefaultRoles []string `json:"defaultRoles,omitempty"`
+
 	// WithGrantOption is the flag to indicate whether the user has grant option.
 	// +optional
 	// +kubebuilder:default:=false
@@ -62,6 +72,83 @@ type UserSpec struct {
 	// https://dev.mysql.com/doc/refman/5.7/en/create-user.html
 	// +kubebuilder:default:={type: "NONE"}
 	TLSOptions TLSOptions `json:"tlsOptions,omitempty"`
+
+	// PasswordRotation configures rotating the password without breaking the live connections.
+	// +optional
+	PasswordRotation *PasswordRotation `json:"passwordRotation,omitempty"`
+
+	// ResourceLimits limits the resources used by the user, 0 means no limit.
+	// https://dev.mysql.com/doc/refman/5.7/en/user-resources.html
+	// +optional
+	ResourceLimits UserResourceLimits `json:"resourceLimits,omitempty"`
+
+	// AccountLocked locks the user, the locked user can not connect to the server.
+	// +optional
+	AccountLocked bool `json:"accountLocked,omitempty"`
+
+	// PasswordExpireDays is the days that the password is valid after changed, 0 means never expire.
+	// Use the global `default_password_lifetime` if not set.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=65535
+	PasswordExpireDays *int32 `json:"passwordExpireDays,omitempty"`
+
+	// AuthPlugin is the authentication plugin of the user, use the `default_authentication_plugin` if not set.
+	// caching_sha2_password requires MySQL 8.0.
+	// +optional
+	// +kubebuilder:validation:Enum=mysql_native_password;caching_sha2_password
+	AuthPlugin string `json:"authPlugin,omitempty"`
+}
+
+// UserResourceLimits defines the resource limits of the user.
+type UserResourceLimits struct {
+	// MaxQueriesPerHour is the number of queries the user can issue per hour.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxQueriesPerHour int32 `json:"maxQueriesPerHour,omitempty"`
+
+	// MaxUpdatesPerHour is the number of updates the user can issue per hour.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxUpdatesPerHour int32 `json:"maxUpdatesPerHour,omitempty"`
+
+	// MaxConnectionsPerHour is the number of times the user can connect to the server per hour.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxConnectionsPerHour int32 `json:"maxConnectionsPerHour,omitempty"`
+
+	// MaxUserConnections is the number of simultaneous connections to the server by the user.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxUserConnections int32 `json:"maxUserConnections,omitempty"`
+}
+
+// PasswordRotation defines how the password of the user is rotated.
+type PasswordRotation struct {
+	// GracePeriodSeconds is the time that the old password is still valid after the password changes.
+	// The old password is retained by `RETAIN CURRENT PASSWORD`, which requires MySQL 8.0.14 or later,
+	// otherwise the old password is invalid immediately.
+	// +optional
+	// +kubebuilder:default:=3600
+	// +kubebuilder:validation:Minimum=0
+	GracePeriodSeconds int32 `json:"gracePeriodSeconds,omitempty"`
+
+	// GeneratePassword makes the operator generate the password into the secret.
+	// +optional
+	GeneratePassword bool `json:"generatePassword,omitempty"`
+
+	// IntervalDays is the days to generate a new password, 0 means never.
+	// It only works with generatePassword.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	IntervalDays int32 `json:"intervalDays,omitempty"`
+
+	// PasswordLength is the length of the generated password.
+	// +optional
+	// +kubebuilder:default:=24
+	// +kubebuilder:validation:Minimum=12
+	// +kubebuilder:validation:Maximum=64
+	PasswordLength int32 `json:"passwordLength,omitempty"`
 }
 
 type UserOwner struct {
@@ -115,6 +202,36 @@ type UserStatus struct {
 	AllowedHosts []string `json:"allowedHosts,omitempty"`
 	// Identifies the users changed or not.
 	Revision string `json:"revision,omitempty"`
+	// Grants is the list of the grants applied to the user in mysql, reported by `SHOW GRANTS`.
+	// +optional
+	Grants []string `json:"grants,omitempty"`
+	// PasswordRotation is the status of the password rotation.
+	// +optional
+	PasswordRotation *PasswordRotationStatus `json:"passwordRotation,omitempty"`
+}
+
+// PasswordRotationPhase is the phase of the password rotation.
+type PasswordRotationPhase string
+
+const (
+	// PasswordRotationRetaining means both the new and the old passwords are valid.
+	PasswordRotationRetaining PasswordRotationPhase = "RetainingOldPassword"
+	// PasswordRotationCompleted means only the new password is valid.
+	PasswordRotationCompleted PasswordRotationPhase = "Completed"
+)
+
+// PasswordRotationStatus defines the observed state of the password rotation.
+type PasswordRotationStatus struct {
+	// Phase is the phase of the last rotation.
+	// +optional
+	Phase PasswordRotationPhase `json:"phase,omitempty"`
+	// PasswordHMAC identifies the password applied in mysql, it's the HMAC-SHA256 of the
+	// password keyed with the `<secretKey>-hmac-key` of the secret.
+	// +optional
+	PasswordHMAC string `json:"passwordHMAC,omitempty"`
+	// LastRotationTime is the time when the password was applied in mysql.
+	// +optional
+	LastRotationTime *metav1.Time `json:"lastRotationTime,omitempty"`
 }
 
 // MysqlUserConditionType defines the condition types of a MysqlUser resource.
@@ -151,6 +268,7 @@ type MySQLUserCondition struct {
 // +kubebuilder:printcolumn:name="Cluster",type="string",JSONPath=".spec.userOwner.clusterName",description="The cluster of the user"
 // +kubebuilder:printcolumn:name="NameSpace",type="string",JSONPath=".spec.userOwner.nameSpace",description="The namespace of the user"
 // +kubebuilder:printcolumn:name="Available",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description="The availability of the user"
+// +kubebuilder:printcolumn:name="Rotation",type="string",priority=1,JSONPath=".status.passwordRotation.phase",description="The phase of the password rotation"
 // +kubebuilder:printcolumn:name="SecretName",type="string",priority=1,JSONPath=".spec.secretSelector.secretName",description="The name of the secret object"
 // +kubebuilder:printcolumn:name="SecretKey",type="string",priority=1,JSONPath=".spec.secretSelector.secretKey",description="The key of the secret object"
 // MysqlUser is the Schema for the users API.
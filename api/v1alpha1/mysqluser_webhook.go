@@ -0,0 +1,225 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+// log is for logging in this package.
+var mysqluserlog = logf.Log.WithName("mysqluser-resource")
+
+// webhookReader reads the clusters, the namespaces and the secrets referenced by the resources.
+var webhookReader client.Reader
+
+// mysql57Privileges are the privileges supported by MySQL 5.7.
+// https://dev.mysql.com/doc/refman/5.7/en/privileges-provided.html
+var mysql57Privileges = []string{
+	"ALL", "ALL PRIVILEGES", "ALTER", "ALTER ROUTINE", "CREATE", "CREATE ROUTINE", "CREATE TABLESPACE",
+	"CREATE TEMPORARY TABLES", "CREATE USER", "CREATE VIEW", "DELETE", "DROP", "EVENT", "EXECUTE", "FILE",
+	"GRANT OPTION", "INDEX", "INSERT", "LOCK TABLES", "PROCESS", "PROXY", "REFERENCES", "RELOAD",
+	"REPLICATION CLIENT", "REPLICATION SLAVE", "SELECT", "SHOW DATABASES", "SHOW VIEW", "SHUTDOWN",
+	"SUPER", "TRIGGER", "UPDATE", "USAGE",
+}
+
+func (r *MysqlUser) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	webhookReader = mgr.GetAPIReader()
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-mysql-radondb-com-v1alpha1-mysqluser,mutating=true,failurePolicy=fail,sideEffects=None,groups=mysql.radondb.com,resources=mysqlusers,verbs=create;update,versions=v1alpha1,name=mmysqluser.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Defaulter = &MysqlUser{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type
+func (r *MysqlUser) Default() {
+	mysqluserlog.Info("default", "name", r.Name)
+
+	if r.Spec.UserOwner.NameSpace == "" {
+		r.Spec.UserOwner.NameSpace = r.Namespace
+	}
+	if r.Spec.TLSOptions.Type == "" {
+		r.Spec.TLSOptions.Type = "NONE"
+	}
+	for i := range r.Spec.Permissions {
+		for j, priv := range r.Spec.Permissions[i].Privileges {
+			r.Spec.Permissions[i].Privileges[j] = strings.ToUpper(strings.TrimSpace(priv))
+		}
+	}
+}
+
+//+kubebuilder:webhook:path=/validate-mysql-radondb-com-v1alpha1-mysqluser,mutating=false,failurePolicy=fail,sideEffects=None,groups=mysql.radondb.com,resources=mysqlusers,verbs=create;update,versions=v1alpha1,name=vmysqluser.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &MysqlUser{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *MysqlUser) ValidateCreate() error {
+	mysqluserlog.Info("validate create", "name", r.Name)
+
+	return r.validateUser()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *MysqlUser) ValidateUpdate(old runtime.Object) error {
+	mysqluserlog.Info("validate update", "name", r.Name)
+
+	// Do not block removing the finalizer of the deleting user.
+	if !r.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	return r.validateUser()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *MysqlUser) ValidateDelete() error {
+	mysqluserlog.Info("validate delete", "name", r.Name)
+
+	return nil
+}
+
+func (r *MysqlUser) validateUser() error {
+	if len(r.Spec.Hosts) == 0 {
+		return r.forbidden(fmt.Errorf("hosts must not be empty"))
+	}
+	for _, role := range r.Spec.DefaultRoles {
+		if !containsString(r.Spec.Roles, role) {
+			return r.forbidden(fmt.Errorf("the default role %s is not in the roles", role))
+		}
+	}
+	if webhookReader == nil {
+		return nil
+	}
+
+	ctx := context.TODO()
+	cluster := &MysqlCluster{}
+	if err := webhookReader.Get(ctx, r.clusterKey(), cluster); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return err
+		}
+		// The cluster may be created later, the controller checks the namespace again.
+		mysqluserlog.Info("the cluster is not found, skip validating the namespace and the version",
+			"name", r.Name, "cluster", r.clusterKey())
+		return r.validateSecret(ctx)
+	}
+	if err := r.validateNamespace(ctx, cluster); err != nil {
+		return err
+	}
+	if err := r.validateVersion(cluster.Spec.MysqlVersion); err != nil {
+		return r.forbidden(err)
+	}
+	return r.validateSecret(ctx)
+}
+
+// validateNamespace refuses the MysqlUser whose namespace is not allowed by the cluster.
+func (r *MysqlUser) validateNamespace(ctx context.Context, cluster *MysqlCluster) error {
+	// The users in the namespace of the cluster are always allowed.
+	if cluster.Namespace == r.Namespace {
+		return nil
+	}
+	namespace := &corev1.Namespace{}
+	if err := webhookReader.Get(ctx, client.ObjectKey{Name: r.Namespace}, namespace); err != nil {
+		return err
+	}
+	allowed, err := cluster.AllowsNamespace(namespace)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return r.forbidden(fmt.Errorf("the namespace %s is not allowed by the allowedUserNamespaces of the cluster %s",
+			r.Namespace, r.clusterKey()))
+	}
+	return nil
+}
+
+// validateVersion checks the privileges, the roles and the authentication plugin against the MySQL version.
+// The privileges of MySQL 8.0 are not checked, the plugins and the components can register dynamic privileges.
+func (r *MysqlUser) validateVersion(version string) error {
+	if strings.HasPrefix(version, "8.0") {
+		return nil
+	}
+	for _, perm := range r.Spec.Permissions {
+		for _, privs := range perm.Privileges {
+			for _, priv := range strings.Split(privs, ",") {
+				// Ignore the columns of the column privileges, such as `SELECT (col)`.
+				name := strings.ToUpper(strings.Join(strings.Fields(strings.SplitN(priv, "(", 2)[0]), " "))
+				if !containsString(mysql57Privileges, name) {
+					return fmt.Errorf("the privilege %q is not supported by MySQL 5.7", priv)
+				}
+			}
+		}
+	}
+	if len(r.Spec.Roles) > 0 {
+		return fmt.Errorf("roles require MySQL 8.0")
+	}
+	if r.Spec.AuthPlugin == "caching_sha2_password" {
+		return fmt.Errorf("the authentication plugin %s requires MySQL 8.0", r.Spec.AuthPlugin)
+	}
+	return nil
+}
+
+// validateSecret checks the secret key of the password exists, unless the operator generates the password.
+func (r *MysqlUser) validateSecret(ctx context.Context) error {
+	if r.Spec.PasswordRotation != nil && r.Spec.PasswordRotation.GeneratePassword {
+		return nil
+	}
+	selector := r.Spec.SecretSelector
+	secret := &corev1.Secret{}
+	if err := webhookReader.Get(ctx, client.ObjectKey{Name: selector.SecretName, Namespace: r.Namespace}, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return r.forbidden(fmt.Errorf("the secret %s is not found", selector.SecretName))
+		}
+		return err
+	}
+	if len(secret.Data[selector.SecretKey]) == 0 {
+		return r.forbidden(fmt.Errorf("the key %s of the secret %s is empty", selector.SecretKey, selector.SecretName))
+	}
+	return nil
+}
+
+func (r *MysqlUser) clusterKey() client.ObjectKey {
+	ns := r.Spec.UserOwner.NameSpace
+	if ns == "" {
+		ns = r.Namespace
+	}
+	return client.ObjectKey{Name: r.Spec.UserOwner.ClusterName, Namespace: ns}
+}
+
+func (r *MysqlUser) forbidden(err error) error {
+	return apierrors.NewForbidden(schema.GroupResource{Group: GroupVersion.Group, Resource: "mysqlusers"}, r.Name, err)
+}
+
+func containsString(strs []string, str string) bool {
+	for _, s := range strs {
+		if s == str {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// AllowsNamespace checks whether the MysqlUsers, MysqlDatabases and MysqlRoles in the namespace
+// can be created in the cluster.
+func (r *MysqlCluster) AllowsNamespace(namespace *corev1.Namespace) (bool, error) {
+	allowed := r.Spec.AllowedUserNamespaces
+	if allowed == nil || namespace.Name == r.Namespace {
+		return true, nil
+	}
+
+	switch allowed.From {
+	case NamespacesFromAll:
+		return true, nil
+	case NamespacesFromSelector:
+		if allowed.Selector == nil {
+			return false, nil
+		}
+		selector, err := metav1.LabelSelectorAsSelector(allowed.Selector)
+		if err != nil {
+			return false, err
+		}
+		return selector.Matches(labels.Set(namespace.Labels)), nil
+	default:
+		return false, nil
+	}
+}
+
+// clusterAllowsNamespace checks whether the resources in the namespace can be created in the cluster.
+// The resources are allowed if the cluster does not exist yet, the controllers check them again.
+func clusterAllowsNamespace(ctx context.Context, clusterKey client.ObjectKey, namespace string) (bool, error) {
+	// The resources in the namespace of the cluster are always allowed.
+	if webhookReader == nil || clusterKey.Namespace == namespace {
+		return true, nil
+	}
+	cluster := &MysqlCluster{}
+	if err := webhookReader.Get(ctx, clusterKey, cluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	ns := &corev1.Namespace{}
+	if err := webhookReader.Get(ctx, client.ObjectKey{Name: namespace}, ns); err != nil {
+		return false, err
+	}
+	return cluster.AllowsNamespace(ns)
+}
+
+// clusterKey returns the key of the referenced cluster, which defaults to the namespace of the resource.
+func (r ClusterReference) clusterKey(namespace string) client.ObjectKey {
+	if r.NameSpace != "" {
+		namespace = r.NameSpace
+	}
+	return client.ObjectKey{Name: r.ClusterName, Namespace: namespace}
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ResourceConditionType defines the condition types of the MysqlDatabase and MysqlRole resources.
+type ResourceConditionType string
+
+// ResourceCondition defines the condition struct of the MysqlDatabase and MysqlRole resources.
+type ResourceCondition struct {
+	// Type of the condition.
+	Type ResourceConditionType `json:"type"`
+	// Status of the condition, one of True, False, Unknown.
+	Status corev1.ConditionStatus `json:"status"`
+	// The last time this condition was updated.
+	LastUpdateTime metav1.Time `json:"lastUpdateTime,omitempty"`
+	// Last time the condition transitioned from one status to another.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+	// The reason for the condition's last transition.
+	Reason string `json:"reason"`
+	// A human readable message indicating details about the transition.
+	Message string `json:"message"`
+}
+
+// SetResourceCondition sets the condition of the type to a status in the conditions,
+// returns the condition and whether it is changed.
+func SetResourceCondition(
+	conditions *[]ResourceCondition, condType ResourceConditionType,
+	status corev1.ConditionStatus, reason, message string,
+) (
+	cond *ResourceCondition, changed bool,
+) {
+	t := metav1.NewTime(time.Now())
+
+	existingCondition, exists := FindResourceCondition(*conditions, condType)
+	if !exists {
+		newCondition := ResourceCondition{
+			Type:               condType,
+			Status:             status,
+			Reason:             reason,
+			Message:            message,
+			LastTransitionTime: t,
+			LastUpdateTime:     t,
+		}
+		*conditions = append(*conditions, newCondition)
+
+		return &newCondition, true
+	}
+
+	if status != existingCondition.Status {
+		existingCondition.LastTransitionTime = t
+		changed = true
+	}
+
+	if message != existingCondition.Message || reason != existingCondition.Reason {
+		existingCondition.LastUpdateTime = t
+		changed = true
+	}
+
+	existingCondition.Status = status
+	existingCondition.Message = message
+	existingCondition.Reason = reason
+
+	return existingCondition, changed
+}
+
+// FindResourceCondition returns the condition of the type and whether it exists.
+func FindResourceCondition(conditions []ResourceCondition, condType ResourceConditionType) (*ResourceCondition, bool) {
+	for i := range conditions {
+		cond := &conditions[i]
+		if cond.Type == condType {
+			return cond, true
+		}
+	}
+
+	return nil, false
+}
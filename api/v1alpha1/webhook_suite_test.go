@@ -30,10 +30,14 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	//+kubebuilder:scaffold:imports
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/envtest"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
@@ -99,6 +103,18 @@ var _ = BeforeSuite(func() {
 	err = (&MysqlCluster{}).SetupWebhookWithManager(mgr)
 	Expect(err).NotTo(HaveOccurred())
 
+	err = (&MysqlUser{}).SetupWebhookWithManager(mgr)
+	Expect(err).NotTo(HaveOccurred())
+
+	err = (&MysqlDatabase{}).SetupWebhookWithManager(mgr)
+	Expect(err).NotTo(HaveOccurred())
+
+	err = (&MysqlRole{}).SetupWebhookWithManager(mgr)
+	Expect(err).NotTo(HaveOccurred())
+
+	err = (&Backup{}).SetupWebhookWithManager(mgr)
+	Expect(err).NotTo(HaveOccurred())
+
 	//+kubebuilder:scaffold:webhook
 
 	go func() {
@@ -154,4 +170,201 @@ func TestValidateUpdate(t *testing.T) {
 		err := mysqlcluster.validateMysqlVersionAndImage(mysqlcluster)
 		assert.NoError(t, err)
 	}
+	{
+		oldCluster := &MysqlCluster{
+			Spec: MysqlClusterSpec{
+				MysqlVersion: "5.7",
+				MysqlOpts: MysqlOpts{
+					Image: "percona/percona-server:5.7.34",
+				},
+			},
+			Status: MysqlClusterStatus{
+				State: ClusterReadyState,
+			},
+		}
+		mysqlcluster := &MysqlCluster{
+			Spec: MysqlClusterSpec{
+				MysqlVersion: "8.0",
+				MysqlOpts: MysqlOpts{
+					Image: "percona/percona-server:8.0.25",
+				},
+			},
+		}
+		err := mysqlcluster.validateMysqlVersionAndImage(oldCluster)
+		assert.NoError(t, err)
+
+		mysqlcluster.Spec.MysqlOpts.MysqlConf = MysqlConf{"query-cache-size": "0"}
+		err = mysqlcluster.validateMysqlVersionAndImage(oldCluster)
+		assert.Error(t, err)
+
+		mysqlcluster.Spec.MysqlOpts.MysqlConf = MysqlConf{"sql_mode": "STRICT_TRANS_TABLES,NO_AUTO_CREATE_USER"}
+		err = mysqlcluster.validateMysqlVersionAndImage(oldCluster)
+		assert.Error(t, err)
+
+		mysqlcluster.Spec.MysqlOpts.MysqlConf = nil
+		oldCluster.Status.State = ClusterUpdateState
+		err = mysqlcluster.validateMysqlVersionAndImage(oldCluster)
+		assert.Error(t, err)
+	}
+}
+
+// test the compression supported by the xtrabackup of the mysql version
+func TestCheckCompression(t *testing.T) {
+	assert.NoError(t, CheckCompression("", "5.7"))
+	assert.NoError(t, CheckCompression("qpress", "5.7"))
+	assert.Error(t, CheckCompression("lz4", "5.7"))
+	assert.Error(t, CheckCompression("zstd", "5.7"))
+	assert.NoError(t, CheckCompression("zstd", "8.0"))
+}
+
+// test AllowsNamespace for webhook
+func TestAllowsNamespace(t *testing.T) {
+	cluster := &MysqlCluster{ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"}}
+	same := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}
+	tenant := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant", Labels: map[string]string{"team": "app"}}}
+	other := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other"}}
+
+	allowed, err := cluster.AllowsNamespace(other)
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+
+	cluster.Spec.AllowedUserNamespaces = &AllowedNamespaces{From: NamespacesFromSame}
+	allowed, _ = cluster.AllowsNamespace(same)
+	assert.True(t, allowed)
+	allowed, _ = cluster.AllowsNamespace(tenant)
+	assert.False(t, allowed)
+
+	cluster.Spec.AllowedUserNamespaces = &AllowedNamespaces{
+		From:     NamespacesFromSelector,
+		Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "app"}},
+	}
+	allowed, _ = cluster.AllowsNamespace(tenant)
+	assert.True(t, allowed)
+	allowed, _ = cluster.AllowsNamespace(other)
+	assert.False(t, allowed)
+}
+
+// test the namespace validation of MysqlDatabase and MysqlRole for webhook
+func TestValidateClusterReferenceNamespace(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	cluster := &MysqlCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"},
+		Spec: MysqlClusterSpec{AllowedUserNamespaces: &AllowedNamespaces{
+			From:     NamespacesFromSelector,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "app"}},
+		}},
+	}
+	tenant := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant", Labels: map[string]string{"team": "app"}}}
+	other := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other"}}
+	oldReader := webhookReader
+	webhookReader = fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster, tenant, other).Build()
+	defer func() { webhookReader = oldReader }()
+
+	ref := ClusterReference{ClusterName: "sample", NameSpace: "default"}
+	database := &MysqlDatabase{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "tenant"}, Spec: DatabaseSpec{ClusterRef: ref}}
+	assert.NoError(t, database.ValidateCreate())
+	database.Namespace = "other"
+	assert.True(t, apierrors.IsForbidden(database.ValidateCreate()))
+
+	role := &MysqlRole{ObjectMeta: metav1.ObjectMeta{Name: "role", Namespace: "tenant"}, Spec: RoleSpec{ClusterRef: ref}}
+	assert.NoError(t, role.ValidateCreate())
+	role.Namespace = "other"
+	assert.True(t, apierrors.IsForbidden(role.ValidateUpdate(role)))
+
+	// The cluster may be created later, the controller checks the namespace again.
+	role.Spec.ClusterRef.ClusterName = "later"
+	assert.NoError(t, role.ValidateCreate())
+}
+
+// test validateVersion for webhook
+func TestValidateUserVersion(t *testing.T) {
+	user := &MysqlUser{
+		Spec: UserSpec{
+			Permissions: []UserPermission{
+				{Database: "*", Tables: []string{"*"}, Privileges: []string{"SELECT,INSERT", "UPDATE (`col`)"}},
+			},
+		},
+	}
+	assert.NoError(t, user.validateVersion("5.7"))
+
+	user.Spec.Permissions[0].Privileges = []string{"BACKUP_ADMIN"}
+	assert.Error(t, user.validateVersion("5.7"))
+	assert.NoError(t, user.validateVersion("8.0"))
+
+	user.Spec.Permissions[0].Privileges = []string{"SELEC"}
+	assert.Error(t, user.validateVersion("5.7"))
+
+	// The dynamic privileges registered by the plugins are allowed.
+	user.Spec.Permissions[0].Privileges = []string{"FIREWALL_ADMIN"}
+	assert.NoError(t, user.validateVersion("8.0"))
+
+	user.Spec.Permissions = nil
+	user.Spec.Roles = []string{"app_readonly"}
+	assert.Error(t, user.validateVersion("5.7"))
+	assert.NoError(t, user.validateVersion("8.0"))
+}
+
+// test the cluster of MysqlUser may be created later
+func TestValidateUserClusterNotFound(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "user", Namespace: "tenant"},
+		Data:       map[string][]byte{"password": []byte("secret")},
+	}
+	oldReader := webhookReader
+	webhookReader = fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret).Build()
+	defer func() { webhookReader = oldReader }()
+
+	user := &MysqlUser{
+		ObjectMeta: metav1.ObjectMeta{Name: "user", Namespace: "tenant"},
+		Spec: UserSpec{
+			Hosts:          []string{"%"},
+			UserOwner:      UserOwner{ClusterName: "later", NameSpace: "default"},
+			SecretSelector: SecretSelector{SecretName: "user", SecretKey: "password"},
+		},
+	}
+	assert.NoError(t, user.ValidateCreate())
+
+	// The secret is still required.
+	user.Spec.SecretSelector.SecretKey = "missing"
+	assert.True(t, apierrors.IsForbidden(user.ValidateCreate()))
+}
+
+// test validateBackup of the v1alpha1 Backup for webhook
+func TestValidateBackupV1alpha1(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = AddToScheme(scheme)
+	cluster := &MysqlCluster{ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"}}
+	oldReader := webhookReader
+	webhookReader = fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster).Build()
+	defer func() { webhookReader = oldReader }()
+
+	backup := &Backup{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "default"},
+		Spec:       BackupSpec{ClusterName: "sample", HostName: "sample-mysql-0"},
+	}
+	assert.NoError(t, backup.ValidateCreate())
+
+	backup.Spec.HostName = "sample-mysql-1.sample-mysql.default"
+	backup.Spec.NFSServerAddress = "10.0.0.1:/backup"
+	assert.NoError(t, backup.ValidateCreate())
+
+	backup.Spec.HostName = "other-mysql-0"
+	assert.True(t, apierrors.IsForbidden(backup.ValidateCreate()))
+
+	backup.Spec.HostName = ""
+	backup.Spec.NFSServerAddress = "10.0.0.1:backup"
+	assert.True(t, apierrors.IsForbidden(backup.ValidateCreate()))
+	backup.Spec.NFSServerAddress = ":/backup"
+	assert.True(t, apierrors.IsForbidden(backup.ValidateCreate()))
+
+	backup.Spec.NFSServerAddress = ""
+	backup.Spec.ClusterName = "missing"
+	assert.True(t, apierrors.IsForbidden(backup.ValidateCreate()))
+	backup.Spec.ClusterName = ""
+	assert.True(t, apierrors.IsForbidden(backup.ValidateCreate()))
 }
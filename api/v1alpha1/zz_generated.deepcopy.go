@@ -23,9 +23,45 @@ package v1alpha1
 
 import (
 	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AllowedNamespaces) DeepCopyInto(out *AllowedNamespaces) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AllowedNamespaces.
+func (in *AllowedNamespaces) DeepCopy() *AllowedNamespaces {
+	if in == nil {
+		return nil
+	}
+	out := new(AllowedNamespaces)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutoTLSOpts) DeepCopyInto(out *AutoTLSOpts) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoTLSOpts.
+func (in *AutoTLSOpts) DeepCopy() *AutoTLSOpts {
+	if in == nil {
+		return nil
+	}
+	out := new(AutoTLSOpts)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Backup) DeepCopyInto(out *Backup) {
 	*out = *in
@@ -143,6 +179,22 @@ func (in *BackupStatus) DeepCopy() *BackupStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BinlogArchiveOpts) DeepCopyInto(out *BinlogArchiveOpts) {
+	*out = *in
+	in.Resources.DeepCopyInto(&out.Resources)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BinlogArchiveOpts.
+func (in *BinlogArchiveOpts) DeepCopy() *BinlogArchiveOpts {
+	if in == nil {
+		return nil
+	}
+	out := new(BinlogArchiveOpts)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BothS3NFSOpt) DeepCopyInto(out *BothS3NFSOpt) {
 	*out = *in
@@ -158,6 +210,21 @@ func (in *BothS3NFSOpt) DeepCopy() *BothS3NFSOpt {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CanaryOpts) DeepCopyInto(out *CanaryOpts) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryOpts.
+func (in *CanaryOpts) DeepCopy() *CanaryOpts {
+	if in == nil {
+		return nil
+	}
+	out := new(CanaryOpts)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterCondition) DeepCopyInto(out *ClusterCondition) {
 	*out = *in
@@ -174,6 +241,102 @@ func (in *ClusterCondition) DeepCopy() *ClusterCondition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterReference) DeepCopyInto(out *ClusterReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterReference.
+func (in *ClusterReference) DeepCopy() *ClusterReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CredentialsRotationOpts) DeepCopyInto(out *CredentialsRotationOpts) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialsRotationOpts.
+func (in *CredentialsRotationOpts) DeepCopy() *CredentialsRotationOpts {
+	if in == nil {
+		return nil
+	}
+	out := new(CredentialsRotationOpts)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CredentialsRotationStatus) DeepCopyInto(out *CredentialsRotationStatus) {
+	*out = *in
+	if in.LastRotationTime != nil {
+		in, out := &in.LastRotationTime, &out.LastRotationTime
+		*out = (*in).DeepCopy()
+	}
+	if in.StartedAt != nil {
+		in, out := &in.StartedAt, &out.StartedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.ReconfiguredPods != nil {
+		in, out := &in.ReconfiguredPods, &out.ReconfiguredPods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialsRotationStatus.
+func (in *CredentialsRotationStatus) DeepCopy() *CredentialsRotationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CredentialsRotationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatabaseSpec) DeepCopyInto(out *DatabaseSpec) {
+	*out = *in
+	out.ClusterRef = in.ClusterRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseSpec.
+func (in *DatabaseSpec) DeepCopy() *DatabaseSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DatabaseStatus) DeepCopyInto(out *DatabaseStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]ResourceCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DatabaseStatus.
+func (in *DatabaseStatus) DeepCopy() *DatabaseStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DatabaseStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LogPVC) DeepCopyInto(out *LogPVC) {
 	*out = *in
@@ -311,9 +474,16 @@ func (in *MysqlClusterSpec) DeepCopyInto(out *MysqlClusterSpec) {
 	}
 	in.MysqlOpts.DeepCopyInto(&out.MysqlOpts)
 	in.XenonOpts.DeepCopyInto(&out.XenonOpts)
+	in.SemiSync.DeepCopyInto(&out.SemiSync)
+	in.UpdateStrategy.DeepCopyInto(&out.UpdateStrategy)
 	in.MetricsOpts.DeepCopyInto(&out.MetricsOpts)
 	in.PodPolicy.DeepCopyInto(&out.PodPolicy)
 	in.Persistence.DeepCopyInto(&out.Persistence)
+	if in.RestoreTarget != nil {
+		in, out := &in.RestoreTarget, &out.RestoreTarget
+		*out = new(RestoreTarget)
+		**out = **in
+	}
 	if in.BothS3NFS != nil {
 		in, out := &in.BothS3NFS, &out.BothS3NFS
 		*out = new(BothS3NFSOpt)
@@ -324,6 +494,11 @@ func (in *MysqlClusterSpec) DeepCopyInto(out *MysqlClusterSpec) {
 		*out = new(int)
 		**out = **in
 	}
+	if in.AutoTLS != nil {
+		in, out := &in.AutoTLS, &out.AutoTLS
+		*out = new(AutoTLSOpts)
+		**out = **in
+	}
 	if in.SourceConfig != nil {
 		in, out := &in.SourceConfig, &out.SourceConfig
 		*out = new(v1.SecretProjection)
@@ -334,6 +509,21 @@ func (in *MysqlClusterSpec) DeepCopyInto(out *MysqlClusterSpec) {
 		*out = new(RemoteSourceStruct)
 		**out = **in
 	}
+	if in.AllowedUserNamespaces != nil {
+		in, out := &in.AllowedUserNamespaces, &out.AllowedUserNamespaces
+		*out = new(AllowedNamespaces)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RotateInternalCredentials != nil {
+		in, out := &in.RotateInternalCredentials, &out.RotateInternalCredentials
+		*out = new(CredentialsRotationOpts)
+		**out = **in
+	}
+	if in.BinlogArchive != nil {
+		in, out := &in.BinlogArchive, &out.BinlogArchive
+		*out = new(BinlogArchiveOpts)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlClusterSpec.
@@ -364,6 +554,26 @@ func (in *MysqlClusterStatus) DeepCopyInto(out *MysqlClusterStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.RollingUpdate != nil {
+		in, out := &in.RollingUpdate, &out.RollingUpdate
+		*out = new(RollingUpdateStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Upgrade != nil {
+		in, out := &in.Upgrade, &out.Upgrade
+		*out = new(UpgradeStatus)
+		**out = **in
+	}
+	if in.CredentialsRotation != nil {
+		in, out := &in.CredentialsRotation, &out.CredentialsRotation
+		*out = new(CredentialsRotationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TryLeader != nil {
+		in, out := &in.TryLeader, &out.TryLeader
+		*out = new(TryLeaderStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlClusterStatus.
@@ -397,6 +607,65 @@ func (in MysqlConf) DeepCopy() MysqlConf {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MysqlDatabase) DeepCopyInto(out *MysqlDatabase) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlDatabase.
+func (in *MysqlDatabase) DeepCopy() *MysqlDatabase {
+	if in == nil {
+		return nil
+	}
+	out := new(MysqlDatabase)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MysqlDatabase) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MysqlDatabaseList) DeepCopyInto(out *MysqlDatabaseList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MysqlDatabase, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlDatabaseList.
+func (in *MysqlDatabaseList) DeepCopy() *MysqlDatabaseList {
+	if in == nil {
+		return nil
+	}
+	out := new(MysqlDatabaseList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MysqlDatabaseList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MysqlOpts) DeepCopyInto(out *MysqlOpts) {
 	*out = *in
@@ -433,7 +702,7 @@ func (in *MysqlOpts) DeepCopy() *MysqlOpts {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MysqlUser) DeepCopyInto(out *MysqlUser) {
+func (in *MysqlRestore) DeepCopyInto(out *MysqlRestore) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
@@ -441,18 +710,18 @@ func (in *MysqlUser) DeepCopyInto(out *MysqlUser) {
 	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlUser.
-func (in *MysqlUser) DeepCopy() *MysqlUser {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlRestore.
+func (in *MysqlRestore) DeepCopy() *MysqlRestore {
 	if in == nil {
 		return nil
 	}
-	out := new(MysqlUser)
+	out := new(MysqlRestore)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *MysqlUser) DeepCopyObject() runtime.Object {
+func (in *MysqlRestore) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -460,31 +729,31 @@ func (in *MysqlUser) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MysqlUserList) DeepCopyInto(out *MysqlUserList) {
+func (in *MysqlRestoreList) DeepCopyInto(out *MysqlRestoreList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]MysqlUser, len(*in))
+		*out = make([]MysqlRestore, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlUserList.
-func (in *MysqlUserList) DeepCopy() *MysqlUserList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlRestoreList.
+func (in *MysqlRestoreList) DeepCopy() *MysqlRestoreList {
 	if in == nil {
 		return nil
 	}
-	out := new(MysqlUserList)
+	out := new(MysqlRestoreList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *MysqlUserList) DeepCopyObject() runtime.Object {
+func (in *MysqlRestoreList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -492,87 +761,368 @@ func (in *MysqlUserList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NodeCondition) DeepCopyInto(out *NodeCondition) {
+func (in *MysqlRestoreSpec) DeepCopyInto(out *MysqlRestoreSpec) {
 	*out = *in
-	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	if in.Backup != nil {
+		in, out := &in.Backup, &out.Backup
+		*out = new(RestoreBackupSource)
+		**out = **in
+	}
+	if in.S3 != nil {
+		in, out := &in.S3, &out.S3
+		*out = new(RestoreS3Source)
+		**out = **in
+	}
+	if in.Target != nil {
+		in, out := &in.Target, &out.Target
+		*out = new(RestoreTarget)
+		**out = **in
+	}
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeCondition.
-func (in *NodeCondition) DeepCopy() *NodeCondition {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlRestoreSpec.
+func (in *MysqlRestoreSpec) DeepCopy() *MysqlRestoreSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(NodeCondition)
+	out := new(MysqlRestoreSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NodeStatus) DeepCopyInto(out *NodeStatus) {
+func (in *MysqlRestoreStatus) DeepCopyInto(out *MysqlRestoreStatus) {
 	*out = *in
-	in.RaftStatus.DeepCopyInto(&out.RaftStatus)
-	if in.RoStatus != nil {
-		in, out := &in.RoStatus, &out.RoStatus
-		*out = new(RoStatus)
-		**out = **in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
 	}
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make([]NodeCondition, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeStatus.
-func (in *NodeStatus) DeepCopy() *NodeStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlRestoreStatus.
+func (in *MysqlRestoreStatus) DeepCopy() *MysqlRestoreStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(NodeStatus)
+	out := new(MysqlRestoreStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Persistence) DeepCopyInto(out *Persistence) {
+func (in *MysqlRole) DeepCopyInto(out *MysqlRole) {
 	*out = *in
-	if in.AccessModes != nil {
-		in, out := &in.AccessModes, &out.AccessModes
-		*out = make([]v1.PersistentVolumeAccessMode, len(*in))
-		copy(*out, *in)
-	}
-	if in.StorageClass != nil {
-		in, out := &in.StorageClass, &out.StorageClass
-		*out = new(string)
-		**out = **in
-	}
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Persistence.
-func (in *Persistence) DeepCopy() *Persistence {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlRole.
+func (in *MysqlRole) DeepCopy() *MysqlRole {
 	if in == nil {
 		return nil
 	}
-	out := new(Persistence)
+	out := new(MysqlRole)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MysqlRole) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PodPolicy) DeepCopyInto(out *PodPolicy) {
+func (in *MysqlRoleList) DeepCopyInto(out *MysqlRoleList) {
 	*out = *in
-	if in.Labels != nil {
-		in, out := &in.Labels, &out.Labels
-		*out = make(map[string]string, len(*in))
-		for key, val := range *in {
-			(*out)[key] = val
-		}
-	}
-	if in.Annotations != nil {
-		in, out := &in.Annotations, &out.Annotations
-		*out = make(map[string]string, len(*in))
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MysqlRole, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlRoleList.
+func (in *MysqlRoleList) DeepCopy() *MysqlRoleList {
+	if in == nil {
+		return nil
+	}
+	out := new(MysqlRoleList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MysqlRoleList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MysqlSwitchover) DeepCopyInto(out *MysqlSwitchover) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlSwitchover.
+func (in *MysqlSwitchover) DeepCopy() *MysqlSwitchover {
+	if in == nil {
+		return nil
+	}
+	out := new(MysqlSwitchover)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MysqlSwitchover) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MysqlSwitchoverList) DeepCopyInto(out *MysqlSwitchoverList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MysqlSwitchover, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlSwitchoverList.
+func (in *MysqlSwitchoverList) DeepCopy() *MysqlSwitchoverList {
+	if in == nil {
+		return nil
+	}
+	out := new(MysqlSwitchoverList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MysqlSwitchoverList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MysqlUser) DeepCopyInto(out *MysqlUser) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlUser.
+func (in *MysqlUser) DeepCopy() *MysqlUser {
+	if in == nil {
+		return nil
+	}
+	out := new(MysqlUser)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MysqlUser) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MysqlUserList) DeepCopyInto(out *MysqlUserList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MysqlUser, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlUserList.
+func (in *MysqlUserList) DeepCopy() *MysqlUserList {
+	if in == nil {
+		return nil
+	}
+	out := new(MysqlUserList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MysqlUserList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeCondition) DeepCopyInto(out *NodeCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeCondition.
+func (in *NodeCondition) DeepCopy() *NodeCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeStatus) DeepCopyInto(out *NodeStatus) {
+	*out = *in
+	if in.PendingRestart != nil {
+		in, out := &in.PendingRestart, &out.PendingRestart
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AppliedVariables != nil {
+		in, out := &in.AppliedVariables, &out.AppliedVariables
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.RaftStatus.DeepCopyInto(&out.RaftStatus)
+	if in.RoStatus != nil {
+		in, out := &in.RoStatus, &out.RoStatus
+		*out = new(RoStatus)
+		**out = **in
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]NodeCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeStatus.
+func (in *NodeStatus) DeepCopy() *NodeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PasswordRotation) DeepCopyInto(out *PasswordRotation) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PasswordRotation.
+func (in *PasswordRotation) DeepCopy() *PasswordRotation {
+	if in == nil {
+		return nil
+	}
+	out := new(PasswordRotation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PasswordRotationStatus) DeepCopyInto(out *PasswordRotationStatus) {
+	*out = *in
+	if in.LastRotationTime != nil {
+		in, out := &in.LastRotationTime, &out.LastRotationTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PasswordRotationStatus.
+func (in *PasswordRotationStatus) DeepCopy() *PasswordRotationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PasswordRotationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Persistence) DeepCopyInto(out *Persistence) {
+	*out = *in
+	if in.AccessModes != nil {
+		in, out := &in.AccessModes, &out.AccessModes
+		*out = make([]v1.PersistentVolumeAccessMode, len(*in))
+		copy(*out, *in)
+	}
+	if in.StorageClass != nil {
+		in, out := &in.StorageClass, &out.StorageClass
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Persistence.
+func (in *Persistence) DeepCopy() *Persistence {
+	if in == nil {
+		return nil
+	}
+	out := new(Persistence)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodPolicy) DeepCopyInto(out *PodPolicy) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
 		for key, val := range *in {
 			(*out)[key] = val
 		}
@@ -669,6 +1219,68 @@ func (in *RemoteSourceStruct) DeepCopy() *RemoteSourceStruct {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceCondition) DeepCopyInto(out *ResourceCondition) {
+	*out = *in
+	in.LastUpdateTime.DeepCopyInto(&out.LastUpdateTime)
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceCondition.
+func (in *ResourceCondition) DeepCopy() *ResourceCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreBackupSource) DeepCopyInto(out *RestoreBackupSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreBackupSource.
+func (in *RestoreBackupSource) DeepCopy() *RestoreBackupSource {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreBackupSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreS3Source) DeepCopyInto(out *RestoreS3Source) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreS3Source.
+func (in *RestoreS3Source) DeepCopy() *RestoreS3Source {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreS3Source)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreTarget) DeepCopyInto(out *RestoreTarget) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreTarget.
+func (in *RestoreTarget) DeepCopy() *RestoreTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RoStatus) DeepCopyInto(out *RoStatus) {
 	*out = *in
@@ -684,6 +1296,84 @@ func (in *RoStatus) DeepCopy() *RoStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RoleSpec) DeepCopyInto(out *RoleSpec) {
+	*out = *in
+	out.ClusterRef = in.ClusterRef
+	if in.Permissions != nil {
+		in, out := &in.Permissions, &out.Permissions
+		*out = make([]UserPermission, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RoleSpec.
+func (in *RoleSpec) DeepCopy() *RoleSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RoleSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RoleStatus) DeepCopyInto(out *RoleStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]ResourceCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Grants != nil {
+		in, out := &in.Grants, &out.Grants
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RoleStatus.
+func (in *RoleStatus) DeepCopy() *RoleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RoleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RollingUpdateStatus) DeepCopyInto(out *RollingUpdateStatus) {
+	*out = *in
+	if in.CurrentPods != nil {
+		in, out := &in.CurrentPods, &out.CurrentPods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.StartedAt != nil {
+		in, out := &in.StartedAt, &out.StartedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.SwitchedAt != nil {
+		in, out := &in.SwitchedAt, &out.SwitchedAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RollingUpdateStatus.
+func (in *RollingUpdateStatus) DeepCopy() *RollingUpdateStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RollingUpdateStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SecretSelector) DeepCopyInto(out *SecretSelector) {
 	*out = *in
@@ -699,6 +1389,77 @@ func (in *SecretSelector) DeepCopy() *SecretSelector {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SemiSyncOpts) DeepCopyInto(out *SemiSyncOpts) {
+	*out = *in
+	if in.WaitForSlaveCount != nil {
+		in, out := &in.WaitForSlaveCount, &out.WaitForSlaveCount
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SemiSyncOpts.
+func (in *SemiSyncOpts) DeepCopy() *SemiSyncOpts {
+	if in == nil {
+		return nil
+	}
+	out := new(SemiSyncOpts)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SwitchoverSpec) DeepCopyInto(out *SwitchoverSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SwitchoverSpec.
+func (in *SwitchoverSpec) DeepCopy() *SwitchoverSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SwitchoverSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SwitchoverStatus) DeepCopyInto(out *SwitchoverStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.FenceTime != nil {
+		in, out := &in.FenceTime, &out.FenceTime
+		*out = (*in).DeepCopy()
+	}
+	if in.SwitchTime != nil {
+		in, out := &in.SwitchTime, &out.SwitchTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SwitchoverStatus.
+func (in *SwitchoverStatus) DeepCopy() *SwitchoverStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SwitchoverStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TLSOptions) DeepCopyInto(out *TLSOptions) {
 	*out = *in
@@ -714,6 +1475,74 @@ func (in *TLSOptions) DeepCopy() *TLSOptions {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TryLeaderStatus) DeepCopyInto(out *TryLeaderStatus) {
+	*out = *in
+	if in.FenceTime != nil {
+		in, out := &in.FenceTime, &out.FenceTime
+		*out = (*in).DeepCopy()
+	}
+	if in.SwitchTime != nil {
+		in, out := &in.SwitchTime, &out.SwitchTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TryLeaderStatus.
+func (in *TryLeaderStatus) DeepCopy() *TryLeaderStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TryLeaderStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpdateStrategy) DeepCopyInto(out *UpdateStrategy) {
+	*out = *in
+	if in.Partition != nil {
+		in, out := &in.Partition, &out.Partition
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Canary != nil {
+		in, out := &in.Canary, &out.Canary
+		*out = new(CanaryOpts)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpdateStrategy.
+func (in *UpdateStrategy) DeepCopy() *UpdateStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(UpdateStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeStatus) DeepCopyInto(out *UpgradeStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeStatus.
+func (in *UpgradeStatus) DeepCopy() *UpgradeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *UserOwner) DeepCopyInto(out *UserOwner) {
 	*out = *in
@@ -754,6 +1583,21 @@ func (in *UserPermission) DeepCopy() *UserPermission {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UserResourceLimits) DeepCopyInto(out *UserResourceLimits) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserResourceLimits.
+func (in *UserResourceLimits) DeepCopy() *UserResourceLimits {
+	if in == nil {
+		return nil
+	}
+	out := new(UserResourceLimits)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *UserSpec) DeepCopyInto(out *UserSpec) {
 	*out = *in
@@ -771,7 +1615,28 @@ func (in *UserSpec) DeepCopyInto(out *UserSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Roles != nil {
+		in, out := &in.Roles, &out.Roles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DefaultRoles != nil {
+		in, out := &in.DefaultRoles, &out.DefaultRoles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	out.TLSOptions = in.TLSOptions
+	if in.PasswordRotation != nil {
+		in, out := &in.PasswordRotation, &out.PasswordRotation
+		*out = new(PasswordRotation)
+		**out = **in
+	}
+	out.ResourceLimits = in.ResourceLimits
+	if in.PasswordExpireDays != nil {
+		in, out := &in.PasswordExpireDays, &out.PasswordExpireDays
+		*out = new(int32)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserSpec.
@@ -799,6 +1664,16 @@ func (in *UserStatus) DeepCopyInto(out *UserStatus) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Grants != nil {
+		in, out := &in.Grants, &out.Grants
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PasswordRotation != nil {
+		in, out := &in.PasswordRotation, &out.PasswordRotation
+		*out = new(PasswordRotationStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UserStatus.
@@ -824,6 +1699,11 @@ func (in *XenonOpts) DeepCopyInto(out *XenonOpts) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.PromoteCatchUpTimeout != nil {
+		in, out := &in.PromoteCatchUpTimeout, &out.PromoteCatchUpTimeout
+		*out = new(int32)
+		**out = **in
+	}
 	in.Resources.DeepCopyInto(&out.Resources)
 }
 
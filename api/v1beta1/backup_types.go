@@ -49,6 +49,38 @@ type BackupOps struct {
 	S3         *S3       `json:"s3,omitempty"`
 	NFS        *NFS      `json:"nfs,omitempty"`
 	S3Binlog   *S3Binlog `json:"s3binlog,omitempty"`
+	// Encryption encrypts the backup with xtrabackup before it leaves the node,
+	// it requires the encryptInTransit of the cluster to send the key to the node.
+	// +optional
+	Encryption *BackupEncryption `json:"encryption,omitempty"`
+	// Compression is the algorithm to compress the backup with xtrabackup, mysql 5.7 only
+	// supports qpress and zstd requires xtrabackup 8.0.30 or later.
+	// +optional
+	// +kubebuilder:validation:Enum=qpress;lz4;zstd
+	Compression string `json:"compression,omitempty"`
+	// Parallel is the number of threads to copy and compress the data files with xtrabackup.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	Parallel int32 `json:"parallel,omitempty"`
+	// UploadParallel is the number of threads to upload the backup to S3 with xbcloud, defaults to 10.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	UploadParallel int32 `json:"uploadParallel,omitempty"`
+}
+
+// EncryptionKeyLength is the length of the AES256 encryption key.
+const EncryptionKeyLength = 32
+
+// BackupEncryption defines the encryption of the backup data.
+type BackupEncryption struct {
+	// Algorithm is the encryption algorithm of xtrabackup.
+	// +optional
+	// +kubebuilder:validation:Enum=AES256
+	// +kubebuilder:default:=AES256
+	Algorithm string `json:"algorithm,omitempty"`
+	// KeySecret selects the encryption key in a secret, the key of AES256 must be 32 bytes.
+	// The name of the key is the key ID recorded in the backup status.
+	KeySecret corev1.SecretKeySelector `json:"keySecret"`
 }
 
 type S3Binlog struct {
@@ -68,6 +100,10 @@ type NFS struct {
 }
 
 type ManualBackup struct {
+	// BackupType is full or incremental, defaults to full. The incremental backup is based on
+	// the last successful backup of the cluster in the same storage, it falls back to a full
+	// backup if there is no such backup.
+	// +optional
 	BackupType string `json:"type,omitempty"`
 	// Backup Retention
 	// +optional
@@ -82,12 +118,41 @@ type BackupSchedule struct {
 	// Backup Retention
 	// +optional
 	BackupRetention *int32 `json:"backupRetention,omitempty"`
-	BackupType      string `json:"type,omitempty"`
+	// BackupType is full or incremental, defaults to full. Each incremental backup is based on
+	// the last successful backup, so the chain grows until a full backup is taken.
+	// +optional
+	BackupType string `json:"type,omitempty"`
 	// History Limit of job
 	// +optional
 	BackupJobHistoryLimit *int32 `json:"jobhistoryLimit,omitempty"`
 }
 
+const (
+	// FullBackupType takes a full backup of the cluster.
+	FullBackupType = "full"
+	// IncrementalBackupType takes the changes since the last successful backup.
+	IncrementalBackupType = "incremental"
+)
+
+// BackupChain is the position of a backup in the backup chain. A full backup starts a chain,
+// an incremental backup is based on the last backup of its parents.
+type BackupChain struct {
+	// Base is the name of the full backup which the chain starts from.
+	Base string `json:"base,omitempty"`
+	// Parents are the names of the backups this backup is based on, from the base to the
+	// direct parent, empty for a full backup.
+	// +optional
+	Parents []string `json:"parents,omitempty"`
+	// FromLSN is the LSN the backup starts from, 0 for a full backup.
+	FromLSN string `json:"fromLSN,omitempty"`
+	// ToLSN is the LSN the backup ends at, the next incremental backup starts from it.
+	ToLSN string `json:"toLSN,omitempty"`
+	// Host is the node the backup was taken from, the LSNs are only meaningful on that node,
+	// so an incremental backup is based on the backups of the same node.
+	// +optional
+	Host string `json:"host,omitempty"`
+}
+
 type BackupStatus struct {
 	Type           BackupInitiator     `json:"type,omitempty"`
 	BackupName     string              `json:"backupName,omitempty"`
@@ -100,6 +165,10 @@ type BackupStatus struct {
 	Gtid             string                  `json:"gtid,omitempty"`
 	ManualBackup     *ManualBackupStatus     `json:"manual,omitempty"`
 	ScheduledBackups []ScheduledBackupStatus `json:"scheduled,omitempty"`
+	// EncryptionKeyID is the ID of the key which encrypts the backup.
+	EncryptionKeyID string `json:"encryptionKeyID,omitempty"`
+	// Chain is the position of the backup in the backup chain.
+	Chain *BackupChain `json:"chain,omitempty"`
 }
 
 type BackupConditionType string
@@ -145,6 +214,10 @@ type ManualBackupStatus struct {
 	BackupSize string `json:"backupSize,omitempty"`
 	// Get the Gtid
 	Gtid string `json:"gtid,omitempty"`
+	// EncryptionKeyID is the ID of the key which encrypts the backup.
+	EncryptionKeyID string `json:"encryptionKeyID,omitempty"`
+	// Chain is the position of the backup in the backup chain.
+	Chain *BackupChain `json:"chain,omitempty"`
 	// Get current backup status
 	State BackupConditionType `json:"state,omitempty"`
 }
@@ -175,6 +248,10 @@ type ScheduledBackupStatus struct {
 	BackupSize string `json:"backupSize,omitempty"`
 	// Get the Gtid
 	Gtid string `json:"gtid,omitempty"`
+	// EncryptionKeyID is the ID of the key which encrypts the backup.
+	EncryptionKeyID string `json:"encryptionKeyID,omitempty"`
+	// Chain is the position of the backup in the backup chain.
+	Chain *BackupChain `json:"chain,omitempty"`
 	// Get current backup status
 	State BackupConditionType `json:"state,omitempty"`
 }
@@ -0,0 +1,243 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/robfig/cron/v3"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+
+	"github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+)
+
+// log is for logging in this package.
+var backuplog = logf.Log.WithName("backup-resource")
+
+// backupWebhookReader reads the cluster and the secrets of the Backup.
+var backupWebhookReader client.Reader
+
+// s3SecretKeys are the keys required in the S3 secret, the access keys are optional.
+var s3SecretKeys = []string{"s3-endpoint", "s3-bucket"}
+
+func (r *Backup) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	backupWebhookReader = mgr.GetAPIReader()
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate-mysql-radondb-com-v1beta1-backup,mutating=true,failurePolicy=fail,sideEffects=None,groups=mysql.radondb.com,resources=backups,verbs=create;update,versions=v1beta1,name=mbackup.kb.io,matchPolicy=Exact,admissionReviewVersions=v1
+
+var _ webhook.Defaulter = &Backup{}
+
+// Default implements webhook.Defaulter so a webhook will be registered for the type
+func (r *Backup) Default() {
+	backuplog.Info("default", "name", r.Name)
+
+	// The backup controller lists the backups of the cluster by the label.
+	if r.Spec.ClusterName != "" && r.Labels["cluster"] == "" {
+		if r.Labels == nil {
+			r.Labels = map[string]string{}
+		}
+		r.Labels["cluster"] = r.Spec.ClusterName
+	}
+}
+
+//+kubebuilder:webhook:path=/validate-mysql-radondb-com-v1beta1-backup,mutating=false,failurePolicy=fail,sideEffects=None,groups=mysql.radondb.com,resources=backups,verbs=create;update,versions=v1beta1,name=vbackup.kb.io,matchPolicy=Exact,admissionReviewVersions=v1
+
+var _ webhook.Validator = &Backup{}
+
+// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
+func (r *Backup) ValidateCreate() error {
+	backuplog.Info("validate create", "name", r.Name)
+
+	return r.validateBackup()
+}
+
+// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
+func (r *Backup) ValidateUpdate(old runtime.Object) error {
+	backuplog.Info("validate update", "name", r.Name)
+
+	if !r.DeletionTimestamp.IsZero() {
+		return nil
+	}
+	return r.validateBackup()
+}
+
+// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+func (r *Backup) ValidateDelete() error {
+	backuplog.Info("validate delete", "name", r.Name)
+
+	return nil
+}
+
+func (r *Backup) validateBackup() error {
+	if r.Spec.ClusterName == "" {
+		return r.forbidden(fmt.Errorf("clusterName must not be empty"))
+	}
+	if err := r.validateStorage(); err != nil {
+		return r.forbidden(err)
+	}
+	if err := r.validateSchedule(); err != nil {
+		return r.forbidden(err)
+	}
+	if err := r.validateBackupType(); err != nil {
+		return r.forbidden(err)
+	}
+	if backupWebhookReader == nil {
+		return nil
+	}
+
+	ctx := context.TODO()
+	cluster := &MysqlCluster{}
+	if err := backupWebhookReader.Get(ctx, client.ObjectKey{Name: r.Spec.ClusterName, Namespace: r.Namespace}, cluster); err != nil {
+		if apierrors.IsNotFound(err) {
+			return r.forbidden(fmt.Errorf("the cluster %s is not found", r.Spec.ClusterName))
+		}
+		return err
+	}
+	if s3 := r.Spec.BackupOpts.S3; s3 != nil {
+		if err := r.validateSecret(ctx, s3.BackupSecretName); err != nil {
+			return err
+		}
+	}
+	if s3Binlog := r.Spec.BackupOpts.S3Binlog; s3Binlog != nil {
+		if err := r.validateSecret(ctx, s3Binlog.BackupSecretName); err != nil {
+			return err
+		}
+	}
+	if err := v1alpha1.CheckCompression(r.Spec.BackupOpts.Compression, cluster.Spec.MysqlVersion); err != nil {
+		return r.forbidden(fmt.Errorf("backupops.compression: %s", err))
+	}
+	if encryption := r.Spec.BackupOpts.Encryption; encryption != nil {
+		// The backup job sends the key to the backup container of the node, which must be over https.
+		if !cluster.Spec.EncryptInTransit {
+			return r.forbidden(fmt.Errorf("backupops.encryption requires the encryptInTransit of the cluster %s", r.Spec.ClusterName))
+		}
+		if err := r.validateEncryptionKey(ctx, encryption.KeySecret); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateStorage checks at most one of the S3 and NFS is configured, the binlog backups only
+// require the S3Binlog.
+func (r *Backup) validateStorage() error {
+	opts := r.Spec.BackupOpts
+	switch {
+	case opts.S3 == nil && opts.NFS == nil && opts.S3Binlog == nil:
+		return fmt.Errorf("one of backupops.s3, backupops.nfs and backupops.s3binlog must be configured")
+	case opts.S3 != nil && opts.NFS != nil:
+		return fmt.Errorf("backup can only be configured with one of S3 or NFS")
+	case opts.S3 != nil && opts.S3.BackupSecretName == "":
+		return fmt.Errorf("backupops.s3.secretName must not be empty")
+	case opts.S3Binlog != nil && opts.S3Binlog.BackupSecretName == "":
+		return fmt.Errorf("backupops.s3binlog.secretName must not be empty")
+	case opts.NFS != nil && (opts.NFS.Volume.Server == "" || opts.NFS.Volume.Path == ""):
+		return fmt.Errorf("backupops.nfs.volume requires both the server and the path")
+	}
+	return nil
+}
+
+// validateSchedule checks the cron expression, which is used by the CronJob.
+func (r *Backup) validateSchedule() error {
+	schedule := r.Spec.BackupSchedule
+	if schedule == nil {
+		return nil
+	}
+	if schedule.CronExpression == "" {
+		return fmt.Errorf("schedule.cronExpression must not be empty")
+	}
+	if _, err := cron.ParseStandard(schedule.CronExpression); err != nil {
+		return fmt.Errorf("invalid schedule.cronExpression %q: %s", schedule.CronExpression, err)
+	}
+	return nil
+}
+
+// validateBackupType checks the type of the manual and the scheduled backup is full or incremental.
+func (r *Backup) validateBackupType() error {
+	if r.Spec.Manual != nil {
+		if err := checkBackupType("manual.type", r.Spec.Manual.BackupType); err != nil {
+			return err
+		}
+	}
+	if r.Spec.BackupSchedule != nil {
+		if err := checkBackupType("schedule.type", r.Spec.BackupSchedule.BackupType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkBackupType(field, backupType string) error {
+	switch backupType {
+	case "", FullBackupType, IncrementalBackupType:
+		return nil
+	}
+	return fmt.Errorf("%s must be %s or %s, got %q", field, FullBackupType, IncrementalBackupType, backupType)
+}
+
+// validateSecret checks the S3 secret exists and contains the required keys.
+func (r *Backup) validateSecret(ctx context.Context, name string) error {
+	secret := &corev1.Secret{}
+	if err := backupWebhookReader.Get(ctx, client.ObjectKey{Name: name, Namespace: r.Namespace}, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return r.forbidden(fmt.Errorf("the secret %s is not found", name))
+		}
+		return err
+	}
+	for _, key := range s3SecretKeys {
+		if len(secret.Data[key]) == 0 {
+			return r.forbidden(fmt.Errorf("the key %s of the secret %s is empty", key, name))
+		}
+	}
+	return nil
+}
+
+// validateEncryptionKey checks the encryption key exists and is usable by AES256.
+func (r *Backup) validateEncryptionKey(ctx context.Context, selector corev1.SecretKeySelector) error {
+	secret := &corev1.Secret{}
+	if err := backupWebhookReader.Get(ctx, client.ObjectKey{Name: selector.Name, Namespace: r.Namespace}, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return r.forbidden(fmt.Errorf("the secret %s is not found", selector.Name))
+		}
+		return err
+	}
+	key, ok := secret.Data[selector.Key]
+	if !ok {
+		return r.forbidden(fmt.Errorf("the key %s of the secret %s is not found", selector.Key, selector.Name))
+	}
+	if len(strings.TrimSpace(string(key))) != EncryptionKeyLength {
+		return r.forbidden(fmt.Errorf("the key %s of the secret %s must be %d bytes", selector.Key, selector.Name, EncryptionKeyLength))
+	}
+	return nil
+}
+
+func (r *Backup) forbidden(err error) error {
+	return apierrors.NewForbidden(schema.GroupResource{Group: GroupVersion.Group, Resource: "backups"}, r.Name, err)
+}
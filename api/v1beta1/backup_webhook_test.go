@@ -0,0 +1,158 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestValidateStorage(t *testing.T) {
+	nfs := &NFS{Volume: corev1.NFSVolumeSource{Server: "10.0.0.1", Path: "/backup"}}
+	cases := []struct {
+		name  string
+		opts  BackupOps
+		valid bool
+	}{
+		{name: "empty", opts: BackupOps{}},
+		{name: "s3", opts: BackupOps{S3: &S3{BackupSecretName: "s3"}}, valid: true},
+		{name: "s3 without secret", opts: BackupOps{S3: &S3{}}},
+		{name: "nfs", opts: BackupOps{NFS: nfs}, valid: true},
+		{name: "nfs without path", opts: BackupOps{NFS: &NFS{Volume: corev1.NFSVolumeSource{Server: "10.0.0.1"}}}},
+		{name: "s3 and nfs", opts: BackupOps{S3: &S3{BackupSecretName: "s3"}, NFS: nfs}},
+		{name: "s3binlog", opts: BackupOps{S3Binlog: &S3Binlog{BackupSecretName: "s3"}}, valid: true},
+		{name: "s3binlog without secret", opts: BackupOps{S3Binlog: &S3Binlog{}}},
+		{name: "s3 and s3binlog", opts: BackupOps{S3: &S3{BackupSecretName: "s3"}, S3Binlog: &S3Binlog{BackupSecretName: "s3"}}, valid: true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			backup := &Backup{Spec: BackupSpec{BackupOpts: c.opts}}
+			err := backup.validateStorage()
+			if c.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateSchedule(t *testing.T) {
+	backup := &Backup{}
+	assert.NoError(t, backup.validateSchedule())
+
+	backup.Spec.BackupSchedule = &BackupSchedule{}
+	assert.Error(t, backup.validateSchedule())
+
+	backup.Spec.BackupSchedule.CronExpression = "0 0 * * *"
+	assert.NoError(t, backup.validateSchedule())
+
+	backup.Spec.BackupSchedule.CronExpression = "0 0 * *"
+	assert.Error(t, backup.validateSchedule())
+}
+
+func TestValidateBackupType(t *testing.T) {
+	backup := &Backup{Spec: BackupSpec{
+		Manual:         &ManualBackup{},
+		BackupSchedule: &BackupSchedule{BackupType: IncrementalBackupType},
+	}}
+	assert.NoError(t, backup.validateBackupType())
+
+	backup.Spec.Manual.BackupType = "differential"
+	assert.Error(t, backup.validateBackupType())
+}
+
+func TestValidateBackup(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	cluster := &MysqlCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"},
+		Spec:       MysqlClusterSpec{EncryptInTransit: true, MysqlVersion: "8.0"},
+	}
+	plain := &MysqlCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "plain", Namespace: "default"},
+		Spec:       MysqlClusterSpec{MysqlVersion: "5.7"},
+	}
+	s3Secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "s3", Namespace: "default"},
+		Data:       map[string][]byte{"s3-endpoint": []byte("http://s3"), "s3-bucket": []byte("backup")},
+	}
+	keySecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "key", Namespace: "default"},
+		Data: map[string][]byte{
+			"valid": []byte("0123456789abcdef0123456789abcdef\n"),
+			"short": []byte("0123456789abcdef"),
+		},
+	}
+	oldReader := backupWebhookReader
+	backupWebhookReader = fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster, plain, s3Secret, keySecret).Build()
+	defer func() { backupWebhookReader = oldReader }()
+
+	backup := &Backup{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "default"},
+		Spec: BackupSpec{
+			ClusterName: "sample",
+			BackupOpts:  BackupOps{S3: &S3{BackupSecretName: "s3"}},
+		},
+	}
+	assert.NoError(t, backup.ValidateCreate())
+
+	// The binlog backups do not require the S3 or the NFS.
+	backup.Spec.BackupOpts = BackupOps{S3Binlog: &S3Binlog{BackupSecretName: "s3"}}
+	assert.NoError(t, backup.ValidateCreate())
+
+	backup.Spec.BackupOpts = BackupOps{S3: &S3{BackupSecretName: "missing"}}
+	assert.True(t, apierrors.IsForbidden(backup.ValidateCreate()))
+
+	backup.Spec.BackupOpts = BackupOps{
+		S3:         &S3{BackupSecretName: "s3"},
+		Encryption: &BackupEncryption{KeySecret: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "key"}, Key: "valid"}},
+	}
+	assert.NoError(t, backup.ValidateUpdate(backup))
+	backup.Spec.BackupOpts.Encryption.KeySecret.Key = "short"
+	assert.True(t, apierrors.IsForbidden(backup.ValidateUpdate(backup)))
+
+	// The encryption key is sent to the node over https.
+	backup.Spec.BackupOpts.Encryption.KeySecret.Key = "valid"
+	backup.Spec.ClusterName = "plain"
+	assert.True(t, apierrors.IsForbidden(backup.ValidateCreate()))
+	backup.Spec.ClusterName = "sample"
+
+	backup.Spec.BackupOpts.Encryption = nil
+	// The xtrabackup of mysql 5.7 only supports qpress.
+	backup.Spec.BackupOpts.Compression = "zstd"
+	assert.NoError(t, backup.ValidateCreate())
+	backup.Spec.ClusterName = "plain"
+	assert.True(t, apierrors.IsForbidden(backup.ValidateCreate()))
+	backup.Spec.BackupOpts.Compression = "qpress"
+	assert.NoError(t, backup.ValidateCreate())
+
+	backup.Spec.ClusterName = "missing"
+	assert.True(t, apierrors.IsForbidden(backup.ValidateCreate()))
+
+	// Do not block removing the finalizer of the deleting backup.
+	now := metav1.Now()
+	backup.DeletionTimestamp = &now
+	assert.NoError(t, backup.ValidateUpdate(backup))
+}
@@ -129,6 +129,11 @@ func Convert_v1alpha1_MysqlClusterSpec_To_v1beta1_MysqlClusterSpec(in *v1alpha1.
 		}
 
 	}
+	out.DataSource.EncryptionKeySecret = in.RestoreEncryptionKeySecret
+	out.DataSource.Compression = in.RestoreCompression
+	out.DataSource.Parallel = in.RestoreParallel
+	out.DataSource.PrepareMemory = in.RestorePrepareMemory
+	out.DataSource.RestoreTarget = (*RestoreTarget)(in.RestoreTarget)
 	if in.TlsSecretName != "" {
 		out.CustomTLSSecret = &corev1.SecretProjection{
 			LocalObjectReference: corev1.LocalObjectReference{
@@ -203,6 +208,11 @@ func Convert_v1beta1_MysqlClusterSpec_To_v1alpha1_MysqlClusterSpec(in *MysqlClus
 			in.DataSource.NFSBackup.Volume.Server, in.DataSource.NFSBackup.Volume.Path)
 		out.RestorePoint = in.DataSource.RestorePoint
 	}
+	out.RestoreEncryptionKeySecret = in.DataSource.EncryptionKeySecret
+	out.RestoreCompression = in.DataSource.Compression
+	out.RestoreParallel = in.DataSource.Parallel
+	out.RestorePrepareMemory = in.DataSource.PrepareMemory
+	out.RestoreTarget = (*v1alpha1.RestoreTarget)(in.DataSource.RestoreTarget)
 
 	//TODO in.Log n.Service
 	return nil
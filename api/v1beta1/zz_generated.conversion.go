@@ -24,7 +24,8 @@ import (
 	unsafe "unsafe"
 
 	v1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
-	v1 "k8s.io/api/core/v1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	conversion "k8s.io/apimachinery/pkg/conversion"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
@@ -36,6 +37,26 @@ func init() {
 // RegisterConversions adds conversion functions to the given scheme.
 // Public to allow building arbitrary schemes.
 func RegisterConversions(s *runtime.Scheme) error {
+	if err := s.AddGeneratedConversionFunc((*AllowedNamespaces)(nil), (*v1alpha1.AllowedNamespaces)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_AllowedNamespaces_To_v1alpha1_AllowedNamespaces(a.(*AllowedNamespaces), b.(*v1alpha1.AllowedNamespaces), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1alpha1.AllowedNamespaces)(nil), (*AllowedNamespaces)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_AllowedNamespaces_To_v1beta1_AllowedNamespaces(a.(*v1alpha1.AllowedNamespaces), b.(*AllowedNamespaces), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*AutoTLSOpts)(nil), (*v1alpha1.AutoTLSOpts)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_AutoTLSOpts_To_v1alpha1_AutoTLSOpts(a.(*AutoTLSOpts), b.(*v1alpha1.AutoTLSOpts), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1alpha1.AutoTLSOpts)(nil), (*AutoTLSOpts)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_AutoTLSOpts_To_v1beta1_AutoTLSOpts(a.(*v1alpha1.AutoTLSOpts), b.(*AutoTLSOpts), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*Backup)(nil), (*v1alpha1.Backup)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1beta1_Backup_To_v1alpha1_Backup(a.(*Backup), b.(*v1alpha1.Backup), scope)
 	}); err != nil {
@@ -56,6 +77,26 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*BinlogArchiveOpts)(nil), (*v1alpha1.BinlogArchiveOpts)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_BinlogArchiveOpts_To_v1alpha1_BinlogArchiveOpts(a.(*BinlogArchiveOpts), b.(*v1alpha1.BinlogArchiveOpts), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1alpha1.BinlogArchiveOpts)(nil), (*BinlogArchiveOpts)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_BinlogArchiveOpts_To_v1beta1_BinlogArchiveOpts(a.(*v1alpha1.BinlogArchiveOpts), b.(*BinlogArchiveOpts), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*CanaryOpts)(nil), (*v1alpha1.CanaryOpts)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_CanaryOpts_To_v1alpha1_CanaryOpts(a.(*CanaryOpts), b.(*v1alpha1.CanaryOpts), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1alpha1.CanaryOpts)(nil), (*CanaryOpts)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_CanaryOpts_To_v1beta1_CanaryOpts(a.(*v1alpha1.CanaryOpts), b.(*CanaryOpts), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*ClusterCondition)(nil), (*v1alpha1.ClusterCondition)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1beta1_ClusterCondition_To_v1alpha1_ClusterCondition(a.(*ClusterCondition), b.(*v1alpha1.ClusterCondition), scope)
 	}); err != nil {
@@ -66,6 +107,26 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*CredentialsRotationOpts)(nil), (*v1alpha1.CredentialsRotationOpts)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_CredentialsRotationOpts_To_v1alpha1_CredentialsRotationOpts(a.(*CredentialsRotationOpts), b.(*v1alpha1.CredentialsRotationOpts), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1alpha1.CredentialsRotationOpts)(nil), (*CredentialsRotationOpts)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_CredentialsRotationOpts_To_v1beta1_CredentialsRotationOpts(a.(*v1alpha1.CredentialsRotationOpts), b.(*CredentialsRotationOpts), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*CredentialsRotationStatus)(nil), (*v1alpha1.CredentialsRotationStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_CredentialsRotationStatus_To_v1alpha1_CredentialsRotationStatus(a.(*CredentialsRotationStatus), b.(*v1alpha1.CredentialsRotationStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1alpha1.CredentialsRotationStatus)(nil), (*CredentialsRotationStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_CredentialsRotationStatus_To_v1beta1_CredentialsRotationStatus(a.(*v1alpha1.CredentialsRotationStatus), b.(*CredentialsRotationStatus), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*MysqlCluster)(nil), (*v1alpha1.MysqlCluster)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1beta1_MysqlCluster_To_v1alpha1_MysqlCluster(a.(*MysqlCluster), b.(*v1alpha1.MysqlCluster), scope)
 	}); err != nil {
@@ -146,6 +207,16 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*RestoreTarget)(nil), (*v1alpha1.RestoreTarget)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_RestoreTarget_To_v1alpha1_RestoreTarget(a.(*RestoreTarget), b.(*v1alpha1.RestoreTarget), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1alpha1.RestoreTarget)(nil), (*RestoreTarget)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_RestoreTarget_To_v1beta1_RestoreTarget(a.(*v1alpha1.RestoreTarget), b.(*RestoreTarget), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*RoStatus)(nil), (*v1alpha1.RoStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1beta1_RoStatus_To_v1alpha1_RoStatus(a.(*RoStatus), b.(*v1alpha1.RoStatus), scope)
 	}); err != nil {
@@ -156,6 +227,56 @@ func RegisterConversions(s *runtime.Scheme) error {
 	}); err != nil {
 		return err
 	}
+	if err := s.AddGeneratedConversionFunc((*RollingUpdateStatus)(nil), (*v1alpha1.RollingUpdateStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_RollingUpdateStatus_To_v1alpha1_RollingUpdateStatus(a.(*RollingUpdateStatus), b.(*v1alpha1.RollingUpdateStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1alpha1.RollingUpdateStatus)(nil), (*RollingUpdateStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_RollingUpdateStatus_To_v1beta1_RollingUpdateStatus(a.(*v1alpha1.RollingUpdateStatus), b.(*RollingUpdateStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*SemiSyncOpts)(nil), (*v1alpha1.SemiSyncOpts)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_SemiSyncOpts_To_v1alpha1_SemiSyncOpts(a.(*SemiSyncOpts), b.(*v1alpha1.SemiSyncOpts), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1alpha1.SemiSyncOpts)(nil), (*SemiSyncOpts)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_SemiSyncOpts_To_v1beta1_SemiSyncOpts(a.(*v1alpha1.SemiSyncOpts), b.(*SemiSyncOpts), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*TryLeaderStatus)(nil), (*v1alpha1.TryLeaderStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_TryLeaderStatus_To_v1alpha1_TryLeaderStatus(a.(*TryLeaderStatus), b.(*v1alpha1.TryLeaderStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1alpha1.TryLeaderStatus)(nil), (*TryLeaderStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_TryLeaderStatus_To_v1beta1_TryLeaderStatus(a.(*v1alpha1.TryLeaderStatus), b.(*TryLeaderStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*UpdateStrategy)(nil), (*v1alpha1.UpdateStrategy)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_UpdateStrategy_To_v1alpha1_UpdateStrategy(a.(*UpdateStrategy), b.(*v1alpha1.UpdateStrategy), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1alpha1.UpdateStrategy)(nil), (*UpdateStrategy)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_UpdateStrategy_To_v1beta1_UpdateStrategy(a.(*v1alpha1.UpdateStrategy), b.(*UpdateStrategy), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*UpgradeStatus)(nil), (*v1alpha1.UpgradeStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1beta1_UpgradeStatus_To_v1alpha1_UpgradeStatus(a.(*UpgradeStatus), b.(*v1alpha1.UpgradeStatus), scope)
+	}); err != nil {
+		return err
+	}
+	if err := s.AddGeneratedConversionFunc((*v1alpha1.UpgradeStatus)(nil), (*UpgradeStatus)(nil), func(a, b interface{}, scope conversion.Scope) error {
+		return Convert_v1alpha1_UpgradeStatus_To_v1beta1_UpgradeStatus(a.(*v1alpha1.UpgradeStatus), b.(*UpgradeStatus), scope)
+	}); err != nil {
+		return err
+	}
 	if err := s.AddGeneratedConversionFunc((*XenonOpts)(nil), (*v1alpha1.XenonOpts)(nil), func(a, b interface{}, scope conversion.Scope) error {
 		return Convert_v1beta1_XenonOpts_To_v1alpha1_XenonOpts(a.(*XenonOpts), b.(*v1alpha1.XenonOpts), scope)
 	}); err != nil {
@@ -199,6 +320,52 @@ func RegisterConversions(s *runtime.Scheme) error {
 	return nil
 }
 
+func autoConvert_v1beta1_AllowedNamespaces_To_v1alpha1_AllowedNamespaces(in *AllowedNamespaces, out *v1alpha1.AllowedNamespaces, s conversion.Scope) error {
+	out.From = v1alpha1.NamespacesFrom(in.From)
+	out.Selector = (*v1.LabelSelector)(unsafe.Pointer(in.Selector))
+	return nil
+}
+
+// Convert_v1beta1_AllowedNamespaces_To_v1alpha1_AllowedNamespaces is an autogenerated conversion function.
+func Convert_v1beta1_AllowedNamespaces_To_v1alpha1_AllowedNamespaces(in *AllowedNamespaces, out *v1alpha1.AllowedNamespaces, s conversion.Scope) error {
+	return autoConvert_v1beta1_AllowedNamespaces_To_v1alpha1_AllowedNamespaces(in, out, s)
+}
+
+func autoConvert_v1alpha1_AllowedNamespaces_To_v1beta1_AllowedNamespaces(in *v1alpha1.AllowedNamespaces, out *AllowedNamespaces, s conversion.Scope) error {
+	out.From = NamespacesFrom(in.From)
+	out.Selector = (*v1.LabelSelector)(unsafe.Pointer(in.Selector))
+	return nil
+}
+
+// Convert_v1alpha1_AllowedNamespaces_To_v1beta1_AllowedNamespaces is an autogenerated conversion function.
+func Convert_v1alpha1_AllowedNamespaces_To_v1beta1_AllowedNamespaces(in *v1alpha1.AllowedNamespaces, out *AllowedNamespaces, s conversion.Scope) error {
+	return autoConvert_v1alpha1_AllowedNamespaces_To_v1beta1_AllowedNamespaces(in, out, s)
+}
+
+func autoConvert_v1beta1_AutoTLSOpts_To_v1alpha1_AutoTLSOpts(in *AutoTLSOpts, out *v1alpha1.AutoTLSOpts, s conversion.Scope) error {
+	out.Enabled = in.Enabled
+	out.CertValidityDays = in.CertValidityDays
+	out.RenewBeforeDays = in.RenewBeforeDays
+	return nil
+}
+
+// Convert_v1beta1_AutoTLSOpts_To_v1alpha1_AutoTLSOpts is an autogenerated conversion function.
+func Convert_v1beta1_AutoTLSOpts_To_v1alpha1_AutoTLSOpts(in *AutoTLSOpts, out *v1alpha1.AutoTLSOpts, s conversion.Scope) error {
+	return autoConvert_v1beta1_AutoTLSOpts_To_v1alpha1_AutoTLSOpts(in, out, s)
+}
+
+func autoConvert_v1alpha1_AutoTLSOpts_To_v1beta1_AutoTLSOpts(in *v1alpha1.AutoTLSOpts, out *AutoTLSOpts, s conversion.Scope) error {
+	out.Enabled = in.Enabled
+	out.CertValidityDays = in.CertValidityDays
+	out.RenewBeforeDays = in.RenewBeforeDays
+	return nil
+}
+
+// Convert_v1alpha1_AutoTLSOpts_To_v1beta1_AutoTLSOpts is an autogenerated conversion function.
+func Convert_v1alpha1_AutoTLSOpts_To_v1beta1_AutoTLSOpts(in *v1alpha1.AutoTLSOpts, out *AutoTLSOpts, s conversion.Scope) error {
+	return autoConvert_v1alpha1_AutoTLSOpts_To_v1beta1_AutoTLSOpts(in, out, s)
+}
+
 func autoConvert_v1beta1_Backup_To_v1alpha1_Backup(in *Backup, out *v1alpha1.Backup, s conversion.Scope) error {
 	out.ObjectMeta = in.ObjectMeta
 	if err := Convert_v1beta1_BackupSpec_To_v1alpha1_BackupSpec(&in.Spec, &out.Spec, s); err != nil {
@@ -302,6 +469,8 @@ func autoConvert_v1beta1_BackupStatus_To_v1alpha1_BackupStatus(in *BackupStatus,
 	out.Gtid = in.Gtid
 	// WARNING: in.ManualBackup requires manual conversion: does not exist in peer-type
 	// WARNING: in.ScheduledBackups requires manual conversion: does not exist in peer-type
+	// WARNING: in.EncryptionKeyID requires manual conversion: does not exist in peer-type
+	// WARNING: in.Chain requires manual conversion: does not exist in peer-type
 	return nil
 }
 
@@ -315,9 +484,59 @@ func autoConvert_v1alpha1_BackupStatus_To_v1beta1_BackupStatus(in *v1alpha1.Back
 	return nil
 }
 
+func autoConvert_v1beta1_BinlogArchiveOpts_To_v1alpha1_BinlogArchiveOpts(in *BinlogArchiveOpts, out *v1alpha1.BinlogArchiveOpts, s conversion.Scope) error {
+	out.Enabled = in.Enabled
+	out.S3SecretName = in.S3SecretName
+	out.Prefix = in.Prefix
+	out.FlushIntervalSeconds = in.FlushIntervalSeconds
+	out.Resources = in.Resources
+	return nil
+}
+
+// Convert_v1beta1_BinlogArchiveOpts_To_v1alpha1_BinlogArchiveOpts is an autogenerated conversion function.
+func Convert_v1beta1_BinlogArchiveOpts_To_v1alpha1_BinlogArchiveOpts(in *BinlogArchiveOpts, out *v1alpha1.BinlogArchiveOpts, s conversion.Scope) error {
+	return autoConvert_v1beta1_BinlogArchiveOpts_To_v1alpha1_BinlogArchiveOpts(in, out, s)
+}
+
+func autoConvert_v1alpha1_BinlogArchiveOpts_To_v1beta1_BinlogArchiveOpts(in *v1alpha1.BinlogArchiveOpts, out *BinlogArchiveOpts, s conversion.Scope) error {
+	out.Enabled = in.Enabled
+	out.S3SecretName = in.S3SecretName
+	out.Prefix = in.Prefix
+	out.FlushIntervalSeconds = in.FlushIntervalSeconds
+	out.Resources = in.Resources
+	return nil
+}
+
+// Convert_v1alpha1_BinlogArchiveOpts_To_v1beta1_BinlogArchiveOpts is an autogenerated conversion function.
+func Convert_v1alpha1_BinlogArchiveOpts_To_v1beta1_BinlogArchiveOpts(in *v1alpha1.BinlogArchiveOpts, out *BinlogArchiveOpts, s conversion.Scope) error {
+	return autoConvert_v1alpha1_BinlogArchiveOpts_To_v1beta1_BinlogArchiveOpts(in, out, s)
+}
+
+func autoConvert_v1beta1_CanaryOpts_To_v1alpha1_CanaryOpts(in *CanaryOpts, out *v1alpha1.CanaryOpts, s conversion.Scope) error {
+	out.SoakSeconds = in.SoakSeconds
+	out.MaxLagSeconds = in.MaxLagSeconds
+	return nil
+}
+
+// Convert_v1beta1_CanaryOpts_To_v1alpha1_CanaryOpts is an autogenerated conversion function.
+func Convert_v1beta1_CanaryOpts_To_v1alpha1_CanaryOpts(in *CanaryOpts, out *v1alpha1.CanaryOpts, s conversion.Scope) error {
+	return autoConvert_v1beta1_CanaryOpts_To_v1alpha1_CanaryOpts(in, out, s)
+}
+
+func autoConvert_v1alpha1_CanaryOpts_To_v1beta1_CanaryOpts(in *v1alpha1.CanaryOpts, out *CanaryOpts, s conversion.Scope) error {
+	out.SoakSeconds = in.SoakSeconds
+	out.MaxLagSeconds = in.MaxLagSeconds
+	return nil
+}
+
+// Convert_v1alpha1_CanaryOpts_To_v1beta1_CanaryOpts is an autogenerated conversion function.
+func Convert_v1alpha1_CanaryOpts_To_v1beta1_CanaryOpts(in *v1alpha1.CanaryOpts, out *CanaryOpts, s conversion.Scope) error {
+	return autoConvert_v1alpha1_CanaryOpts_To_v1beta1_CanaryOpts(in, out, s)
+}
+
 func autoConvert_v1beta1_ClusterCondition_To_v1alpha1_ClusterCondition(in *ClusterCondition, out *v1alpha1.ClusterCondition, s conversion.Scope) error {
 	out.Type = v1alpha1.ClusterConditionType(in.Type)
-	out.Status = v1.ConditionStatus(in.Status)
+	out.Status = corev1.ConditionStatus(in.Status)
 	out.LastTransitionTime = in.LastTransitionTime
 	out.Reason = in.Reason
 	out.Message = in.Message
@@ -331,7 +550,7 @@ func Convert_v1beta1_ClusterCondition_To_v1alpha1_ClusterCondition(in *ClusterCo
 
 func autoConvert_v1alpha1_ClusterCondition_To_v1beta1_ClusterCondition(in *v1alpha1.ClusterCondition, out *ClusterCondition, s conversion.Scope) error {
 	out.Type = ClusterConditionType(in.Type)
-	out.Status = v1.ConditionStatus(in.Status)
+	out.Status = corev1.ConditionStatus(in.Status)
 	out.LastTransitionTime = in.LastTransitionTime
 	out.Reason = in.Reason
 	out.Message = in.Message
@@ -343,6 +562,60 @@ func Convert_v1alpha1_ClusterCondition_To_v1beta1_ClusterCondition(in *v1alpha1.
 	return autoConvert_v1alpha1_ClusterCondition_To_v1beta1_ClusterCondition(in, out, s)
 }
 
+func autoConvert_v1beta1_CredentialsRotationOpts_To_v1alpha1_CredentialsRotationOpts(in *CredentialsRotationOpts, out *v1alpha1.CredentialsRotationOpts, s conversion.Scope) error {
+	out.IntervalDays = in.IntervalDays
+	return nil
+}
+
+// Convert_v1beta1_CredentialsRotationOpts_To_v1alpha1_CredentialsRotationOpts is an autogenerated conversion function.
+func Convert_v1beta1_CredentialsRotationOpts_To_v1alpha1_CredentialsRotationOpts(in *CredentialsRotationOpts, out *v1alpha1.CredentialsRotationOpts, s conversion.Scope) error {
+	return autoConvert_v1beta1_CredentialsRotationOpts_To_v1alpha1_CredentialsRotationOpts(in, out, s)
+}
+
+func autoConvert_v1alpha1_CredentialsRotationOpts_To_v1beta1_CredentialsRotationOpts(in *v1alpha1.CredentialsRotationOpts, out *CredentialsRotationOpts, s conversion.Scope) error {
+	out.IntervalDays = in.IntervalDays
+	return nil
+}
+
+// Convert_v1alpha1_CredentialsRotationOpts_To_v1beta1_CredentialsRotationOpts is an autogenerated conversion function.
+func Convert_v1alpha1_CredentialsRotationOpts_To_v1beta1_CredentialsRotationOpts(in *v1alpha1.CredentialsRotationOpts, out *CredentialsRotationOpts, s conversion.Scope) error {
+	return autoConvert_v1alpha1_CredentialsRotationOpts_To_v1beta1_CredentialsRotationOpts(in, out, s)
+}
+
+func autoConvert_v1beta1_CredentialsRotationStatus_To_v1alpha1_CredentialsRotationStatus(in *CredentialsRotationStatus, out *v1alpha1.CredentialsRotationStatus, s conversion.Scope) error {
+	out.LastRotationTime = (*v1.Time)(unsafe.Pointer(in.LastRotationTime))
+	out.Trigger = in.Trigger
+	out.Message = in.Message
+	out.Phase = v1alpha1.CredentialsRotationPhase(in.Phase)
+	out.StartedAt = (*v1.Time)(unsafe.Pointer(in.StartedAt))
+	out.ReconfiguredPods = *(*[]string)(unsafe.Pointer(&in.ReconfiguredPods))
+	out.RestartingPod = in.RestartingPod
+	out.XenonRestartCount = in.XenonRestartCount
+	return nil
+}
+
+// Convert_v1beta1_CredentialsRotationStatus_To_v1alpha1_CredentialsRotationStatus is an autogenerated conversion function.
+func Convert_v1beta1_CredentialsRotationStatus_To_v1alpha1_CredentialsRotationStatus(in *CredentialsRotationStatus, out *v1alpha1.CredentialsRotationStatus, s conversion.Scope) error {
+	return autoConvert_v1beta1_CredentialsRotationStatus_To_v1alpha1_CredentialsRotationStatus(in, out, s)
+}
+
+func autoConvert_v1alpha1_CredentialsRotationStatus_To_v1beta1_CredentialsRotationStatus(in *v1alpha1.CredentialsRotationStatus, out *CredentialsRotationStatus, s conversion.Scope) error {
+	out.LastRotationTime = (*v1.Time)(unsafe.Pointer(in.LastRotationTime))
+	out.Trigger = in.Trigger
+	out.Message = in.Message
+	out.Phase = CredentialsRotationPhase(in.Phase)
+	out.StartedAt = (*v1.Time)(unsafe.Pointer(in.StartedAt))
+	out.ReconfiguredPods = *(*[]string)(unsafe.Pointer(&in.ReconfiguredPods))
+	out.RestartingPod = in.RestartingPod
+	out.XenonRestartCount = in.XenonRestartCount
+	return nil
+}
+
+// Convert_v1alpha1_CredentialsRotationStatus_To_v1beta1_CredentialsRotationStatus is an autogenerated conversion function.
+func Convert_v1alpha1_CredentialsRotationStatus_To_v1beta1_CredentialsRotationStatus(in *v1alpha1.CredentialsRotationStatus, out *CredentialsRotationStatus, s conversion.Scope) error {
+	return autoConvert_v1alpha1_CredentialsRotationStatus_To_v1beta1_CredentialsRotationStatus(in, out, s)
+}
+
 func autoConvert_v1beta1_MysqlCluster_To_v1alpha1_MysqlCluster(in *MysqlCluster, out *v1alpha1.MysqlCluster, s conversion.Scope) error {
 	out.ObjectMeta = in.ObjectMeta
 	if err := Convert_v1beta1_MysqlClusterSpec_To_v1alpha1_MysqlClusterSpec(&in.Spec, &out.Spec, s); err != nil {
@@ -425,9 +698,18 @@ func autoConvert_v1beta1_MysqlClusterSpec_To_v1alpha1_MysqlClusterSpec(in *Mysql
 	// WARNING: in.MySQLConfig requires manual conversion: does not exist in peer-type
 	// WARNING: in.Resources requires manual conversion: does not exist in peer-type
 	// WARNING: in.CustomTLSSecret requires manual conversion: does not exist in peer-type
+	out.AutoTLS = (*v1alpha1.AutoTLSOpts)(unsafe.Pointer(in.AutoTLS))
+	out.EncryptInTransit = in.EncryptInTransit
 	// WARNING: in.Storage requires manual conversion: does not exist in peer-type
 	out.MysqlVersion = in.MysqlVersion
 	// WARNING: in.Xenon requires manual conversion: does not exist in peer-type
+	out.ReplicationMode = in.ReplicationMode
+	if err := Convert_v1beta1_SemiSyncOpts_To_v1alpha1_SemiSyncOpts(&in.SemiSync, &out.SemiSync, s); err != nil {
+		return err
+	}
+	if err := Convert_v1beta1_UpdateStrategy_To_v1alpha1_UpdateStrategy(&in.UpdateStrategy, &out.UpdateStrategy, s); err != nil {
+		return err
+	}
 	// WARNING: in.Backup requires manual conversion: does not exist in peer-type
 	// WARNING: in.Monitoring requires manual conversion: does not exist in peer-type
 	// WARNING: in.Image requires manual conversion: does not exist in peer-type
@@ -444,6 +726,9 @@ func autoConvert_v1beta1_MysqlClusterSpec_To_v1alpha1_MysqlClusterSpec(in *Mysql
 	// WARNING: in.Service requires manual conversion: does not exist in peer-type
 	out.LeaderAsFollower = in.LeaderAsFollower
 	out.ServerIDOffset = in.ServerIDOffset
+	out.AllowedUserNamespaces = (*v1alpha1.AllowedNamespaces)(unsafe.Pointer(in.AllowedUserNamespaces))
+	out.RotateInternalCredentials = (*v1alpha1.CredentialsRotationOpts)(unsafe.Pointer(in.RotateInternalCredentials))
+	out.BinlogArchive = (*v1alpha1.BinlogArchiveOpts)(unsafe.Pointer(in.BinlogArchive))
 	return nil
 }
 
@@ -454,6 +739,13 @@ func autoConvert_v1alpha1_MysqlClusterSpec_To_v1beta1_MysqlClusterSpec(in *v1alp
 	out.MinAvailable = in.MinAvailable
 	// WARNING: in.MysqlOpts requires manual conversion: does not exist in peer-type
 	// WARNING: in.XenonOpts requires manual conversion: does not exist in peer-type
+	out.ReplicationMode = in.ReplicationMode
+	if err := Convert_v1alpha1_SemiSyncOpts_To_v1beta1_SemiSyncOpts(&in.SemiSync, &out.SemiSync, s); err != nil {
+		return err
+	}
+	if err := Convert_v1alpha1_UpdateStrategy_To_v1beta1_UpdateStrategy(&in.UpdateStrategy, &out.UpdateStrategy, s); err != nil {
+		return err
+	}
 	// WARNING: in.MetricsOpts requires manual conversion: does not exist in peer-type
 	out.MysqlVersion = in.MysqlVersion
 	// WARNING: in.PodPolicy requires manual conversion: does not exist in peer-type
@@ -461,15 +753,25 @@ func autoConvert_v1alpha1_MysqlClusterSpec_To_v1beta1_MysqlClusterSpec(in *v1alp
 	// WARNING: in.BackupSecretName requires manual conversion: does not exist in peer-type
 	// WARNING: in.RestoreFrom requires manual conversion: does not exist in peer-type
 	// WARNING: in.RestorePoint requires manual conversion: does not exist in peer-type
+	// WARNING: in.RestoreTarget requires manual conversion: does not exist in peer-type
 	// WARNING: in.NFSServerAddress requires manual conversion: does not exist in peer-type
+	// WARNING: in.RestoreEncryptionKeySecret requires manual conversion: does not exist in peer-type
+	// WARNING: in.RestoreCompression requires manual conversion: does not exist in peer-type
+	// WARNING: in.RestoreParallel requires manual conversion: does not exist in peer-type
+	// WARNING: in.RestorePrepareMemory requires manual conversion: does not exist in peer-type
 	// WARNING: in.BackupSchedule requires manual conversion: does not exist in peer-type
 	// WARNING: in.BothS3NFS requires manual conversion: does not exist in peer-type
 	// WARNING: in.BackupScheduleJobsHistoryLimit requires manual conversion: does not exist in peer-type
 	// WARNING: in.TlsSecretName requires manual conversion: does not exist in peer-type
+	out.AutoTLS = (*AutoTLSOpts)(unsafe.Pointer(in.AutoTLS))
+	out.EncryptInTransit = in.EncryptInTransit
 	// WARNING: in.SourceConfig requires manual conversion: does not exist in peer-type
 	// WARNING: in.RemoteCluster requires manual conversion: does not exist in peer-type
 	out.LeaderAsFollower = in.LeaderAsFollower
 	out.ServerIDOffset = in.ServerIDOffset
+	out.AllowedUserNamespaces = (*AllowedNamespaces)(unsafe.Pointer(in.AllowedUserNamespaces))
+	out.RotateInternalCredentials = (*CredentialsRotationOpts)(unsafe.Pointer(in.RotateInternalCredentials))
+	out.BinlogArchive = (*BinlogArchiveOpts)(unsafe.Pointer(in.BinlogArchive))
 	return nil
 }
 
@@ -481,6 +783,10 @@ func autoConvert_v1beta1_MysqlClusterStatus_To_v1alpha1_MysqlClusterStatus(in *M
 	out.LastBackupTime = in.LastBackupTime
 	out.Conditions = *(*[]v1alpha1.ClusterCondition)(unsafe.Pointer(&in.Conditions))
 	out.Nodes = *(*[]v1alpha1.NodeStatus)(unsafe.Pointer(&in.Nodes))
+	out.RollingUpdate = (*v1alpha1.RollingUpdateStatus)(unsafe.Pointer(in.RollingUpdate))
+	out.Upgrade = (*v1alpha1.UpgradeStatus)(unsafe.Pointer(in.Upgrade))
+	out.CredentialsRotation = (*v1alpha1.CredentialsRotationStatus)(unsafe.Pointer(in.CredentialsRotation))
+	out.TryLeader = (*v1alpha1.TryLeaderStatus)(unsafe.Pointer(in.TryLeader))
 	return nil
 }
 
@@ -497,6 +803,10 @@ func autoConvert_v1alpha1_MysqlClusterStatus_To_v1beta1_MysqlClusterStatus(in *v
 	out.LastBackupGtid = in.LastBackupGtid
 	out.Conditions = *(*[]ClusterCondition)(unsafe.Pointer(&in.Conditions))
 	out.Nodes = *(*[]NodeStatus)(unsafe.Pointer(&in.Nodes))
+	out.RollingUpdate = (*RollingUpdateStatus)(unsafe.Pointer(in.RollingUpdate))
+	out.Upgrade = (*UpgradeStatus)(unsafe.Pointer(in.Upgrade))
+	out.CredentialsRotation = (*CredentialsRotationStatus)(unsafe.Pointer(in.CredentialsRotation))
+	out.TryLeader = (*TryLeaderStatus)(unsafe.Pointer(in.TryLeader))
 	return nil
 }
 
@@ -507,8 +817,9 @@ func Convert_v1alpha1_MysqlClusterStatus_To_v1beta1_MysqlClusterStatus(in *v1alp
 
 func autoConvert_v1beta1_NodeCondition_To_v1alpha1_NodeCondition(in *NodeCondition, out *v1alpha1.NodeCondition, s conversion.Scope) error {
 	out.Type = v1alpha1.NodeConditionType(in.Type)
-	out.Status = v1.ConditionStatus(in.Status)
+	out.Status = corev1.ConditionStatus(in.Status)
 	out.LastTransitionTime = in.LastTransitionTime
+	out.Message = in.Message
 	return nil
 }
 
@@ -519,8 +830,9 @@ func Convert_v1beta1_NodeCondition_To_v1alpha1_NodeCondition(in *NodeCondition,
 
 func autoConvert_v1alpha1_NodeCondition_To_v1beta1_NodeCondition(in *v1alpha1.NodeCondition, out *NodeCondition, s conversion.Scope) error {
 	out.Type = NodeConditionType(in.Type)
-	out.Status = v1.ConditionStatus(in.Status)
+	out.Status = corev1.ConditionStatus(in.Status)
 	out.LastTransitionTime = in.LastTransitionTime
+	out.Message = in.Message
 	return nil
 }
 
@@ -532,6 +844,9 @@ func Convert_v1alpha1_NodeCondition_To_v1beta1_NodeCondition(in *v1alpha1.NodeCo
 func autoConvert_v1beta1_NodeStatus_To_v1alpha1_NodeStatus(in *NodeStatus, out *v1alpha1.NodeStatus, s conversion.Scope) error {
 	out.Name = in.Name
 	out.Message = in.Message
+	out.Version = in.Version
+	out.PendingRestart = *(*[]string)(unsafe.Pointer(&in.PendingRestart))
+	out.AppliedVariables = *(*map[string]string)(unsafe.Pointer(&in.AppliedVariables))
 	if err := Convert_v1beta1_RaftStatus_To_v1alpha1_RaftStatus(&in.RaftStatus, &out.RaftStatus, s); err != nil {
 		return err
 	}
@@ -548,6 +863,9 @@ func Convert_v1beta1_NodeStatus_To_v1alpha1_NodeStatus(in *NodeStatus, out *v1al
 func autoConvert_v1alpha1_NodeStatus_To_v1beta1_NodeStatus(in *v1alpha1.NodeStatus, out *NodeStatus, s conversion.Scope) error {
 	out.Name = in.Name
 	out.Message = in.Message
+	out.Version = in.Version
+	out.PendingRestart = *(*[]string)(unsafe.Pointer(&in.PendingRestart))
+	out.AppliedVariables = *(*map[string]string)(unsafe.Pointer(&in.AppliedVariables))
 	if err := Convert_v1alpha1_RaftStatus_To_v1beta1_RaftStatus(&in.RaftStatus, &out.RaftStatus, s); err != nil {
 		return err
 	}
@@ -588,9 +906,9 @@ func Convert_v1alpha1_RaftStatus_To_v1beta1_RaftStatus(in *v1alpha1.RaftStatus,
 func autoConvert_v1beta1_ReadOnlyType_To_v1alpha1_ReadOnlyType(in *ReadOnlyType, out *v1alpha1.ReadOnlyType, s conversion.Scope) error {
 	out.Num = in.Num
 	out.Host = in.Host
-	out.Resources = (*v1.ResourceRequirements)(unsafe.Pointer(in.Resources))
-	out.Affinity = (*v1.Affinity)(unsafe.Pointer(in.Affinity))
-	out.Tolerations = *(*[]v1.Toleration)(unsafe.Pointer(&in.Tolerations))
+	out.Resources = (*corev1.ResourceRequirements)(unsafe.Pointer(in.Resources))
+	out.Affinity = (*corev1.Affinity)(unsafe.Pointer(in.Affinity))
+	out.Tolerations = *(*[]corev1.Toleration)(unsafe.Pointer(&in.Tolerations))
 	return nil
 }
 
@@ -602,9 +920,9 @@ func Convert_v1beta1_ReadOnlyType_To_v1alpha1_ReadOnlyType(in *ReadOnlyType, out
 func autoConvert_v1alpha1_ReadOnlyType_To_v1beta1_ReadOnlyType(in *v1alpha1.ReadOnlyType, out *ReadOnlyType, s conversion.Scope) error {
 	out.Num = in.Num
 	out.Host = in.Host
-	out.Resources = (*v1.ResourceRequirements)(unsafe.Pointer(in.Resources))
-	out.Affinity = (*v1.Affinity)(unsafe.Pointer(in.Affinity))
-	out.Tolerations = *(*[]v1.Toleration)(unsafe.Pointer(&in.Tolerations))
+	out.Resources = (*corev1.ResourceRequirements)(unsafe.Pointer(in.Resources))
+	out.Affinity = (*corev1.Affinity)(unsafe.Pointer(in.Affinity))
+	out.Tolerations = *(*[]corev1.Toleration)(unsafe.Pointer(&in.Tolerations))
 	return nil
 }
 
@@ -635,6 +953,36 @@ func Convert_v1alpha1_RemoteSourceStruct_To_v1beta1_RemoteSourceStruct(in *v1alp
 	return autoConvert_v1alpha1_RemoteSourceStruct_To_v1beta1_RemoteSourceStruct(in, out, s)
 }
 
+func autoConvert_v1beta1_RestoreTarget_To_v1alpha1_RestoreTarget(in *RestoreTarget, out *v1alpha1.RestoreTarget, s conversion.Scope) error {
+	out.StopGTID = in.StopGTID
+	out.Inclusive = in.Inclusive
+	out.StopFile = in.StopFile
+	out.StopPosition = in.StopPosition
+	out.SkipGTIDs = in.SkipGTIDs
+	out.BinlogArchivePrefix = in.BinlogArchivePrefix
+	return nil
+}
+
+// Convert_v1beta1_RestoreTarget_To_v1alpha1_RestoreTarget is an autogenerated conversion function.
+func Convert_v1beta1_RestoreTarget_To_v1alpha1_RestoreTarget(in *RestoreTarget, out *v1alpha1.RestoreTarget, s conversion.Scope) error {
+	return autoConvert_v1beta1_RestoreTarget_To_v1alpha1_RestoreTarget(in, out, s)
+}
+
+func autoConvert_v1alpha1_RestoreTarget_To_v1beta1_RestoreTarget(in *v1alpha1.RestoreTarget, out *RestoreTarget, s conversion.Scope) error {
+	out.StopGTID = in.StopGTID
+	out.Inclusive = in.Inclusive
+	out.StopFile = in.StopFile
+	out.StopPosition = in.StopPosition
+	out.SkipGTIDs = in.SkipGTIDs
+	out.BinlogArchivePrefix = in.BinlogArchivePrefix
+	return nil
+}
+
+// Convert_v1alpha1_RestoreTarget_To_v1beta1_RestoreTarget is an autogenerated conversion function.
+func Convert_v1alpha1_RestoreTarget_To_v1beta1_RestoreTarget(in *v1alpha1.RestoreTarget, out *RestoreTarget, s conversion.Scope) error {
+	return autoConvert_v1alpha1_RestoreTarget_To_v1beta1_RestoreTarget(in, out, s)
+}
+
 func autoConvert_v1beta1_RoStatus_To_v1alpha1_RoStatus(in *RoStatus, out *v1alpha1.RoStatus, s conversion.Scope) error {
 	out.ReadOnly = in.ReadOnly
 	out.Replication = in.Replication
@@ -659,11 +1007,148 @@ func Convert_v1alpha1_RoStatus_To_v1beta1_RoStatus(in *v1alpha1.RoStatus, out *R
 	return autoConvert_v1alpha1_RoStatus_To_v1beta1_RoStatus(in, out, s)
 }
 
+func autoConvert_v1beta1_RollingUpdateStatus_To_v1alpha1_RollingUpdateStatus(in *RollingUpdateStatus, out *v1alpha1.RollingUpdateStatus, s conversion.Scope) error {
+	out.Revision = in.Revision
+	out.CurrentPods = *(*[]string)(unsafe.Pointer(&in.CurrentPods))
+	out.Phase = v1alpha1.RollingUpdatePhase(in.Phase)
+	out.StartedAt = (*v1.Time)(unsafe.Pointer(in.StartedAt))
+	out.SwitchedAt = (*v1.Time)(unsafe.Pointer(in.SwitchedAt))
+	out.CanaryPod = in.CanaryPod
+	out.CanaryPhase = v1alpha1.CanaryPhase(in.CanaryPhase)
+	out.Message = in.Message
+	out.RetryTrigger = in.RetryTrigger
+	return nil
+}
+
+// Convert_v1beta1_RollingUpdateStatus_To_v1alpha1_RollingUpdateStatus is an autogenerated conversion function.
+func Convert_v1beta1_RollingUpdateStatus_To_v1alpha1_RollingUpdateStatus(in *RollingUpdateStatus, out *v1alpha1.RollingUpdateStatus, s conversion.Scope) error {
+	return autoConvert_v1beta1_RollingUpdateStatus_To_v1alpha1_RollingUpdateStatus(in, out, s)
+}
+
+func autoConvert_v1alpha1_RollingUpdateStatus_To_v1beta1_RollingUpdateStatus(in *v1alpha1.RollingUpdateStatus, out *RollingUpdateStatus, s conversion.Scope) error {
+	out.Revision = in.Revision
+	out.CurrentPods = *(*[]string)(unsafe.Pointer(&in.CurrentPods))
+	out.Phase = RollingUpdatePhase(in.Phase)
+	out.StartedAt = (*v1.Time)(unsafe.Pointer(in.StartedAt))
+	out.SwitchedAt = (*v1.Time)(unsafe.Pointer(in.SwitchedAt))
+	out.CanaryPod = in.CanaryPod
+	out.CanaryPhase = CanaryPhase(in.CanaryPhase)
+	out.Message = in.Message
+	out.RetryTrigger = in.RetryTrigger
+	return nil
+}
+
+// Convert_v1alpha1_RollingUpdateStatus_To_v1beta1_RollingUpdateStatus is an autogenerated conversion function.
+func Convert_v1alpha1_RollingUpdateStatus_To_v1beta1_RollingUpdateStatus(in *v1alpha1.RollingUpdateStatus, out *RollingUpdateStatus, s conversion.Scope) error {
+	return autoConvert_v1alpha1_RollingUpdateStatus_To_v1beta1_RollingUpdateStatus(in, out, s)
+}
+
+func autoConvert_v1beta1_SemiSyncOpts_To_v1alpha1_SemiSyncOpts(in *SemiSyncOpts, out *v1alpha1.SemiSyncOpts, s conversion.Scope) error {
+	out.WaitForSlaveCount = (*int32)(unsafe.Pointer(in.WaitForSlaveCount))
+	out.Timeout = (*int64)(unsafe.Pointer(in.Timeout))
+	return nil
+}
+
+// Convert_v1beta1_SemiSyncOpts_To_v1alpha1_SemiSyncOpts is an autogenerated conversion function.
+func Convert_v1beta1_SemiSyncOpts_To_v1alpha1_SemiSyncOpts(in *SemiSyncOpts, out *v1alpha1.SemiSyncOpts, s conversion.Scope) error {
+	return autoConvert_v1beta1_SemiSyncOpts_To_v1alpha1_SemiSyncOpts(in, out, s)
+}
+
+func autoConvert_v1alpha1_SemiSyncOpts_To_v1beta1_SemiSyncOpts(in *v1alpha1.SemiSyncOpts, out *SemiSyncOpts, s conversion.Scope) error {
+	out.WaitForSlaveCount = (*int32)(unsafe.Pointer(in.WaitForSlaveCount))
+	out.Timeout = (*int64)(unsafe.Pointer(in.Timeout))
+	return nil
+}
+
+// Convert_v1alpha1_SemiSyncOpts_To_v1beta1_SemiSyncOpts is an autogenerated conversion function.
+func Convert_v1alpha1_SemiSyncOpts_To_v1beta1_SemiSyncOpts(in *v1alpha1.SemiSyncOpts, out *SemiSyncOpts, s conversion.Scope) error {
+	return autoConvert_v1alpha1_SemiSyncOpts_To_v1beta1_SemiSyncOpts(in, out, s)
+}
+
+func autoConvert_v1beta1_TryLeaderStatus_To_v1alpha1_TryLeaderStatus(in *TryLeaderStatus, out *v1alpha1.TryLeaderStatus, s conversion.Scope) error {
+	out.Pod = in.Pod
+	out.Leader = in.Leader
+	out.FenceTime = (*v1.Time)(unsafe.Pointer(in.FenceTime))
+	out.SwitchTime = (*v1.Time)(unsafe.Pointer(in.SwitchTime))
+	return nil
+}
+
+// Convert_v1beta1_TryLeaderStatus_To_v1alpha1_TryLeaderStatus is an autogenerated conversion function.
+func Convert_v1beta1_TryLeaderStatus_To_v1alpha1_TryLeaderStatus(in *TryLeaderStatus, out *v1alpha1.TryLeaderStatus, s conversion.Scope) error {
+	return autoConvert_v1beta1_TryLeaderStatus_To_v1alpha1_TryLeaderStatus(in, out, s)
+}
+
+func autoConvert_v1alpha1_TryLeaderStatus_To_v1beta1_TryLeaderStatus(in *v1alpha1.TryLeaderStatus, out *TryLeaderStatus, s conversion.Scope) error {
+	out.Pod = in.Pod
+	out.Leader = in.Leader
+	out.FenceTime = (*v1.Time)(unsafe.Pointer(in.FenceTime))
+	out.SwitchTime = (*v1.Time)(unsafe.Pointer(in.SwitchTime))
+	return nil
+}
+
+// Convert_v1alpha1_TryLeaderStatus_To_v1beta1_TryLeaderStatus is an autogenerated conversion function.
+func Convert_v1alpha1_TryLeaderStatus_To_v1beta1_TryLeaderStatus(in *v1alpha1.TryLeaderStatus, out *TryLeaderStatus, s conversion.Scope) error {
+	return autoConvert_v1alpha1_TryLeaderStatus_To_v1beta1_TryLeaderStatus(in, out, s)
+}
+
+func autoConvert_v1beta1_UpdateStrategy_To_v1alpha1_UpdateStrategy(in *UpdateStrategy, out *v1alpha1.UpdateStrategy, s conversion.Scope) error {
+	out.Partition = (*int32)(unsafe.Pointer(in.Partition))
+	out.MaxUnavailable = (*int32)(unsafe.Pointer(in.MaxUnavailable))
+	out.Paused = in.Paused
+	out.Canary = (*v1alpha1.CanaryOpts)(unsafe.Pointer(in.Canary))
+	return nil
+}
+
+// Convert_v1beta1_UpdateStrategy_To_v1alpha1_UpdateStrategy is an autogenerated conversion function.
+func Convert_v1beta1_UpdateStrategy_To_v1alpha1_UpdateStrategy(in *UpdateStrategy, out *v1alpha1.UpdateStrategy, s conversion.Scope) error {
+	return autoConvert_v1beta1_UpdateStrategy_To_v1alpha1_UpdateStrategy(in, out, s)
+}
+
+func autoConvert_v1alpha1_UpdateStrategy_To_v1beta1_UpdateStrategy(in *v1alpha1.UpdateStrategy, out *UpdateStrategy, s conversion.Scope) error {
+	out.Partition = (*int32)(unsafe.Pointer(in.Partition))
+	out.MaxUnavailable = (*int32)(unsafe.Pointer(in.MaxUnavailable))
+	out.Paused = in.Paused
+	out.Canary = (*CanaryOpts)(unsafe.Pointer(in.Canary))
+	return nil
+}
+
+// Convert_v1alpha1_UpdateStrategy_To_v1beta1_UpdateStrategy is an autogenerated conversion function.
+func Convert_v1alpha1_UpdateStrategy_To_v1beta1_UpdateStrategy(in *v1alpha1.UpdateStrategy, out *UpdateStrategy, s conversion.Scope) error {
+	return autoConvert_v1alpha1_UpdateStrategy_To_v1beta1_UpdateStrategy(in, out, s)
+}
+
+func autoConvert_v1beta1_UpgradeStatus_To_v1alpha1_UpgradeStatus(in *UpgradeStatus, out *v1alpha1.UpgradeStatus, s conversion.Scope) error {
+	out.FromVersion = in.FromVersion
+	out.ToVersion = in.ToVersion
+	out.Phase = v1alpha1.UpgradePhase(in.Phase)
+	out.Message = in.Message
+	return nil
+}
+
+// Convert_v1beta1_UpgradeStatus_To_v1alpha1_UpgradeStatus is an autogenerated conversion function.
+func Convert_v1beta1_UpgradeStatus_To_v1alpha1_UpgradeStatus(in *UpgradeStatus, out *v1alpha1.UpgradeStatus, s conversion.Scope) error {
+	return autoConvert_v1beta1_UpgradeStatus_To_v1alpha1_UpgradeStatus(in, out, s)
+}
+
+func autoConvert_v1alpha1_UpgradeStatus_To_v1beta1_UpgradeStatus(in *v1alpha1.UpgradeStatus, out *UpgradeStatus, s conversion.Scope) error {
+	out.FromVersion = in.FromVersion
+	out.ToVersion = in.ToVersion
+	out.Phase = UpgradePhase(in.Phase)
+	out.Message = in.Message
+	return nil
+}
+
+// Convert_v1alpha1_UpgradeStatus_To_v1beta1_UpgradeStatus is an autogenerated conversion function.
+func Convert_v1alpha1_UpgradeStatus_To_v1beta1_UpgradeStatus(in *v1alpha1.UpgradeStatus, out *UpgradeStatus, s conversion.Scope) error {
+	return autoConvert_v1alpha1_UpgradeStatus_To_v1beta1_UpgradeStatus(in, out, s)
+}
+
 func autoConvert_v1beta1_XenonOpts_To_v1alpha1_XenonOpts(in *XenonOpts, out *v1alpha1.XenonOpts, s conversion.Scope) error {
 	out.Image = in.Image
 	out.AdmitDefeatHearbeatCount = (*int32)(unsafe.Pointer(in.AdmitDefeatHearbeatCount))
 	out.ElectionTimeout = (*int32)(unsafe.Pointer(in.ElectionTimeout))
 	out.EnableAutoRebuild = in.EnableAutoRebuild
+	out.PromoteCatchUpTimeout = (*int32)(unsafe.Pointer(in.PromoteCatchUpTimeout))
 	out.Resources = in.Resources
 	return nil
 }
@@ -678,6 +1163,7 @@ func autoConvert_v1alpha1_XenonOpts_To_v1beta1_XenonOpts(in *v1alpha1.XenonOpts,
 	out.AdmitDefeatHearbeatCount = (*int32)(unsafe.Pointer(in.AdmitDefeatHearbeatCount))
 	out.ElectionTimeout = (*int32)(unsafe.Pointer(in.ElectionTimeout))
 	out.EnableAutoRebuild = in.EnableAutoRebuild
+	out.PromoteCatchUpTimeout = (*int32)(unsafe.Pointer(in.PromoteCatchUpTimeout))
 	out.Resources = in.Resources
 	return nil
 }
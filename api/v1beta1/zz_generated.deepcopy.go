@@ -23,9 +23,45 @@ package v1beta1
 
 import (
 	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AllowedNamespaces) DeepCopyInto(out *AllowedNamespaces) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AllowedNamespaces.
+func (in *AllowedNamespaces) DeepCopy() *AllowedNamespaces {
+	if in == nil {
+		return nil
+	}
+	out := new(AllowedNamespaces)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutoTLSOpts) DeepCopyInto(out *AutoTLSOpts) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoTLSOpts.
+func (in *AutoTLSOpts) DeepCopy() *AutoTLSOpts {
+	if in == nil {
+		return nil
+	}
+	out := new(AutoTLSOpts)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Backup) DeepCopyInto(out *Backup) {
 	*out = *in
@@ -53,6 +89,42 @@ func (in *Backup) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupChain) DeepCopyInto(out *BackupChain) {
+	*out = *in
+	if in.Parents != nil {
+		in, out := &in.Parents, &out.Parents
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupChain.
+func (in *BackupChain) DeepCopy() *BackupChain {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupChain)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupEncryption) DeepCopyInto(out *BackupEncryption) {
+	*out = *in
+	in.KeySecret.DeepCopyInto(&out.KeySecret)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupEncryption.
+func (in *BackupEncryption) DeepCopy() *BackupEncryption {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupEncryption)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BackupList) DeepCopyInto(out *BackupList) {
 	*out = *in
@@ -103,6 +175,11 @@ func (in *BackupOps) DeepCopyInto(out *BackupOps) {
 		*out = new(S3Binlog)
 		**out = **in
 	}
+	if in.Encryption != nil {
+		in, out := &in.Encryption, &out.Encryption
+		*out = new(BackupEncryption)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupOps.
@@ -205,6 +282,11 @@ func (in *BackupStatus) DeepCopyInto(out *BackupStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Chain != nil {
+		in, out := &in.Chain, &out.Chain
+		*out = new(BackupChain)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupStatus.
@@ -217,6 +299,37 @@ func (in *BackupStatus) DeepCopy() *BackupStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BinlogArchiveOpts) DeepCopyInto(out *BinlogArchiveOpts) {
+	*out = *in
+	in.Resources.DeepCopyInto(&out.Resources)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BinlogArchiveOpts.
+func (in *BinlogArchiveOpts) DeepCopy() *BinlogArchiveOpts {
+	if in == nil {
+		return nil
+	}
+	out := new(BinlogArchiveOpts)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CanaryOpts) DeepCopyInto(out *CanaryOpts) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryOpts.
+func (in *CanaryOpts) DeepCopy() *CanaryOpts {
+	if in == nil {
+		return nil
+	}
+	out := new(CanaryOpts)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterCondition) DeepCopyInto(out *ClusterCondition) {
 	*out = *in
@@ -233,6 +346,49 @@ func (in *ClusterCondition) DeepCopy() *ClusterCondition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CredentialsRotationOpts) DeepCopyInto(out *CredentialsRotationOpts) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialsRotationOpts.
+func (in *CredentialsRotationOpts) DeepCopy() *CredentialsRotationOpts {
+	if in == nil {
+		return nil
+	}
+	out := new(CredentialsRotationOpts)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CredentialsRotationStatus) DeepCopyInto(out *CredentialsRotationStatus) {
+	*out = *in
+	if in.LastRotationTime != nil {
+		in, out := &in.LastRotationTime, &out.LastRotationTime
+		*out = (*in).DeepCopy()
+	}
+	if in.StartedAt != nil {
+		in, out := &in.StartedAt, &out.StartedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.ReconfiguredPods != nil {
+		in, out := &in.ReconfiguredPods, &out.ReconfiguredPods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CredentialsRotationStatus.
+func (in *CredentialsRotationStatus) DeepCopy() *CredentialsRotationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CredentialsRotationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *DataSource) DeepCopyInto(out *DataSource) {
 	*out = *in
@@ -243,6 +399,11 @@ func (in *DataSource) DeepCopyInto(out *DataSource) {
 		*out = new(NFSBackupDataSource)
 		**out = **in
 	}
+	if in.RestoreTarget != nil {
+		in, out := &in.RestoreTarget, &out.RestoreTarget
+		*out = new(RestoreTarget)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataSource.
@@ -328,6 +489,11 @@ func (in *ManualBackupStatus) DeepCopyInto(out *ManualBackupStatus) {
 		in, out := &in.CompletionTime, &out.CompletionTime
 		*out = (*in).DeepCopy()
 	}
+	if in.Chain != nil {
+		in, out := &in.Chain, &out.Chain
+		*out = new(BackupChain)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManualBackupStatus.
@@ -489,8 +655,15 @@ func (in *MysqlClusterSpec) DeepCopyInto(out *MysqlClusterSpec) {
 		*out = new(v1.SecretProjection)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.AutoTLS != nil {
+		in, out := &in.AutoTLS, &out.AutoTLS
+		*out = new(AutoTLSOpts)
+		**out = **in
+	}
 	in.Storage.DeepCopyInto(&out.Storage)
 	in.Xenon.DeepCopyInto(&out.Xenon)
+	in.SemiSync.DeepCopyInto(&out.SemiSync)
+	in.UpdateStrategy.DeepCopyInto(&out.UpdateStrategy)
 	in.Backup.DeepCopyInto(&out.Backup)
 	in.Monitoring.DeepCopyInto(&out.Monitoring)
 	if in.Tolerations != nil {
@@ -517,6 +690,21 @@ func (in *MysqlClusterSpec) DeepCopyInto(out *MysqlClusterSpec) {
 		*out = new(ServiceSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.AllowedUserNamespaces != nil {
+		in, out := &in.AllowedUserNamespaces, &out.AllowedUserNamespaces
+		*out = new(AllowedNamespaces)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RotateInternalCredentials != nil {
+		in, out := &in.RotateInternalCredentials, &out.RotateInternalCredentials
+		*out = new(CredentialsRotationOpts)
+		**out = **in
+	}
+	if in.BinlogArchive != nil {
+		in, out := &in.BinlogArchive, &out.BinlogArchive
+		*out = new(BinlogArchiveOpts)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlClusterSpec.
@@ -547,6 +735,26 @@ func (in *MysqlClusterStatus) DeepCopyInto(out *MysqlClusterStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.RollingUpdate != nil {
+		in, out := &in.RollingUpdate, &out.RollingUpdate
+		*out = new(RollingUpdateStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Upgrade != nil {
+		in, out := &in.Upgrade, &out.Upgrade
+		*out = new(UpgradeStatus)
+		**out = **in
+	}
+	if in.CredentialsRotation != nil {
+		in, out := &in.CredentialsRotation, &out.CredentialsRotation
+		*out = new(CredentialsRotationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TryLeader != nil {
+		in, out := &in.TryLeader, &out.TryLeader
+		*out = new(TryLeaderStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlClusterStatus.
@@ -610,6 +818,18 @@ func (in *NodeCondition) DeepCopy() *NodeCondition {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NodeStatus) DeepCopyInto(out *NodeStatus) {
 	*out = *in
+	if in.PendingRestart != nil {
+		in, out := &in.PendingRestart, &out.PendingRestart
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AppliedVariables != nil {
+		in, out := &in.AppliedVariables, &out.AppliedVariables
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	in.RaftStatus.DeepCopyInto(&out.RaftStatus)
 	if in.RoStatus != nil {
 		in, out := &in.RoStatus, &out.RoStatus
@@ -727,6 +947,21 @@ func (in *RemoteSourceStruct) DeepCopy() *RemoteSourceStruct {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreTarget) DeepCopyInto(out *RestoreTarget) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreTarget.
+func (in *RestoreTarget) DeepCopy() *RestoreTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RoStatus) DeepCopyInto(out *RoStatus) {
 	*out = *in
@@ -742,6 +977,34 @@ func (in *RoStatus) DeepCopy() *RoStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RollingUpdateStatus) DeepCopyInto(out *RollingUpdateStatus) {
+	*out = *in
+	if in.CurrentPods != nil {
+		in, out := &in.CurrentPods, &out.CurrentPods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.StartedAt != nil {
+		in, out := &in.StartedAt, &out.StartedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.SwitchedAt != nil {
+		in, out := &in.SwitchedAt, &out.SwitchedAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RollingUpdateStatus.
+func (in *RollingUpdateStatus) DeepCopy() *RollingUpdateStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RollingUpdateStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *S3) DeepCopyInto(out *S3) {
 	*out = *in
@@ -798,6 +1061,11 @@ func (in *ScheduledBackupStatus) DeepCopyInto(out *ScheduledBackupStatus) {
 		in, out := &in.CompletionTime, &out.CompletionTime
 		*out = (*in).DeepCopy()
 	}
+	if in.Chain != nil {
+		in, out := &in.Chain, &out.Chain
+		*out = new(BackupChain)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScheduledBackupStatus.
@@ -810,6 +1078,31 @@ func (in *ScheduledBackupStatus) DeepCopy() *ScheduledBackupStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SemiSyncOpts) DeepCopyInto(out *SemiSyncOpts) {
+	*out = *in
+	if in.WaitForSlaveCount != nil {
+		in, out := &in.WaitForSlaveCount, &out.WaitForSlaveCount
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SemiSyncOpts.
+func (in *SemiSyncOpts) DeepCopy() *SemiSyncOpts {
+	if in == nil {
+		return nil
+	}
+	out := new(SemiSyncOpts)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ServiceSpec) DeepCopyInto(out *ServiceSpec) {
 	*out = *in
@@ -830,6 +1123,74 @@ func (in *ServiceSpec) DeepCopy() *ServiceSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TryLeaderStatus) DeepCopyInto(out *TryLeaderStatus) {
+	*out = *in
+	if in.FenceTime != nil {
+		in, out := &in.FenceTime, &out.FenceTime
+		*out = (*in).DeepCopy()
+	}
+	if in.SwitchTime != nil {
+		in, out := &in.SwitchTime, &out.SwitchTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TryLeaderStatus.
+func (in *TryLeaderStatus) DeepCopy() *TryLeaderStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(TryLeaderStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpdateStrategy) DeepCopyInto(out *UpdateStrategy) {
+	*out = *in
+	if in.Partition != nil {
+		in, out := &in.Partition, &out.Partition
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Canary != nil {
+		in, out := &in.Canary, &out.Canary
+		*out = new(CanaryOpts)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpdateStrategy.
+func (in *UpdateStrategy) DeepCopy() *UpdateStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(UpdateStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeStatus) DeepCopyInto(out *UpgradeStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeStatus.
+func (in *UpgradeStatus) DeepCopy() *UpgradeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *XenonOpts) DeepCopyInto(out *XenonOpts) {
 	*out = *in
@@ -843,6 +1204,11 @@ func (in *XenonOpts) DeepCopyInto(out *XenonOpts) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.PromoteCatchUpTimeout != nil {
+		in, out := &in.PromoteCatchUpTimeout, &out.PromoteCatchUpTimeout
+		*out = new(int32)
+		**out = **in
+	}
 	in.Resources.DeepCopyInto(&out.Resources)
 }
 
@@ -169,7 +169,7 @@ func (s *jobSyncer) ensurePodSpec(in corev1.PodSpec) corev1.PodSpec {
 		checkUsage := `[ $(echo "$(df /backup|awk 'NR>1 {print $4}') > $(du  /backup |awk 'END {if (NR > 1) {print $1 /(NR-1)} else print 0}')"|bc) -eq '1' ] || { echo disk available may be too small; exit 1;};`
 		in.Containers[0].Args = []string{
 			checkUsage + fmt.Sprintf("mkdir -p /backup/%s;"+
-				"curl --user $BACKUP_USER:$BACKUP_PASSWORD %s/download|xbstream -x -C /backup/%s; err1=${PIPESTATUS[0]};"+
+				"curl "+utils.CurlBackupAuth+" %s/download|xbstream -x -C /backup/%s; err1=${PIPESTATUS[0]};"+
 				strAnnonations+"retval_final=$?; exit $err1||$retval_final",
 				backupToDir,
 				s.backup.GetBackupURL(s.backup.Spec.ClusterName, s.backup.Spec.HostName), backupToDir),
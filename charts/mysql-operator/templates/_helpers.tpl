@@ -27,6 +27,10 @@ If release name contains chart name it will be used as a full name.
     {{ default "radondb-mysql-validation" }}
 {{- end }}
 
+{{- define "mutating-webhook-configuration.name" -}}
+    {{ default "radondb-mysql-mutation" }}
+{{- end }}
+
 {{- define "certificate.name" -}}
     {{ default "radondb-mysql-certificate" }}
 {{- end }}
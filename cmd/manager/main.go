@@ -146,6 +146,43 @@ func main() {
 		setupLog.Error(err, "unable to create controller", "controller", "MysqlUser")
 		os.Exit(1)
 	}
+	if err = (&controllers.MysqlDatabaseReconciler{
+		Client:           mgr.GetClient(),
+		Scheme:           mgr.GetScheme(),
+		Recorder:         mgr.GetEventRecorderFor("controller.mysqldatabase"),
+		SQLRunnerFactory: internal.NewSQLRunner,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "MysqlDatabase")
+		os.Exit(1)
+	}
+	if err = (&controllers.MysqlRoleReconciler{
+		Client:           mgr.GetClient(),
+		Scheme:           mgr.GetScheme(),
+		Recorder:         mgr.GetEventRecorderFor("controller.mysqlrole"),
+		SQLRunnerFactory: internal.NewSQLRunner,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "MysqlRole")
+		os.Exit(1)
+	}
+	if err = (&controllers.MysqlSwitchoverReconciler{
+		Client:           mgr.GetClient(),
+		Scheme:           mgr.GetScheme(),
+		Recorder:         mgr.GetEventRecorderFor("controller.mysqlswitchover"),
+		SQLRunnerFactory: internal.NewSQLRunner,
+		XenonExecutor:    internal.NewXenonExecutor(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "MysqlSwitchover")
+		os.Exit(1)
+	}
+	if err = (&controllers.MysqlRestoreReconciler{
+		Client:           mgr.GetClient(),
+		Scheme:           mgr.GetScheme(),
+		Recorder:         mgr.GetEventRecorderFor("controller.mysqlrestore"),
+		SQLRunnerFactory: internal.NewSQLRunner,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "MysqlRestore")
+		os.Exit(1)
+	}
 	if err = (&controllers.BackupCronReconciler{
 		Client:          mgr.GetClient(),
 		Scheme:          mgr.GetScheme(),
@@ -163,6 +200,26 @@ func main() {
 			setupLog.Error(err, "unable to create webhook", "webhook", "MysqlCluster")
 			os.Exit(1)
 		}
+		if err = (&mysqlv1alpha1.MysqlUser{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "MysqlUser")
+			os.Exit(1)
+		}
+		if err = (&mysqlv1alpha1.MysqlDatabase{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "MysqlDatabase")
+			os.Exit(1)
+		}
+		if err = (&mysqlv1alpha1.MysqlRole{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "MysqlRole")
+			os.Exit(1)
+		}
+		if err = (&mysqlv1alpha1.Backup{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Backup")
+			os.Exit(1)
+		}
+		if err = (&mysqlv1beta1.Backup{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Backup")
+			os.Exit(1)
+		}
 
 	}
 
@@ -21,6 +21,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"strings"
@@ -164,7 +165,14 @@ func New() *Agent {
 
 func main() {
 	if len(os.Args) < 2 {
-		log.Fatalf("Usage: %s leaderStart|leaderStop|liveness|readiness|postStart|preStop", os.Args[0])
+		log.Fatalf("Usage: %s leaderStart|leaderStop|liveness|readiness|postStart|preStop|setPassword", os.Args[0])
+	}
+	// The password in client.conf may not work before it is updated, so don't connect to mysql.
+	if os.Args[1] == "setPassword" {
+		if err := setClientPassword(os.Stdin); err != nil {
+			log.Fatalf("setPassword failed: %s", err.Error())
+		}
+		return
 	}
 	agent := New()
 	defer agent.CloseDB()
@@ -186,7 +194,7 @@ func main() {
 			log.Fatalf("postStop failed: %s", err.Error())
 		}
 	default:
-		log.Fatalf("Usage: %s leaderStart|leaderStop|liveness|readiness|postStart|preStop", os.Args[0])
+		log.Fatalf("Usage: %s leaderStart|leaderStop|liveness|readiness|postStart|preStop|setPassword", os.Args[0])
 	}
 }
 func (c *Agent) liveness() error {
@@ -251,6 +259,10 @@ func (c *Agent) readiness() error {
 	case "LEADER":
 		{
 			if !utils.ExistUpdateFile() && readOnly {
+				// The leader is fenced by the operator during the switchover.
+				if fenced, err := c.isFenced(); err != nil || fenced {
+					return err
+				}
 				log.Errorf("am leader but read_only is on")
 				if err := c.setGlobalReadOnlyOff(); err != nil {
 					return err
@@ -319,6 +331,25 @@ func getMySQLclientConf() *MySQLConfig {
 
 }
 
+// setClientPassword replaces the password in client.conf with the one read from r, the file is
+// replaced atomically so that the probes never read a partial file.
+func setClientPassword(r io.Reader) error {
+	password, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	cfg, err := ini.Load(clientConfDir)
+	if err != nil {
+		return err
+	}
+	cfg.Section("client").Key("password").SetValue(strings.TrimSpace(string(password)))
+	tmp := clientConfDir + ".tmp"
+	if err := cfg.SaveTo(tmp); err != nil {
+		return err
+	}
+	return os.Rename(tmp, clientConfDir)
+}
+
 func getMySQLConn(conf *MySQLConfig) (*sqlx.DB, error) {
 	c := mysql.NewConfig()
 	c.User = conf.User
@@ -353,6 +384,16 @@ func (c *Agent) getRoleBylabel() (string, error) {
 	return "", fmt.Errorf("role label not found")
 }
 
+// isFenced checks whether the pod is made read-only by the operator.
+func (c *Agent) isFenced() (bool, error) {
+	podMeta, err := c.ksClient.CoreV1().Pods(c.nameSpace).Get(context.TODO(), c.podName, metav1.GetOptions{})
+	if err != nil {
+		return false, err
+	}
+	_, ok := podMeta.Labels[utils.LabelFenced]
+	return ok, nil
+}
+
 func (c *Agent) setGlobalReadOnlyOff() error {
 	_, err := c.db.Exec("set global read_only=0")
 	if err != nil {
@@ -22,8 +22,9 @@ import (
 )
 
 func main() {
-	if len(os.Args) < 3 {
-		log.Fatalf("Usage: %s host passwd", os.Args[0])
+	if len(os.Args) < 2 {
+		log.Fatalf("Usage: %s host", os.Args[0])
 	}
-	UploadBinLog(os.Args[1], os.Args[2])
+	// The password is passed by the env from the secret, instead of the command line.
+	UploadBinLog(os.Args[1], os.Getenv("MYSQL_ROOT_PASSWORD"))
 }
@@ -56,12 +56,44 @@ func UploadBinLog(host, interP string) {
 
 }
 
+// optionValueEscaper escapes the quoted values in the option file.
+var optionValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
 func runBinlogDump(host, port, user, pass string) error {
-	cmdstr := fmt.Sprintf(`cd /tmp/;mysql -uroot -p%s -h%s -N -e "SHOW BINARY LOGS" | awk '{print "mysqlbinlog --read-from-remote-server --raw --host=%s --port=3306 --user=root --password=%s --raw", $1}'|bash`,
-		pass, host, host, pass)
+	// Pass the credentials by the option file, so that they do not appear in the command line.
+	optionFile, err := ioutil.TempFile("", "client-*.cnf")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(optionFile.Name())
+	if _, err = fmt.Fprintf(optionFile, "[client]\nuser=%s\npassword=\"%s\"\n", user, optionValueEscaper.Replace(pass)); err != nil {
+		optionFile.Close()
+		return err
+	}
+	if err = optionFile.Close(); err != nil {
+		return err
+	}
+	defaultsArg := "--defaults-extra-file=" + optionFile.Name()
 
-	cmd := exec.Command("bash", "-c", cmdstr)
-	cmd.Stdout = os.Stdout
+	cmd := exec.Command("mysql", defaultsArg, "-h"+host, "-P"+port, "-N", "-e", "SHOW BINARY LOGS")
 	cmd.Stderr = os.Stderr
-	return cmd.Run()
+	out, err := cmd.Output()
+	if err != nil {
+		return err
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		cmd = exec.Command("mysqlbinlog", defaultsArg, "--read-from-remote-server", "--raw",
+			"--host="+host, "--port="+port, fields[0])
+		cmd.Dir = "/tmp/"
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err = cmd.Run(); err != nil {
+			return err
+		}
+	}
+	return nil
 }
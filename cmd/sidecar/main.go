@@ -98,6 +98,40 @@ func main() {
 		}
 		cmd.AddCommand(reqBackupCmd)
 
+	case utils.ContainerBinlogArchiverName:
+		archiverCfg := sidecar.NewBinlogArchiverConfig()
+		archiveCmd := &cobra.Command{
+			Use:   "archive_binlogs",
+			Short: "stream the binlogs from the leader and archive them to s3",
+			Run: func(cmd *cobra.Command, args []string) {
+				if err := sidecar.RunBinlogArchiver(archiverCfg, stop); err != nil {
+					log.Error(err, "run command failed")
+					os.Exit(1)
+				}
+			},
+		}
+		cmd.AddCommand(archiveCmd)
+
+	// Run by the init-mysql container to apply the binlogs planned by the init-sidecar.
+	case utils.ContainerInitMysqlName:
+		applyBinlogsCmd := &cobra.Command{
+			Use:   "apply_binlogs",
+			Short: "apply the binlogs for the point-in-time restore",
+			Args: func(cmd *cobra.Command, args []string) error {
+				if len(args) != 1 {
+					return fmt.Errorf("require one arguments. ")
+				}
+				return nil
+			},
+			Run: func(cmd *cobra.Command, args []string) {
+				if err := sidecar.ApplyBinlogs(args[0]); err != nil {
+					log.Error(err, "run command failed")
+					os.Exit(1)
+				}
+			},
+		}
+		cmd.AddCommand(applyBinlogsCmd)
+
 	default:
 		initCfg := sidecar.NewInitConfig()
 		initCmd := sidecar.NewInitCommand(initCfg)
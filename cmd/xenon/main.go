@@ -22,10 +22,12 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	_ "github.com/go-sql-driver/mysql"
@@ -45,6 +47,7 @@ const (
 	raftEnableCommand  = "xenoncli raft enable"
 	raftStatusCommand  = "xenoncli raft status"
 	mysqlGtidCommand   = "xenoncli cluster gtid json"
+	xenonConfigFile    = "/etc/xenon/xenon.json"
 )
 
 var (
@@ -82,7 +85,7 @@ func init() {
 
 func main() {
 	if len(os.Args) < 2 {
-		log.Fatalf("Usage: %s leaderStart|leaderStop|liveness|readiness|postStart|preStop", os.Args[0])
+		log.Fatalf("Usage: %s leaderStart|leaderStop|liveness|readiness|postStart|preStop|setReplicationPassword", os.Args[0])
 	}
 	switch os.Args[1] {
 	case "leaderStart":
@@ -109,8 +112,13 @@ func main() {
 		if err := preStop(); err != nil {
 			log.Fatalf("postStop failed: %s", err.Error())
 		}
+	case "setReplicationPassword":
+		restart := len(os.Args) > 2 && os.Args[2] == "restart"
+		if err := setReplicationPassword(os.Stdin, restart); err != nil {
+			log.Fatalf("setReplicationPassword failed: %s", err.Error())
+		}
 	default:
-		log.Fatalf("Usage: %s leaderStart|leaderStop|liveness|readiness|postStart|preStop", os.Args[0])
+		log.Fatalf("Usage: %s leaderStart|leaderStop|liveness|readiness|postStart|preStop|setReplicationPassword", os.Args[0])
 	}
 }
 
@@ -309,6 +317,70 @@ func preStop() error {
 	return nil
 }
 
+// setReplicationPassword replaces the replication password in the xenon config with the one read
+// from r, and stops xenon to make the container restart with the new config if restart is true.
+func setReplicationPassword(r io.Reader, restart bool) error {
+	password, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(xenonConfigFile)
+	if err != nil {
+		return err
+	}
+	// Keep the numbers as they are, they are decoded as float64 by default.
+	conf := map[string]interface{}{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&conf); err != nil {
+		return err
+	}
+	replication, ok := conf["replication"].(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("replication is not found in %s", xenonConfigFile)
+	}
+	replication["passwd"] = strings.TrimSpace(string(password))
+	if data, err = json.MarshalIndent(conf, "", "\t"); err != nil {
+		return err
+	}
+	tmp := xenonConfigFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, xenonConfigFile); err != nil {
+		return err
+	}
+	log.Info("the replication password of xenon is updated")
+	if !restart {
+		return nil
+	}
+	pid, err := findProcess("xenon")
+	if err != nil {
+		return err
+	}
+	log.Infof("stopping xenon %d to reload the config", pid)
+	return syscall.Kill(pid, syscall.SIGTERM)
+}
+
+// findProcess returns the pid of the process with the name.
+func findProcess(name string) (int, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return 0, err
+	}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		comm, err := os.ReadFile(fmt.Sprintf("/proc/%d/comm", pid))
+		if err == nil && strings.TrimSpace(string(comm)) == name {
+			return pid, nil
+		}
+	}
+	return 0, fmt.Errorf("process %s is not found", name)
+}
+
 func myself(role string) MySQLNode {
 	return MySQLNode{
 		PodName:   podName,
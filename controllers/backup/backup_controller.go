@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strconv"
 
 	"github.com/pkg/errors"
 	"github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
@@ -240,6 +241,8 @@ func (r *BackupReconciler) reconcileManualBackup(ctx context.Context,
 				manualStatus.BackupSize = currentBackupJob.GetAnnotations()["backupSize"]
 				manualStatus.BackupType = currentBackupJob.GetAnnotations()["backupType"]
 				manualStatus.Gtid = currentBackupJob.GetAnnotations()["gtid"]
+				manualStatus.EncryptionKeyID = currentBackupJob.GetAnnotations()[utils.JobAnonationEncryptionKeyID]
+				manualStatus.Chain = getJobBackupChain(currentBackupJob)
 
 			}
 			if completed || failed {
@@ -261,6 +264,8 @@ func (r *BackupReconciler) reconcileManualBackup(ctx context.Context,
 			backup.Status.BackupSize = manualStatus.BackupSize
 			backup.Status.BackupType = manualStatus.BackupType
 			backup.Status.Gtid = manualStatus.Gtid
+			backup.Status.EncryptionKeyID = manualStatus.EncryptionKeyID
+			backup.Status.Chain = manualStatus.Chain
 			backup.Status.State = manualStatus.State
 			backup.Status.CompletionTime = manualStatus.CompletionTime
 			backup.Status.StartTime = manualStatus.StartTime
@@ -293,7 +298,19 @@ func (r *BackupReconciler) reconcileManualBackup(ctx context.Context,
 	labels := ManualBackupLabels(cluster.Name)
 	backupJob.ObjectMeta.Labels = labels
 
-	spec, err := r.generateBackupJobSpec(backup, cluster, labels)
+	// The incremental backup is based on the last successful backup, keep the base of the
+	// existing job, whose template cannot be changed.
+	var base *incrementalBase
+	if currentBackupJob != nil {
+		base = getJobIncrementalBase(currentBackupJob)
+	} else {
+		var err error
+		if base, err = r.findIncrementalBase(ctx, backup, cluster); err != nil {
+			return errors.WithStack(err)
+		}
+	}
+
+	spec, err := r.generateBackupJobSpec(backup, cluster, labels, base)
 	if err != nil {
 		return errors.WithStack(err)
 	}
@@ -364,6 +381,8 @@ func (r *BackupReconciler) reconcileCronBackup(ctx context.Context, backup *v1be
 			sbs.BackupSize = job.GetAnnotations()["backupSize"]
 			sbs.BackupType = job.GetAnnotations()["backupType"]
 			sbs.Gtid = job.GetAnnotations()["gtid"]
+			sbs.EncryptionKeyID = job.GetAnnotations()[utils.JobAnonationEncryptionKeyID]
+			sbs.Chain = getJobBackupChain(job)
 			sbs.CompletionTime = job.Status.CompletionTime
 			sbs.Failed = job.Status.Failed
 			sbs.Succeeded = job.Status.Succeeded
@@ -394,6 +413,8 @@ func (r *BackupReconciler) reconcileCronBackup(ctx context.Context, backup *v1be
 		backup.Status.Type = v1beta1.CronJobBackupInitiator
 		backup.Status.State = latestScheduledStatus.State
 		backup.Status.Gtid = latestScheduledStatus.Gtid
+		backup.Status.EncryptionKeyID = latestScheduledStatus.EncryptionKeyID
+		backup.Status.Chain = latestScheduledStatus.Chain
 		backup.Status.BackupType = latestScheduledStatus.BackupType
 	}
 	// file the scheduled backup status
@@ -417,7 +438,13 @@ func (r *BackupReconciler) reconcileCronBackup(ctx context.Context, backup *v1be
 	}
 	objectMeta.Labels = labels
 	// objectmeta.Annotations = annotations
-	jobSpec, err := r.generateBackupJobSpec(backup, cluster, labels)
+	// The next incremental backup is based on the last successful backup, the template is
+	// updated once the backup is reported in the status.
+	base, err := r.findIncrementalBase(ctx, backup, cluster)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	jobSpec, err := r.generateBackupJobSpec(backup, cluster, labels, base)
 	if err != nil {
 		return errors.WithStack(err)
 	}
@@ -449,7 +476,7 @@ func (r *BackupReconciler) reconcileCronBackup(ctx context.Context, backup *v1be
 
 }
 
-func (r *BackupReconciler) generateBackupJobSpec(backup *v1beta1.Backup, cluster *v1beta1.MysqlCluster, labels map[string]string) (*batchv1.JobSpec, error) {
+func (r *BackupReconciler) generateBackupJobSpec(backup *v1beta1.Backup, cluster *v1beta1.MysqlCluster, labels map[string]string, base *incrementalBase) (*batchv1.JobSpec, error) {
 
 	// If backup.Spec.BackupOpts.S3 is not nil then use ENV BACKUP_TYPE=s3 and set the s3SecretName
 	// If backup.Spec.BackupOpts.NFS is not nil then use ENV BACKUP_TYPE=nfs and mount the nfs volume
@@ -467,6 +494,10 @@ func (r *BackupReconciler) generateBackupJobSpec(backup *v1beta1.Backup, cluster
 	if backup.Spec.BackupOpts.S3 != nil && backup.Spec.BackupOpts.NFS != nil {
 		return nil, errors.New("backup can only be configured with one of S3 or NFS")
 	}
+	// The job sends the encryption key to the backup container of the node.
+	if backup.Spec.BackupOpts.Encryption != nil && !cluster.Spec.EncryptInTransit {
+		return nil, errors.New("the encryption of the backup requires the encryptInTransit of the cluster")
+	}
 
 	if backup.Spec.BackupOpts.S3 != nil {
 		s3SecretName := backup.Spec.BackupOpts.S3.BackupSecretName
@@ -512,15 +543,10 @@ func (r *BackupReconciler) generateBackupJobSpec(backup *v1beta1.Backup, cluster
 		ImagePullPolicy: cluster.Spec.ImagePullPolicy,
 		Name:            utils.ContainerBackupName,
 	}
+	// The incremental base is chosen for the node, so the job must request the same node.
 	container.Args = []string{
 		"request_a_backup",
-		func() string {
-			if len(backup.Spec.BackupOpts.BackupHost) != 0 {
-				return GetBackupURL(cluster.Name, backup.Spec.BackupOpts.BackupHost, cluster.Namespace)
-			} else {
-				return GetXtrabackupURL(GetBackupHost(cluster))
-			}
-		}(),
+		GetXtrabackupURL(getBackupNode(backup, cluster)),
 	}
 	// Add backup user and password to the env
 	container.Env = append(container.Env,
@@ -539,6 +565,38 @@ func (r *BackupReconciler) generateBackupJobSpec(backup *v1beta1.Backup, cluster
 	}
 
 	container.Env = append(container.Env, backupTypeEnv)
+	// The backup container encrypts the backup with the key, the name of the key is the key ID.
+	if encryption := backup.Spec.BackupOpts.Encryption; encryption != nil {
+		container.Env = append(container.Env,
+			corev1.EnvVar{Name: "BACKUP_ENCRYPTION_ALGORITHM", Value: encryption.Algorithm},
+			corev1.EnvVar{Name: "BACKUP_ENCRYPTION_KEY_ID", Value: encryption.KeySecret.Key},
+			getEnvVarFromSecret(encryption.KeySecret.Name, "BACKUP_ENCRYPTION_KEY", encryption.KeySecret.Key, false),
+		)
+	}
+	// Tune the compression and the parallelism of xtrabackup and xbcloud.
+	if compression := backup.Spec.BackupOpts.Compression; compression != "" {
+		container.Env = append(container.Env, corev1.EnvVar{Name: "BACKUP_COMPRESSION", Value: compression})
+	}
+	if parallel := backup.Spec.BackupOpts.Parallel; parallel > 0 {
+		container.Env = append(container.Env, corev1.EnvVar{Name: "BACKUP_PARALLEL", Value: strconv.Itoa(int(parallel))})
+	}
+	if uploadParallel := backup.Spec.BackupOpts.UploadParallel; uploadParallel > 0 {
+		container.Env = append(container.Env, corev1.EnvVar{Name: "BACKUP_UPLOAD_PARALLEL", Value: strconv.Itoa(int(uploadParallel))})
+	}
+	// Take the changes since the base, a full backup is taken if there is no base.
+	if base != nil {
+		container.Env = append(container.Env, base.env()...)
+	}
+	// Trust the CA of the cluster to request the backup over https.
+	tlsSecretName := getTransitTLSSecretName(cluster)
+	if tlsSecretName != "" {
+		container.Env = append(container.Env, corev1.EnvVar{Name: "ENCRYPT_IN_TRANSIT", Value: "true"})
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      utils.TlsVolumeName,
+			MountPath: utils.TlsSecretMountPath,
+			ReadOnly:  true,
+		})
+	}
 
 	jobSpec := &batchv1.JobSpec{
 		Template: corev1.PodTemplateSpec{
@@ -553,6 +611,17 @@ func (r *BackupReconciler) generateBackupJobSpec(backup *v1beta1.Backup, cluster
 	if NFSVolume != nil {
 		jobSpec.Template.Spec.Volumes = []corev1.Volume{*NFSVolume}
 	}
+	if tlsSecretName != "" {
+		jobSpec.Template.Spec.Volumes = append(jobSpec.Template.Spec.Volumes, corev1.Volume{
+			Name: utils.TlsVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: tlsSecretName,
+					Items:      []corev1.KeyToPath{{Key: utils.TlsCAFile, Path: utils.TlsCAFile}},
+				},
+			},
+		})
+	}
 	var backoffLimit int32 = 1
 
 	jobSpec.Template.Spec.Tolerations = cluster.Spec.Tolerations
@@ -562,21 +631,12 @@ func (r *BackupReconciler) generateBackupJobSpec(backup *v1beta1.Backup, cluster
 }
 
 func (r *BackupReconciler) createJobPodTemplate(ctx context.Context, backup *v1beta1.Backup, backupDir string) corev1.PodTemplateSpec {
-	log := log.FromContext(ctx).WithValues("backup", "create job pod template")
 	labels := map[string]string{
 		"app": "binlogbackup",
 	}
 	cluster := &v1alpha1.MysqlCluster{}
 	cluster.Name = backup.Spec.ClusterName
-	secret := &corev1.Secret{}
-	secretKey := client.ObjectKey{Name: mysqlcluster.New(cluster).GetNameForResource(utils.Secret), Namespace: backup.Namespace}
-
-	if err := r.Get(context.TODO(), secretKey, secret); err != nil {
-		log.V(4).Info("binlogjob", "is is creating", "err", err)
-	}
-	internalRootPass := string(secret.Data["internal-root-password"])
-	// Add shell commond
-	log.V(4).Info("internal password", internalRootPass)
+	secretName := mysqlcluster.New(cluster).GetNameForResource(utils.Secret)
 	hostname := func() string {
 		if backup.Spec.BackupOpts.BackupHost != "" {
 			return fmt.Sprintf("%s.%s-mysql.%s", backup.Spec.BackupOpts.BackupHost, backup.Spec.ClusterName, backup.Namespace)
@@ -610,10 +670,14 @@ func (r *BackupReconciler) createJobPodTemplate(ctx context.Context, backup *v1b
 				{
 					Name:  utils.ContainerBackupName,
 					Image: "percona:8.0",
+					Env: []corev1.EnvVar{
+						getEnvVarFromSecret(secretName, "MYSQL_ROOT_PASSWORD", "internal-root-password", false),
+					},
+					// The password is passed by the option file, instead of the command line.
 					Command: []string{
 						"/bin/bash", "-c", "--",
-						fmt.Sprintf(`cd /backup/%s;mysql -uroot -p%s -h%s -N -e "SHOW BINARY LOGS" | awk '{print "mysqlbinlog --read-from-remote-server --raw --host=%s --port=3306 --user=root --password=%s --raw", $1}'|bash`,
-							backupDir, internalRootPass, hostname, hostname, internalRootPass),
+						fmt.Sprintf(`%s;cd /backup/%s;mysql --defaults-extra-file=%s -h%s -N -e "SHOW BINARY LOGS" | awk '{print "mysqlbinlog --defaults-extra-file=%s --read-from-remote-server --raw --host=%s --port=3306 --raw", $1}'|bash`,
+							clientOptionFileCmd, backupDir, clientOptionFile, hostname, clientOptionFile, hostname),
 					},
 					VolumeMounts: []corev1.VolumeMount{
 						{
@@ -695,7 +759,6 @@ func (r *BackupReconciler) createBinlogJob(ctx context.Context, backup *v1beta1.
 func (r *BackupReconciler) genBinlogJobTemplate(backup *v1beta1.Backup, cluster *v1beta1.MysqlCluster) (*batchv1.JobSpec, error) {
 	var S3BackuptEnv []corev1.EnvVar
 	s3SecretName := backup.Spec.BackupOpts.S3Binlog.BackupSecretName
-	log := log.FromContext(context.TODO()).WithValues("backup", "BinLog")
 	S3BackuptEnv = append(S3BackuptEnv,
 		getEnvVarFromSecret(s3SecretName, "S3_ENDPOINT", "s3-endpoint", false),
 		getEnvVarFromSecret(s3SecretName, "S3_ACCESSKEY", "s3-access-key", true),
@@ -713,13 +776,8 @@ func (r *BackupReconciler) genBinlogJobTemplate(backup *v1beta1.Backup, cluster
 	}()
 	c := &v1alpha1.MysqlCluster{}
 	c.Name = backup.Spec.ClusterName
-	secret := &corev1.Secret{}
-	secretKey := client.ObjectKey{Name: mysqlcluster.New(c).GetNameForResource(utils.Secret), Namespace: backup.Namespace}
-
-	if err := r.Get(context.TODO(), secretKey, secret); err != nil {
-		log.V(4).Info("binlogjob", "is is creating", "err", err)
-	}
-	internalRootPass := string(secret.Data["internal-root-password"])
+	S3BackuptEnv = append(S3BackuptEnv,
+		getEnvVarFromSecret(mysqlcluster.New(c).GetNameForResource(utils.Secret), "MYSQL_ROOT_PASSWORD", "internal-root-password", false))
 
 	jobSpec := &batchv1.JobSpec{
 		Template: corev1.PodTemplateSpec{
@@ -754,7 +812,7 @@ func (r *BackupReconciler) genBinlogJobTemplate(backup *v1beta1.Backup, cluster
 						ImagePullPolicy: cluster.Spec.ImagePullPolicy,
 						Env:             S3BackuptEnv,
 						Command: []string{
-							"bash", "-c", fmt.Sprintf("/opt/radondb/s3upload %s %s", hostname, internalRootPass),
+							"bash", "-c", fmt.Sprintf("/opt/radondb/s3upload %s", hostname),
 						},
 						VolumeMounts: []corev1.VolumeMount{
 							{
@@ -0,0 +1,192 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"context"
+	"strings"
+
+	"github.com/radondb/radondb-mysql-kubernetes/api/v1beta1"
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// incrementalBase is the backup which an incremental backup is based on.
+type incrementalBase struct {
+	// chain is the names of the backups from the full base backup to the parent.
+	chain []string
+	// toLSN is the LSN the parent ends at, the incremental backup starts from it.
+	toLSN string
+}
+
+// env returns the environment variables of the backup job to take the incremental backup.
+func (b *incrementalBase) env() []corev1.EnvVar {
+	return []corev1.EnvVar{
+		{Name: "BACKUP_INCREMENTAL_LSN", Value: b.toLSN},
+		{Name: "BACKUP_CHAIN", Value: strings.Join(b.chain, ",")},
+	}
+}
+
+// chainedBackup is a backup in the status which may be the parent of an incremental backup.
+type chainedBackup struct {
+	name            string
+	state           v1beta1.BackupConditionType
+	encryptionKeyID string
+	chain           *v1beta1.BackupChain
+	completionTime  *metav1.Time
+}
+
+// findIncrementalBase finds the last successful backup of the cluster in the same storage, which
+// the incremental backup is based on. It returns nil for a full backup, or if there is no such
+// backup, then the backup job takes a full backup.
+func (r *BackupReconciler) findIncrementalBase(ctx context.Context, backup *v1beta1.Backup, cluster *v1beta1.MysqlCluster) (*incrementalBase, error) {
+	if getBackupType(backup) != v1beta1.IncrementalBackupType {
+		return nil, nil
+	}
+	backups := &v1beta1.BackupList{}
+	if err := r.List(ctx, backups, client.InNamespace(backup.Namespace),
+		client.MatchingLabels{"cluster": backup.Spec.ClusterName}); err != nil {
+		return nil, err
+	}
+	return lastIncrementalBase(backup, backups.Items, getBackupNode(backup, cluster)), nil
+}
+
+// lastIncrementalBase returns the last successful backup in the backups which the incremental
+// backup can be based on, the parent must be in the same storage and encrypted with the same key.
+// The LSNs are only meaningful on the node the parent was taken from, so it returns nil if the
+// last backup was taken from another node than the host, then a full backup is taken.
+func lastIncrementalBase(backup *v1beta1.Backup, backups []v1beta1.Backup, host string) *incrementalBase {
+	var last *chainedBackup
+	keyID := ""
+	if encryption := backup.Spec.BackupOpts.Encryption; encryption != nil {
+		keyID = encryption.KeySecret.Key
+	}
+	for i := range backups {
+		if backups[i].Spec.ClusterName != backup.Spec.ClusterName || !sameStorage(&backups[i], backup) {
+			continue
+		}
+		for _, candidate := range getChainedBackups(&backups[i]) {
+			candidate := candidate
+			if candidate.state != v1beta1.BackupSucceeded || len(candidate.name) == 0 ||
+				candidate.chain == nil || len(candidate.chain.ToLSN) == 0 ||
+				candidate.completionTime == nil || candidate.encryptionKeyID != keyID {
+				continue
+			}
+			if last == nil || last.completionTime.Before(candidate.completionTime) {
+				last = &candidate
+			}
+		}
+	}
+	if last == nil || len(host) == 0 || last.chain.Host != host {
+		return nil
+	}
+	return &incrementalBase{
+		chain: append(append([]string{}, last.chain.Parents...), last.name),
+		toLSN: last.chain.ToLSN,
+	}
+}
+
+// getChainedBackups returns the manual and the scheduled backups in the status.
+func getChainedBackups(backup *v1beta1.Backup) []chainedBackup {
+	var backups []chainedBackup
+	if manual := backup.Status.ManualBackup; manual != nil {
+		backups = append(backups, chainedBackup{
+			name:            manual.BackupName,
+			state:           manual.State,
+			encryptionKeyID: manual.EncryptionKeyID,
+			chain:           manual.Chain,
+			completionTime:  manual.CompletionTime,
+		})
+	}
+	for _, scheduled := range backup.Status.ScheduledBackups {
+		backups = append(backups, chainedBackup{
+			name:            scheduled.BackupName,
+			state:           scheduled.State,
+			encryptionKeyID: scheduled.EncryptionKeyID,
+			chain:           scheduled.Chain,
+			completionTime:  scheduled.CompletionTime,
+		})
+	}
+	return backups
+}
+
+// getBackupType returns the type of the manual or the scheduled backup.
+func getBackupType(backup *v1beta1.Backup) string {
+	if backup.Spec.BackupSchedule != nil {
+		return backup.Spec.BackupSchedule.BackupType
+	}
+	if backup.Spec.Manual != nil {
+		return backup.Spec.Manual.BackupType
+	}
+	return v1beta1.FullBackupType
+}
+
+// sameStorage returns true if the backups are stored in the same S3 bucket or NFS volume.
+func sameStorage(a, b *v1beta1.Backup) bool {
+	aOpts, bOpts := a.Spec.BackupOpts, b.Spec.BackupOpts
+	switch {
+	case aOpts.S3 != nil && bOpts.S3 != nil:
+		return aOpts.S3.BackupSecretName == bOpts.S3.BackupSecretName
+	case aOpts.NFS != nil && bOpts.NFS != nil:
+		return aOpts.NFS.Volume.Server == bOpts.NFS.Volume.Server &&
+			aOpts.NFS.Volume.Path == bOpts.NFS.Volume.Path
+	}
+	return false
+}
+
+// getJobIncrementalBase returns the base of the existing backup job, the template of the job
+// cannot be changed once it is created.
+func getJobIncrementalBase(job *batchv1.Job) *incrementalBase {
+	for _, container := range job.Spec.Template.Spec.Containers {
+		base := &incrementalBase{}
+		for _, env := range container.Env {
+			switch env.Name {
+			case "BACKUP_INCREMENTAL_LSN":
+				base.toLSN = env.Value
+			case "BACKUP_CHAIN":
+				base.chain = strings.Split(env.Value, ",")
+			}
+		}
+		if len(base.toLSN) != 0 {
+			return base
+		}
+	}
+	return nil
+}
+
+// getJobBackupChain builds the backup chain from the annotations set by the backup job,
+// it returns nil if the job does not report the LSNs.
+func getJobBackupChain(job *batchv1.Job) *v1beta1.BackupChain {
+	annotations := job.GetAnnotations()
+	if len(annotations[utils.JobAnonationToLSN]) == 0 {
+		return nil
+	}
+	chain := &v1beta1.BackupChain{
+		Base:    annotations[utils.JobAnonationName],
+		FromLSN: annotations[utils.JobAnonationFromLSN],
+		ToLSN:   annotations[utils.JobAnonationToLSN],
+		Host:    annotations[utils.JobAnonationBackupHost],
+	}
+	if parents := annotations[utils.JobAnonationBackupChain]; len(parents) != 0 {
+		chain.Parents = strings.Split(parents, ",")
+		chain.Base = chain.Parents[0]
+	}
+	return chain
+}
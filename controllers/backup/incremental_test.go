@@ -0,0 +1,94 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/radondb/radondb-mysql-kubernetes/api/v1beta1"
+)
+
+const (
+	testHost0 = "sample-mysql-0.sample-mysql.default"
+	testHost1 = "sample-mysql-1.sample-mysql.default"
+)
+
+func newIncrementalBackup() *v1beta1.Backup {
+	return &v1beta1.Backup{
+		Spec: v1beta1.BackupSpec{
+			ClusterName: "sample",
+			Manual:      &v1beta1.ManualBackup{BackupType: v1beta1.IncrementalBackupType},
+			BackupOpts:  v1beta1.BackupOps{S3: &v1beta1.S3{BackupSecretName: "sample-s3"}},
+		},
+	}
+}
+
+func TestLastIncrementalBase(t *testing.T) {
+	early := metav1.NewTime(time.Now().Add(-time.Hour))
+	late := metav1.NewTime(time.Now())
+	scheduled := newIncrementalBackup()
+	scheduled.Status.ScheduledBackups = []v1beta1.ScheduledBackupStatus{
+		{
+			BackupName: "sample_2022", State: v1beta1.BackupSucceeded, CompletionTime: &early,
+			Chain: &v1beta1.BackupChain{Base: "sample_2022", FromLSN: "0", ToLSN: "100", Host: testHost0},
+		},
+		{
+			BackupName: "sample_2023", State: v1beta1.BackupSucceeded, CompletionTime: &late,
+			Chain: &v1beta1.BackupChain{Base: "sample_2022", Parents: []string{"sample_2022"}, FromLSN: "100", ToLSN: "200", Host: testHost0},
+		},
+		{
+			BackupName: "sample_2024", State: v1beta1.BackupFailed, CompletionTime: &late,
+		},
+	}
+	otherStorage := newIncrementalBackup()
+	otherStorage.Spec.BackupOpts.S3.BackupSecretName = "other-s3"
+	otherStorage.Status.ManualBackup = &v1beta1.ManualBackupStatus{
+		BackupName: "sample_2025", State: v1beta1.BackupSucceeded, CompletionTime: &late,
+		Chain: &v1beta1.BackupChain{Base: "sample_2025", FromLSN: "0", ToLSN: "300", Host: testHost0},
+	}
+	backups := []v1beta1.Backup{*scheduled, *otherStorage}
+
+	base := lastIncrementalBase(newIncrementalBackup(), backups, testHost0)
+	assert.Equal(t, &incrementalBase{chain: []string{"sample_2022", "sample_2023"}, toLSN: "200"}, base)
+
+	// The LSNs of another node are meaningless, take a full backup.
+	assert.Nil(t, lastIncrementalBase(newIncrementalBackup(), backups, testHost1))
+	// The backups which do not record the node cannot be the parent.
+	scheduled.Status.ScheduledBackups[1].Chain.Host = ""
+	assert.Nil(t, lastIncrementalBase(newIncrementalBackup(), []v1beta1.Backup{*scheduled}, testHost0))
+
+	// The parent must be encrypted with the same key.
+	encrypted := newIncrementalBackup()
+	encrypted.Spec.BackupOpts.Encryption = &v1beta1.BackupEncryption{}
+	encrypted.Spec.BackupOpts.Encryption.KeySecret.Key = "key-1"
+	assert.Nil(t, lastIncrementalBase(encrypted, backups, testHost0))
+}
+
+func TestGetBackupNode(t *testing.T) {
+	cluster := &v1beta1.MysqlCluster{}
+	cluster.Name, cluster.Namespace = "sample", "default"
+	cluster.Status.Nodes = []v1beta1.NodeStatus{{Name: testHost0}, {Name: testHost1}}
+	backup := newIncrementalBackup()
+	assert.Equal(t, GetBackupHost(cluster), getBackupNode(backup, cluster))
+
+	backup.Spec.BackupOpts.BackupHost = "sample-mysql-0"
+	assert.Equal(t, testHost0, getBackupNode(backup, cluster))
+}
@@ -110,12 +110,29 @@ func GetBackupHost(cluster *v1beta1.MysqlCluster) string {
 	return host
 }
 
+// getBackupNode returns the node the backup job takes the backup from, such as
+// sample-mysql-0.sample-mysql.default.
+func getBackupNode(backup *v1beta1.Backup, cluster *v1beta1.MysqlCluster) string {
+	if host := backup.Spec.BackupOpts.BackupHost; len(host) != 0 {
+		return fmt.Sprintf("%s.%s-mysql.%s", host, cluster.Name, cluster.Namespace)
+	}
+	return GetBackupHost(cluster)
+}
+
 func GetXtrabackupURL(backupHost string) string {
 	xtrabackupPort := utils.XBackupPort
 	url := fmt.Sprintf("%s:%d", backupHost, xtrabackupPort)
 	return url
 }
 
+const (
+	// clientOptionFile is the mysql option file contains the credentials of the internal root user.
+	clientOptionFile = "/tmp/client.cnf"
+	// clientOptionFileCmd writes the password in the env into the option file, so that it does not
+	// appear in the command line. The quotes and backslashes in the password are escaped.
+	clientOptionFileCmd = `(umask 077; printf '[client]\nuser=root\npassword="%s"\n' "$(printf '%s' "$MYSQL_ROOT_PASSWORD" | sed 's/[\\"]/\\&/g')" > ` + clientOptionFile + `)`
+)
+
 func getEnvVarFromSecret(sctName, name, key string, opt bool) corev1.EnvVar {
 	return corev1.EnvVar{
 		Name: name,
@@ -131,6 +148,21 @@ func getEnvVarFromSecret(sctName, name, key string, opt bool) corev1.EnvVar {
 	}
 }
 
+// getTransitTLSSecretName returns the name of the secret contains the certificates of the cluster,
+// returns empty if the cluster does not encrypt in transit.
+func getTransitTLSSecretName(cluster *v1beta1.MysqlCluster) string {
+	if !cluster.Spec.EncryptInTransit {
+		return ""
+	}
+	if cluster.Spec.CustomTLSSecret != nil {
+		return cluster.Spec.CustomTLSSecret.Name
+	}
+	if cluster.Spec.AutoTLS != nil && cluster.Spec.AutoTLS.Enabled {
+		return fmt.Sprintf("%s-tls", cluster.GetName())
+	}
+	return ""
+}
+
 func GetBackupURL(clusterName string, hostName, Namespace string) string {
 	if len(hostName) != 0 {
 		return fmt.Sprintf("%s.%s-mysql.%s:%v", hostName, clusterName, Namespace, utils.XBackupPort)
@@ -148,9 +148,9 @@ func (r *BackupReconciler) clearHistoryJob(ctx context.Context, req ctrl.Request
 		}
 		if backup.Status.Completed {
 			if err := r.Delete(ctx, job, client.PropagationPolicy(metav1.DeletePropagationBackground)); client.IgnoreNotFound(err) != nil {
-				log.Error(err, "unable to delete old completed job", "job", job)
+				log.Error(err, "unable to delete old completed job", "job", job.Name)
 			} else {
-				log.V(0).Info("deleted old completed job", "job", job)
+				log.V(0).Info("deleted old completed job", "job", job.Name)
 			}
 		}
 
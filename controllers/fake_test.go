@@ -0,0 +1,137 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	apiv1beta1 "github.com/radondb/radondb-mysql-kubernetes/api/v1beta1"
+	"github.com/radondb/radondb-mysql-kubernetes/internal"
+)
+
+// newFakeClient returns a fake client with the objects, the scheme contains the API of the operator.
+func newFakeClient(objs ...client.Object) client.Client {
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = apiv1alpha1.AddToScheme(scheme)
+	_ = apiv1beta1.AddToScheme(scheme)
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+}
+
+// newFakeClusterSecret returns the secret of the cluster which contains the internal passwords.
+func newFakeClusterSecret(clusterName, namespace string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: clusterName + "-secret", Namespace: namespace},
+		Data: map[string][]byte{
+			"operator-password":      []byte("operator"),
+			"internal-root-password": []byte("root"),
+		},
+	}
+}
+
+// fakeSQLRunner answers the global variables of the hosts, and records the executed queries.
+type fakeSQLRunner struct {
+	host string
+	// variables is the global variables of each host.
+	variables map[string]map[string]string
+	// execs is the executed queries of each host, nil if not recorded.
+	execs map[string][]string
+}
+
+// newFakeSQLRunnerFactory returns a factory of the runners which answer the global variables of the hosts.
+func newFakeSQLRunnerFactory(variables map[string]map[string]string) internal.SQLRunnerFactory {
+	return newRecordingSQLRunnerFactory(variables, nil)
+}
+
+// newRecordingSQLRunnerFactory is like newFakeSQLRunnerFactory, and records the executed queries in execs.
+func newRecordingSQLRunnerFactory(variables map[string]map[string]string, execs map[string][]string) internal.SQLRunnerFactory {
+	return func(cfg *internal.Config, errs ...error) (internal.SQLRunner, internal.CloseFunc, error) {
+		if len(errs) > 0 && errs[0] != nil {
+			return nil, nil, errs[0]
+		}
+		if _, ok := variables[cfg.Host]; !ok {
+			return nil, nil, fmt.Errorf("failed to connect to %s", cfg.Host)
+		}
+		return &fakeSQLRunner{host: cfg.Host, variables: variables, execs: execs}, func() {}, nil
+	}
+}
+
+func (f *fakeSQLRunner) QueryExec(query internal.Query) error {
+	if f.execs != nil {
+		f.execs[f.host] = append(f.execs[f.host], query.String())
+	}
+	return nil
+}
+
+func (f *fakeSQLRunner) QueryRow(query internal.Query, dest ...interface{}) error {
+	return f.QueryRowContext(context.TODO(), query, dest...)
+}
+
+func (f *fakeSQLRunner) QueryRowContext(ctx context.Context, query internal.Query, dest ...interface{}) error {
+	args := query.Args()
+	if query.String() != "select @@global.?;" || len(args) != 1 || len(dest) != 1 {
+		return fmt.Errorf("unexpected query %s", query.String())
+	}
+	val, ok := f.variables[f.host][args[0].(string)]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	*dest[0].(*string) = val
+	return nil
+}
+
+func (f *fakeSQLRunner) QueryRows(query internal.Query) (*sql.Rows, error) {
+	return nil, fmt.Errorf("unexpected query %s", query.String())
+}
+
+func (f *fakeSQLRunner) QueryRowsContext(ctx context.Context, query internal.Query) (*sql.Rows, error) {
+	return nil, fmt.Errorf("unexpected query %s", query.String())
+}
+
+// fakeXenonExecutor records the leader transfers and answers the raft roles of the hosts.
+type fakeXenonExecutor struct {
+	roles      map[string]string
+	tryLeaders []string
+}
+
+func (f *fakeXenonExecutor) GetRootPassword() string             { return "" }
+func (f *fakeXenonExecutor) SetRootPassword(rootPassword string) {}
+func (f *fakeXenonExecutor) SetTLSConfig(config *tls.Config)     {}
+func (f *fakeXenonExecutor) XenonPing(host string) error         { return nil }
+
+func (f *fakeXenonExecutor) RaftStatus(host string) (*apiv1alpha1.RaftStatus, error) {
+	return &apiv1alpha1.RaftStatus{Role: f.roles[host]}, nil
+}
+
+func (f *fakeXenonExecutor) RaftTryToLeader(host string) error {
+	f.tryLeaders = append(f.tryLeaders, host)
+	return nil
+}
+
+func (f *fakeXenonExecutor) ClusterAdd(host string, toAdd string) error       { return nil }
+func (f *fakeXenonExecutor) ClusterRemove(host string, toRemove string) error { return nil }
@@ -19,6 +19,7 @@ package controllers
 import (
 	"context"
 	"reflect"
+	"time"
 
 	"github.com/presslabs/controller-util/pkg/meta"
 	"github.com/presslabs/controller-util/pkg/syncer"
@@ -27,6 +28,7 @@ import (
 	policyv1beta1 "k8s.io/api/policy/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
@@ -59,6 +61,7 @@ type MysqlClusterReconciler struct {
 // +kubebuilder:rbac:groups=mysql.radondb.com,resources=mysqlclusters,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=mysql.radondb.com,resources=mysqlclusters/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=mysql.radondb.com,resources=mysqlclusters/finalizers,verbs=update
+// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=configmaps;secrets;services;pods;pods/exec;persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=events,verbs=get;create;patch
@@ -126,11 +129,35 @@ func (r *MysqlClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		return ctrl.Result{}, err
 	}
 
-	// Todo: modify mysql cm will trigger rolling update but it will not be applied.
-	cmRev := mysqlCMSyncer.Object().(*corev1.ConfigMap).ResourceVersion
-	sctRev := secretSyncer.Object().(*corev1.Secret).ResourceVersion
+	// Only the changes of the static configs trigger rolling update, the dynamic ones are applied online.
+	cmRev := mysqlCMSyncer.Object().(*corev1.ConfigMap).Annotations[utils.AnnotationStaticConfigRev]
+	// The rotation of the internal credentials doesn't change the secret revision.
+	sctRev := secretSyncer.Object().(*corev1.Secret).Annotations[utils.AnnotationSecretRev]
+
+	var tlsRev string
+	var tlsRenewAfter time.Duration
+	if instance.AutoTLSEnabled() {
+		caSyncer := clustersyncer.NewTLSCASyncer(r.Client, instance)
+		if err = syncer.Sync(ctx, caSyncer, r.Recorder); err != nil {
+			return ctrl.Result{}, err
+		}
+		tlsSyncer := clustersyncer.NewTLSSecretSyncer(r.Client, instance, caSyncer.Object().(*corev1.Secret))
+		if err = syncer.Sync(ctx, tlsSyncer, r.Recorder); err != nil {
+			return ctrl.Result{}, err
+		}
+		tlsSecret := tlsSyncer.Object().(*corev1.Secret)
+		tlsRev = tlsSecret.ResourceVersion
+		tlsRenewAfter = clustersyncer.GetTLSRenewAfter(instance, tlsSecret)
+	}
 
 	r.XenonExecutor.SetRootPassword(instance.Spec.MysqlOpts.RootPassword)
+	tlsConfig, err := internal.NewXenonTLSConfig(r.Client, instance)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	r.XenonExecutor.SetTLSConfig(tlsConfig)
+
+	sfsSyncer := clustersyncer.NewStatefulSetSyncer(r.Client, instance, cmRev, sctRev, tlsRev, r.SQLRunnerFactory, r.XenonExecutor)
 
 	// run the syncers for services, pdb and statefulset
 	syncers := []syncer.Interface{
@@ -153,25 +180,33 @@ func (r *MysqlClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		// Delete follower service
 		r.deleteFollowerService(ctx, req, instance.Unwrap())
 		syncers = append(syncers,
-			clustersyncer.NewStatefulSetSyncer(r.Client, instance, cmRev, sctRev, r.SQLRunnerFactory, r.XenonExecutor),
+			sfsSyncer,
 			clustersyncer.NewPDBSyncer(r.Client, instance),
 			clustersyncer.NewXenonCMSyncer(r.Client, instance),
 		)
 	} else {
 		syncers = append(syncers,
 			clustersyncer.NewFollowerSVCSyncer(r.Client, instance),
-			clustersyncer.NewStatefulSetSyncer(r.Client, instance, cmRev, sctRev, r.SQLRunnerFactory, r.XenonExecutor),
+			sfsSyncer,
 			clustersyncer.NewPDBSyncer(r.Client, instance),
 			clustersyncer.NewXenonCMSyncer(r.Client, instance),
 		)
 	}
 
 	if instance.Spec.RemoteCluster != nil {
-		syncers = append(syncers, clustersyncer.NewRemoteClusterCMSyncer(r.Client, instance))
+		syncers = append(syncers, clustersyncer.NewRemoteClusterSecretSyncer(r.Client, instance))
+	}
+	if err = r.deleteRemoteClusterConfigMap(ctx, instance); err != nil {
+		return ctrl.Result{}, err
 	}
 	if instance.Spec.MetricsOpts.Enabled {
 		syncers = append(syncers, clustersyncer.NewMetricsSVCSyncer(r.Client, instance))
 	}
+	if opts := instance.Spec.BinlogArchive; opts != nil && opts.Enabled {
+		syncers = append(syncers, clustersyncer.NewBinlogArchiverSyncer(r.Client, instance))
+	} else if err = r.deleteBinlogArchiver(ctx, instance); err != nil {
+		return ctrl.Result{}, err
+	}
 
 	// run the syncers
 	for _, sync := range syncers {
@@ -180,7 +215,13 @@ func (r *MysqlClusterReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		}
 	}
 
-	return ctrl.Result{}, nil
+	// Requeue to continue the rolling update instead of blocking the reconciler,
+	// or to renew the server certificate before it expires.
+	requeueAfter := sfsSyncer.RequeueAfter()
+	if tlsRenewAfter > 0 && (requeueAfter == 0 || tlsRenewAfter < requeueAfter) {
+		requeueAfter = tlsRenewAfter
+	}
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -188,6 +229,7 @@ func (r *MysqlClusterReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&apiv1alpha1.MysqlCluster{}).
 		Owns(&appsv1.StatefulSet{}).
+		Owns(&appsv1.Deployment{}).
 		Owns(&corev1.ConfigMap{}).
 		Owns(&corev1.Service{}).
 		Owns(&rbacv1.Role{}).
@@ -231,6 +273,30 @@ func (r *MysqlClusterReconciler) deleteAllBackup(ctx context.Context, req ctrl.R
 	return nil
 }
 
+// deleteBinlogArchiver deletes the binlog archiver after the archiving is disabled.
+func (r *MysqlClusterReconciler) deleteBinlogArchiver(ctx context.Context, instance *mysqlcluster.MysqlCluster) error {
+	deploy := &appsv1.Deployment{}
+	key := client.ObjectKey{Namespace: instance.Namespace, Name: instance.GetNameForResource(utils.BinlogArchiver)}
+	if err := r.Get(ctx, key, deploy); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	return client.IgnoreNotFound(r.Delete(ctx, deploy))
+}
+
+// deleteRemoteClusterConfigMap deletes the configmap of the remote cluster created by the older
+// versions, which contains the plaintext password and is replaced by the secret.
+func (r *MysqlClusterReconciler) deleteRemoteClusterConfigMap(ctx context.Context, instance *mysqlcluster.MysqlCluster) error {
+	cm := &corev1.ConfigMap{}
+	key := client.ObjectKey{Namespace: instance.Namespace, Name: instance.GetNameForResource(utils.RemoteCluster)}
+	if err := r.Get(ctx, key, cm); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if !metav1.IsControlledBy(cm, instance.Unwrap()) {
+		return nil
+	}
+	return client.IgnoreNotFound(r.Delete(ctx, cm))
+}
+
 // For SingleNode, follower service do not need.
 func (r *MysqlClusterReconciler) deleteFollowerService(ctx context.Context, req ctrl.Request, instance *apiv1alpha1.MysqlCluster) error {
 	log := log.FromContext(ctx).WithName("controllers").WithName("MysqlCluster")
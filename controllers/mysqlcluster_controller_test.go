@@ -0,0 +1,72 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlcluster"
+)
+
+func TestDeleteRemoteClusterConfigMap(t *testing.T) {
+	cluster := mysqlcluster.New(&apiv1alpha1.MysqlCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default", UID: "sample-uid"},
+	})
+	controlled := true
+	owner := metav1.OwnerReference{
+		APIVersion: apiv1alpha1.GroupVersion.String(),
+		Kind:       "MysqlCluster",
+		Name:       "sample",
+		UID:        "sample-uid",
+		Controller: &controlled,
+	}
+	tests := []struct {
+		name       string
+		owners     []metav1.OwnerReference
+		wantExists bool
+	}{
+		{name: "created by the operator", owners: []metav1.OwnerReference{owner}},
+		{name: "created by the user", wantExists: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cm := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "sample-remote", Namespace: "default", OwnerReferences: tt.owners},
+			}
+			r := &MysqlClusterReconciler{Client: newFakeClient(cm)}
+			assert.NoError(t, r.deleteRemoteClusterConfigMap(context.TODO(), cluster))
+			err := r.Get(context.TODO(), client.ObjectKeyFromObject(cm), &corev1.ConfigMap{})
+			if tt.wantExists {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.IsNotFound(err))
+			}
+		})
+	}
+
+	// Nothing to delete.
+	r := &MysqlClusterReconciler{Client: newFakeClient()}
+	assert.NoError(t, r.deleteRemoteClusterConfigMap(context.TODO(), cluster))
+}
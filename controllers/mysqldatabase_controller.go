@@ -0,0 +1,225 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/presslabs/controller-util/pkg/meta"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/internal"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlcluster"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqldatabase"
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+// MysqlDatabaseReconciler reconciles a MysqlDatabase object.
+type MysqlDatabaseReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// MySQL query runner.
+	internal.SQLRunnerFactory
+}
+
+var (
+	databaseLog       = log.Log.WithName("controller").WithName("mysqldatabase")
+	databaseFinalizer = "mysqldatabase-finalizer"
+)
+
+//+kubebuilder:rbac:groups=mysql.radondb.com,resources=mysqldatabases,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=mysql.radondb.com,resources=mysqldatabases/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=mysql.radondb.com,resources=mysqldatabases/finalizers,verbs=update
+
+// Reconcile creates the database in the leader of the cluster, keeps its character set
+// and collation as the spec, and drops it when the MysqlDatabase is deleted with the
+// `Drop` deletion policy if it was created by the MysqlDatabase.
+func (r *MysqlDatabaseReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	database := mysqldatabase.New(&apiv1alpha1.MysqlDatabase{})
+
+	err := r.Get(ctx, req.NamespacedName, database.Unwrap())
+	if err != nil {
+		if errors.IsNotFound(err) {
+			databaseLog.Info("mysql database not found, maybe deleted")
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	oldStatus := database.Status.DeepCopy()
+
+	// If mysql database has been deleted then remove it from mysql cluster if needed.
+	if !database.ObjectMeta.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, r.removeDatabase(ctx, database)
+	}
+
+	// Refuse the database if its namespace is not allowed by the cluster.
+	allowed, err := isNamespaceAllowed(ctx, r.Client, database.GetClusterKey(), database.Namespace)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	var rdErr error
+	if allowed {
+		rdErr = r.reconcileDatabaseInCluster(ctx, database)
+	} else {
+		r.refuseDatabase(database)
+	}
+	if !reflect.DeepEqual(oldStatus, &database.Status) {
+		if err := r.Status().Update(ctx, database.Unwrap()); err != nil {
+			if rdErr != nil {
+				return ctrl.Result{}, fmt.Errorf("failed to update status: %s, previous error was: %s", err, rdErr)
+			}
+			return ctrl.Result{}, err
+		}
+	}
+	if rdErr != nil {
+		return ctrl.Result{}, rdErr
+	}
+
+	// Enqueue the resource again after to keep the database up to date in mysql
+	// in case is changed directly into mysql.
+	return ctrl.Result{
+		Requeue:      true,
+		RequeueAfter: 2 * time.Minute,
+	}, nil
+}
+
+// refuseDatabase marks the database not ready, the database is not created in mysql.
+func (r *MysqlDatabaseReconciler) refuseDatabase(database *mysqldatabase.MysqlDatabase) {
+	msg := fmt.Sprintf("The namespace %s is not allowed by the cluster %s.", database.Namespace, database.GetClusterKey())
+	database.UpdateStatusCondition(
+		apiv1alpha1.MysqlDatabaseReady, corev1.ConditionFalse,
+		mysqldatabase.NamespaceNotAllowedReason, msg,
+	)
+	r.Recorder.Event(database.Unwrap(), corev1.EventTypeWarning, mysqldatabase.NamespaceNotAllowedReason, msg)
+}
+
+// reconcileDatabaseInCluster creates or alters the database in mysql, and updates the status.
+func (r *MysqlDatabaseReconciler) reconcileDatabaseInCluster(ctx context.Context, database *mysqldatabase.MysqlDatabase) (err error) {
+	defer func() {
+		if err != nil {
+			database.UpdateStatusCondition(
+				apiv1alpha1.MysqlDatabaseReady, corev1.ConditionFalse,
+				mysqldatabase.ProvisionFailedReason, fmt.Sprintf("The database provisioning has failed: %s", err),
+			)
+			r.Recorder.Event(database.Unwrap(), corev1.EventTypeWarning, mysqldatabase.ProvisionFailedReason, err.Error())
+		}
+	}()
+
+	// Add finalizer before creating the database, so it can be dropped as the policy.
+	if !meta.HasFinalizer(&database.ObjectMeta, databaseFinalizer) {
+		meta.AddFinalizer(&database.ObjectMeta, databaseFinalizer)
+		if err = r.Update(ctx, database.Unwrap()); err != nil {
+			return
+		}
+	}
+
+	sqlRunner, closeConn, err := r.SQLRunnerFactory(internal.NewConfigFromClusterKey(
+		r.Client, database.GetClusterKey(), utils.RootUser, utils.LeaderHost))
+	if err != nil {
+		return
+	}
+	defer closeConn()
+
+	charset, collation, err := internal.GetDatabaseCharset(sqlRunner, database.Spec.Database)
+	if err != nil {
+		return
+	}
+	exists := charset != ""
+	if !exists {
+		// Record the database is created by the MysqlDatabase, so it can be dropped as the policy.
+		database.Status.Created = true
+	}
+	if !exists || !strings.EqualFold(charset, database.Spec.CharacterSet) ||
+		(database.Spec.Collation != "" && !strings.EqualFold(collation, database.Spec.Collation)) {
+		databaseLog.Info("creating or altering mysql database", "key", database.GetKey(),
+			"database", database.Spec.Database, "cluster", database.GetClusterKey())
+		if err = internal.CreateOrAlterDatabase(sqlRunner, database.Spec.Database,
+			database.Spec.CharacterSet, database.Spec.Collation, exists); err != nil {
+			return
+		}
+		if charset, collation, err = internal.GetDatabaseCharset(sqlRunner, database.Spec.Database); err != nil {
+			return
+		}
+	}
+
+	database.Status.CharacterSet = charset
+	database.Status.Collation = collation
+	database.UpdateStatusCondition(
+		apiv1alpha1.MysqlDatabaseReady, corev1.ConditionTrue,
+		mysqldatabase.ProvisionSucceededReason, "The database provisioning has succeeded.",
+	)
+	return
+}
+
+// removeDatabase drops the database as the deletion policy before the MysqlDatabase is deleted.
+func (r *MysqlDatabaseReconciler) removeDatabase(ctx context.Context, database *mysqldatabase.MysqlDatabase) error {
+	if !meta.HasFinalizer(&database.ObjectMeta, databaseFinalizer) {
+		return nil
+	}
+
+	// Only drop the database created by the MysqlDatabase, the adopted database is kept.
+	if database.Spec.DeletionPolicy == apiv1alpha1.DatabaseDrop && database.Status.Created {
+		if err := r.dropDatabaseFromDB(database); err != nil {
+			return err
+		}
+	}
+
+	meta.RemoveFinalizer(&database.ObjectMeta, databaseFinalizer)
+	return r.Update(ctx, database.Unwrap())
+}
+
+func (r *MysqlDatabaseReconciler) dropDatabaseFromDB(database *mysqldatabase.MysqlDatabase) error {
+	sqlRunner, closeConn, err := r.SQLRunnerFactory(internal.NewConfigFromClusterKey(
+		r.Client, database.GetClusterKey(), utils.RootUser, utils.LeaderHost))
+	if errors.IsNotFound(err) {
+		// If the mysql cluster does not exists then we can safely assume that
+		// the database is deleted.
+		statusErr, ok := err.(*errors.StatusError)
+		if ok && mysqlcluster.IsClusterKind(statusErr.Status().Details.Kind) {
+			return nil
+		}
+	}
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+
+	databaseLog.Info("dropping database from mysql cluster", "key", database.GetKey(),
+		"database", database.Spec.Database, "cluster", database.GetClusterKey())
+	return internal.DropDatabase(sqlRunner, database.Spec.Database)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *MysqlDatabaseReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&apiv1alpha1.MysqlDatabase{}).
+		Complete(r)
+}
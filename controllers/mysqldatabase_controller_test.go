@@ -0,0 +1,229 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/internal"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqldatabase"
+)
+
+// newNamespaceTestObjects returns a cluster which only allows the namespaces of the app team.
+func newNamespaceTestObjects() []client.Object {
+	return []client.Object{
+		&apiv1alpha1.MysqlCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"},
+			Spec: apiv1alpha1.MysqlClusterSpec{AllowedUserNamespaces: &apiv1alpha1.AllowedNamespaces{
+				From:     apiv1alpha1.NamespacesFromSelector,
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "app"}},
+			}},
+		},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other"}},
+		newFakeClusterSecret("sample", "default"),
+	}
+}
+
+func TestDatabaseNamespaceNotAllowed(t *testing.T) {
+	database := &apiv1alpha1.MysqlDatabase{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "other"},
+		Spec: apiv1alpha1.DatabaseSpec{
+			Database:   "db",
+			ClusterRef: apiv1alpha1.ClusterReference{ClusterName: "sample", NameSpace: "default"},
+		},
+	}
+	r := &MysqlDatabaseReconciler{
+		Client:           newFakeClient(append(newNamespaceTestObjects(), database)...),
+		Recorder:         record.NewFakeRecorder(10),
+		SQLRunnerFactory: newFakeSQLRunnerFactory(nil),
+	}
+
+	key := client.ObjectKeyFromObject(database)
+	_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key})
+	assert.NoError(t, err)
+	assert.NoError(t, r.Get(context.TODO(), key, database))
+	cond, ok := mysqldatabase.New(database).ConditionExists(apiv1alpha1.MysqlDatabaseReady)
+	assert.True(t, ok)
+	assert.Equal(t, corev1.ConditionFalse, cond.Status)
+	assert.Equal(t, mysqldatabase.NamespaceNotAllowedReason, cond.Reason)
+	// The database is not created, so it will not be dropped.
+	assert.Empty(t, database.Finalizers)
+}
+
+// fakeDatabaseRunner keeps the character set and collation of the databases in the leader,
+// and records the executed queries.
+type fakeDatabaseRunner struct {
+	fakeSQLRunner
+	// databases is the character set and collation of each database.
+	databases map[string][2]string
+	execs     []string
+}
+
+func (f *fakeDatabaseRunner) factory(cfg *internal.Config, errs ...error) (internal.SQLRunner, internal.CloseFunc, error) {
+	if len(errs) > 0 && errs[0] != nil {
+		return nil, nil, errs[0]
+	}
+	return f, func() {}, nil
+}
+
+func (f *fakeDatabaseRunner) QueryExec(query internal.Query) error {
+	stmt := query.String()
+	f.execs = append(f.execs, stmt)
+	name := strings.Split(stmt, "`")[1]
+	switch {
+	case strings.HasPrefix(stmt, "DROP DATABASE"):
+		delete(f.databases, name)
+	case strings.HasPrefix(stmt, "CREATE DATABASE"), strings.HasPrefix(stmt, "ALTER DATABASE"):
+		args := query.Args()
+		charset, collation := args[0].(string), args[0].(string)+"_general_ci"
+		if len(args) > 1 {
+			collation = args[1].(string)
+		}
+		f.databases[name] = [2]string{charset, collation}
+	}
+	return nil
+}
+
+func (f *fakeDatabaseRunner) QueryRowContext(ctx context.Context, query internal.Query, dest ...interface{}) error {
+	if !strings.Contains(query.String(), "information_schema.SCHEMATA") {
+		return fmt.Errorf("unexpected query %s", query.String())
+	}
+	db, ok := f.databases[query.Args()[0].(string)]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	*dest[0].(*string), *dest[1].(*string) = db[0], db[1]
+	return nil
+}
+
+// newDatabaseReconciler returns the reconciler of the database with the cluster and the databases in the leader.
+func newDatabaseReconciler(database *apiv1alpha1.MysqlDatabase, databases map[string][2]string) (*MysqlDatabaseReconciler, *fakeDatabaseRunner) {
+	runner := &fakeDatabaseRunner{databases: databases}
+	cluster := &apiv1alpha1.MysqlCluster{ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"}}
+	return &MysqlDatabaseReconciler{
+		Client: newFakeClient(cluster, newFakeClusterSecret("sample", "default"), database,
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}),
+		Recorder:         record.NewFakeRecorder(10),
+		SQLRunnerFactory: runner.factory,
+	}, runner
+}
+
+func newTestDatabase(policy apiv1alpha1.DatabaseDeletionPolicy) *apiv1alpha1.MysqlDatabase {
+	return &apiv1alpha1.MysqlDatabase{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"},
+		Spec: apiv1alpha1.DatabaseSpec{
+			Database:       "db",
+			ClusterRef:     apiv1alpha1.ClusterReference{ClusterName: "sample"},
+			CharacterSet:   "utf8mb4",
+			DeletionPolicy: policy,
+		},
+	}
+}
+
+// deleteDatabase marks the database deleting, as the api server does when it has finalizers.
+func deleteDatabase(t *testing.T, r *MysqlDatabaseReconciler, database *apiv1alpha1.MysqlDatabase) {
+	now := metav1.NewTime(time.Now())
+	database.DeletionTimestamp = &now
+	assert.NoError(t, r.Update(context.TODO(), database))
+}
+
+func TestDatabaseCreateAndDrop(t *testing.T) {
+	database := newTestDatabase(apiv1alpha1.DatabaseDrop)
+	r, runner := newDatabaseReconciler(database, map[string][2]string{})
+	key := client.ObjectKeyFromObject(database)
+
+	_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"CREATE DATABASE IF NOT EXISTS `db` CHARACTER SET ?;"}, runner.execs)
+	assert.NoError(t, r.Get(context.TODO(), key, database))
+	assert.True(t, database.Status.Created)
+	assert.Equal(t, "utf8mb4", database.Status.CharacterSet)
+	assert.Equal(t, []string{databaseFinalizer}, database.Finalizers)
+	cond, ok := mysqldatabase.New(database).ConditionExists(apiv1alpha1.MysqlDatabaseReady)
+	assert.True(t, ok)
+	assert.Equal(t, corev1.ConditionTrue, cond.Status)
+
+	// The database is up to date, nothing is executed.
+	_, err = r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key})
+	assert.NoError(t, err)
+	assert.Len(t, runner.execs, 1)
+
+	deleteDatabase(t, r, database)
+	_, err = r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key})
+	assert.NoError(t, err)
+	assert.Equal(t, "DROP DATABASE IF EXISTS `db`;", runner.execs[1])
+	assert.NotContains(t, runner.databases, "db")
+	// The finalizer is removed, so the MysqlDatabase is deleted.
+	assert.True(t, errors.IsNotFound(r.Get(context.TODO(), key, database)))
+}
+
+func TestDatabaseAlterAndRetain(t *testing.T) {
+	database := newTestDatabase(apiv1alpha1.DatabaseRetain)
+	database.Spec.Collation = "utf8mb4_bin"
+	r, runner := newDatabaseReconciler(database, map[string][2]string{"db": {"latin1", "latin1_swedish_ci"}})
+	key := client.ObjectKeyFromObject(database)
+
+	_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ALTER DATABASE `db` CHARACTER SET ? COLLATE ?;"}, runner.execs)
+	assert.NoError(t, r.Get(context.TODO(), key, database))
+	assert.False(t, database.Status.Created)
+	assert.Equal(t, "utf8mb4", database.Status.CharacterSet)
+	assert.Equal(t, "utf8mb4_bin", database.Status.Collation)
+
+	deleteDatabase(t, r, database)
+	_, err = r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key})
+	assert.NoError(t, err)
+	assert.Len(t, runner.execs, 1)
+	// The finalizer is removed, so the MysqlDatabase is deleted.
+	assert.True(t, errors.IsNotFound(r.Get(context.TODO(), key, database)))
+}
+
+func TestDatabaseNotDropAdopted(t *testing.T) {
+	database := newTestDatabase(apiv1alpha1.DatabaseDrop)
+	r, runner := newDatabaseReconciler(database, map[string][2]string{"db": {"utf8mb4", "utf8mb4_general_ci"}})
+	key := client.ObjectKeyFromObject(database)
+
+	_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key})
+	assert.NoError(t, err)
+	assert.Empty(t, runner.execs)
+	assert.NoError(t, r.Get(context.TODO(), key, database))
+	assert.False(t, database.Status.Created)
+
+	// The existing database is adopted, so it is kept though the policy is Drop.
+	deleteDatabase(t, r, database)
+	_, err = r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key})
+	assert.NoError(t, err)
+	assert.Empty(t, runner.execs)
+	assert.Contains(t, runner.databases, "db")
+	// The finalizer is removed, so the MysqlDatabase is deleted.
+	assert.True(t, errors.IsNotFound(r.Get(context.TODO(), key, database)))
+}
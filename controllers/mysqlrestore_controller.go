@@ -0,0 +1,277 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	apiv1beta1 "github.com/radondb/radondb-mysql-kubernetes/api/v1beta1"
+	"github.com/radondb/radondb-mysql-kubernetes/internal"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlcluster"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlrestore"
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+// MysqlRestoreReconciler reconciles a MysqlRestore object.
+type MysqlRestoreReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// Mysql query runner.
+	internal.SQLRunnerFactory
+}
+
+var (
+	restoreLog = log.Log.WithName("controller").WithName("mysqlrestore")
+	// restoreCheckPeriod is the period to check the progress of the restore.
+	restoreCheckPeriod = 10 * time.Second
+)
+
+//+kubebuilder:rbac:groups=mysql.radondb.com,resources=mysqlrestores,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=mysql.radondb.com,resources=mysqlrestores/status,verbs=get;update;patch
+
+// Reconcile drives a MysqlRestore through its phases:
+//  1. Pending: resolve the backup and create the cluster which restores from it.
+//  2. Downloading, Preparing and ApplyingBinlogs: follow the init containers of the first pod,
+//     which report the progress in the annotations of the pod.
+//  3. Verifying: wait for the cluster being ready and check the restored GTID set.
+//  4. Succeeded or Failed: the restore is finished and will not be handled again.
+func (r *MysqlRestoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	restore := mysqlrestore.New(&apiv1alpha1.MysqlRestore{})
+
+	err := r.Get(ctx, req.NamespacedName, restore.Unwrap())
+	if err != nil {
+		if errors.IsNotFound(err) {
+			restoreLog.Info("mysql restore not found, maybe deleted")
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	if restore.IsFinished() {
+		return ctrl.Result{}, nil
+	}
+
+	oldStatus := restore.Status.DeepCopy()
+	defer func() {
+		if !reflect.DeepEqual(oldStatus, &restore.Status) {
+			if sErr := r.Status().Update(ctx, restore.Unwrap()); sErr != nil {
+				restoreLog.Error(sErr, "failed to update restore status", "key", restore.GetKey())
+			}
+		}
+	}()
+
+	if restore.Status.Phase == apiv1alpha1.RestorePending {
+		return r.createCluster(ctx, restore)
+	}
+
+	cluster := mysqlcluster.New(&apiv1alpha1.MysqlCluster{})
+	if err := r.Get(ctx, restore.GetClusterKey(), cluster.Unwrap()); err != nil {
+		if errors.IsNotFound(err) {
+			r.fail(restore, mysqlrestore.RestoreFailedReason,
+				fmt.Sprintf("cluster %s was deleted during the restore", restore.GetClusterKey()))
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	pod := &corev1.Pod{}
+	podKey := client.ObjectKey{
+		Name:      fmt.Sprintf("%s-0", cluster.GetNameForResource(utils.StatefulSet)),
+		Namespace: cluster.Namespace,
+	}
+	if err := r.Get(ctx, podKey, pod); err != nil {
+		if errors.IsNotFound(err) {
+			restore.UpdatePhase(apiv1alpha1.RestoreDownloading, "", "", "waiting for the pod to be created")
+			return ctrl.Result{RequeueAfter: restoreCheckPeriod}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	if msg := getInitContainerFailure(pod); len(msg) != 0 {
+		r.fail(restore, mysqlrestore.RestoreFailedReason, msg)
+		return ctrl.Result{}, nil
+	}
+
+	if !isPodInitialized(pod) {
+		phase := apiv1alpha1.RestorePhase(pod.Annotations[utils.AnnotationRestorePhase])
+		if len(phase) == 0 {
+			phase = apiv1alpha1.RestoreDownloading
+		}
+		restore.UpdatePhase(phase, pod.Annotations[utils.AnnotationRestoreProgress], "", "")
+		return ctrl.Result{RequeueAfter: restoreCheckPeriod}, nil
+	}
+
+	if cluster.Status.State != apiv1alpha1.ClusterReadyState {
+		restore.UpdatePhase(apiv1alpha1.RestoreVerifying, "", "", "waiting for the cluster to be ready")
+		return ctrl.Result{RequeueAfter: restoreCheckPeriod}, nil
+	}
+	restore.UpdatePhase(apiv1alpha1.RestoreVerifying, "", "", "checking the restored gtid")
+	gtid, err := r.getRestoredGtid(cluster)
+	if err != nil {
+		restoreLog.Info("failed to get the restored gtid", "key", restore.GetKey(), "error", err.Error())
+		return ctrl.Result{RequeueAfter: restoreCheckPeriod}, nil
+	}
+	restore.Status.RestoredGtid = gtid
+	if err := restore.Verify(gtid); err != nil {
+		r.fail(restore, mysqlrestore.VerificationFailedReason, err.Error())
+		return ctrl.Result{}, nil
+	}
+	restore.UpdatePhase(apiv1alpha1.RestoreSucceeded, "", mysqlrestore.RestoreSucceededReason,
+		fmt.Sprintf("cluster %s is ready with the restored data", cluster.Name))
+	r.Recorder.Eventf(restore.Unwrap(), corev1.EventTypeNormal, mysqlrestore.RestoreSucceededReason,
+		"restored %s into cluster %s in %s", restore.Status.BackupName, cluster.Name,
+		restore.Status.CompletionTime.Sub(restore.Status.StartTime.Time).Round(time.Second))
+	return ctrl.Result{}, nil
+}
+
+// createCluster resolves the backup and creates the cluster which restores from it.
+func (r *MysqlRestoreReconciler) createCluster(ctx context.Context, restore *mysqlrestore.MysqlRestore) (ctrl.Result, error) {
+	var source *mysqlrestore.BackupSource
+	switch {
+	case restore.Spec.Backup != nil && restore.Spec.S3 != nil:
+		r.fail(restore, mysqlrestore.InvalidSourceReason, "only one of backup and s3 can be set")
+		return ctrl.Result{}, nil
+	case restore.Spec.Backup != nil:
+		backup := &apiv1beta1.Backup{}
+		if err := r.Get(ctx, client.ObjectKey{Name: restore.Spec.Backup.Name, Namespace: restore.Namespace}, backup); err != nil {
+			if errors.IsNotFound(err) {
+				r.fail(restore, mysqlrestore.InvalidSourceReason, fmt.Sprintf("backup %s not found", restore.Spec.Backup.Name))
+				return ctrl.Result{}, nil
+			}
+			return ctrl.Result{}, err
+		}
+		var err error
+		if source, err = restore.GetBackupSource(backup); err != nil {
+			r.fail(restore, mysqlrestore.InvalidSourceReason, err.Error())
+			return ctrl.Result{}, nil
+		}
+	case restore.Spec.S3 != nil:
+		source = restore.GetS3Source()
+	default:
+		r.fail(restore, mysqlrestore.InvalidSourceReason, "one of backup and s3 must be set")
+		return ctrl.Result{}, nil
+	}
+
+	existing := &apiv1alpha1.MysqlCluster{}
+	err := r.Get(ctx, restore.GetClusterKey(), existing)
+	if err == nil {
+		// The cluster may be created before the status was updated.
+		if existing.Labels[utils.LabelRestore] != restore.Name {
+			r.fail(restore, mysqlrestore.ClusterExistsReason, fmt.Sprintf("cluster %s already exists", existing.Name))
+			return ctrl.Result{}, nil
+		}
+	} else if !errors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	} else {
+		var sourceSpec *apiv1alpha1.MysqlClusterSpec
+		if name := restore.GetSourceClusterName(source); len(name) != 0 {
+			sourceCluster := &apiv1alpha1.MysqlCluster{}
+			err := r.Get(ctx, client.ObjectKey{Name: name, Namespace: restore.Namespace}, sourceCluster)
+			if err == nil {
+				sourceSpec = &sourceCluster.Spec
+			} else if !errors.IsNotFound(err) {
+				return ctrl.Result{}, err
+			}
+		}
+		cluster := &apiv1alpha1.MysqlCluster{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      restore.Spec.ClusterName,
+				Namespace: restore.Namespace,
+				Labels:    map[string]string{utils.LabelRestore: restore.Name},
+			},
+			Spec: restore.BuildClusterSpec(sourceSpec, source),
+		}
+		if err := r.Create(ctx, cluster); err != nil {
+			// The cluster rejected by the webhooks or the apiserver will not be created by retries.
+			if errors.IsInvalid(err) || errors.IsForbidden(err) {
+				r.fail(restore, mysqlrestore.CreateClusterFailedReason, err.Error())
+				return ctrl.Result{}, nil
+			}
+			return ctrl.Result{}, err
+		}
+		r.Recorder.Eventf(restore.Unwrap(), corev1.EventTypeNormal, "CreatedCluster",
+			"created cluster %s to restore %s", cluster.Name, source.BackupName)
+	}
+
+	restore.Status.BackupName = source.BackupName
+	restore.Status.BackupGtid = source.Gtid
+	restore.UpdatePhase(apiv1alpha1.RestoreDownloading, "", "", "waiting for the pod to be created")
+	return ctrl.Result{RequeueAfter: restoreCheckPeriod}, nil
+}
+
+// getRestoredGtid returns the gtid_executed of the leader of the cluster.
+func (r *MysqlRestoreReconciler) getRestoredGtid(cluster *mysqlcluster.MysqlCluster) (string, error) {
+	leader := fmt.Sprintf("%s.%s", cluster.GetNameForResource(utils.LeaderService), cluster.Namespace)
+	sqlRunner, closeConn, err := r.SQLRunnerFactory(internal.NewConfigFromClusterKey(
+		r.Client, cluster.GetClusterKey(), utils.OperatorUser, leader))
+	if err != nil {
+		return "", err
+	}
+	defer closeConn()
+	return internal.GetGtidExecuted(sqlRunner)
+}
+
+func (r *MysqlRestoreReconciler) fail(restore *mysqlrestore.MysqlRestore, reason, msg string) {
+	restoreLog.Info("restore failed", "key", restore.GetKey(), "reason", reason, "message", msg)
+	restore.UpdatePhase(apiv1alpha1.RestoreFailed, restore.Status.Progress, reason, msg)
+	r.Recorder.Event(restore.Unwrap(), corev1.EventTypeWarning, reason, msg)
+}
+
+// getInitContainerFailure returns the message if an init container of the pod exited with error,
+// the restore is not retried because the data directory may be partially restored.
+func getInitContainerFailure(pod *corev1.Pod) string {
+	for _, status := range pod.Status.InitContainerStatuses {
+		for _, state := range []corev1.ContainerState{status.State, status.LastTerminationState} {
+			if t := state.Terminated; t != nil && t.ExitCode != 0 {
+				return fmt.Sprintf("init container %s of pod %s exited with code %d: %s %s",
+					status.Name, pod.Name, t.ExitCode, t.Reason, t.Message)
+			}
+		}
+	}
+	return ""
+}
+
+// isPodInitialized returns true if all the init containers of the pod have completed.
+func isPodInitialized(pod *corev1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodInitialized {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *MysqlRestoreReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&apiv1alpha1.MysqlRestore{}).
+		Complete(r)
+}
@@ -0,0 +1,268 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlrestore"
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+const (
+	restoreLeader = "sample-leader.default"
+	restoreUUID   = "3e11fa47-71ca-11e1-9e33-c80aa9429562"
+)
+
+// createErrorClient fails to create the objects with the error.
+type createErrorClient struct {
+	client.Client
+	err error
+}
+
+func (c *createErrorClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	return c.err
+}
+
+func newRestoreReconciler(restoredGtid string, objs ...client.Object) *MysqlRestoreReconciler {
+	restore := &apiv1alpha1.MysqlRestore{
+		ObjectMeta: metav1.ObjectMeta{Name: "restore", Namespace: "default"},
+		Spec: apiv1alpha1.MysqlRestoreSpec{
+			ClusterName: "sample",
+			S3:          &apiv1alpha1.RestoreS3Source{SecretName: "sample-s3", BackupName: "backup_2022"},
+			Target:      &apiv1alpha1.RestoreTarget{StopGTID: restoreUUID + ":10"},
+		},
+	}
+	objs = append(objs, restore, newFakeClusterSecret("sample", "default"))
+	return &MysqlRestoreReconciler{
+		Client:   newFakeClient(objs...),
+		Recorder: record.NewFakeRecorder(100),
+		SQLRunnerFactory: newFakeSQLRunnerFactory(map[string]map[string]string{
+			restoreLeader: {"gtid_executed": restoredGtid},
+		}),
+	}
+}
+
+func reconcileRestore(t *testing.T, r *MysqlRestoreReconciler) (ctrl.Result, *apiv1alpha1.MysqlRestore, error) {
+	key := client.ObjectKey{Name: "restore", Namespace: "default"}
+	res, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key})
+	restore := &apiv1alpha1.MysqlRestore{}
+	assert.NoError(t, r.Get(context.TODO(), key, restore))
+	return res, restore, err
+}
+
+// newRestorePod returns the first pod of the cluster, which restores in the init containers.
+func newRestorePod(phase, progress string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "sample-mysql-0",
+			Namespace: "default",
+			Annotations: map[string]string{
+				utils.AnnotationRestorePhase:    phase,
+				utils.AnnotationRestoreProgress: progress,
+			},
+		},
+		Status: corev1.PodStatus{
+			Conditions: []corev1.PodCondition{{Type: corev1.PodInitialized, Status: corev1.ConditionFalse}},
+		},
+	}
+}
+
+func TestRestorePhases(t *testing.T) {
+	r := newRestoreReconciler(restoreUUID + ":1-9")
+	ctx := context.TODO()
+
+	// Pending: create the cluster.
+	res, restore, err := reconcileRestore(t, r)
+	assert.NoError(t, err)
+	assert.Equal(t, restoreCheckPeriod, res.RequeueAfter)
+	assert.Equal(t, apiv1alpha1.RestoreDownloading, restore.Status.Phase)
+	assert.Equal(t, "backup_2022", restore.Status.BackupName)
+	assert.NotNil(t, restore.Status.StartTime)
+	cluster := &apiv1alpha1.MysqlCluster{}
+	assert.NoError(t, r.Get(ctx, client.ObjectKey{Name: "sample", Namespace: "default"}, cluster))
+	assert.Equal(t, "restore", cluster.Labels[utils.LabelRestore])
+
+	// Downloading: wait for the pod.
+	_, restore, err = reconcileRestore(t, r)
+	assert.NoError(t, err)
+	assert.Equal(t, apiv1alpha1.RestoreDownloading, restore.Status.Phase)
+	assert.Equal(t, "waiting for the pod to be created", restore.Status.Message)
+
+	// Follow the progress reported by the init containers.
+	pod := newRestorePod("", "")
+	assert.NoError(t, r.Create(ctx, pod))
+	_, restore, err = reconcileRestore(t, r)
+	assert.NoError(t, err)
+	assert.Equal(t, apiv1alpha1.RestoreDownloading, restore.Status.Phase)
+
+	pod.Annotations[utils.AnnotationRestorePhase] = string(apiv1alpha1.RestorePreparing)
+	pod.Annotations[utils.AnnotationRestoreProgress] = "1/2"
+	assert.NoError(t, r.Update(ctx, pod))
+	res, restore, err = reconcileRestore(t, r)
+	assert.NoError(t, err)
+	assert.Equal(t, restoreCheckPeriod, res.RequeueAfter)
+	assert.Equal(t, apiv1alpha1.RestorePreparing, restore.Status.Phase)
+	assert.Equal(t, "1/2", restore.Status.Progress)
+
+	// Verifying: wait for the cluster.
+	pod.Status.Conditions[0].Status = corev1.ConditionTrue
+	assert.NoError(t, r.Status().Update(ctx, pod))
+	_, restore, err = reconcileRestore(t, r)
+	assert.NoError(t, err)
+	assert.Equal(t, apiv1alpha1.RestoreVerifying, restore.Status.Phase)
+	assert.Equal(t, "waiting for the cluster to be ready", restore.Status.Message)
+
+	cluster.Status.State = apiv1alpha1.ClusterReadyState
+	assert.NoError(t, r.Status().Update(ctx, cluster))
+	res, restore, err = reconcileRestore(t, r)
+	assert.NoError(t, err)
+	assert.Equal(t, ctrl.Result{}, res)
+	assert.Equal(t, apiv1alpha1.RestoreSucceeded, restore.Status.Phase)
+	assert.Equal(t, mysqlrestore.RestoreSucceededReason, restore.Status.Reason)
+	assert.Equal(t, restoreUUID+":1-9", restore.Status.RestoredGtid)
+	assert.NotNil(t, restore.Status.CompletionTime)
+
+	// The finished restore is not handled again.
+	assert.NoError(t, r.Delete(ctx, cluster))
+	_, restore, err = reconcileRestore(t, r)
+	assert.NoError(t, err)
+	assert.Equal(t, apiv1alpha1.RestoreSucceeded, restore.Status.Phase)
+}
+
+func TestRestoreFailed(t *testing.T) {
+	readyCluster := func(labels map[string]string) *apiv1alpha1.MysqlCluster {
+		return &apiv1alpha1.MysqlCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default", Labels: labels},
+			Status:     apiv1alpha1.MysqlClusterStatus{State: apiv1alpha1.ClusterReadyState},
+		}
+	}
+	initializedPod := newRestorePod("", "")
+	initializedPod.Status.Conditions[0].Status = corev1.ConditionTrue
+	failedPod := newRestorePod(string(apiv1alpha1.RestorePreparing), "0/1")
+	failedPod.Status.InitContainerStatuses = []corev1.ContainerStatus{{
+		Name: "init-mysql",
+		State: corev1.ContainerState{
+			Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Reason: "Error"},
+		},
+	}}
+	restoring := map[string]string{utils.LabelRestore: "restore"}
+
+	tests := []struct {
+		name       string
+		gtid       string
+		phase      apiv1alpha1.RestorePhase
+		objs       []client.Object
+		wantReason string
+	}{
+		{
+			name:       "cluster exists",
+			objs:       []client.Object{readyCluster(nil)},
+			wantReason: mysqlrestore.ClusterExistsReason,
+		},
+		{
+			name:       "cluster deleted",
+			phase:      apiv1alpha1.RestoreDownloading,
+			wantReason: mysqlrestore.RestoreFailedReason,
+		},
+		{
+			name:       "init container failed",
+			phase:      apiv1alpha1.RestorePreparing,
+			objs:       []client.Object{readyCluster(restoring), failedPod},
+			wantReason: mysqlrestore.RestoreFailedReason,
+		},
+		{
+			name:       "stop gtid applied",
+			gtid:       restoreUUID + ":1-10",
+			phase:      apiv1alpha1.RestoreVerifying,
+			objs:       []client.Object{readyCluster(restoring), initializedPod},
+			wantReason: mysqlrestore.VerificationFailedReason,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRestoreReconciler(tt.gtid, tt.objs...)
+			if tt.phase != apiv1alpha1.RestorePending {
+				restore := &apiv1alpha1.MysqlRestore{}
+				assert.NoError(t, r.Get(context.TODO(), client.ObjectKey{Name: "restore", Namespace: "default"}, restore))
+				restore.Status.Phase = tt.phase
+				assert.NoError(t, r.Status().Update(context.TODO(), restore))
+			}
+
+			res, restore, err := reconcileRestore(t, r)
+			assert.NoError(t, err)
+			assert.Equal(t, ctrl.Result{}, res)
+			assert.Equal(t, apiv1alpha1.RestoreFailed, restore.Status.Phase)
+			assert.Equal(t, tt.wantReason, restore.Status.Reason)
+			assert.NotNil(t, restore.Status.CompletionTime)
+		})
+	}
+}
+
+func TestRestoreCreateClusterError(t *testing.T) {
+	gk := schema.GroupKind{Group: "mysql.radondb.com", Kind: "MysqlCluster"}
+	tests := []struct {
+		name      string
+		err       error
+		wantPhase apiv1alpha1.RestorePhase
+		wantErr   bool
+	}{
+		{
+			name:      "invalid",
+			err:       errors.NewInvalid(gk, "sample", field.ErrorList{field.Invalid(field.NewPath("spec"), "", "invalid")}),
+			wantPhase: apiv1alpha1.RestoreFailed,
+		},
+		{
+			name:      "forbidden",
+			err:       errors.NewForbidden(schema.GroupResource{Group: gk.Group, Resource: "mysqlclusters"}, "sample", nil),
+			wantPhase: apiv1alpha1.RestoreFailed,
+		},
+		{
+			name:      "retry the transient error",
+			err:       errors.NewServerTimeout(schema.GroupResource{Group: gk.Group, Resource: "mysqlclusters"}, "create", 1),
+			wantPhase: apiv1alpha1.RestorePending,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRestoreReconciler("")
+			r.Client = &createErrorClient{Client: r.Client, err: tt.err}
+
+			_, restore, err := reconcileRestore(t, r)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, mysqlrestore.CreateClusterFailedReason, restore.Status.Reason)
+			}
+			assert.Equal(t, tt.wantPhase, restore.Status.Phase)
+		})
+	}
+}
@@ -0,0 +1,238 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/presslabs/controller-util/pkg/meta"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/internal"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlcluster"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlrole"
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+// MysqlRoleReconciler reconciles a MysqlRole object.
+type MysqlRoleReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// MySQL query runner.
+	internal.SQLRunnerFactory
+}
+
+var (
+	roleLog       = log.Log.WithName("controller").WithName("mysqlrole")
+	roleFinalizer = "mysqlrole-finalizer"
+)
+
+//+kubebuilder:rbac:groups=mysql.radondb.com,resources=mysqlroles,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=mysql.radondb.com,resources=mysqlroles/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=mysql.radondb.com,resources=mysqlroles/finalizers,verbs=update
+
+// Reconcile creates the role in the leader of the cluster, keeps its privileges as the
+// spec, and drops it when the MysqlRole is deleted. The existing account is not adopted.
+func (r *MysqlRoleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	role := mysqlrole.New(&apiv1alpha1.MysqlRole{})
+
+	err := r.Get(ctx, req.NamespacedName, role.Unwrap())
+	if err != nil {
+		if errors.IsNotFound(err) {
+			roleLog.Info("mysql role not found, maybe deleted")
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	oldStatus := role.Status.DeepCopy()
+
+	// If mysql role has been deleted then drop it from mysql cluster.
+	if !role.ObjectMeta.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, r.removeRole(ctx, role)
+	}
+
+	// Refuse the role if its namespace is not allowed by the cluster.
+	allowed, err := isNamespaceAllowed(ctx, r.Client, role.GetClusterKey(), role.Namespace)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	var rrErr error
+	if allowed {
+		rrErr = r.reconcileRoleInCluster(ctx, role)
+	} else {
+		r.refuseRole(role)
+	}
+	if !reflect.DeepEqual(oldStatus, &role.Status) {
+		if err := r.Status().Update(ctx, role.Unwrap()); err != nil {
+			if rrErr != nil {
+				return ctrl.Result{}, fmt.Errorf("failed to update status: %s, previous error was: %s", err, rrErr)
+			}
+			return ctrl.Result{}, err
+		}
+	}
+	if rrErr != nil {
+		return ctrl.Result{}, rrErr
+	}
+
+	// Enqueue the resource again after to keep the role up to date in mysql
+	// in case is changed directly into mysql.
+	return ctrl.Result{
+		Requeue:      true,
+		RequeueAfter: 2 * time.Minute,
+	}, nil
+}
+
+// refuseRole marks the role not ready, the role is not created in mysql.
+func (r *MysqlRoleReconciler) refuseRole(role *mysqlrole.MysqlRole) {
+	msg := fmt.Sprintf("The namespace %s is not allowed by the cluster %s.", role.Namespace, role.GetClusterKey())
+	role.UpdateStatusCondition(
+		apiv1alpha1.MysqlRoleReady, corev1.ConditionFalse,
+		mysqlrole.NamespaceNotAllowedReason, msg,
+	)
+	r.Recorder.Event(role.Unwrap(), corev1.EventTypeWarning, mysqlrole.NamespaceNotAllowedReason, msg)
+}
+
+// refuseExistingRole marks the role not ready, the existing account is not changed.
+func (r *MysqlRoleReconciler) refuseExistingRole(role *mysqlrole.MysqlRole) {
+	msg := fmt.Sprintf("The account %s@%s already exists and was not created by the MysqlRole.", role.Spec.Role, internal.RoleHost)
+	role.UpdateStatusCondition(
+		apiv1alpha1.MysqlRoleReady, corev1.ConditionFalse,
+		mysqlrole.AccountExistsReason, msg,
+	)
+	r.Recorder.Event(role.Unwrap(), corev1.EventTypeWarning, mysqlrole.AccountExistsReason, msg)
+}
+
+// reconcileRoleInCluster creates the role and grants the privileges in mysql, and updates the status.
+func (r *MysqlRoleReconciler) reconcileRoleInCluster(ctx context.Context, role *mysqlrole.MysqlRole) (err error) {
+	defer func() {
+		if err != nil {
+			role.UpdateStatusCondition(
+				apiv1alpha1.MysqlRoleReady, corev1.ConditionFalse,
+				mysqlrole.ProvisionFailedReason, fmt.Sprintf("The role provisioning has failed: %s", err),
+			)
+			r.Recorder.Event(role.Unwrap(), corev1.EventTypeWarning, mysqlrole.ProvisionFailedReason, err.Error())
+		}
+	}()
+
+	sqlRunner, closeConn, err := r.SQLRunnerFactory(internal.NewConfigFromClusterKey(
+		r.Client, role.GetClusterKey(), utils.RootUser, utils.LeaderHost))
+	if err != nil {
+		return
+	}
+	defer closeConn()
+
+	// MySQL 5.7 does not support roles.
+	if err = internal.RequireMysql80(sqlRunner, "roles"); err != nil {
+		return
+	}
+
+	exists, err := internal.CheckRoleExists(sqlRunner, role.Spec.Role)
+	if err != nil {
+		return
+	}
+	// Refuse to adopt the existing account, it may be a user or a role managed by others.
+	if exists && !role.Status.Created {
+		r.refuseExistingRole(role)
+		return
+	}
+
+	// Add finalizer before creating the role, so it can be dropped.
+	if !meta.HasFinalizer(&role.ObjectMeta, roleFinalizer) {
+		meta.AddFinalizer(&role.ObjectMeta, roleFinalizer)
+		if err = r.Update(ctx, role.Unwrap()); err != nil {
+			return
+		}
+	}
+
+	// Record the role is created by the MysqlRole, so it can be dropped.
+	role.Status.Created = true
+	if err = internal.CreateRole(sqlRunner, role.Spec.Role); err != nil {
+		return
+	}
+	if err = internal.GrantRolePermissions(sqlRunner, role.Spec.Role, role.Spec.Permissions); err != nil {
+		return
+	}
+
+	grants, err := internal.GetAppliedGrants(sqlRunner, role.Spec.Role, []string{internal.RoleHost})
+	if err != nil {
+		return
+	}
+	role.Status.Grants = grants
+	role.UpdateStatusCondition(
+		apiv1alpha1.MysqlRoleReady, corev1.ConditionTrue,
+		mysqlrole.ProvisionSucceededReason, "The role provisioning has succeeded.",
+	)
+	return
+}
+
+// removeRole drops the role before the MysqlRole is deleted.
+func (r *MysqlRoleReconciler) removeRole(ctx context.Context, role *mysqlrole.MysqlRole) error {
+	if !meta.HasFinalizer(&role.ObjectMeta, roleFinalizer) {
+		return nil
+	}
+
+	// Only drop the role created by the MysqlRole.
+	if role.Status.Created {
+		if err := r.dropRoleFromDB(role); err != nil {
+			return err
+		}
+	}
+
+	meta.RemoveFinalizer(&role.ObjectMeta, roleFinalizer)
+	return r.Update(ctx, role.Unwrap())
+}
+
+func (r *MysqlRoleReconciler) dropRoleFromDB(role *mysqlrole.MysqlRole) error {
+	sqlRunner, closeConn, err := r.SQLRunnerFactory(internal.NewConfigFromClusterKey(
+		r.Client, role.GetClusterKey(), utils.RootUser, utils.LeaderHost))
+	if errors.IsNotFound(err) {
+		// If the mysql cluster does not exists then we can safely assume that
+		// the role is deleted.
+		statusErr, ok := err.(*errors.StatusError)
+		if ok && mysqlcluster.IsClusterKind(statusErr.Status().Details.Kind) {
+			return nil
+		}
+	}
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+
+	roleLog.Info("dropping role from mysql cluster", "key", role.GetKey(),
+		"role", role.Spec.Role, "cluster", role.GetClusterKey())
+	return internal.DropRole(sqlRunner, role.Spec.Role)
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *MysqlRoleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&apiv1alpha1.MysqlRole{}).
+		Complete(r)
+}
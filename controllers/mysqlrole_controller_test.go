@@ -0,0 +1,118 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/internal"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlrole"
+)
+
+func TestRoleNamespaceNotAllowed(t *testing.T) {
+	role := &apiv1alpha1.MysqlRole{
+		ObjectMeta: metav1.ObjectMeta{Name: "role", Namespace: "other"},
+		Spec: apiv1alpha1.RoleSpec{
+			Role:       "reader",
+			ClusterRef: apiv1alpha1.ClusterReference{ClusterName: "sample", NameSpace: "default"},
+		},
+	}
+	r := &MysqlRoleReconciler{
+		Client:           newFakeClient(append(newNamespaceTestObjects(), role)...),
+		Recorder:         record.NewFakeRecorder(10),
+		SQLRunnerFactory: newFakeSQLRunnerFactory(nil),
+	}
+
+	key := client.ObjectKeyFromObject(role)
+	_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key})
+	assert.NoError(t, err)
+	assert.NoError(t, r.Get(context.TODO(), key, role))
+	cond, ok := mysqlrole.New(role).ConditionExists(apiv1alpha1.MysqlRoleReady)
+	assert.True(t, ok)
+	assert.Equal(t, corev1.ConditionFalse, cond.Status)
+	assert.Equal(t, mysqlrole.NamespaceNotAllowedReason, cond.Reason)
+	assert.Empty(t, role.Finalizers)
+}
+
+// fakeRoleRunner answers the version of mysql and the accounts in mysql.user.
+type fakeRoleRunner struct {
+	fakeSQLRunner
+	accounts []string
+}
+
+func (f *fakeRoleRunner) QueryRowContext(ctx context.Context, query internal.Query, dest ...interface{}) error {
+	if !strings.HasPrefix(query.String(), "SELECT COUNT(*) FROM mysql.user") {
+		return f.fakeSQLRunner.QueryRowContext(ctx, query, dest...)
+	}
+	args := query.Args()
+	count := 0
+	for _, account := range f.accounts {
+		if account == fmt.Sprintf("%s@%s", args[0], args[1]) {
+			count++
+		}
+	}
+	*dest[0].(*int) = count
+	return nil
+}
+
+func TestRoleNotAdoptExisting(t *testing.T) {
+	role := &apiv1alpha1.MysqlRole{
+		ObjectMeta: metav1.ObjectMeta{Name: "role", Namespace: "default"},
+		Spec: apiv1alpha1.RoleSpec{
+			Role:       "app",
+			ClusterRef: apiv1alpha1.ClusterReference{ClusterName: "sample"},
+		},
+	}
+	runner := &fakeRoleRunner{
+		fakeSQLRunner: fakeSQLRunner{host: "sample-leader.default", execs: map[string][]string{},
+			variables: map[string]map[string]string{"sample-leader.default": {"version": "8.0.25"}}},
+		accounts: []string{"app@%"},
+	}
+	r := &MysqlRoleReconciler{
+		Client: newFakeClient(role, newFakeClusterSecret("sample", "default"),
+			&apiv1alpha1.MysqlCluster{ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}),
+		Recorder: record.NewFakeRecorder(10),
+		SQLRunnerFactory: func(cfg *internal.Config, errs ...error) (internal.SQLRunner, internal.CloseFunc, error) {
+			return runner, func() {}, nil
+		},
+	}
+
+	key := client.ObjectKeyFromObject(role)
+	_, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key})
+	assert.NoError(t, err)
+	assert.NoError(t, r.Get(context.TODO(), key, role))
+	cond, ok := mysqlrole.New(role).ConditionExists(apiv1alpha1.MysqlRoleReady)
+	assert.True(t, ok)
+	assert.Equal(t, corev1.ConditionFalse, cond.Status)
+	assert.Equal(t, mysqlrole.AccountExistsReason, cond.Reason)
+	assert.False(t, role.Status.Created)
+	// The existing account is neither changed nor dropped when the MysqlRole is deleted.
+	assert.Empty(t, role.Finalizers)
+	assert.Empty(t, runner.execs)
+}
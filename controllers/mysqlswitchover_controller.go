@@ -0,0 +1,405 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/internal"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlcluster"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlswitchover"
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+// MysqlSwitchoverReconciler reconciles a MysqlSwitchover object.
+type MysqlSwitchoverReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+
+	// Mysql query runner.
+	internal.SQLRunnerFactory
+	// XenonExecutor is used to execute Xenon HTTP instructions.
+	internal.XenonExecutor
+}
+
+var (
+	switchoverLog = log.Log.WithName("controller").WithName("mysqlswitchover")
+	// switchoverCheckPeriod is the period to check whether the target has become leader.
+	switchoverCheckPeriod = 5 * time.Second
+)
+
+//+kubebuilder:rbac:groups=mysql.radondb.com,resources=mysqlswitchovers,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=mysql.radondb.com,resources=mysqlswitchovers/status,verbs=get;update;patch
+
+// Reconcile drives a MysqlSwitchover through its phases:
+// 1. Validating: check no other leader switch is running, and the target is healthy and not lagged.
+// 2. Fencing: make the leader read-only, and wait for the target applying the leader's transactions.
+// 3. Switching: ask xenon on the target to become the leader and wait for it.
+// 4. Succeeded or Failed: the switchover is finished and will not be handled again.
+func (r *MysqlSwitchoverReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	switchover := mysqlswitchover.New(&apiv1alpha1.MysqlSwitchover{})
+
+	err := r.Get(ctx, req.NamespacedName, switchover.Unwrap())
+	if err != nil {
+		if errors.IsNotFound(err) {
+			switchoverLog.Info("mysql switchover not found, maybe deleted")
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	if switchover.IsFinished() {
+		return ctrl.Result{}, nil
+	}
+
+	oldStatus := switchover.Status.DeepCopy()
+	defer func() {
+		if !reflect.DeepEqual(oldStatus, &switchover.Status) {
+			if sErr := r.Status().Update(ctx, switchover.Unwrap()); sErr != nil {
+				switchoverLog.Error(sErr, "failed to update switchover status", "key", switchover.GetKey())
+			}
+		}
+	}()
+
+	cluster := mysqlcluster.New(&apiv1alpha1.MysqlCluster{})
+	if err := r.Get(ctx, switchover.GetClusterKey(), cluster.Unwrap()); err != nil {
+		if errors.IsNotFound(err) {
+			r.fail(switchover, mysqlswitchover.ValidationFailedReason,
+				fmt.Sprintf("cluster %s not found", switchover.GetClusterKey()))
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	r.XenonExecutor.SetRootPassword(cluster.Spec.MysqlOpts.RootPassword)
+	tlsConfig, err := internal.NewXenonTLSConfig(r.Client, cluster)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	r.XenonExecutor.SetTLSConfig(tlsConfig)
+	targetHost := getPodHost(cluster, switchover.Spec.Target)
+
+	switch switchover.Status.Phase {
+	case apiv1alpha1.SwitchoverPending, apiv1alpha1.SwitchoverValidating:
+		if switchover.Status.Phase == apiv1alpha1.SwitchoverPending {
+			switchover.UpdatePhase(apiv1alpha1.SwitchoverValidating, "", "")
+		}
+		conflict, err := r.checkConflict(ctx, cluster, switchover)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if conflict != "" {
+			r.fail(switchover, mysqlswitchover.ConflictReason, conflict)
+			return ctrl.Result{}, nil
+		}
+		leader, err := r.validateTarget(ctx, cluster, switchover)
+		if err != nil {
+			r.fail(switchover, mysqlswitchover.ValidationFailedReason, err.Error())
+			return ctrl.Result{}, nil
+		}
+		if leader == targetHost {
+			switchover.Status.FromLeader = switchover.Spec.Target
+			switchover.UpdatePhase(apiv1alpha1.SwitchoverSucceeded,
+				mysqlswitchover.AlreadyLeaderReason, "the target is already the leader")
+			return ctrl.Result{}, nil
+		}
+		switchover.Status.FromLeader = getPodName(leader)
+
+		// Stop the writes on the leader, so that the target can catch up and no transaction
+		// is committed between the last check and the promotion.
+		switchoverLog.Info("fence the leader", "key", switchover.GetKey(), "leader", switchover.Status.FromLeader)
+		if err := r.fence(ctx, cluster, switchover.Status.FromLeader); err != nil {
+			r.unfence(ctx, cluster, switchover.Status.FromLeader)
+			r.fail(switchover, mysqlswitchover.ValidationFailedReason, fmt.Sprintf("failed to fence the leader: %s", err))
+			return ctrl.Result{}, nil
+		}
+		switchover.UpdatePhase(apiv1alpha1.SwitchoverFencing, "", "waiting for the target to apply the leader's transactions")
+		return r.promote(cluster, switchover, targetHost)
+
+	case apiv1alpha1.SwitchoverFencing:
+		return r.promote(cluster, switchover, targetHost)
+
+	case apiv1alpha1.SwitchoverSwitching:
+		raftStatus, err := r.XenonExecutor.RaftStatus(targetHost)
+		if err == nil && raftStatus.Role == string(utils.Leader) {
+			// The old leader has become a follower, which is kept read-only by xenon.
+			r.unfence(ctx, cluster, switchover.Status.FromLeader)
+			switchover.UpdatePhase(apiv1alpha1.SwitchoverSucceeded,
+				mysqlswitchover.SwitchoverSucceededReason, "the target has become the leader")
+			r.Recorder.Eventf(switchover.Unwrap(), corev1.EventTypeNormal, mysqlswitchover.SwitchoverSucceededReason,
+				"%s has become the leader", switchover.Spec.Target)
+			return ctrl.Result{}, nil
+		}
+		timeout := time.Duration(switchover.Spec.TimeoutSeconds) * time.Second
+		if switchover.Status.SwitchTime != nil && time.Since(switchover.Status.SwitchTime.Time) > timeout {
+			msg := fmt.Sprintf("the target did not become leader in %s", timeout)
+			if err != nil {
+				msg = fmt.Sprintf("%s, last error: %s", msg, err)
+			}
+			r.unfence(ctx, cluster, switchover.Status.FromLeader)
+			r.fail(switchover, mysqlswitchover.TimeoutReason, msg)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{RequeueAfter: switchoverCheckPeriod}, nil
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// promote asks xenon to promote the target after it has applied all the transactions of the
+// fenced leader, the leader is made writable again if the target can't catch up in time.
+func (r *MysqlSwitchoverReconciler) promote(cluster *mysqlcluster.MysqlCluster,
+	switchover *mysqlswitchover.MysqlSwitchover, targetHost string) (ctrl.Result, error) {
+	ctx := context.TODO()
+	leaderHost := getPodHost(cluster, switchover.Status.FromLeader)
+	// Do not block the worker, check again later if the target has not caught up.
+	caughtUp, err := r.checkGtidCaughtUp(cluster, leaderHost, targetHost)
+	if err != nil || !caughtUp {
+		timeout := cluster.GetPromoteCatchUpTimeout()
+		msg := "waiting for the target to apply the leader's transactions"
+		if err != nil {
+			msg = fmt.Sprintf("%s, last error: %s", msg, err)
+		}
+		if time.Since(switchover.Status.FenceTime.Time) > timeout {
+			r.unfence(ctx, cluster, switchover.Status.FromLeader)
+			r.fail(switchover, mysqlswitchover.TimeoutReason,
+				fmt.Sprintf("the target did not catch up the leader in %s", timeout))
+			return ctrl.Result{}, nil
+		}
+		switchover.Status.Message = msg
+		return ctrl.Result{RequeueAfter: switchoverCheckPeriod}, nil
+	}
+
+	switchoverLog.Info("try to switch the leader", "key", switchover.GetKey(),
+		"from", switchover.Status.FromLeader, "to", switchover.Spec.Target)
+	if err := r.XenonExecutor.RaftTryToLeader(targetHost); err != nil {
+		r.unfence(ctx, cluster, switchover.Status.FromLeader)
+		r.fail(switchover, mysqlswitchover.TryLeaderFailedReason, err.Error())
+		return ctrl.Result{}, nil
+	}
+	switchover.UpdatePhase(apiv1alpha1.SwitchoverSwitching, "", "waiting for the target to become leader")
+	r.Recorder.Eventf(switchover.Unwrap(), corev1.EventTypeNormal, "Switching",
+		"switching leader from %s to %s", switchover.Status.FromLeader, switchover.Spec.Target)
+	return ctrl.Result{RequeueAfter: switchoverCheckPeriod}, nil
+}
+
+// checkConflict returns the message if another switchover, a tryleader or the leader switch of
+// the rolling update is running in the cluster.
+func (r *MysqlSwitchoverReconciler) checkConflict(ctx context.Context, cluster *mysqlcluster.MysqlCluster,
+	switchover *mysqlswitchover.MysqlSwitchover) (string, error) {
+	switchovers := apiv1alpha1.MysqlSwitchoverList{}
+	if err := r.List(ctx, &switchovers, client.InNamespace(switchover.Namespace)); err != nil {
+		return "", err
+	}
+	for i := range switchovers.Items {
+		other := mysqlswitchover.New(&switchovers.Items[i])
+		if other.Name == switchover.Name || other.Spec.ClusterName != switchover.Spec.ClusterName || other.IsFinished() {
+			continue
+		}
+		// The pending switchovers are handled in the order of creation.
+		if other.Status.Phase != apiv1alpha1.SwitchoverPending ||
+			other.CreationTimestamp.Before(&switchover.CreationTimestamp) ||
+			(other.CreationTimestamp.Equal(&switchover.CreationTimestamp) && other.Name < switchover.Name) {
+			return fmt.Sprintf("switchover %s of cluster %s is in progress", other.Name, cluster.Name), nil
+		}
+	}
+
+	if status := cluster.Status.RollingUpdate; status != nil && status.Phase == apiv1alpha1.RollingUpdateSwitching {
+		return fmt.Sprintf("the rolling update of cluster %s is switching the leader", cluster.Name), nil
+	}
+	if status := cluster.Status.TryLeader; status != nil {
+		return fmt.Sprintf("pod %s is trying to be the leader", status.Pod), nil
+	}
+
+	pods := corev1.PodList{}
+	if err := r.List(ctx, &pods, client.InNamespace(cluster.Namespace),
+		client.MatchingLabels(cluster.GetSelectorLabels())); err != nil {
+		return "", err
+	}
+	for _, pod := range pods.Items {
+		if _, ok := pod.Labels[utils.LabelTryLeader]; ok {
+			return fmt.Sprintf("pod %s is trying to be the leader", pod.Name), nil
+		}
+	}
+	return "", nil
+}
+
+// validateTarget checks whether the target can be the leader, and returns the current leader host.
+func (r *MysqlSwitchoverReconciler) validateTarget(ctx context.Context, cluster *mysqlcluster.MysqlCluster,
+	switchover *mysqlswitchover.MysqlSwitchover) (string, error) {
+	pod := &corev1.Pod{}
+	podKey := client.ObjectKey{Name: switchover.Spec.Target, Namespace: switchover.Namespace}
+	if err := r.Get(ctx, podKey, pod); err != nil {
+		return "", fmt.Errorf("failed to get target pod: %s", err)
+	}
+	if !cluster.GetSelectorLabels().AsSelector().Matches(labels.Set(pod.Labels)) {
+		return "", fmt.Errorf("pod %s does not belong to cluster %s", pod.Name, cluster.Name)
+	}
+	if _, ok := pod.Labels["readonly"]; ok {
+		return "", fmt.Errorf("pod %s is a readonly node", pod.Name)
+	}
+
+	var leader string
+	var target *apiv1alpha1.NodeStatus
+	targetHost := getPodHost(cluster, pod.Name)
+	for i := range cluster.Status.Nodes {
+		node := &cluster.Status.Nodes[i]
+		// Skip the readonly nodes.
+		if len(node.Conditions) <= int(apiv1alpha1.IndexReplicating) ||
+			node.Conditions[apiv1alpha1.IndexLeader].Type != apiv1alpha1.NodeConditionLeader {
+			continue
+		}
+		if node.Conditions[apiv1alpha1.IndexLeader].Status == corev1.ConditionTrue {
+			leader = node.Name
+		}
+		if node.Name == targetHost {
+			target = node
+		}
+	}
+	if leader == "" {
+		return "", fmt.Errorf("cluster %s has no leader", cluster.Name)
+	}
+	if target == nil {
+		return "", fmt.Errorf("node %s not found in cluster status", targetHost)
+	}
+	if leader == targetHost {
+		return leader, nil
+	}
+
+	if pod.Labels["healthy"] != "yes" {
+		return "", fmt.Errorf("pod %s is not healthy", pod.Name)
+	}
+	if target.Conditions[apiv1alpha1.IndexLagged].Status != corev1.ConditionFalse {
+		return "", fmt.Errorf("node %s is lagged or its lag is unknown", targetHost)
+	}
+	if target.Conditions[apiv1alpha1.IndexReplicating].Status != corev1.ConditionTrue {
+		return "", fmt.Errorf("node %s is not replicating", targetHost)
+	}
+
+	return leader, nil
+}
+
+// checkGtidCaughtUp returns true if the target has applied all the transactions of the leader.
+func (r *MysqlSwitchoverReconciler) checkGtidCaughtUp(cluster *mysqlcluster.MysqlCluster, leader, target string) (bool, error) {
+	leaderRunner, closeLeader, err := r.SQLRunnerFactory(internal.NewConfigFromClusterKey(
+		r.Client, cluster.GetClusterKey(), utils.OperatorUser, leader))
+	if err != nil {
+		return false, err
+	}
+	defer closeLeader()
+
+	targetRunner, closeTarget, err := r.SQLRunnerFactory(internal.NewConfigFromClusterKey(
+		r.Client, cluster.GetClusterKey(), utils.OperatorUser, target))
+	if err != nil {
+		return false, err
+	}
+	defer closeTarget()
+
+	return internal.CheckGtidCaughtUp(leaderRunner, targetRunner)
+}
+
+// fence labels the leader, so that its readiness check does not make it writable again, then
+// makes it read-only.
+func (r *MysqlSwitchoverReconciler) fence(ctx context.Context, cluster *mysqlcluster.MysqlCluster, name string) error {
+	pod := &corev1.Pod{}
+	if err := r.Get(ctx, client.ObjectKey{Name: name, Namespace: cluster.Namespace}, pod); err != nil {
+		return err
+	}
+	if pod.Labels == nil {
+		pod.Labels = map[string]string{}
+	}
+	if _, ok := pod.Labels[utils.LabelFenced]; !ok {
+		pod.Labels[utils.LabelFenced] = "true"
+		if err := r.Update(ctx, pod); err != nil {
+			return err
+		}
+	}
+
+	sqlRunner, closeConn, err := r.SQLRunnerFactory(internal.NewConfigFromClusterKey(
+		r.Client, cluster.GetClusterKey(), utils.RootUser, getPodHost(cluster, name)))
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+	return sqlRunner.QueryExec(internal.NewQuery("SET GLOBAL super_read_only=on"))
+}
+
+// unfence makes the pod writable again if it is still the leader, and removes its fenced label.
+func (r *MysqlSwitchoverReconciler) unfence(ctx context.Context, cluster *mysqlcluster.MysqlCluster, name string) {
+	host := getPodHost(cluster, name)
+	if raftStatus, err := r.XenonExecutor.RaftStatus(host); err == nil && raftStatus.Role == string(utils.Leader) {
+		sqlRunner, closeConn, err := r.SQLRunnerFactory(internal.NewConfigFromClusterKey(
+			r.Client, cluster.GetClusterKey(), utils.RootUser, host))
+		if err == nil {
+			err = sqlRunner.QueryExec(internal.NewQuery("SET GLOBAL read_only=off"))
+			closeConn()
+		}
+		if err != nil {
+			switchoverLog.Error(err, "failed to make the leader writable", "pod", name)
+		}
+	}
+
+	pod := &corev1.Pod{}
+	if err := r.Get(ctx, client.ObjectKey{Name: name, Namespace: cluster.Namespace}, pod); err != nil {
+		switchoverLog.Error(err, "failed to get the fenced pod", "pod", name)
+		return
+	}
+	if _, ok := pod.Labels[utils.LabelFenced]; !ok {
+		return
+	}
+	delete(pod.Labels, utils.LabelFenced)
+	if err := r.Update(ctx, pod); err != nil {
+		switchoverLog.Error(err, "failed to remove the fenced label", "pod", name)
+	}
+}
+
+func (r *MysqlSwitchoverReconciler) fail(switchover *mysqlswitchover.MysqlSwitchover, reason, msg string) {
+	switchoverLog.Info("switchover failed", "key", switchover.GetKey(), "reason", reason, "message", msg)
+	switchover.UpdatePhase(apiv1alpha1.SwitchoverFailed, reason, msg)
+	r.Recorder.Event(switchover.Unwrap(), corev1.EventTypeWarning, reason, msg)
+}
+
+// getPodHost returns the host of the pod in the headless service.
+func getPodHost(cluster *mysqlcluster.MysqlCluster, name string) string {
+	return fmt.Sprintf("%s.%s.%s", name, cluster.GetNameForResource(utils.HeadlessSVC), cluster.Namespace)
+}
+
+// getPodName returns the pod name of the host, such as sample-mysql-0.sample-mysql.default.
+func getPodName(host string) string {
+	return strings.SplitN(host, ".", 2)[0]
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *MysqlSwitchoverReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&apiv1alpha1.MysqlSwitchover{}).
+		Complete(r)
+}
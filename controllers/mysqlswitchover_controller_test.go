@@ -0,0 +1,261 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+const (
+	switchoverLeader = "sample-mysql-0.sample-mysql.default"
+	switchoverTarget = "sample-mysql-1.sample-mysql.default"
+	switchoverUUID   = "3e11fa47-71ca-11e1-9e33-c80aa9429562"
+)
+
+func newSwitchoverNode(name string, leader bool) apiv1alpha1.NodeStatus {
+	isLeader := corev1.ConditionFalse
+	if leader {
+		isLeader = corev1.ConditionTrue
+	}
+	return apiv1alpha1.NodeStatus{
+		Name: name,
+		Conditions: []apiv1alpha1.NodeCondition{
+			{Type: apiv1alpha1.NodeConditionLagged, Status: corev1.ConditionFalse},
+			{Type: apiv1alpha1.NodeConditionLeader, Status: isLeader},
+			{Type: apiv1alpha1.NodeConditionReadOnly, Status: corev1.ConditionFalse},
+			{Type: apiv1alpha1.NodeConditionReplicating, Status: corev1.ConditionTrue},
+		},
+	}
+}
+
+// switchoverFixture is the reconciler of the switchover with the fake xenon and the executed queries.
+type switchoverFixture struct {
+	*MysqlSwitchoverReconciler
+	xenon *fakeXenonExecutor
+	execs map[string][]string
+}
+
+func newSwitchoverPod(name string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels: map[string]string{
+				"mysql.radondb.com/cluster":    "sample",
+				"app.kubernetes.io/name":       "mysql",
+				"app.kubernetes.io/managed-by": "mysql.radondb.com",
+				"healthy":                      "yes",
+			},
+		},
+	}
+}
+
+func newSwitchoverReconciler(targetGtid string, objs ...client.Object) *switchoverFixture {
+	cluster := &apiv1alpha1.MysqlCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"},
+		Status: apiv1alpha1.MysqlClusterStatus{
+			Nodes: []apiv1alpha1.NodeStatus{
+				newSwitchoverNode(switchoverLeader, true),
+				newSwitchoverNode(switchoverTarget, false),
+			},
+		},
+	}
+	switchover := &apiv1alpha1.MysqlSwitchover{
+		ObjectMeta: metav1.ObjectMeta{Name: "switchover", Namespace: "default"},
+		Spec:       apiv1alpha1.SwitchoverSpec{ClusterName: "sample", Target: "sample-mysql-1", TimeoutSeconds: 60},
+	}
+	objs = append(objs, cluster, newSwitchoverPod("sample-mysql-0"), newSwitchoverPod("sample-mysql-1"),
+		switchover, newFakeClusterSecret("sample", "default"))
+	xenon := &fakeXenonExecutor{roles: map[string]string{switchoverLeader: string(utils.Leader)}}
+	execs := map[string][]string{}
+	return &switchoverFixture{
+		MysqlSwitchoverReconciler: &MysqlSwitchoverReconciler{
+			Client:   newFakeClient(objs...),
+			Recorder: record.NewFakeRecorder(10),
+			SQLRunnerFactory: newRecordingSQLRunnerFactory(map[string]map[string]string{
+				switchoverLeader: {"gtid_executed": switchoverUUID + ":1-10"},
+				switchoverTarget: {"gtid_executed": targetGtid},
+			}, execs),
+			XenonExecutor: xenon,
+		},
+		xenon: xenon,
+		execs: execs,
+	}
+}
+
+func reconcileSwitchover(t *testing.T, f *switchoverFixture) (ctrl.Result, *apiv1alpha1.MysqlSwitchover) {
+	key := client.ObjectKey{Name: "switchover", Namespace: "default"}
+	res, err := f.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key})
+	assert.NoError(t, err)
+	switchover := &apiv1alpha1.MysqlSwitchover{}
+	assert.NoError(t, f.Get(context.TODO(), key, switchover))
+	return res, switchover
+}
+
+// isFenced returns whether the pod is labeled as fenced.
+func isFenced(t *testing.T, f *switchoverFixture, name string) bool {
+	pod := &corev1.Pod{}
+	assert.NoError(t, f.Get(context.TODO(), client.ObjectKey{Name: name, Namespace: "default"}, pod))
+	_, ok := pod.Labels[utils.LabelFenced]
+	return ok
+}
+
+func TestSwitchoverWaitCatchUp(t *testing.T) {
+	f := newSwitchoverReconciler(switchoverUUID + ":1-8")
+
+	res, switchover := reconcileSwitchover(t, f)
+	assert.Equal(t, apiv1alpha1.SwitchoverFencing, switchover.Status.Phase)
+	assert.Equal(t, "sample-mysql-0", switchover.Status.FromLeader)
+	assert.Equal(t, switchoverCheckPeriod, res.RequeueAfter)
+	assert.NotNil(t, switchover.Status.StartTime)
+	assert.NotNil(t, switchover.Status.FenceTime)
+	assert.Empty(t, f.xenon.tryLeaders)
+	// The leader is fenced before the target is checked.
+	assert.True(t, isFenced(t, f, "sample-mysql-0"))
+	assert.Equal(t, []string{"SET GLOBAL super_read_only=on;"}, f.execs[switchoverLeader])
+	fenceTime := switchover.Status.FenceTime
+
+	// The fence time is not reset by the following reconciles.
+	time.Sleep(time.Second)
+	res, switchover = reconcileSwitchover(t, f)
+	assert.Equal(t, apiv1alpha1.SwitchoverFencing, switchover.Status.Phase)
+	assert.Equal(t, switchoverCheckPeriod, res.RequeueAfter)
+	assert.True(t, fenceTime.Equal(switchover.Status.FenceTime))
+	assert.Empty(t, f.xenon.tryLeaders)
+	assert.Len(t, f.execs[switchoverLeader], 1)
+}
+
+func TestSwitchoverCaughtUp(t *testing.T) {
+	f := newSwitchoverReconciler(switchoverUUID + ":1-10")
+
+	res, switchover := reconcileSwitchover(t, f)
+	assert.Equal(t, apiv1alpha1.SwitchoverSwitching, switchover.Status.Phase)
+	assert.Equal(t, switchoverCheckPeriod, res.RequeueAfter)
+	assert.Equal(t, []string{switchoverTarget}, f.xenon.tryLeaders)
+	assert.True(t, isFenced(t, f, "sample-mysql-0"))
+
+	// Keep switching until xenon reports the target as the leader.
+	_, switchover = reconcileSwitchover(t, f)
+	assert.Equal(t, apiv1alpha1.SwitchoverSwitching, switchover.Status.Phase)
+
+	f.xenon.roles[switchoverLeader] = "FOLLOWER"
+	f.xenon.roles[switchoverTarget] = string(utils.Leader)
+	res, switchover = reconcileSwitchover(t, f)
+	assert.Equal(t, apiv1alpha1.SwitchoverSucceeded, switchover.Status.Phase)
+	assert.Equal(t, ctrl.Result{}, res)
+	assert.NotNil(t, switchover.Status.CompletionTime)
+	// The old leader is unfenced but kept read-only as a follower.
+	assert.False(t, isFenced(t, f, "sample-mysql-0"))
+	assert.Equal(t, []string{"SET GLOBAL super_read_only=on;"}, f.execs[switchoverLeader])
+}
+
+func TestSwitchoverCatchUpTimeout(t *testing.T) {
+	f := newSwitchoverReconciler(switchoverUUID + ":1-8")
+
+	_, switchover := reconcileSwitchover(t, f)
+	assert.Equal(t, apiv1alpha1.SwitchoverFencing, switchover.Status.Phase)
+
+	// Pretend the leader was fenced before the catch up timeout.
+	fenceTime := metav1.NewTime(time.Now().Add(-utils.DefaultPromoteCatchUpTimeout - time.Second))
+	switchover.Status.FenceTime = &fenceTime
+	assert.NoError(t, f.Status().Update(context.TODO(), switchover))
+
+	res, switchover := reconcileSwitchover(t, f)
+	assert.Equal(t, apiv1alpha1.SwitchoverFailed, switchover.Status.Phase)
+	assert.Equal(t, "Timeout", switchover.Status.Reason)
+	assert.Equal(t, ctrl.Result{}, res)
+	assert.Empty(t, f.xenon.tryLeaders)
+	// The leader is made writable again.
+	assert.False(t, isFenced(t, f, "sample-mysql-0"))
+	assert.Equal(t, []string{"SET GLOBAL super_read_only=on;", "SET GLOBAL read_only=off;"}, f.execs[switchoverLeader])
+}
+
+func TestSwitchoverValidationFailed(t *testing.T) {
+	f := newSwitchoverReconciler(switchoverUUID + ":1-10")
+	pod := &corev1.Pod{}
+	assert.NoError(t, f.Get(context.TODO(), client.ObjectKey{Name: "sample-mysql-1", Namespace: "default"}, pod))
+	pod.Labels["healthy"] = "no"
+	assert.NoError(t, f.Update(context.TODO(), pod))
+
+	_, switchover := reconcileSwitchover(t, f)
+	assert.Equal(t, apiv1alpha1.SwitchoverFailed, switchover.Status.Phase)
+	assert.Equal(t, "ValidationFailed", switchover.Status.Reason)
+	assert.False(t, isFenced(t, f, "sample-mysql-0"))
+	assert.Empty(t, f.execs[switchoverLeader])
+}
+
+func TestSwitchoverConflict(t *testing.T) {
+	newOther := func(name string, phase apiv1alpha1.SwitchoverPhase) *apiv1alpha1.MysqlSwitchover {
+		return &apiv1alpha1.MysqlSwitchover{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       apiv1alpha1.SwitchoverSpec{ClusterName: "sample", Target: "sample-mysql-0"},
+			Status:     apiv1alpha1.SwitchoverStatus{Phase: phase},
+		}
+	}
+	tryLeader := newSwitchoverPod("sample-mysql-2")
+	tryLeader.Labels[utils.LabelTryLeader] = "true"
+
+	cases := []struct {
+		name     string
+		objs     []client.Object
+		conflict bool
+	}{
+		{"switching", []client.Object{newOther("other", apiv1alpha1.SwitchoverSwitching)}, true},
+		{"earlier pending", []client.Object{newOther("a-other", apiv1alpha1.SwitchoverPending)}, true},
+		{"later pending", []client.Object{newOther("z-other", apiv1alpha1.SwitchoverPending)}, false},
+		{"finished", []client.Object{newOther("other", apiv1alpha1.SwitchoverFailed)}, false},
+		{"tryleader", []client.Object{tryLeader}, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			f := newSwitchoverReconciler(switchoverUUID+":1-10", c.objs...)
+			_, switchover := reconcileSwitchover(t, f)
+			if c.conflict {
+				assert.Equal(t, apiv1alpha1.SwitchoverFailed, switchover.Status.Phase)
+				assert.Equal(t, "Conflict", switchover.Status.Reason)
+				assert.False(t, isFenced(t, f, "sample-mysql-0"))
+				assert.Empty(t, f.xenon.tryLeaders)
+			} else {
+				assert.Equal(t, apiv1alpha1.SwitchoverSwitching, switchover.Status.Phase)
+			}
+		})
+	}
+}
+
+func TestSwitchoverRollingUpdateConflict(t *testing.T) {
+	f := newSwitchoverReconciler(switchoverUUID + ":1-10")
+	cluster := &apiv1alpha1.MysqlCluster{}
+	assert.NoError(t, f.Get(context.TODO(), client.ObjectKey{Name: "sample", Namespace: "default"}, cluster))
+	cluster.Status.RollingUpdate = &apiv1alpha1.RollingUpdateStatus{Phase: apiv1alpha1.RollingUpdateSwitching}
+	assert.NoError(t, f.Status().Update(context.TODO(), cluster))
+
+	_, switchover := reconcileSwitchover(t, f)
+	assert.Equal(t, apiv1alpha1.SwitchoverFailed, switchover.Status.Phase)
+	assert.Equal(t, "Conflict", switchover.Status.Reason)
+}
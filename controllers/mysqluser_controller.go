@@ -24,12 +24,15 @@ import (
 
 	"github.com/go-test/deep"
 	"github.com/presslabs/controller-util/pkg/meta"
+	"github.com/presslabs/controller-util/pkg/rand"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
@@ -54,9 +57,17 @@ var (
 	userFinalizer = "mysqluser-finalizer"
 )
 
+const (
+	// The suffix of the secret key which stages the generated password.
+	stagedPasswordSuffix = "-next"
+	// The suffix of the secret key which keys the HMAC of the password recorded in the status.
+	hmacKeySuffix = "-hmac-key"
+)
+
 //+kubebuilder:rbac:groups=mysql.radondb.com,resources=mysqlusers,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=mysql.radondb.com,resources=mysqlusers/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=mysql.radondb.com,resources=mysqlusers/finalizers,verbs=update
+//+kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -89,6 +100,19 @@ func (r *MysqlUserReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		return ctrl.Result{}, r.removeUser(ctx, user)
 	}
 
+	// Refuse the user if its namespace is not allowed by the cluster.
+	allowed, err := isNamespaceAllowed(ctx, r.Client, user.GetClusterKey(), user.Namespace)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if !allowed {
+		ruErr := r.refuseUser(ctx, user)
+		if err := r.updateStatusAndErr(ctx, user, oldStatus, ruErr); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: 2 * time.Minute}, nil
+	}
+
 	// Write the desired status into mysql cluster.
 	ruErr := r.reconcileUserInCluster(ctx, user)
 	if err := r.updateStatusAndErr(ctx, user, oldStatus, ruErr); err != nil {
@@ -97,9 +121,13 @@ func (r *MysqlUserReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 
 	// Enqueue the resource again after to keep the resource up to date in mysql
 	// in case is changed directly into mysql.
+	requeueAfter := 2 * time.Minute
+	if wait := user.RetainedPasswordRemaining(); wait > 0 && wait < requeueAfter {
+		requeueAfter = wait
+	}
 	return ctrl.Result{
 		Requeue:      true,
-		RequeueAfter: 2 * time.Minute,
+		RequeueAfter: requeueAfter,
 	}, nil
 }
 
@@ -122,12 +150,54 @@ func (r *MysqlUserReconciler) removeUser(ctx context.Context, mysqlUser *mysqlus
 	return nil
 }
 
+// isNamespaceAllowed checks whether the namespace of the MysqlUser, MysqlDatabase or MysqlRole is allowed
+// by the allowedUserNamespaces of the cluster.
+func isNamespaceAllowed(ctx context.Context, c client.Client, clusterKey client.ObjectKey, namespace string) (bool, error) {
+	cluster := &apiv1alpha1.MysqlCluster{}
+	if err := c.Get(ctx, clusterKey, cluster); err != nil {
+		if errors.IsNotFound(err) {
+			// Let the provisioning report the missing cluster.
+			return true, nil
+		}
+		return false, err
+	}
+	ns := &corev1.Namespace{}
+	if err := c.Get(ctx, client.ObjectKey{Name: namespace}, ns); err != nil {
+		return false, err
+	}
+	return cluster.AllowsNamespace(ns)
+}
+
+// refuseUser drops the user which has been created in mysql, and marks the user not ready.
+func (r *MysqlUserReconciler) refuseUser(ctx context.Context, mysqlUser *mysqluser.MysqlUser) error {
+	msg := fmt.Sprintf("The namespace %s is not allowed by the cluster %s.", mysqlUser.Namespace, mysqlUser.GetClusterKey())
+	mysqlUser.UpdateStatusCondition(
+		apiv1alpha1.MySQLUserReady, corev1.ConditionFalse,
+		mysqluser.NamespaceNotAllowedReason, msg,
+	)
+	r.Recorder.Event(mysqlUser.Unwrap(), corev1.EventTypeWarning, mysqluser.NamespaceNotAllowedReason, msg)
+
+	if len(mysqlUser.Status.AllowedHosts) == 0 {
+		return nil
+	}
+	userLog.Info("dropping the user of the namespace not allowed", "key", mysqlUser.GetKey(), "cluster", mysqlUser.GetClusterKey())
+	if err := r.dropUserFromDB(ctx, mysqlUser); err != nil {
+		return err
+	}
+	// The user is created again if the namespace is allowed later.
+	mysqlUser.Status.AllowedHosts = nil
+	mysqlUser.Status.Revision = ""
+	mysqlUser.Status.Grants = nil
+	return nil
+}
+
 // reconcileUserInCluster reconcileUserInCluster creates or updates users in mysql.
 // Proceed as follows:
 // 1. Create users and authorize according to the Spec.
 // 2. Remove the host that does not exist in the spec from MySQL.
-// 3. Make sure mysqluser has finalizer set.
-// 4. Update status and condition.
+// 3. Revoke the privileges that do not exist in the spec.
+// 4. Make sure mysqluser has finalizer set.
+// 5. Update status and condition.
 func (r *MysqlUserReconciler) reconcileUserInCluster(ctx context.Context, mysqlUser *mysqluser.MysqlUser) (err error) {
 	// Catch the error and set the failed status.
 	defer setFailedStatus(&err, mysqlUser)
@@ -160,7 +230,8 @@ func (r *MysqlUserReconciler) reconcileUserInCluster(ctx context.Context, mysqlU
 }
 
 // reconcileUserInDB creates and authorizes(If needed) users based on
-// spec.Hosts, and then deletes users that do not exist in spec.Hosts.
+// spec.Hosts, deletes users that do not exist in spec.Hosts, and revokes
+// the privileges that do not exist in spec.Permissions.
 func (r *MysqlUserReconciler) reconcileUserInDB(ctx context.Context, mysqlUser *mysqluser.MysqlUser) error {
 	sqlRunner, closeConn, err := r.SQLRunnerFactory(internal.NewConfigFromClusterKey(
 		r.Client, mysqlUser.GetClusterKey(), utils.RootUser, utils.LeaderHost))
@@ -169,17 +240,16 @@ func (r *MysqlUserReconciler) reconcileUserInDB(ctx context.Context, mysqlUser *
 	}
 	defer closeConn()
 
-	secret := &corev1.Secret{}
-	secretKey := client.ObjectKey{Name: mysqlUser.Spec.SecretSelector.SecretName, Namespace: mysqlUser.Namespace}
-
-	if err := r.Get(ctx, secretKey, secret); err != nil {
+	password, staged, err := r.getPassword(ctx, mysqlUser)
+	if err != nil {
 		return err
 	}
-
-	password := string(secret.Data[mysqlUser.Spec.SecretSelector.SecretKey])
 	if password == "" {
 		return fmt.Errorf("the MySQL user's password must not be empty")
 	}
+	if err := internal.ValidateUserOptions(sqlRunner, mysqlUser.Unwrap()); err != nil {
+		return err
+	}
 	var exists bool
 	var err2 error
 	if exists, err2 = internal.CheckUserExists(sqlRunner, mysqlUser.Spec.User); err2 != nil {
@@ -188,6 +258,10 @@ func (r *MysqlUserReconciler) reconcileUserInDB(ctx context.Context, mysqlUser *
 	if !exists {
 		mysqlUser.Status.Revision = ""
 	}
+	// Keep the old password valid before the password is changed by the following `ALTER USER`.
+	if err := r.rotatePassword(ctx, sqlRunner, mysqlUser, password, exists); err != nil {
+		return err
+	}
 	// Remove allowed hosts for user.
 	toRemove := utils.StringDiffIn(mysqlUser.Status.AllowedHosts, mysqlUser.Spec.Hosts)
 	for _, host := range toRemove {
@@ -195,6 +269,17 @@ func (r *MysqlUserReconciler) reconcileUserInDB(ctx context.Context, mysqlUser *
 			return err
 		}
 	}
+	// Revoke the privileges removed from the spec, the declared privileges revoked
+	// together(such as `ALL PRIVILEGES`) are granted again by the following sql.
+	if exists {
+		revoked, err := internal.PruneUserGrants(sqlRunner, mysqlUser.Unwrap(), mysqlUser.Spec.Hosts)
+		if err != nil {
+			return err
+		}
+		if revoked {
+			mysqlUser.Status.Revision = ""
+		}
+	}
 	// build user management  sql and calculate hash.
 	SQL, err := internal.BuildUserManagementSQL(mysqlUser.Unwrap(), password)
 	if err != nil {
@@ -202,17 +287,197 @@ func (r *MysqlUserReconciler) reconcileUserInDB(ctx context.Context, mysqlUser *
 	}
 	argsToString := fmt.Sprintf("%v", SQL.Args())
 	SQLhash, err := utils.Hash(SQL.String() + argsToString)
-	if err == nil && SQLhash == mysqlUser.Status.Revision {
-		// If the user has not been changed, then skip the following steps.
+	// If the user has not been changed, then skip creating the user.
+	if err != nil || SQLhash != mysqlUser.Status.Revision {
+		// Create/Update user in database.
+		userLog.Info("creating mysql user", "key", mysqlUser.GetKey(), "username", mysqlUser.Spec.User, "cluster", mysqlUser.GetClusterKey())
+		if err := sqlRunner.QueryExec(SQL); err != nil {
+			return err
+		}
+		mysqlUser.Status.Revision = SQLhash
+	}
+	// Publish the generated password after it's valid in mysql.
+	if staged {
+		if err := r.commitPassword(ctx, mysqlUser); err != nil {
+			return err
+		}
+	}
+
+	// Grant the roles after the user is created.
+	if err := internal.ReconcileUserRoles(sqlRunner, mysqlUser.Unwrap()); err != nil {
+		return err
+	}
+
+	// Record the grants in mysql, so the resource reflects the real privileges of the user.
+	grants, err := internal.GetAppliedGrants(sqlRunner, mysqlUser.Spec.User, mysqlUser.Spec.Hosts)
+	if err != nil {
+		return err
+	}
+	mysqlUser.Status.Grants = grants
+	return nil
+}
+
+// getPassword returns the password in the secret, generates the password into the secret
+// if the user asks the operator to generate it. The generated password is staged in the
+// `<key>-next` of the secret and returned with staged true, it's moved to the key after it's
+// changed in mysql, so an interrupted rotation is resumed with the same password.
+func (r *MysqlUserReconciler) getPassword(ctx context.Context, mysqlUser *mysqluser.MysqlUser) (string, bool, error) {
+	secret := &corev1.Secret{}
+	secretKey := client.ObjectKey{Name: mysqlUser.Spec.SecretSelector.SecretName, Namespace: mysqlUser.Namespace}
+	rotation := mysqlUser.Spec.PasswordRotation
+	generate := rotation != nil && rotation.GeneratePassword
+
+	if err := r.Get(ctx, secretKey, secret); err != nil {
+		if !errors.IsNotFound(err) || !generate {
+			return "", false, err
+		}
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      secretKey.Name,
+				Namespace: secretKey.Namespace,
+			},
+		}
+		if err := controllerutil.SetControllerReference(mysqlUser.Unwrap(), secret, r.Scheme); err != nil {
+			return "", false, err
+		}
+	}
+
+	key := mysqlUser.Spec.SecretSelector.SecretKey
+	password := string(secret.Data[key])
+	if !generate {
+		return password, false, nil
+	}
+	if next := string(secret.Data[key+stagedPasswordSuffix]); next != "" {
+		return next, true, nil
+	}
+	if password != "" && !mysqlUser.RotationDue() {
+		return password, false, nil
+	}
+
+	password, err := rand.AlphaNumericString(int(rotation.PasswordLength))
+	if err != nil {
+		return "", false, err
+	}
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	secret.Data[key+stagedPasswordSuffix] = []byte(password)
+	userLog.Info("generating password into secret", "key", mysqlUser.GetKey(), "secret", secretKey)
+	if secret.ResourceVersion == "" {
+		err = r.Create(ctx, secret)
+	} else {
+		err = r.Update(ctx, secret)
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return password, true, nil
+}
+
+// commitPassword moves the staged password to the key of the secret.
+func (r *MysqlUserReconciler) commitPassword(ctx context.Context, mysqlUser *mysqluser.MysqlUser) error {
+	secret := &corev1.Secret{}
+	secretKey := client.ObjectKey{Name: mysqlUser.Spec.SecretSelector.SecretName, Namespace: mysqlUser.Namespace}
+	if err := r.Get(ctx, secretKey, secret); err != nil {
+		return err
+	}
+	key := mysqlUser.Spec.SecretSelector.SecretKey
+	next, ok := secret.Data[key+stagedPasswordSuffix]
+	if !ok {
+		return nil
+	}
+	secret.Data[key] = next
+	delete(secret.Data, key+stagedPasswordSuffix)
+	userLog.Info("publishing the generated password", "key", mysqlUser.GetKey(), "secret", secretKey)
+	return r.Update(ctx, secret)
+}
+
+// getHMACKey returns the key of the password HMAC in the secret, generates it into the
+// secret if not exists. The key is only readable with the password, so the HMAC in the
+// status does not help to guess the password.
+func (r *MysqlUserReconciler) getHMACKey(ctx context.Context, mysqlUser *mysqluser.MysqlUser) ([]byte, error) {
+	secret := &corev1.Secret{}
+	secretKey := client.ObjectKey{Name: mysqlUser.Spec.SecretSelector.SecretName, Namespace: mysqlUser.Namespace}
+	if err := r.Get(ctx, secretKey, secret); err != nil {
+		return nil, err
+	}
+	key := mysqlUser.Spec.SecretSelector.SecretKey + hmacKeySuffix
+	if hmacKey := secret.Data[key]; len(hmacKey) > 0 {
+		return hmacKey, nil
+	}
+
+	hmacKey, err := rand.AlphaNumericString(32)
+	if err != nil {
+		return nil, err
+	}
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	secret.Data[key] = []byte(hmacKey)
+	userLog.Info("generating the password hmac key into secret", "key", mysqlUser.GetKey(), "secret", secretKey)
+	if err := r.Update(ctx, secret); err != nil {
+		return nil, err
+	}
+	return secret.Data[key], nil
+}
+
+// rotatePassword retains the current password as the secondary one when the password changes,
+// and discards it after the grace period.
+func (r *MysqlUserReconciler) rotatePassword(ctx context.Context, sqlRunner internal.SQLRunner, mysqlUser *mysqluser.MysqlUser, password string, exists bool) error {
+	rotation := mysqlUser.Spec.PasswordRotation
+	if rotation == nil {
+		mysqlUser.Status.PasswordRotation = nil
 		return nil
 	}
-	// Create/Update user in database.
-	userLog.Info("creating mysql user", "key", mysqlUser.GetKey(), "username", mysqlUser.Spec.User, "cluster", mysqlUser.GetClusterKey())
-	if err := sqlRunner.QueryExec(SQL); err != nil {
+	if mysqlUser.Status.PasswordRotation == nil {
+		mysqlUser.Status.PasswordRotation = &apiv1alpha1.PasswordRotationStatus{}
+	}
+	status := mysqlUser.Status.PasswordRotation
+	hmacKey, err := r.getHMACKey(ctx, mysqlUser)
+	if err != nil {
 		return err
 	}
+	passwordHMAC := utils.HMAC(hmacKey, password)
+	now := metav1.Now()
+
+	if status.PasswordHMAC != passwordHMAC {
+		dual := false
+		if exists && status.PasswordHMAC != "" {
+			if dual, err = internal.SupportsDualPassword(sqlRunner); err != nil {
+				return err
+			}
+			if !dual {
+				r.Recorder.Event(mysqlUser.Unwrap(), corev1.EventTypeWarning, "DualPasswordUnsupported",
+					"the MySQL version does not support RETAIN CURRENT PASSWORD, the old password is invalid immediately")
+			}
+		}
+		if dual {
+			userLog.Info("retaining the current password", "key", mysqlUser.GetKey(), "username", mysqlUser.Spec.User)
+			// The new hosts are created with the new password later.
+			newHosts := utils.StringDiffIn(mysqlUser.Spec.Hosts, mysqlUser.Status.AllowedHosts)
+			for _, host := range utils.StringDiffIn(mysqlUser.Spec.Hosts, newHosts) {
+				if err := internal.RetainCurrentPassword(sqlRunner, mysqlUser.Spec.User, host, password); err != nil {
+					return err
+				}
+			}
+			status.Phase = apiv1alpha1.PasswordRotationRetaining
+		} else {
+			status.Phase = apiv1alpha1.PasswordRotationCompleted
+		}
+		status.PasswordHMAC = passwordHMAC
+		status.LastRotationTime = &now
+		return nil
+	}
 
-	mysqlUser.Status.Revision = SQLhash
+	if status.Phase == apiv1alpha1.PasswordRotationRetaining && mysqlUser.RetainedPasswordExpired() {
+		userLog.Info("discarding the old password", "key", mysqlUser.GetKey(), "username", mysqlUser.Spec.User)
+		for _, host := range mysqlUser.Spec.Hosts {
+			if err := internal.DiscardOldPassword(sqlRunner, mysqlUser.Spec.User, host); err != nil {
+				return err
+			}
+		}
+		status.Phase = apiv1alpha1.PasswordRotationCompleted
+	}
 	return nil
 }
 
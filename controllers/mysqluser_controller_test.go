@@ -0,0 +1,114 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/internal"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqluser"
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+func TestGetPasswordStaged(t *testing.T) {
+	user := mysqluser.New(&apiv1alpha1.MysqlUser{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default", UID: "uid"},
+		Spec: apiv1alpha1.UserSpec{
+			SecretSelector:   apiv1alpha1.SecretSelector{SecretName: "app-password", SecretKey: "password"},
+			PasswordRotation: &apiv1alpha1.PasswordRotation{GeneratePassword: true, PasswordLength: 16},
+		},
+	})
+	r := &MysqlUserReconciler{Client: newFakeClient()}
+	r.Scheme = r.Client.Scheme()
+	key := client.ObjectKey{Name: "app-password", Namespace: "default"}
+	secret := &corev1.Secret{}
+
+	// The generated password is staged until it's changed in mysql.
+	password, staged, err := r.getPassword(context.TODO(), user)
+	assert.NoError(t, err)
+	assert.True(t, staged)
+	assert.Len(t, password, 16)
+	assert.NoError(t, r.Get(context.TODO(), key, secret))
+	assert.Empty(t, secret.Data["password"])
+	assert.Equal(t, password, string(secret.Data["password"+stagedPasswordSuffix]))
+
+	// The interrupted rotation is resumed with the same password.
+	resumed, staged, err := r.getPassword(context.TODO(), user)
+	assert.NoError(t, err)
+	assert.True(t, staged)
+	assert.Equal(t, password, resumed)
+
+	assert.NoError(t, r.commitPassword(context.TODO(), user))
+	assert.NoError(t, r.Get(context.TODO(), key, secret))
+	assert.Equal(t, password, string(secret.Data["password"]))
+	assert.NotContains(t, secret.Data, "password"+stagedPasswordSuffix)
+
+	current, staged, err := r.getPassword(context.TODO(), user)
+	assert.NoError(t, err)
+	assert.False(t, staged)
+	assert.Equal(t, password, current)
+}
+
+func TestRotatePasswordHMAC(t *testing.T) {
+	user := mysqluser.New(&apiv1alpha1.MysqlUser{
+		ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		Spec: apiv1alpha1.UserSpec{
+			User:             "app",
+			Hosts:            []string{"%"},
+			SecretSelector:   apiv1alpha1.SecretSelector{SecretName: "app-password", SecretKey: "password"},
+			PasswordRotation: &apiv1alpha1.PasswordRotation{},
+		},
+		Status: apiv1alpha1.UserStatus{AllowedHosts: []string{"%"}},
+	})
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-password", Namespace: "default"},
+		Data:       map[string][]byte{"password": []byte("old")},
+	}
+	execs := map[string][]string{}
+	sqlRunner, _, err := newRecordingSQLRunnerFactory(map[string]map[string]string{"leader": {"version": "8.0.25"}}, execs)(
+		&internal.Config{Host: "leader"})
+	assert.NoError(t, err)
+	r := &MysqlUserReconciler{Client: newFakeClient(secret), Recorder: record.NewFakeRecorder(10)}
+
+	assert.NoError(t, r.rotatePassword(context.TODO(), sqlRunner, user, "old", true))
+	status := user.Status.PasswordRotation
+	assert.Equal(t, apiv1alpha1.PasswordRotationCompleted, status.Phase)
+	// The status does not reveal the password without the key in the secret.
+	assert.NoError(t, r.Get(context.TODO(), client.ObjectKeyFromObject(secret), secret))
+	hmacKey := secret.Data["password"+hmacKeySuffix]
+	assert.Len(t, hmacKey, 32)
+	assert.Equal(t, utils.HMAC(hmacKey, "old"), status.PasswordHMAC)
+	assert.Empty(t, execs["leader"])
+
+	// The unchanged password is not rotated again.
+	assert.NoError(t, r.rotatePassword(context.TODO(), sqlRunner, user, "old", true))
+	assert.Empty(t, execs["leader"])
+
+	// The current password is retained when the password changes.
+	assert.NoError(t, r.rotatePassword(context.TODO(), sqlRunner, user, "new", true))
+	assert.Equal(t, apiv1alpha1.PasswordRotationRetaining, status.Phase)
+	assert.Equal(t, utils.HMAC(hmacKey, "new"), status.PasswordHMAC)
+	assert.Equal(t, []string{"ALTER USER ?@? IDENTIFIED BY ? RETAIN CURRENT PASSWORD;"}, execs["leader"])
+}
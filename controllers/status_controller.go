@@ -95,8 +95,13 @@ func (r *StatusReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 	}()
 
 	r.XenonExecutor.SetRootPassword(instance.Spec.MysqlOpts.RootPassword)
+	tlsConfig, err := internal.NewXenonTLSConfig(r.Client, instance)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	r.XenonExecutor.SetTLSConfig(tlsConfig)
 
-	statusSyncer := clustersyncer.NewStatusSyncer(instance, r.Client, r.SQLRunnerFactory, r.XenonExecutor)
+	statusSyncer := clustersyncer.NewStatusSyncer(instance, r.Client, r.SQLRunnerFactory, r.XenonExecutor, r.Recorder)
 	if err := syncer.Sync(ctx, statusSyncer, r.Recorder); err != nil {
 		return ctrl.Result{}, err
 	}
@@ -118,7 +123,7 @@ func (r *StatusReconciler) SetupWithManager(mgr ctrl.Manager) error {
 					return
 				}
 
-				log.V(1).Info("register cluster in clusters list", "obj", evt.Object)
+				log.V(1).Info("register cluster in clusters list", "obj", clustersyncer.Redact(evt.Object))
 				clusters.Store(getKey(evt.Object), event.GenericEvent(evt))
 			},
 			DeleteFunc: func(evt event.DeleteEvent, _ workqueue.RateLimitingInterface) {
@@ -127,7 +132,7 @@ func (r *StatusReconciler) SetupWithManager(mgr ctrl.Manager) error {
 					return
 				}
 
-				log.V(1).Info("remove cluster from clusters list", "obj", evt.Object)
+				log.V(1).Info("remove cluster from clusters list", "obj", clustersyncer.Redact(evt.Object))
 				clusters.Delete(getKey(evt.Object))
 			},
 		}).
@@ -0,0 +1,260 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+)
+
+const (
+	// errNonexistingGrant is returned by `SHOW GRANTS` if the user does not exist.
+	errNonexistingGrant = 1141
+
+	privilegeAll   = "ALL PRIVILEGES"
+	privilegeUsage = "USAGE"
+)
+
+// grantRegexp matches the privilege grants returned by `SHOW GRANTS`, such as
+// "GRANT SELECT, INSERT ON `db`.* TO `user`@`%` WITH GRANT OPTION".
+// The role grants have no `ON` clause, so they are not matched.
+var grantRegexp = regexp.MustCompile("^GRANT (.+?) ON (.+) TO [`'].*$")
+
+// Grant is a privilege grant of the user on a database or table.
+type Grant struct {
+	Privileges  []string
+	Scope       string
+	GrantOption bool
+}
+
+// ParseGrant parses a line returned by `SHOW GRANTS`, returns false if the line is not a
+// grant on a database or table, such as the role, proxy and routine grants.
+func ParseGrant(line string) (Grant, bool) {
+	matches := grantRegexp.FindStringSubmatch(line)
+	if matches == nil {
+		return Grant{}, false
+	}
+	scope := matches[2]
+	if strings.Contains(scope, "@") || strings.HasPrefix(scope, "PROCEDURE ") || strings.HasPrefix(scope, "FUNCTION ") {
+		return Grant{}, false
+	}
+
+	privileges := []string{}
+	for _, priv := range splitPrivileges(matches[1]) {
+		privileges = append(privileges, normalizePrivilege(priv)...)
+	}
+	return Grant{
+		Privileges:  privileges,
+		Scope:       scope,
+		GrantOption: strings.HasSuffix(line, " WITH GRANT OPTION"),
+	}, true
+}
+
+// splitPrivileges splits the comma separated privileges, ignoring the commas
+// between the parentheses of the column privileges.
+func splitPrivileges(privs string) []string {
+	result := []string{}
+	depth, start := 0, 0
+	for i, c := range privs {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				result = append(result, privs[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(result, privs[start:])
+}
+
+// normalizePrivilege normalizes the privilege declared in the spec or returned by `SHOW GRANTS`,
+// so that they can be compared. The column privilege is split into one privilege per column,
+// such as "update(a, B)" into "UPDATE (`a`)" and "UPDATE (`b`)".
+func normalizePrivilege(priv string) []string {
+	priv = strings.Join(strings.Fields(priv), " ")
+	open := strings.Index(priv, "(")
+	if open < 0 {
+		priv = strings.ToUpper(priv)
+		if priv == "ALL" {
+			return []string{privilegeAll}
+		}
+		return []string{priv}
+	}
+
+	name := strings.ToUpper(strings.TrimSpace(priv[:open]))
+	columns := strings.TrimSuffix(strings.TrimSpace(priv[open+1:]), ")")
+	privileges := []string{}
+	for _, column := range strings.Split(columns, ",") {
+		// The column names are case insensitive.
+		column = strings.ToLower(strings.TrimSpace(column))
+		privileges = append(privileges, fmt.Sprintf("%s (%s)", name, escapeID(column)))
+	}
+	return privileges
+}
+
+// GetUserGrants returns the grants of the user, returns nil if the user does not exist.
+func GetUserGrants(sqlRunner SQLRunner, user, host string) ([]string, error) {
+	grants, err := queryStrings(sqlRunner, NewQuery("SHOW GRANTS FOR ?@?", user, host))
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == errNonexistingGrant {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to show grants, err: %s", err)
+	}
+	return grants, nil
+}
+
+// desiredPrivileges returns the declared privileges of each database or table.
+func desiredPrivileges(permissions []apiv1alpha1.UserPermission) map[string]map[string]bool {
+	desired := map[string]map[string]bool{}
+	for _, perm := range permissions {
+		for _, table := range perm.Tables {
+			scope := fmt.Sprintf("%s.%s", escapeID(perm.Database), escapeID(table))
+			if desired[scope] == nil {
+				desired[scope] = map[string]bool{}
+			}
+			for _, privs := range perm.Privileges {
+				for _, priv := range splitPrivileges(privs) {
+					for _, p := range normalizePrivilege(priv) {
+						desired[scope][p] = true
+					}
+				}
+			}
+		}
+	}
+	return desired
+}
+
+// BuildRevokeQueries returns the queries which revoke the privileges not declared in the
+// permissions from the grants of `user@host`.
+func BuildRevokeQueries(permissions []apiv1alpha1.UserPermission, withGrant bool, user, host string, grants []string) []Query {
+	desired := desiredPrivileges(permissions)
+	queries := []Query{}
+	for _, line := range grants {
+		grant, ok := ParseGrant(line)
+		if !ok {
+			continue
+		}
+		privs, declared := desired[grant.Scope]
+		toRevoke := []string{}
+		for _, priv := range grant.Privileges {
+			// USAGE means no privileges, it can not be revoked.
+			if priv == privilegeUsage || (declared && (privs[priv] || privs[privilegeAll])) {
+				continue
+			}
+			toRevoke = append(toRevoke, priv)
+		}
+		if grant.GrantOption && (!declared || !withGrant) {
+			toRevoke = append(toRevoke, "GRANT OPTION")
+		}
+		if len(toRevoke) == 0 {
+			continue
+		}
+		queries = append(queries, NewQuery(
+			fmt.Sprintf("REVOKE %s ON %s FROM ?@?", strings.Join(toRevoke, ", "), grant.Scope), user, host))
+	}
+	return queries
+}
+
+// BuildGrantQueries returns the queries which grant the privileges declared in the permissions
+// but missing in the grants of `user@host`.
+func BuildGrantQueries(permissions []apiv1alpha1.UserPermission, user, host string, grants []string) []Query {
+	current := map[string]map[string]bool{}
+	for _, line := range grants {
+		grant, ok := ParseGrant(line)
+		if !ok {
+			continue
+		}
+		if current[grant.Scope] == nil {
+			current[grant.Scope] = map[string]bool{}
+		}
+		for _, priv := range grant.Privileges {
+			current[grant.Scope][priv] = true
+		}
+	}
+
+	queries := []Query{}
+	for _, perm := range permissions {
+		for _, table := range perm.Tables {
+			scope := fmt.Sprintf("%s.%s", escapeID(perm.Database), escapeID(table))
+			privs := current[scope]
+			toGrant := []string{}
+			for _, declared := range perm.Privileges {
+				for _, priv := range splitPrivileges(declared) {
+					for _, p := range normalizePrivilege(priv) {
+						if privs[p] || privs[privilegeAll] {
+							continue
+						}
+						toGrant = append(toGrant, Escape(p))
+					}
+				}
+			}
+			if len(toGrant) == 0 {
+				continue
+			}
+			queries = append(queries, NewQuery(
+				fmt.Sprintf("GRANT %s ON %s TO ?@?", strings.Join(toGrant, ", "), scope), user, host))
+		}
+	}
+	return queries
+}
+
+// PruneUserGrants revokes the privileges not declared in the spec of the user in all hosts,
+// returns true if any privilege is revoked.
+func PruneUserGrants(sqlRunner SQLRunner, user *apiv1alpha1.MysqlUser, hosts []string) (bool, error) {
+	revoked := false
+	for _, host := range hosts {
+		grants, err := GetUserGrants(sqlRunner, user.Spec.User, host)
+		if err != nil {
+			return revoked, err
+		}
+		for _, query := range BuildRevokeQueries(user.Spec.Permissions, user.Spec.WithGrantOption, user.Spec.User, host, grants) {
+			internalLog.Info("revoking undeclared privileges", "user", user.Spec.User, "host", host, "query", query.String())
+			if err := sqlRunner.QueryExec(query); err != nil {
+				return revoked, fmt.Errorf("failed to revoke privileges, err: %s", err)
+			}
+			revoked = true
+		}
+	}
+	return revoked, nil
+}
+
+// GetAppliedGrants returns the sorted grants of the user in all hosts.
+func GetAppliedGrants(sqlRunner SQLRunner, user string, hosts []string) ([]string, error) {
+	result := []string{}
+	for _, host := range hosts {
+		grants, err := GetUserGrants(sqlRunner, user, host)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, grants...)
+	}
+	sort.Strings(result)
+	return result, nil
+}
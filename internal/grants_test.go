@@ -0,0 +1,109 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"reflect"
+	"testing"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+)
+
+func TestParseGrant(t *testing.T) {
+	grant, ok := ParseGrant("GRANT SELECT, UPDATE (`a`, `b`) ON `db`.`t` TO `u`@`%` WITH GRANT OPTION")
+	want := Grant{Privileges: []string{"SELECT", "UPDATE (`a`)", "UPDATE (`b`)"}, Scope: "`db`.`t`", GrantOption: true}
+	if !ok || !reflect.DeepEqual(grant, want) {
+		t.Errorf("ParseGrant() = %v, want %v", grant, want)
+	}
+	for _, line := range []string{
+		"GRANT `role`@`%` TO `u`@`%`",
+		"GRANT PROXY ON ''@'' TO 'u'@'%'",
+		"GRANT EXECUTE ON PROCEDURE `db`.`p` TO `u`@`%`",
+	} {
+		if _, ok := ParseGrant(line); ok {
+			t.Errorf("ParseGrant(%q) should be skipped", line)
+		}
+	}
+}
+
+func TestBuildRevokeQueries(t *testing.T) {
+	permissions := []apiv1alpha1.UserPermission{
+		{Database: "db", Tables: []string{"*"}, Privileges: []string{"SELECT,INSERT"}},
+		{Database: "all", Tables: []string{"*"}, Privileges: []string{"ALL"}},
+		{Database: "db", Tables: []string{"t"}, Privileges: []string{"select", "update(A,b)", "INSERT (c)"}},
+	}
+	grants := []string{
+		"GRANT USAGE ON *.* TO 'u'@'%'",
+		"GRANT SELECT, INSERT, DELETE ON `db`.* TO 'u'@'%' WITH GRANT OPTION",
+		"GRANT ALL PRIVILEGES ON `all`.* TO 'u'@'%'",
+		"GRANT SELECT ON `old`.`t` TO 'u'@'%'",
+		"GRANT SELECT, INSERT (`c`), UPDATE (`a`, `b`, `d`) ON `db`.`t` TO 'u'@'%'",
+	}
+	queries := BuildRevokeQueries(permissions, false, "u", "%", grants)
+	want := []string{
+		"REVOKE DELETE, GRANT OPTION ON `db`.* FROM ?@?;",
+		"REVOKE SELECT ON `old`.`t` FROM ?@?;",
+		"REVOKE UPDATE (`d`) ON `db`.`t` FROM ?@?;",
+	}
+	if len(queries) != len(want) {
+		t.Fatalf("BuildRevokeQueries() returns %d queries, want %d", len(queries), len(want))
+	}
+	for i, q := range queries {
+		if q.String() != want[i] {
+			t.Errorf("BuildRevokeQueries()[%d] = %q, want %q", i, q.String(), want[i])
+		}
+	}
+}
+
+func TestBuildGrantQueries(t *testing.T) {
+	permissions := []apiv1alpha1.UserPermission{
+		{Database: "db", Tables: []string{"*"}, Privileges: []string{"SELECT,INSERT"}},
+		{Database: "all", Tables: []string{"*"}, Privileges: []string{"SELECT"}},
+		{Database: "db", Tables: []string{"t"}, Privileges: []string{"update(a, b)"}},
+		{Database: "new", Tables: []string{"*"}, Privileges: []string{"ALL"}},
+	}
+	grants := []string{
+		"GRANT USAGE ON *.* TO `r`@`%`",
+		"GRANT SELECT ON `db`.* TO `r`@`%`",
+		"GRANT ALL PRIVILEGES ON `all`.* TO `r`@`%`",
+		"GRANT UPDATE (`a`) ON `db`.`t` TO `r`@`%`",
+	}
+	queries := BuildGrantQueries(permissions, "r", "%", grants)
+	want := []string{
+		"GRANT INSERT ON `db`.* TO ?@?;",
+		"GRANT UPDATE (`b`) ON `db`.`t` TO ?@?;",
+		"GRANT ALL PRIVILEGES ON `new`.* TO ?@?;",
+	}
+	if len(queries) != len(want) {
+		t.Fatalf("BuildGrantQueries() returns %d queries, want %d", len(queries), len(want))
+	}
+	for i, q := range queries {
+		if q.String() != want[i] {
+			t.Errorf("BuildGrantQueries()[%d] = %q, want %q", i, q.String(), want[i])
+		}
+	}
+}
+
+func TestParseRoleGrant(t *testing.T) {
+	roles, ok := ParseRoleGrant("GRANT `app_read`@`%`,`app_write`@`%`,`other`@`localhost` TO `u`@`%` WITH ADMIN OPTION")
+	if !ok || !reflect.DeepEqual(roles, []string{"app_read", "app_write"}) {
+		t.Errorf("ParseRoleGrant() = %v, %v", roles, ok)
+	}
+	if _, ok := ParseRoleGrant("GRANT SELECT ON `db`.* TO `u`@`%`"); ok {
+		t.Errorf("ParseRoleGrant() should skip the privilege grants")
+	}
+}
@@ -17,9 +17,10 @@ limitations under the License.
 package internal
 
 import (
-	"bufio"
 	"bytes"
 	"fmt"
+	"io"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -27,6 +28,8 @@ import (
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/remotecommand"
+
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
 )
 
 type PodExecutor struct {
@@ -61,6 +64,11 @@ func NewPodExecutor() (*PodExecutor, error) {
 }
 
 func (p *PodExecutor) Exec(namespace, podName, containerName string, command ...string) ([]byte, []byte, error) {
+	return p.ExecWithStdin(namespace, podName, containerName, nil, command...)
+}
+
+// ExecWithStdin runs the command with the stdin, which keeps the secrets out of the command line.
+func (p *PodExecutor) ExecWithStdin(namespace, podName, containerName string, stdin io.Reader, command ...string) ([]byte, []byte, error) {
 	request := p.client.RESTClient().
 		Post().
 		Resource("pods").
@@ -72,7 +80,7 @@ func (p *PodExecutor) Exec(namespace, podName, containerName string, command ...
 			Command:   command,
 			Stdout:    true,
 			Stderr:    true,
-			Stdin:     false,
+			Stdin:     stdin != nil,
 		}, scheme.ParameterCodec)
 
 	exec, err := remotecommand.NewSPDYExecutor(p.config, "POST", request.URL())
@@ -84,9 +92,9 @@ func (p *PodExecutor) Exec(namespace, podName, containerName string, command ...
 	stdErr := bytes.Buffer{}
 
 	err = exec.Stream(remotecommand.StreamOptions{
-		Stdout: bufio.NewWriter(&stdOut),
-		Stderr: bufio.NewWriter(&stdErr),
-		Stdin:  nil,
+		Stdout: &stdOut,
+		Stderr: &stdErr,
+		Stdin:  stdin,
 		Tty:    false,
 	})
 
@@ -105,6 +113,32 @@ func (p *PodExecutor) SetGlobalSysVar(namespace, podName string, query string) e
 	return nil
 }
 
+// CopyTLSFiles copies the certificates from the mounted secret to the ssl directory of mysqld.
+func (p *PodExecutor) CopyTLSFiles(namespace, podName string) error {
+	cmd := []string{"sh", "-c", fmt.Sprintf("cp %s/* %s", utils.TlsSecretMountPath, utils.TlsMountPath)}
+	_, stderr, err := p.Exec(namespace, podName, utils.ContainerMysqlName, cmd...)
+	if err != nil {
+		return err
+	}
+	if len(stderr) != 0 {
+		return fmt.Errorf("run command %s in mysql failed: %s", cmd, stderr)
+	}
+	return nil
+}
+
+// GetLoadedCA returns the CA bundle in the ssl directory of mysqld, which is loaded by mysqld.
+func (p *PodExecutor) GetLoadedCA(namespace, podName string) (string, error) {
+	cmd := []string{"cat", fmt.Sprintf("%s/%s", utils.TlsMountPath, utils.TlsCAFile)}
+	stdout, stderr, err := p.Exec(namespace, podName, utils.ContainerMysqlName, cmd...)
+	if err != nil {
+		return "", err
+	}
+	if len(stderr) != 0 {
+		return "", fmt.Errorf("run command %s in mysql failed: %s", cmd, stderr)
+	}
+	return string(stdout), nil
+}
+
 func (p *PodExecutor) CloseXenonSemiCheck(namespace, podName string) error {
 	cmd := []string{"xenoncli", "raft", "disablechecksemisync"}
 	_, stderr, err := p.Exec(namespace, podName, "xenon", cmd...)
@@ -128,3 +162,67 @@ func (p *PodExecutor) XenonTryLeader(namespace, podName string) error {
 	}
 	return nil
 }
+
+// CheckTablesForUpgrade runs mysqlcheck in the mysql container, and returns the tables
+// which are incompatible with the new version.
+func (p *PodExecutor) CheckTablesForUpgrade(namespace, podName string) ([]string, error) {
+	cmd := []string{"mysqlcheck", "-uroot", "--password=", "--all-databases", "--check-upgrade"}
+	stdout, stderr, err := p.Exec(namespace, podName, "mysql", cmd...)
+	var problems []string
+	var table string
+	// The output is like "db.table    OK", or "db.table" followed by lines like "error    : Table upgrade required".
+	for _, line := range strings.Split(string(stdout), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "error"):
+			msg := line
+			if kv := strings.SplitN(line, ":", 2); len(kv) == 2 {
+				msg = strings.TrimSpace(kv[1])
+			}
+			problems = append(problems, fmt.Sprintf("%s: %s", table, msg))
+		case strings.HasPrefix(line, "warning"), strings.HasPrefix(line, "note"), strings.HasPrefix(line, "status"):
+		default:
+			table = strings.Fields(line)[0]
+		}
+	}
+	if err != nil && len(problems) == 0 {
+		return nil, fmt.Errorf("run command %s in mysql failed: %s, %s", cmd, err, stderr)
+	}
+	return problems, nil
+}
+
+// SetClientPassword updates the password of the operator user in the client.conf of the mysql container.
+func (p *PodExecutor) SetClientPassword(namespace, podName, password string) error {
+	cmd := []string{"/opt/radondb/mysqlchecker", "setPassword"}
+	_, stderr, err := p.ExecWithStdin(namespace, podName, utils.ContainerMysqlName, strings.NewReader(password), cmd...)
+	if err != nil {
+		return fmt.Errorf("run command %s in mysql failed: %s, %s", cmd, err, stderr)
+	}
+	return nil
+}
+
+// SetXenonReplicationPassword updates the replication password in the xenon config, and restarts
+// xenon to load it if restart is true.
+func (p *PodExecutor) SetXenonReplicationPassword(namespace, podName, password string, restart bool) error {
+	cmd := []string{"/xenonchecker", "setReplicationPassword"}
+	if restart {
+		cmd = append(cmd, "restart")
+	}
+	_, stderr, err := p.ExecWithStdin(namespace, podName, utils.ContainerXenonName, strings.NewReader(password), cmd...)
+	if err != nil {
+		return fmt.Errorf("run command %s in xenon failed: %s, %s", cmd, err, stderr)
+	}
+	return nil
+}
+
+// RestartContainer stops the main process of the container, the kubelet restarts the container
+// which reloads the environment variables from the secret.
+func (p *PodExecutor) RestartContainer(namespace, podName, containerName string) error {
+	cmd := []string{"kill", "1"}
+	_, stderr, err := p.Exec(namespace, podName, containerName, cmd...)
+	if err != nil {
+		return fmt.Errorf("run command %s in %s failed: %s, %s", cmd, containerName, err, stderr)
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+// RoleHost is the host of the roles managed by MysqlRole.
+const RoleHost = "%"
+
+var (
+	// roleGrantRegexp matches the role grants returned by `SHOW GRANTS`, such as
+	// "GRANT `app_read`@`%`,`app_write`@`%` TO `user`@`%`".
+	roleGrantRegexp = regexp.MustCompile("^GRANT ([`'].+?) TO [`'].*$")
+	// roleRegexp matches a role in the role grants.
+	roleRegexp = regexp.MustCompile("[`']([^`']+)[`']@[`']([^`']*)[`']")
+)
+
+// ParseRoleGrant parses a line returned by `SHOW GRANTS`, returns the roles managed by
+// MysqlRole if the line is a role grant.
+func ParseRoleGrant(line string) ([]string, bool) {
+	matches := roleGrantRegexp.FindStringSubmatch(line)
+	if matches == nil {
+		return nil, false
+	}
+	roles := []string{}
+	for _, role := range roleRegexp.FindAllStringSubmatch(matches[1], -1) {
+		if role[2] == RoleHost {
+			roles = append(roles, role[1])
+		}
+	}
+	return roles, true
+}
+
+// CheckRoleExists checks whether the account of the role exists.
+func CheckRoleExists(sqlRunner SQLRunner, role string) (bool, error) {
+	var count int
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := sqlRunner.QueryRowContext(ctx, NewQuery(
+		"SELECT COUNT(*) FROM mysql.user WHERE user = ? AND host = ?", role, RoleHost), &count); err != nil {
+		return false, fmt.Errorf("failed to check role, err: %s", err)
+	}
+	return count > 0, nil
+}
+
+// CreateRole creates the role if it does not exist.
+func CreateRole(sqlRunner SQLRunner, role string) error {
+	if err := sqlRunner.QueryExec(NewQuery("CREATE ROLE IF NOT EXISTS ?@?", role, RoleHost)); err != nil {
+		return fmt.Errorf("failed to create role, err: %s", err)
+	}
+	return nil
+}
+
+// DropRole removes the role if it exists, the role is revoked from all the users.
+func DropRole(sqlRunner SQLRunner, role string) error {
+	if err := sqlRunner.QueryExec(NewQuery("DROP ROLE IF EXISTS ?@?", role, RoleHost)); err != nil {
+		return fmt.Errorf("failed to drop role, err: %s", err)
+	}
+	return nil
+}
+
+// GrantRolePermissions grants the permissions missing in the grants of the role, and revokes
+// the privileges not declared in the permissions.
+func GrantRolePermissions(sqlRunner SQLRunner, role string, permissions []apiv1alpha1.UserPermission) error {
+	grants, err := GetUserGrants(sqlRunner, role, RoleHost)
+	if err != nil {
+		return err
+	}
+	queries := BuildRevokeQueries(permissions, false, role, RoleHost, grants)
+	queries = append(queries, BuildGrantQueries(permissions, role, RoleHost, grants)...)
+	for _, query := range queries {
+		if err := sqlRunner.QueryExec(query); err != nil {
+			return fmt.Errorf("failed to grant privileges to role, err: %s", err)
+		}
+	}
+	return nil
+}
+
+// ReconcileUserRoles grants the roles in the spec to the user in all hosts, revokes the
+// roles not in the spec, and sets the default roles.
+func ReconcileUserRoles(sqlRunner SQLRunner, user *apiv1alpha1.MysqlUser) error {
+	for _, host := range user.Spec.Hosts {
+		grants, err := GetUserGrants(sqlRunner, user.Spec.User, host)
+		if err != nil {
+			return err
+		}
+		current := []string{}
+		for _, line := range grants {
+			if roles, ok := ParseRoleGrant(line); ok {
+				current = append(current, roles...)
+			}
+		}
+
+		toGrant := utils.StringDiffIn(user.Spec.Roles, current)
+		toRevoke := utils.StringDiffIn(current, user.Spec.Roles)
+		if len(toGrant) > 0 {
+			ids, args := getRolesIdentification(toGrant)
+			query := NewQuery(fmt.Sprintf("GRANT %s TO ?@?", ids), append(args, user.Spec.User, host)...)
+			if err := sqlRunner.QueryExec(query); err != nil {
+				return fmt.Errorf("failed to grant roles, err: %s", err)
+			}
+		}
+		if len(toRevoke) > 0 {
+			ids, args := getRolesIdentification(toRevoke)
+			query := NewQuery(fmt.Sprintf("REVOKE %s FROM ?@?", ids), append(args, user.Spec.User, host)...)
+			if err := sqlRunner.QueryExec(query); err != nil {
+				return fmt.Errorf("failed to revoke roles, err: %s", err)
+			}
+		}
+
+		// The users without roles are not touched, so it works on MySQL 5.7.
+		if len(user.Spec.Roles) == 0 && len(current) == 0 {
+			continue
+		}
+		defaults, args := "NONE", []interface{}{}
+		if len(user.Spec.DefaultRoles) > 0 {
+			defaults, args = getRolesIdentification(user.Spec.DefaultRoles)
+		}
+		query := NewQuery(fmt.Sprintf("SET DEFAULT ROLE %s TO ?@?", defaults), append(args, user.Spec.User, host)...)
+		if err := sqlRunner.QueryExec(query); err != nil {
+			return fmt.Errorf("failed to set default roles, err: %s", err)
+		}
+	}
+	return nil
+}
+
+func getRolesIdentification(roles []string) (string, []interface{}) {
+	ids := []string{}
+	args := []interface{}{}
+	for _, role := range roles {
+		ids = append(ids, "?@?")
+		args = append(args, role, RoleHost)
+	}
+	return strings.Join(ids, ", "), args
+}
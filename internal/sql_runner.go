@@ -25,6 +25,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/blang/semver"
 	_ "github.com/go-sql-driver/mysql"
 	corev1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -122,15 +123,16 @@ type SQLRunner interface {
 	QueryRowsContext(ctx context.Context, query Query) (*sql.Rows, error)
 }
 
-type closeFunc func()
+// CloseFunc closes the connection of the SQLRunner.
+type CloseFunc func()
 
 // SQLRunnerFactory a function that generates a new SQLRunner.
-type SQLRunnerFactory func(cfg *Config, errs ...error) (SQLRunner, closeFunc, error)
+type SQLRunnerFactory func(cfg *Config, errs ...error) (SQLRunner, CloseFunc, error)
 
 // NewSQLRunner opens a connections using the given DSN.
-func NewSQLRunner(cfg *Config, errs ...error) (SQLRunner, closeFunc, error) {
+func NewSQLRunner(cfg *Config, errs ...error) (SQLRunner, CloseFunc, error) {
 	var db *sql.DB
-	var close closeFunc = nil
+	var close CloseFunc = nil
 
 	// Make this factory accept a functions that tries to generate a config.
 	if len(errs) > 0 && errs[0] != nil {
@@ -320,6 +322,256 @@ func GetGlobalVariable(sqlRunner SQLRunner, param string, val interface{}) error
 	return sqlRunner.QueryRowContext(ctx, NewQuery("select @@global.?", param), val)
 }
 
+// sslTimeLayout is the layout of the time in the ssl status variables, such as "Apr 19 17:14:32 2031 GMT".
+const sslTimeLayout = "Jan _2 15:04:05 2006 MST"
+
+// GetServerCertNotAfter returns the expiry time of the server certificate loaded by mysqld,
+// returns zero time if ssl is not enabled.
+func GetServerCertNotAfter(sqlRunner SQLRunner) (time.Time, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var notAfter string
+	if err := sqlRunner.QueryRowContext(ctx, NewQuery(
+		"SELECT VARIABLE_VALUE FROM performance_schema.global_status WHERE VARIABLE_NAME = 'Ssl_server_not_after'"),
+		&notAfter); err != nil {
+		return time.Time{}, err
+	}
+	if notAfter == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(sslTimeLayout, notAfter)
+}
+
+// ReloadTLS makes mysqld reload the certificates, only supported by mysql 8.0.
+func ReloadTLS(sqlRunner SQLRunner) error {
+	return sqlRunner.QueryExec(NewQuery("ALTER INSTANCE RELOAD TLS"))
+}
+
+// GetMasterSSLAllowed returns whether the replica connects to the master with ssl,
+// returns false if the node is not a replica.
+func GetMasterSSLAllowed(sqlRunner SQLRunner) (isReplica, allowed bool, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	rows, err := sqlRunner.QueryRowsContext(ctx, NewQuery("show slave status;"))
+	if err != nil {
+		return false, false, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return false, false, rows.Err()
+	}
+	cols, err := rows.Columns()
+	if err != nil {
+		return false, false, err
+	}
+	scanArgs := make([]interface{}, len(cols))
+	for i := range scanArgs {
+		scanArgs[i] = &sql.RawBytes{}
+	}
+	if err = rows.Scan(scanArgs...); err != nil {
+		return false, false, err
+	}
+	return true, columnValue(scanArgs, cols, "Master_SSL_Allowed") == "Yes", nil
+}
+
+// GetReplicaIOErrno returns the error number of the replication io thread,
+// returns false if the node is not a replica.
+func GetReplicaIOErrno(sqlRunner SQLRunner) (isReplica bool, errno int, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	rows, err := sqlRunner.QueryRowsContext(ctx, NewQuery("show slave status;"))
+	if err != nil {
+		return false, 0, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return false, 0, rows.Err()
+	}
+	cols, err := rows.Columns()
+	if err != nil {
+		return false, 0, err
+	}
+	scanArgs := make([]interface{}, len(cols))
+	for i := range scanArgs {
+		scanArgs[i] = &sql.RawBytes{}
+	}
+	if err = rows.Scan(scanArgs...); err != nil {
+		return false, 0, err
+	}
+	errno, _ = strconv.Atoi(columnValue(scanArgs, cols, "Last_IO_Errno"))
+	return true, errno, nil
+}
+
+// SetMasterCredentials restarts the replication io thread with the user and password.
+func SetMasterCredentials(sqlRunner SQLRunner, user, pass string) error {
+	return sqlRunner.QueryExec(NewQuery(
+		"STOP SLAVE IO_THREAD; CHANGE MASTER TO MASTER_USER=?, MASTER_PASSWORD=?; START SLAVE IO_THREAD;", user, pass))
+}
+
+// SetMasterSSL restarts the replication io thread with or without ssl, the ca file is used
+// to verify the master when ssl is enabled.
+func SetMasterSSL(sqlRunner SQLRunner, enabled bool, caFile string) error {
+	options := "MASTER_SSL=0"
+	if enabled {
+		options = fmt.Sprintf("MASTER_SSL=1, MASTER_SSL_CA='%s'", caFile)
+	}
+	return sqlRunner.QueryExec(NewQuery(fmt.Sprintf(
+		"STOP SLAVE IO_THREAD; CHANGE MASTER TO %s; START SLAVE IO_THREAD;", options)))
+}
+
+// GetUserSSLType returns the ssl type required by the user, empty means no requirement.
+func GetUserSSLType(sqlRunner SQLRunner, user, host string) (string, error) {
+	var sslType string
+	if err := sqlRunner.QueryRow(NewQuery(
+		"SELECT ssl_type FROM mysql.user WHERE user = ? AND host = ?", user, host), &sslType); err != nil {
+		return "", err
+	}
+	return sslType, nil
+}
+
+// SetUserRequireSSL makes the user required or not to connect with ssl.
+func SetUserRequireSSL(sqlRunner SQLRunner, user, host string, require bool) error {
+	option := "NONE"
+	if require {
+		option = "SSL"
+	}
+	return sqlRunner.QueryExec(NewQuery(fmt.Sprintf("ALTER USER ?@? REQUIRE %s", option), user, host))
+}
+
+// CreateUserWithPrivileges creates the user with the global privileges, it does nothing
+// if the user exists.
+func CreateUserWithPrivileges(sqlRunner SQLRunner, user, host, pass string, privileges []string) error {
+	if err := sqlRunner.QueryExec(NewQuery("CREATE USER IF NOT EXISTS ?@? IDENTIFIED BY ?", user, host, pass)); err != nil {
+		return fmt.Errorf("failed to create user %s, err: %s", user, err)
+	}
+	query := NewQuery(fmt.Sprintf("GRANT %s ON *.* TO ?@?", strings.Join(privileges, ", ")), user, host)
+	if err := sqlRunner.QueryExec(query); err != nil {
+		return fmt.Errorf("failed to grant privileges to %s, err: %s", user, err)
+	}
+	return nil
+}
+
+// GetGlobalVariables returns the values of the existing global variables in names.
+func GetGlobalVariables(sqlRunner SQLRunner, names []string) (map[string]string, error) {
+	vars := map[string]string{}
+	if len(names) == 0 {
+		return vars, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	args := make([]interface{}, len(names))
+	for i, name := range names {
+		args[i] = name
+	}
+	rows, err := sqlRunner.QueryRowsContext(ctx, NewQuery(fmt.Sprintf(
+		"SHOW GLOBAL VARIABLES WHERE Variable_name IN (%s)", placeholders(len(names))), args...))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name, val string
+		if err := rows.Scan(&name, &val); err != nil {
+			return nil, err
+		}
+		vars[strings.ToLower(name)] = val
+	}
+	return vars, rows.Err()
+}
+
+// SetGlobalVariable sets the global variable online, persist it if the mysql supports `SET PERSIST`.
+func SetGlobalVariable(sqlRunner SQLRunner, name string, val interface{}, persist bool) error {
+	if err := checkVariableName(name); err != nil {
+		return err
+	}
+	scope := "GLOBAL"
+	if persist {
+		scope = "PERSIST"
+	}
+	return sqlRunner.QueryExec(NewQuery(fmt.Sprintf("SET %s %s = ?", scope, name), val))
+}
+
+// ResetGlobalVariable sets the global variable to the default value online, and removes it
+// from mysqld-auto.cnf if the mysql supports `SET PERSIST`.
+func ResetGlobalVariable(sqlRunner SQLRunner, name string, persist bool) error {
+	if err := checkVariableName(name); err != nil {
+		return err
+	}
+	if persist {
+		if err := sqlRunner.QueryExec(NewQuery(fmt.Sprintf("RESET PERSIST IF EXISTS %s", name))); err != nil {
+			return err
+		}
+	}
+	return sqlRunner.QueryExec(NewQuery(fmt.Sprintf("SET GLOBAL %s = DEFAULT", name)))
+}
+
+// checkVariableName checks the variable name can be used in the query.
+func checkVariableName(name string) error {
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_') {
+			return fmt.Errorf("invalid variable name: %s", name)
+		}
+	}
+	return nil
+}
+
+// GetGtidExecuted returns the gtid_executed of the node.
+func GetGtidExecuted(sqlRunner SQLRunner) (string, error) {
+	var gtid string
+	if err := GetGlobalVariable(sqlRunner, "gtid_executed", &gtid); err != nil {
+		return "", err
+	}
+	return gtid, nil
+}
+
+// WaitGtidCatchUp waits for the candidate's SQL thread to apply all the transactions
+// executed on the leader, returns error if the candidate can't catch up in timeout.
+func WaitGtidCatchUp(leader, candidate SQLRunner, timeout time.Duration) error {
+	leaderGtid, err := GetGtidExecuted(leader)
+	if err != nil {
+		return fmt.Errorf("failed to get the leader's gtid_executed: %s", err)
+	}
+
+	var timedOut uint8
+	ctx, cancel := context.WithTimeout(context.Background(), timeout+5*time.Second)
+	defer cancel()
+	if err := candidate.QueryRowContext(ctx, NewQuery("select wait_for_executed_gtid_set(?, ?)",
+		leaderGtid, int(timeout.Seconds())), &timedOut); err != nil {
+		return fmt.Errorf("failed to wait for the leader's gtid_executed: %s", err)
+	}
+	if timedOut != 0 {
+		candidateGtid, _ := GetGtidExecuted(candidate)
+		return fmt.Errorf("the candidate has not applied the leader's transactions in %s, leader: %s, candidate: %s",
+			timeout, leaderGtid, candidateGtid)
+	}
+	return nil
+}
+
+// CheckGtidCaughtUp returns true if the candidate has applied all the transactions executed on
+// the leader, it does not wait like WaitGtidCatchUp.
+func CheckGtidCaughtUp(leader, candidate SQLRunner) (bool, error) {
+	leaderGtid, err := GetGtidExecuted(leader)
+	if err != nil {
+		return false, fmt.Errorf("failed to get the leader's gtid_executed: %s", err)
+	}
+	candidateGtid, err := GetGtidExecuted(candidate)
+	if err != nil {
+		return false, fmt.Errorf("failed to get the candidate's gtid_executed: %s", err)
+	}
+	leaderSet, err := utils.ParseGtidSet(leaderGtid)
+	if err != nil {
+		return false, err
+	}
+	candidateSet, err := utils.ParseGtidSet(candidateGtid)
+	if err != nil {
+		return false, err
+	}
+	return leaderSet.IsSubsetOf(candidateSet), nil
+}
+
 // Check user exists or not.
 func CheckUserExists(sqlRunner SQLRunner, userName string) (bool, error) {
 	var rows *sql.Rows
@@ -397,8 +649,8 @@ func BuildUserManagementSQL(user *apiv1alpha1.MysqlUser, pass string) (q Query,
 	}
 
 	queries := []Query{
-		getCreateUserQuery(userName, pass, hosts, user.Spec.TLSOptions),
-		getAlterUserQuery(userName, pass, hosts),
+		getCreateUserQuery(userName, pass, hosts, user.Spec),
+		getAlterUserQuery(userName, pass, hosts, user.Spec),
 	}
 
 	if len(permissions) > 0 {
@@ -410,9 +662,10 @@ func BuildUserManagementSQL(user *apiv1alpha1.MysqlUser, pass string) (q Query,
 	return query, nil
 }
 
-func getCreateUserQuery(user, pwd string, allowedHosts []string, tlsOption apiv1alpha1.TLSOptions) Query {
-	idsTmpl, idsArgs := getUsersIdentification(user, &pwd, allowedHosts)
-	idsTmpl += getUserTLSRequire(tlsOption)
+func getCreateUserQuery(user, pwd string, allowedHosts []string, spec apiv1alpha1.UserSpec) Query {
+	idsTmpl, idsArgs := getUsersIdentification(user, spec.AuthPlugin, &pwd, allowedHosts)
+	idsTmpl += getUserTLSRequire(spec.TLSOptions)
+	idsTmpl += getUserAccountOptions(spec)
 
 	return NewQuery(fmt.Sprintf("CREATE USER IF NOT EXISTS%s", idsTmpl), idsArgs...)
 }
@@ -421,19 +674,48 @@ func getUserTLSRequire(tlsOption apiv1alpha1.TLSOptions) string {
 	return fmt.Sprintf(" REQUIRE %s", tlsOption.Type)
 }
 
-// Only support changing passwords.
-func getAlterUserQuery(user, pwd string, allowedHosts []string) Query {
+// Only support changing passwords, authentication plugin, resource limits, password expiry and account lock.
+func getAlterUserQuery(user, pwd string, allowedHosts []string, spec apiv1alpha1.UserSpec) Query {
 	args := []interface{}{}
 	q := "ALTER USER"
 
-	ids, idsArgs := getUsersIdentification(user, &pwd, allowedHosts)
+	ids, idsArgs := getUsersIdentification(user, spec.AuthPlugin, &pwd, allowedHosts)
 	q += ids
+	q += getUserAccountOptions(spec)
 	args = append(args, idsArgs...)
 
 	return NewQuery(q, args...)
 }
 
-func getUsersIdentification(user string, pwd *string, allowedHosts []string) (ids string, args []interface{}) {
+// getUserAccountOptions returns the resource limits, password expiry and account lock options.
+func getUserAccountOptions(spec apiv1alpha1.UserSpec) string {
+	limits := spec.ResourceLimits
+	options := fmt.Sprintf(" WITH MAX_QUERIES_PER_HOUR %d MAX_UPDATES_PER_HOUR %d MAX_CONNECTIONS_PER_HOUR %d MAX_USER_CONNECTIONS %d",
+		limits.MaxQueriesPerHour, limits.MaxUpdatesPerHour, limits.MaxConnectionsPerHour, limits.MaxUserConnections)
+
+	switch {
+	case spec.PasswordExpireDays == nil:
+		options += " PASSWORD EXPIRE DEFAULT"
+	case *spec.PasswordExpireDays == 0:
+		options += " PASSWORD EXPIRE NEVER"
+	default:
+		options += fmt.Sprintf(" PASSWORD EXPIRE INTERVAL %d DAY", *spec.PasswordExpireDays)
+	}
+
+	if spec.AccountLocked {
+		options += " ACCOUNT LOCK"
+	} else {
+		options += " ACCOUNT UNLOCK"
+	}
+	return options
+}
+
+func getUsersIdentification(user, plugin string, pwd *string, allowedHosts []string) (ids string, args []interface{}) {
+	// The plugin is limited by the enum of the spec.
+	identified := " IDENTIFIED BY ?"
+	if plugin != "" {
+		identified = fmt.Sprintf(" IDENTIFIED WITH %s BY ?", plugin)
+	}
 	for i, host := range allowedHosts {
 		// Add comma if more than one allowed hosts are used.
 		if i > 0 {
@@ -441,7 +723,7 @@ func getUsersIdentification(user string, pwd *string, allowedHosts []string) (id
 		}
 
 		if pwd != nil {
-			ids += " ?@? IDENTIFIED BY ?"
+			ids += " ?@?" + identified
 			args = append(args, user, host, *pwd)
 		} else {
 			ids += " ?@?"
@@ -463,6 +745,122 @@ func DropUser(sqlRunner SQLRunner, user, host string) error {
 	return nil
 }
 
+// GetMysqlVersion returns the version of the mysql server.
+func GetMysqlVersion(sqlRunner SQLRunner) (semver.Version, error) {
+	var version string
+	if err := GetGlobalVariable(sqlRunner, "version", &version); err != nil {
+		return semver.Version{}, err
+	}
+	return semver.ParseTolerant(strings.SplitN(version, "-", 2)[0])
+}
+
+// SupportsDualPassword checks whether the mysql supports `RETAIN CURRENT PASSWORD`, which requires 8.0.14 or later.
+func SupportsDualPassword(sqlRunner SQLRunner) (bool, error) {
+	ver, err := GetMysqlVersion(sqlRunner)
+	if err != nil {
+		return false, err
+	}
+	return ver.GE(semver.MustParse("8.0.14")), nil
+}
+
+// ValidateUserOptions checks whether the mysql server supports the options of the user.
+func ValidateUserOptions(sqlRunner SQLRunner, user *apiv1alpha1.MysqlUser) error {
+	if roles := utils.StringDiffIn(user.Spec.DefaultRoles, user.Spec.Roles); len(roles) > 0 {
+		return fmt.Errorf("the default roles %v are not in the roles", roles)
+	}
+
+	features := []string{}
+	if user.Spec.AuthPlugin == "caching_sha2_password" {
+		features = append(features, "the authentication plugin "+user.Spec.AuthPlugin)
+	}
+	if len(user.Spec.Roles) > 0 {
+		features = append(features, "roles")
+	}
+	if len(features) == 0 {
+		return nil
+	}
+	return RequireMysql80(sqlRunner, strings.Join(features, " and "))
+}
+
+// RequireMysql80 returns an error if the version of the mysql server is lower than 8.0.
+func RequireMysql80(sqlRunner SQLRunner, feature string) error {
+	ver, err := GetMysqlVersion(sqlRunner)
+	if err != nil {
+		return err
+	}
+	if ver.LT(semver.MustParse("8.0.0")) {
+		return fmt.Errorf("%s requires MySQL 8.0, but the version is %s", feature, ver)
+	}
+	return nil
+}
+
+// SetUserPassword changes the password of the user.
+func SetUserPassword(sqlRunner SQLRunner, user, host, pass string) error {
+	query := NewQuery("ALTER USER ?@? IDENTIFIED BY ?", user, host, pass)
+	if err := sqlRunner.QueryExec(query); err != nil {
+		return fmt.Errorf("failed to change password, err: %s", err)
+	}
+	return nil
+}
+
+// RetainCurrentPassword changes the password and keeps the current one as the secondary password.
+func RetainCurrentPassword(sqlRunner SQLRunner, user, host, pass string) error {
+	query := NewQuery("ALTER USER ?@? IDENTIFIED BY ? RETAIN CURRENT PASSWORD", user, host, pass)
+	if err := sqlRunner.QueryExec(query); err != nil {
+		return fmt.Errorf("failed to retain current password, err: %s", err)
+	}
+	return nil
+}
+
+// DiscardOldPassword discards the secondary password of the user.
+func DiscardOldPassword(sqlRunner SQLRunner, user, host string) error {
+	query := NewQuery("ALTER USER ?@? DISCARD OLD PASSWORD", user, host)
+	if err := sqlRunner.QueryExec(query); err != nil {
+		return fmt.Errorf("failed to discard old password, err: %s", err)
+	}
+	return nil
+}
+
+// GetDatabaseCharset returns the character set and collation of the database, returns
+// empty strings if the database does not exist.
+func GetDatabaseCharset(sqlRunner SQLRunner, database string) (charset, collation string, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err = sqlRunner.QueryRowContext(ctx, NewQuery(
+		"SELECT DEFAULT_CHARACTER_SET_NAME, DEFAULT_COLLATION_NAME FROM information_schema.SCHEMATA WHERE SCHEMA_NAME = ?",
+		database), &charset, &collation)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", nil
+	}
+	return charset, collation, err
+}
+
+// CreateOrAlterDatabase creates the database if not exists, otherwise alters its character set and collation.
+func CreateOrAlterDatabase(sqlRunner SQLRunner, database, charset, collation string, exists bool) error {
+	stmt := "CREATE DATABASE IF NOT EXISTS"
+	if exists {
+		stmt = "ALTER DATABASE"
+	}
+	query := fmt.Sprintf("%s %s CHARACTER SET ?", stmt, escapeID(database))
+	args := []interface{}{charset}
+	if collation != "" {
+		query += " COLLATE ?"
+		args = append(args, collation)
+	}
+	if err := sqlRunner.QueryExec(NewQuery(query, args...)); err != nil {
+		return fmt.Errorf("failed to create or alter database, err: %s", err)
+	}
+	return nil
+}
+
+// DropDatabase removes the database if it exists.
+func DropDatabase(sqlRunner SQLRunner, database string) error {
+	if err := sqlRunner.QueryExec(NewQuery(fmt.Sprintf("DROP DATABASE IF EXISTS %s", escapeID(database)))); err != nil {
+		return fmt.Errorf("failed to drop database, err: %s", err)
+	}
+	return nil
+}
+
 func permissionsToQuery(permissions []apiv1alpha1.UserPermission, user string, allowedHosts []string, withGrant bool) Query {
 	permQueries := []Query{}
 
@@ -479,7 +877,7 @@ func permissionsToQuery(permissions []apiv1alpha1.UserPermission, user string, a
 			schemaTable := fmt.Sprintf("%s.%s", escapeID(perm.Database), escapeID(table))
 
 			// Build GRANT query.
-			idsTmpl, idsArgs := getUsersIdentification(user, nil, allowedHosts)
+			idsTmpl, idsArgs := getUsersIdentification(user, "", nil, allowedHosts)
 
 			query := "GRANT " + strings.Join(escPerms, ", ") + " ON " + schemaTable + " TO" + idsTmpl
 			if withGrant {
@@ -0,0 +1,163 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+)
+
+func TestGetAlterUserQuery(t *testing.T) {
+	expireDays := int32(90)
+	spec := apiv1alpha1.UserSpec{
+		ResourceLimits:     apiv1alpha1.UserResourceLimits{MaxUserConnections: 10},
+		AccountLocked:      true,
+		PasswordExpireDays: &expireDays,
+		AuthPlugin:         "caching_sha2_password",
+	}
+	query := getAlterUserQuery("u", "pwd", []string{"%", "localhost"}, spec)
+	want := "ALTER USER ?@? IDENTIFIED WITH caching_sha2_password BY ?, ?@? IDENTIFIED WITH caching_sha2_password BY ?" +
+		" WITH MAX_QUERIES_PER_HOUR 0 MAX_UPDATES_PER_HOUR 0 MAX_CONNECTIONS_PER_HOUR 0 MAX_USER_CONNECTIONS 10" +
+		" PASSWORD EXPIRE INTERVAL 90 DAY ACCOUNT LOCK;"
+	if query.String() != want {
+		t.Errorf("getAlterUserQuery() = %q, want %q", query.String(), want)
+	}
+
+	query = getAlterUserQuery("u", "pwd", []string{"%"}, apiv1alpha1.UserSpec{})
+	want = "ALTER USER ?@? IDENTIFIED BY ?" +
+		" WITH MAX_QUERIES_PER_HOUR 0 MAX_UPDATES_PER_HOUR 0 MAX_CONNECTIONS_PER_HOUR 0 MAX_USER_CONNECTIONS 0" +
+		" PASSWORD EXPIRE DEFAULT ACCOUNT UNLOCK;"
+	if query.String() != want {
+		t.Errorf("getAlterUserQuery() = %q, want %q", query.String(), want)
+	}
+}
+
+// fakeGtidRunner answers the gtid_executed, waits for the gtid set by the result of wait_for_executed_gtid_set,
+// and records the executed queries.
+type fakeGtidRunner struct {
+	gtid string
+	// timedOut is the result of wait_for_executed_gtid_set.
+	timedOut uint8
+	// waited is the gtid set and the timeout waited for.
+	waited []interface{}
+	// execs is the executed queries.
+	execs []string
+}
+
+func (f *fakeGtidRunner) QueryExec(query Query) error {
+	f.execs = append(f.execs, query.String())
+	return nil
+}
+
+func (f *fakeGtidRunner) QueryRow(query Query, dest ...interface{}) error {
+	return f.QueryRowContext(context.TODO(), query, dest...)
+}
+
+func (f *fakeGtidRunner) QueryRowContext(ctx context.Context, query Query, dest ...interface{}) error {
+	switch query.String() {
+	case "select @@global.?;":
+		if f.gtid == "" {
+			return sql.ErrNoRows
+		}
+		*dest[0].(*string) = f.gtid
+	case "select wait_for_executed_gtid_set(?, ?);":
+		f.waited = query.Args()
+		*dest[0].(*uint8) = f.timedOut
+	default:
+		return fmt.Errorf("unexpected query %s", query.String())
+	}
+	return nil
+}
+
+func (f *fakeGtidRunner) QueryRows(query Query) (*sql.Rows, error) {
+	return nil, fmt.Errorf("unexpected query %s", query.String())
+}
+
+func (f *fakeGtidRunner) QueryRowsContext(ctx context.Context, query Query) (*sql.Rows, error) {
+	return nil, fmt.Errorf("unexpected query %s", query.String())
+}
+
+func TestWaitGtidCatchUp(t *testing.T) {
+	leaderGtid := "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-10"
+	leader := &fakeGtidRunner{gtid: leaderGtid}
+
+	candidate := &fakeGtidRunner{gtid: leaderGtid}
+	if err := WaitGtidCatchUp(leader, candidate, 30*time.Second); err != nil {
+		t.Errorf("WaitGtidCatchUp() = %v, want nil", err)
+	}
+	if len(candidate.waited) != 2 || candidate.waited[0] != leaderGtid || candidate.waited[1] != 30 {
+		t.Errorf("WaitGtidCatchUp() waited for %v, want [%s 30]", candidate.waited, leaderGtid)
+	}
+
+	candidate = &fakeGtidRunner{gtid: "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-8", timedOut: 1}
+	if err := WaitGtidCatchUp(leader, candidate, 30*time.Second); err == nil {
+		t.Error("WaitGtidCatchUp() = nil, want the timeout error")
+	}
+
+	if err := WaitGtidCatchUp(&fakeGtidRunner{}, candidate, 30*time.Second); err == nil {
+		t.Error("WaitGtidCatchUp() = nil, want the error of the leader")
+	}
+}
+
+func TestCheckGtidCaughtUp(t *testing.T) {
+	leader := &fakeGtidRunner{gtid: "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-10"}
+	tests := []struct {
+		candidate string
+		want      bool
+		wantErr   bool
+	}{
+		{candidate: "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-10", want: true},
+		{candidate: "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-12,4e11fa47-71ca-11e1-9e33-c80aa9429562:1", want: true},
+		{candidate: "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-8", want: false},
+		{candidate: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := CheckGtidCaughtUp(leader, &fakeGtidRunner{gtid: tt.candidate})
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("CheckGtidCaughtUp(%q) = %v, %v, want %v", tt.candidate, got, err, tt.want)
+		}
+	}
+}
+
+func TestResetGlobalVariable(t *testing.T) {
+	runner := &fakeGtidRunner{}
+	if err := ResetGlobalVariable(runner, "max_connections", true); err != nil {
+		t.Errorf("ResetGlobalVariable() = %v, want nil", err)
+	}
+	want := []string{"RESET PERSIST IF EXISTS max_connections;", "SET GLOBAL max_connections = DEFAULT;"}
+	if !reflect.DeepEqual(runner.execs, want) {
+		t.Errorf("ResetGlobalVariable() executed %v, want %v", runner.execs, want)
+	}
+
+	runner = &fakeGtidRunner{}
+	if err := ResetGlobalVariable(runner, "max_connections", false); err != nil {
+		t.Errorf("ResetGlobalVariable() = %v, want nil", err)
+	}
+	if want := []string{"SET GLOBAL max_connections = DEFAULT;"}; !reflect.DeepEqual(runner.execs, want) {
+		t.Errorf("ResetGlobalVariable() executed %v, want %v", runner.execs, want)
+	}
+
+	if err := ResetGlobalVariable(runner, "max_connections; drop", false); err == nil {
+		t.Error("ResetGlobalVariable() = nil, want the error of the invalid name")
+	}
+}
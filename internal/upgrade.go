@@ -0,0 +1,150 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package internal
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// mysql80ReservedWords are the keywords which become reserved in MySQL 8.0.
+var mysql80ReservedWords = []string{
+	"CUBE", "CUME_DIST", "DENSE_RANK", "EMPTY", "EXCEPT", "FIRST_VALUE", "FUNCTION",
+	"GROUPING", "GROUPS", "JSON_TABLE", "LAG", "LAST_VALUE", "LATERAL", "LEAD",
+	"NTH_VALUE", "NTILE", "OF", "OVER", "PERCENT_RANK", "RANK", "RECURSIVE",
+	"ROW", "ROWS", "ROW_NUMBER", "SYSTEM", "WINDOW",
+}
+
+// mysql80DictionaryTables are the data dictionary tables of MySQL 8.0, the tables
+// with the same names in the mysql schema must be removed before upgrade.
+var mysql80DictionaryTables = []string{
+	"catalogs", "character_sets", "check_constraints", "collations", "column_statistics",
+	"column_type_elements", "columns", "dd_properties", "events", "foreign_key_column_usage",
+	"foreign_keys", "index_column_usage", "index_partitions", "index_stats", "indexes",
+	"parameter_type_elements", "parameters", "resource_groups", "routines", "schemata",
+	"st_spatial_reference_systems", "table_partition_values", "table_partitions", "table_stats",
+	"tables", "tablespace_files", "tablespaces", "triggers", "view_routine_usage", "view_table_usage",
+}
+
+// mysql80RemovedFunctions matches the functions removed in MySQL 8.0.
+const mysql80RemovedFunctions = "[[:<:]](encode|decode|encrypt|des_encrypt|des_decrypt|password)[[:space:]]*[(]"
+
+// CheckUpgradeToMySQL80 checks whether the data can be upgraded from 5.7 to 8.0.
+// The problems block the upgrade, the warnings are only for the users to review.
+func CheckUpgradeToMySQL80(sqlRunner SQLRunner) (problems, warnings []string, err error) {
+	dictArgs := make([]interface{}, len(mysql80DictionaryTables))
+	for i, table := range mysql80DictionaryTables {
+		dictArgs[i] = table
+	}
+	tables, err := queryStrings(sqlRunner, NewQuery(fmt.Sprintf(
+		"SELECT TABLE_NAME FROM information_schema.TABLES WHERE TABLE_SCHEMA = 'mysql' AND LOWER(TABLE_NAME) IN (%s)",
+		placeholders(len(dictArgs))), dictArgs...))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(tables) > 0 {
+		problems = append(problems, fmt.Sprintf("tables conflict with the 8.0 data dictionary: mysql.%s",
+			strings.Join(tables, ", mysql.")))
+	}
+
+	tables, err = queryStrings(sqlRunner, NewQuery(
+		"SELECT CONCAT(TABLE_SCHEMA, '.', TABLE_NAME) FROM information_schema.TABLES "+
+			"WHERE CREATE_OPTIONS LIKE '%partitioned%' AND ENGINE NOT IN ('InnoDB', 'ndbcluster')"))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(tables) > 0 {
+		problems = append(problems, fmt.Sprintf("partitioned tables use a storage engine without native partitioning: %s",
+			strings.Join(tables, ", ")))
+	}
+
+	tables, err = queryStrings(sqlRunner, NewQuery(
+		"SELECT CONCAT(TABLE_SCHEMA, '.', TABLE_NAME, '.', CONSTRAINT_NAME) FROM information_schema.TABLE_CONSTRAINTS "+
+			"WHERE CONSTRAINT_TYPE = 'FOREIGN KEY' AND CHAR_LENGTH(CONSTRAINT_NAME) > 64"))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(tables) > 0 {
+		problems = append(problems, fmt.Sprintf("foreign key names are longer than 64 characters: %s",
+			strings.Join(tables, ", ")))
+	}
+
+	objects, err := queryStrings(sqlRunner, NewQuery(
+		"SELECT CONCAT(TABLE_SCHEMA, '.', TABLE_NAME) FROM information_schema.VIEWS WHERE VIEW_DEFINITION REGEXP ? "+
+			"UNION ALL SELECT CONCAT(ROUTINE_SCHEMA, '.', ROUTINE_NAME) FROM information_schema.ROUTINES WHERE ROUTINE_DEFINITION REGEXP ? "+
+			"UNION ALL SELECT CONCAT(TRIGGER_SCHEMA, '.', TRIGGER_NAME) FROM information_schema.TRIGGERS WHERE ACTION_STATEMENT REGEXP ?",
+		mysql80RemovedFunctions, mysql80RemovedFunctions, mysql80RemovedFunctions))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(objects) > 0 {
+		problems = append(problems, fmt.Sprintf("views, routines or triggers use the functions removed in 8.0: %s",
+			strings.Join(objects, ", ")))
+	}
+
+	wordArgs := make([]interface{}, 0, 3*len(mysql80ReservedWords))
+	for i := 0; i < 3; i++ {
+		for _, word := range mysql80ReservedWords {
+			wordArgs = append(wordArgs, word)
+		}
+	}
+	in := placeholders(len(mysql80ReservedWords))
+	objects, err = queryStrings(sqlRunner, NewQuery(fmt.Sprintf(
+		"SELECT SCHEMA_NAME FROM information_schema.SCHEMATA WHERE UPPER(SCHEMA_NAME) IN (%s) "+
+			"UNION ALL SELECT CONCAT(TABLE_SCHEMA, '.', TABLE_NAME) FROM information_schema.TABLES "+
+			"WHERE TABLE_SCHEMA NOT IN ('mysql', 'sys', 'information_schema', 'performance_schema') AND UPPER(TABLE_NAME) IN (%s) "+
+			"UNION ALL SELECT CONCAT(TABLE_SCHEMA, '.', TABLE_NAME, '.', COLUMN_NAME) FROM information_schema.COLUMNS "+
+			"WHERE TABLE_SCHEMA NOT IN ('mysql', 'sys', 'information_schema', 'performance_schema') AND UPPER(COLUMN_NAME) IN (%s)",
+		in, in, in), wordArgs...))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(objects) > 0 {
+		warnings = append(warnings, fmt.Sprintf("identifiers are reserved words in 8.0 and must be quoted: %s",
+			strings.Join(objects, ", ")))
+	}
+
+	return problems, warnings, nil
+}
+
+// queryStrings runs the query and returns the first column of all the rows.
+func queryStrings(sqlRunner SQLRunner, query Query) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	rows, err := sqlRunner.QueryRowsContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []string
+	for rows.Next() {
+		var val string
+		if err := rows.Scan(&val); err != nil {
+			return nil, err
+		}
+		result = append(result, val)
+	}
+	return result, rows.Err()
+}
+
+// placeholders returns n comma separated placeholders.
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
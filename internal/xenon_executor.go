@@ -17,21 +17,31 @@ limitations under the License.
 package internal
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"net/http"
 
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
 	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlcluster"
 	"github.com/radondb/radondb-mysql-kubernetes/utils"
 )
 
 type xenonExecutor struct {
 	httpExecutor
 	rootPassword string
+	// tlsExecutor requests the xenon proxy with mTLS, nil if the cluster does not encrypt in transit.
+	tlsExecutor *httpExecutor
 }
 
 type XenonExecutor interface {
 	GetRootPassword() string
 	SetRootPassword(rootPassword string)
+	SetTLSConfig(config *tls.Config)
 	RaftStatus(host string) (*apiv1alpha1.RaftStatus, error)
 	XenonPing(host string) error
 	RaftTryToLeader(host string) error
@@ -51,14 +61,65 @@ func (executor *xenonExecutor) SetRootPassword(rootPassword string) {
 	executor.rootPassword = rootPassword
 }
 
+// SetTLSConfig sets the client certificates to request xenon with mTLS, nil means plain http.
+func (executor *xenonExecutor) SetTLSConfig(config *tls.Config) {
+	if config == nil {
+		executor.tlsExecutor = nil
+		return
+	}
+	executor.tlsExecutor = &httpExecutor{Client: NewHttpClient(&http.Client{
+		Transport: &http.Transport{TLSClientConfig: config, DisableKeepAlives: true},
+	})}
+}
+
+func (executor *xenonExecutor) newRequest(host string, xenonHttpUrl utils.XenonHttpUrl, data interface{}) (*Request, error) {
+	return NewXenonHttpRequest(NewRequestConfig(host, executor.rootPassword, xenonHttpUrl, data).
+		WithTLS(executor.tlsExecutor != nil))
+}
+
+// execute requests xenon with mTLS if the cluster encrypts in transit. It never falls back to plain
+// http, the xenon apis of the pods not restarted yet after enabling encryptInTransit are unreachable
+// until the rolling update restarts them.
+func (executor *xenonExecutor) execute(req *Request) (*Response, error) {
+	if executor.tlsExecutor == nil {
+		return executor.httpExecutor.Execute(req)
+	}
+	return executor.tlsExecutor.Execute(req)
+}
+
+// NewXenonTLSConfig returns the tls config to request xenon with the certificates of the cluster,
+// returns nil if the cluster does not encrypt in transit.
+func NewXenonTLSConfig(cli client.Client, c *mysqlcluster.MysqlCluster) (*tls.Config, error) {
+	if !c.EncryptInTransit() {
+		return nil, nil
+	}
+	secret := &corev1.Secret{}
+	if err := cli.Get(context.TODO(), client.ObjectKey{Name: c.GetTLSSecretName(), Namespace: c.Namespace}, secret); err != nil {
+		return nil, err
+	}
+	cert, err := tls.X509KeyPair(secret.Data[utils.TlsCertFile], secret.Data[utils.TlsKeyFile])
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the certificate in secret %s: %s", secret.Name, err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(secret.Data[utils.TlsCAFile]) {
+		return nil, fmt.Errorf("failed to parse the CA in secret %s", secret.Name)
+	}
+	return &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+	}, nil
+}
+
 // RaftStatus gets the raft status of incoming host through http.
 func (executor *xenonExecutor) RaftStatus(host string) (*apiv1alpha1.RaftStatus, error) {
-	req, err := NewXenonHttpRequest(NewRequestConfig(host, executor.rootPassword, utils.RaftStatus, nil))
+	req, err := executor.newRequest(host, utils.RaftStatus, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	response, err := executor.httpExecutor.Execute(req)
+	response, err := executor.execute(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get raft status, err: %s", err)
 	}
@@ -78,12 +139,12 @@ func (executor *xenonExecutor) RaftStatus(host string) (*apiv1alpha1.RaftStatus,
 
 // RaftTryToLeader try setting up incoming host to the leader node.
 func (executor *xenonExecutor) RaftTryToLeader(host string) error {
-	req, err := NewXenonHttpRequest(NewRequestConfig(host, executor.rootPassword, utils.RaftTryToLeader, nil))
+	req, err := executor.newRequest(host, utils.RaftTryToLeader, nil)
 	if err != nil {
 		return err
 	}
 
-	_, err = executor.httpExecutor.Execute(req)
+	_, err = executor.execute(req)
 	if err != nil {
 		return fmt.Errorf("failed to execute raft/trytoleader at host[%s], err: %s", req.Req.URL, err)
 	}
@@ -91,11 +152,11 @@ func (executor *xenonExecutor) RaftTryToLeader(host string) error {
 }
 
 func (executor *xenonExecutor) XenonPing(host string) error {
-	req, err := NewXenonHttpRequest(NewRequestConfig(host, executor.GetRootPassword(), utils.XenonPing, nil))
+	req, err := executor.newRequest(host, utils.XenonPing, nil)
 	if err != nil {
 		return err
 	}
-	_, err = executor.httpExecutor.Execute(req)
+	_, err = executor.execute(req)
 	if err != nil {
 		return fmt.Errorf("failed to ping host[%s], err: %s", req.Req.URL, err)
 	}
@@ -104,11 +165,11 @@ func (executor *xenonExecutor) XenonPing(host string) error {
 
 func (executor *xenonExecutor) ClusterAdd(host string, toAdd string) error {
 	addHost := fmt.Sprintf("{\"address\": \"%s\"}", toAdd)
-	req, err := NewXenonHttpRequest(NewRequestConfig(host, executor.GetRootPassword(), utils.ClusterAdd, addHost))
+	req, err := executor.newRequest(host, utils.ClusterAdd, addHost)
 	if err != nil {
 		return err
 	}
-	_, err = executor.httpExecutor.Execute(req)
+	_, err = executor.execute(req)
 	if err != nil {
 		return fmt.Errorf("failed to add host[%s] to host[%s], err: %s", addHost, req.Req.URL, err)
 	}
@@ -117,11 +178,11 @@ func (executor *xenonExecutor) ClusterAdd(host string, toAdd string) error {
 
 func (executor *xenonExecutor) ClusterRemove(host string, toRemove string) error {
 	removeHost := fmt.Sprintf("{\"address\": \"%s\"}", toRemove)
-	req, err := NewXenonHttpRequest(NewRequestConfig(host, executor.GetRootPassword(), utils.ClusterRemove, removeHost))
+	req, err := executor.newRequest(host, utils.ClusterRemove, removeHost)
 	if err != nil {
 		return err
 	}
-	_, err = executor.httpExecutor.Execute(req)
+	_, err = executor.execute(req)
 	if err != nil {
 		return fmt.Errorf("failed to remove host[%s] from host[%s], err: %s", removeHost, req.Req.URL, err)
 	}
@@ -31,6 +31,8 @@ type RequestConfig struct {
 	host         string
 	xenonHttpUrl utils.XenonHttpUrl
 	data         interface{}
+	// Request the xenon proxy with mTLS instead of xenon itself.
+	tls bool
 }
 
 func NewRequestConfig(host, rootPasswd string, xenonHttpUrl utils.XenonHttpUrl, data interface{}) RequestConfig {
@@ -42,6 +44,12 @@ func NewRequestConfig(host, rootPasswd string, xenonHttpUrl utils.XenonHttpUrl,
 	}
 }
 
+// WithTLS returns the configuration to request the xenon proxy with mTLS if tls is set.
+func (cfg RequestConfig) WithTLS(tls bool) RequestConfig {
+	cfg.tls = tls
+	return cfg
+}
+
 // NewXenonHttpRequest returns the http request of the corresponding type according to the incoming configuration.
 func NewXenonHttpRequest(cfg RequestConfig) (*Request, error) {
 	requestType, ok := utils.XenonHttpUrls[cfg.xenonHttpUrl]
@@ -60,7 +68,7 @@ func NewXenonHttpRequest(cfg RequestConfig) (*Request, error) {
 }
 
 func newHttpGetRequest(cfg RequestConfig) (*Request, error) {
-	req, err := http.NewRequest(http.MethodGet, newXenonRequestUrl(cfg.host, cfg.xenonHttpUrl), nil)
+	req, err := http.NewRequest(http.MethodGet, newXenonRequestUrl(cfg.host, cfg.xenonHttpUrl, cfg.tls), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -76,7 +84,7 @@ func newHttpPostRequest(cfg RequestConfig) (*Request, error) {
 		reqBody = cfg.data.(string)
 	}
 
-	req, err := http.NewRequest(http.MethodPost, newXenonRequestUrl(cfg.host, cfg.xenonHttpUrl), bytes.NewBuffer([]byte(reqBody)))
+	req, err := http.NewRequest(http.MethodPost, newXenonRequestUrl(cfg.host, cfg.xenonHttpUrl, cfg.tls), bytes.NewBuffer([]byte(reqBody)))
 	if err != nil {
 		return nil, err
 	}
@@ -87,6 +95,9 @@ func newHttpPostRequest(cfg RequestConfig) (*Request, error) {
 	return &Request{Req: req}, nil
 }
 
-func newXenonRequestUrl(host string, xenonHttpUrl utils.XenonHttpUrl) string {
+func newXenonRequestUrl(host string, xenonHttpUrl utils.XenonHttpUrl, tls bool) string {
+	if tls {
+		return fmt.Sprintf("https://%s:%d%s", host, utils.XenonTLSPort, xenonHttpUrl)
+	}
 	return fmt.Sprintf("http://%s:%d%s", host, utils.XenonPeerPort, xenonHttpUrl)
 }
@@ -61,14 +61,17 @@ func (c *backupSidecar) getEnvVars() []corev1.EnvVar {
 			Name:  "SERVICE_NAME",
 			Value: c.GetNameForResource(utils.HeadlessSVC),
 		},
-		{
-			Name:  "MYSQL_ROOT_PASSWORD",
-			Value: c.Spec.MysqlOpts.RootPassword,
-		},
+		getEnvVarFromSecret(sctName, "MYSQL_ROOT_PASSWORD", "root-password", false),
 		// backup user password for sidecar http server
 		getEnvVarFromSecret(sctName, "BACKUP_USER", "backup-user", true),
 		getEnvVarFromSecret(sctName, "BACKUP_PASSWORD", "backup-password", true),
 	}
+	if c.EncryptInTransit() {
+		envs = append(envs, corev1.EnvVar{
+			Name:  "ENCRYPT_IN_TRANSIT",
+			Value: "true",
+		})
+	}
 	if len(sctNameBakup) != 0 {
 		envs = append(envs,
 			getEnvVarFromSecret(sctNameBakup, "S3_ENDPOINT", "s3-endpoint", false),
@@ -89,20 +92,28 @@ func (c *backupSidecar) getResources() corev1.ResourceRequirements {
 }
 
 func (c *backupSidecar) getPorts() []corev1.ContainerPort {
-	return []corev1.ContainerPort{
+	ports := []corev1.ContainerPort{
 		{
 			Name:          utils.XBackupPortName,
 			ContainerPort: utils.XBackupPort,
 		},
 	}
+	if c.EncryptInTransit() {
+		ports = append(ports, corev1.ContainerPort{
+			Name:          utils.XenonTLSPortName,
+			ContainerPort: utils.XenonTLSPort,
+		})
+	}
+	return ports
 }
 
 func (c *backupSidecar) getLivenessProbe() *corev1.Probe {
 	return &corev1.Probe{
 		ProbeHandler: corev1.ProbeHandler{
 			HTTPGet: &corev1.HTTPGetAction{
-				Path: "/health",
-				Port: intstr.FromInt(utils.XBackupPort),
+				Path:   "/health",
+				Port:   intstr.FromInt(utils.XBackupPort),
+				Scheme: c.getProbeScheme(),
 			},
 		},
 		InitialDelaySeconds: 15,
@@ -117,8 +128,9 @@ func (c *backupSidecar) getReadinessProbe() *corev1.Probe {
 	return &corev1.Probe{
 		ProbeHandler: corev1.ProbeHandler{
 			HTTPGet: &corev1.HTTPGetAction{
-				Path: "/health",
-				Port: intstr.FromInt(utils.XBackupPort),
+				Path:   "/health",
+				Port:   intstr.FromInt(utils.XBackupPort),
+				Scheme: c.getProbeScheme(),
 			},
 		},
 		InitialDelaySeconds: 5,
@@ -129,8 +141,16 @@ func (c *backupSidecar) getReadinessProbe() *corev1.Probe {
 	}
 }
 
+// getProbeScheme returns the scheme of the backup http server.
+func (c *backupSidecar) getProbeScheme() corev1.URIScheme {
+	if c.EncryptInTransit() {
+		return corev1.URISchemeHTTPS
+	}
+	return corev1.URISchemeHTTP
+}
+
 func (c *backupSidecar) getVolumeMounts() []corev1.VolumeMount {
-	return []corev1.VolumeMount{
+	volumeMounts := []corev1.VolumeMount{
 		{
 			Name:      utils.MysqlConfVolumeName,
 			MountPath: utils.MysqlConfVolumeMountPath,
@@ -148,4 +168,13 @@ func (c *backupSidecar) getVolumeMounts() []corev1.VolumeMount {
 			MountPath: utils.SysLocalTimeZoneMountPath,
 		},
 	}
+	// The certificates of the https server, the renewed ones are loaded without restart.
+	if c.EncryptInTransit() {
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      utils.TlsVolumeName + "-sidecar",
+			MountPath: utils.TlsSecretMountPath,
+			ReadOnly:  true,
+		})
+	}
+	return volumeMounts
 }
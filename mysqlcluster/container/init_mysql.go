@@ -48,7 +48,8 @@ func (c *initMysql) getCommand() []string {
 	pluginscript := "if test -f /docker-entrypoint-initdb.d/plugin.sh; then /docker-entrypoint-initdb.d/plugin.sh; fi ;"
 	clonescript := "if test -f " + utils.RadonDBBinDir + "/clone.sh;" + " then " + utils.RadonDBBinDir + "/clone.sh;fi;"
 	updgradescript := "if test -f " + utils.RadonDBBinDir + "/upgrade.sh; then " + utils.RadonDBBinDir + "/upgrade.sh;fi;"
-	restorescript := "if test -f " + utils.RadonDBBinDir + "/restore.sh; then " + utils.RadonDBBinDir + "/restore.sh; fi ;"
+	// The init-sidecar writes the plan to apply the binlogs for the point-in-time restore.
+	restorescript := "if test -f " + utils.RadonDBBinDir + "/restore.json; then CONTAINER_TYPE=" + utils.ContainerInitMysqlName + " " + utils.RadonDBBinDir + "/sidecar apply_binlogs " + utils.RadonDBBinDir + "/restore.json; fi ;"
 	return []string{"bash", "-c", "/docker-entrypoint.sh mysqld;" + pluginscript + clonescript + updgradescript + restorescript}
 }
 
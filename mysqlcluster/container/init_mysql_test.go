@@ -117,7 +117,7 @@ func TestGetInitMysqlImage(t *testing.T) {
 }
 
 func TestGetInitMysqlCommand(t *testing.T) {
-	assert.Equal(t, initMysqlCase.Command, []string{"bash", "-c", "/docker-entrypoint.sh mysqld;if test -f /docker-entrypoint-initdb.d/plugin.sh; then /docker-entrypoint-initdb.d/plugin.sh; fi ;if test -f /opt/radondb/clone.sh; then /opt/radondb/clone.sh;fi;if test -f /opt/radondb/upgrade.sh; then /opt/radondb/upgrade.sh;fi;if test -f /opt/radondb/restore.sh; then /opt/radondb/restore.sh; fi ;"})
+	assert.Equal(t, initMysqlCase.Command, []string{"bash", "-c", "/docker-entrypoint.sh mysqld;if test -f /docker-entrypoint-initdb.d/plugin.sh; then /docker-entrypoint-initdb.d/plugin.sh; fi ;if test -f /opt/radondb/clone.sh; then /opt/radondb/clone.sh;fi;if test -f /opt/radondb/upgrade.sh; then /opt/radondb/upgrade.sh;fi;if test -f /opt/radondb/restore.json; then CONTAINER_TYPE=init-mysql /opt/radondb/sidecar apply_binlogs /opt/radondb/restore.json; fi ;"})
 }
 
 func TestGetInitMysqlEnvVar(t *testing.T) {
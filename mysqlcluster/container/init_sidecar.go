@@ -21,6 +21,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
 	"github.com/radondb/radondb-mysql-kubernetes/mysqlcluster"
 	"github.com/radondb/radondb-mysql-kubernetes/utils"
 )
@@ -86,6 +87,10 @@ func (c *initSidecar) getEnvVars() []corev1.EnvVar {
 			Name:  "ELECTION_TIMEOUT",
 			Value: strconv.Itoa(int(*c.Spec.XenonOpts.ElectionTimeout)),
 		},
+		{
+			Name:  "REPLICATION_MODE",
+			Value: c.Spec.ReplicationMode,
+		},
 		{
 			Name:  "MYSQL_VERSION",
 			Value: c.GetMySQLVersion(),
@@ -129,6 +134,33 @@ func (c *initSidecar) getEnvVars() []corev1.EnvVar {
 			getEnvVarFromSecret(sctNamebackup, "S3_BUCKET", "s3-bucket", true),
 		)
 	}
+	if len(c.Spec.RestoreCompression) != 0 {
+		envs = append(envs, corev1.EnvVar{
+			Name:  "RESTORE_COMPRESSION",
+			Value: c.Spec.RestoreCompression,
+		})
+	}
+	if c.Spec.RestoreParallel > 0 {
+		envs = append(envs, corev1.EnvVar{
+			Name:  "RESTORE_PARALLEL",
+			Value: strconv.Itoa(int(c.Spec.RestoreParallel)),
+		})
+	}
+	if len(c.Spec.RestorePrepareMemory) != 0 {
+		envs = append(envs, corev1.EnvVar{
+			Name:  "RESTORE_PREPARE_MEMORY",
+			Value: c.Spec.RestorePrepareMemory,
+		})
+	}
+	if target := c.Spec.RestoreTarget; target != nil {
+		envs = append(envs, getRestoreTargetEnvVars(target)...)
+	}
+	if c.EncryptInTransit() {
+		envs = append(envs, corev1.EnvVar{
+			Name:  "ENCRYPT_IN_TRANSIT",
+			Value: "true",
+		})
+	}
 	if len(c.Spec.NFSServerAddress) != 0 {
 		envs = append(envs, corev1.EnvVar{
 			Name:  "RESTORE_FROM_NFS",
@@ -239,11 +271,11 @@ func (c *initSidecar) getVolumeMounts() []corev1.VolumeMount {
 			MountPath: utils.RadonDBBinDir,
 		},
 	}
-	if c.Spec.TlsSecretName != "" {
+	if c.GetTLSSecretName() != "" {
 		volumeMounts = append(volumeMounts,
 			corev1.VolumeMount{
 				Name:      utils.TlsVolumeName + "-sidecar",
-				MountPath: "/tmp/mysql-ssl",
+				MountPath: utils.TlsSecretMountPath,
 			}, corev1.VolumeMount{
 				Name:      utils.TlsVolumeName,
 				MountPath: utils.TlsMountPath,
@@ -291,3 +323,24 @@ func (c *initSidecar) getVolumeMounts() []corev1.VolumeMount {
 	}
 	return volumeMounts
 }
+
+// getRestoreTargetEnvVars returns the env of the restore target, only the ones set are returned.
+func getRestoreTargetEnvVars(target *apiv1alpha1.RestoreTarget) []corev1.EnvVar {
+	var envs []corev1.EnvVar
+	add := func(name, value string) {
+		if len(value) != 0 {
+			envs = append(envs, corev1.EnvVar{Name: name, Value: value})
+		}
+	}
+	add("RESTORE_STOP_GTID", target.StopGTID)
+	if target.Inclusive {
+		add("RESTORE_STOP_INCLUSIVE", "true")
+	}
+	add("RESTORE_STOP_FILE", target.StopFile)
+	if target.StopPosition > 0 {
+		add("RESTORE_STOP_POSITION", strconv.FormatInt(target.StopPosition, 10))
+	}
+	add("RESTORE_SKIP_GTIDS", target.SkipGTIDs)
+	add("RESTORE_BINLOG_ARCHIVE_PREFIX", target.BinlogArchivePrefix)
+	return envs
+}
@@ -99,6 +99,10 @@ var (
 			Name:  "ELECTION_TIMEOUT",
 			Value: strconv.Itoa(int(*testInitSidecarCluster.Spec.XenonOpts.ElectionTimeout)),
 		},
+		{
+			Name:  "REPLICATION_MODE",
+			Value: testInitSidecarCluster.Spec.ReplicationMode,
+		},
 		{
 			Name:  "MYSQL_VERSION",
 			Value: "5.7.34",
@@ -439,6 +443,64 @@ func TestGetInitSidecarEnvVar(t *testing.T) {
 		)
 		assert.Equal(t, testBackupEnv, BackupCase.Env)
 	}
+	// Restore tuning
+	{
+		testRestoreMysqlCluster := initSidecarMysqlCluster
+		testRestoreMysqlCluster.Spec.RestoreCompression = "zstd"
+		testRestoreMysqlCluster.Spec.RestoreParallel = 4
+		testRestoreMysqlCluster.Spec.RestorePrepareMemory = "512M"
+		testRestoreMysqlClusterWraper := mysqlcluster.MysqlCluster{
+			MysqlCluster: &testRestoreMysqlCluster,
+		}
+		restoreCase := EnsureContainer("init-sidecar", &testRestoreMysqlClusterWraper)
+		testRestoreEnv := make([]corev1.EnvVar, len(defaultInitSidecarEnvs))
+		copy(testRestoreEnv, defaultInitSidecarEnvs)
+		testRestoreEnv = append(testRestoreEnv,
+			corev1.EnvVar{
+				Name:  "RESTORE_COMPRESSION",
+				Value: "zstd",
+			},
+			corev1.EnvVar{
+				Name:  "RESTORE_PARALLEL",
+				Value: "4",
+			},
+			corev1.EnvVar{
+				Name:  "RESTORE_PREPARE_MEMORY",
+				Value: "512M",
+			},
+		)
+		assert.Equal(t, testRestoreEnv, restoreCase.Env)
+	}
+	// Point-in-time restore by gtid
+	{
+		testPitrMysqlCluster := initSidecarMysqlCluster
+		testPitrMysqlCluster.Spec.RestoreTarget = &mysqlv1alpha1.RestoreTarget{
+			StopGTID:  "3e11fa47-71ca-11e1-9e33-c80aa9429562:23",
+			Inclusive: true,
+			SkipGTIDs: "3e11fa47-71ca-11e1-9e33-c80aa9429562:20",
+		}
+		testPitrMysqlClusterWraper := mysqlcluster.MysqlCluster{
+			MysqlCluster: &testPitrMysqlCluster,
+		}
+		pitrCase := EnsureContainer("init-sidecar", &testPitrMysqlClusterWraper)
+		testPitrEnv := make([]corev1.EnvVar, len(defaultInitSidecarEnvs))
+		copy(testPitrEnv, defaultInitSidecarEnvs)
+		testPitrEnv = append(testPitrEnv,
+			corev1.EnvVar{
+				Name:  "RESTORE_STOP_GTID",
+				Value: "3e11fa47-71ca-11e1-9e33-c80aa9429562:23",
+			},
+			corev1.EnvVar{
+				Name:  "RESTORE_STOP_INCLUSIVE",
+				Value: "true",
+			},
+			corev1.EnvVar{
+				Name:  "RESTORE_SKIP_GTIDS",
+				Value: "3e11fa47-71ca-11e1-9e33-c80aa9429562:20",
+			},
+		)
+		assert.Equal(t, testPitrEnv, pitrCase.Env)
+	}
 }
 
 func TestGetInitSidecarLifecycle(t *testing.T) {
@@ -182,7 +182,7 @@ func (c *mysql) getVolumeMounts() []corev1.VolumeMount {
 		// 	MountPath: "/test",
 		// },
 	}
-	if c.Spec.TlsSecretName != "" {
+	if c.GetTLSSecretName() != "" {
 		volumeMounts = append(volumeMounts,
 			corev1.VolumeMount{
 				Name:      utils.TlsVolumeName,
@@ -190,6 +190,16 @@ func (c *mysql) getVolumeMounts() []corev1.VolumeMount {
 			},
 		)
 	}
+	// Mount the generated certificates to copy the renewed ones when reloading TLS online.
+	if c.AutoTLSEnabled() {
+		volumeMounts = append(volumeMounts,
+			corev1.VolumeMount{
+				Name:      utils.TlsVolumeName + "-sidecar",
+				MountPath: utils.TlsSecretMountPath,
+				ReadOnly:  true,
+			},
+		)
+	}
 	// Just for test
 	// if c.Spec.SourceConfig != nil {
 	// 	volumeMounts = append(volumeMounts, corev1.VolumeMount{
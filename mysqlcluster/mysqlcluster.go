@@ -22,6 +22,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
 
 	corev1 "k8s.io/api/core/v1"
@@ -70,8 +71,8 @@ func (c *MysqlCluster) Unwrap() *apiv1alpha1.MysqlCluster {
 }
 
 func (c *MysqlCluster) Validate() error {
-	if utils.StringInArray(c.Spec.MysqlOpts.User, []string{"root", utils.ReplicationUser, utils.OperatorUser, utils.MetricsUser}) {
-		return fmt.Errorf("spec.mysqlOpts.user cannot be root|%s|%s|%s", utils.ReplicationUser, utils.OperatorUser, utils.MetricsUser)
+	if utils.StringInArray(c.Spec.MysqlOpts.User, []string{"root", utils.ReplicationUser, utils.OperatorUser, utils.MetricsUser, utils.BinlogArchiverUser}) {
+		return fmt.Errorf("spec.mysqlOpts.user cannot be root|%s|%s|%s|%s", utils.ReplicationUser, utils.OperatorUser, utils.MetricsUser, utils.BinlogArchiverUser)
 	}
 	// MySQL8 nerver support TokuDB
 	// https://www.percona.com/blog/2021/05/21/tokudb-support-changes-and-future-removal-from-percona-server-for-mysql-8-0/
@@ -266,12 +267,12 @@ func (c *MysqlCluster) EnsureVolumes() []corev1.Volume {
 		})
 	}
 	// Add the ssl secret mounts.
-	if len(c.Spec.TlsSecretName) != 0 {
+	if tlsSecret := c.GetTLSSecretName(); len(tlsSecret) != 0 {
 		volumes = append(volumes, corev1.Volume{
 			Name: utils.TlsVolumeName + "-sidecar",
 			VolumeSource: corev1.VolumeSource{
 				Secret: &corev1.SecretVolumeSource{
-					SecretName: c.Spec.TlsSecretName,
+					SecretName: tlsSecret,
 				},
 			},
 		}, corev1.Volume{
@@ -307,12 +308,10 @@ func (c *MysqlCluster) EnsureVolumes() []corev1.Volume {
 		volumes = append(volumes, corev1.Volume{
 			Name: utils.RemoteClusterCMVolumeName,
 			VolumeSource: corev1.VolumeSource{
-				ConfigMap: &corev1.ConfigMapVolumeSource{
-					LocalObjectReference: corev1.LocalObjectReference{
-						Name: c.GetNameForResource(utils.RemoteCluster),
-					},
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: c.GetNameForResource(utils.RemoteCluster),
 					DefaultMode: func() *int32 {
-						var tmp int32 = 0777
+						var tmp int32 = 0755
 						return &tmp
 					}(),
 				},
@@ -406,6 +405,12 @@ func (c *MysqlCluster) GetNameForResource(name utils.ResourceName) string {
 		return fmt.Sprintf("%s-xenon", c.Name)
 	case utils.RemoteCluster:
 		return fmt.Sprintf("%s-remote", c.Name)
+	case utils.TLSCASecret:
+		return fmt.Sprintf("%s-tls-ca", c.Name)
+	case utils.TLSSecret:
+		return fmt.Sprintf("%s-tls", c.Name)
+	case utils.BinlogArchiver:
+		return fmt.Sprintf("%s-binlog-archiver", c.Name)
 	case utils.ConfigMap:
 		if template := c.Spec.MysqlOpts.MysqlConfTemplate; template != "" {
 			return template
@@ -420,6 +425,69 @@ func (c *MysqlCluster) GetNameForResource(name utils.ResourceName) string {
 	}
 }
 
+// AutoTLSEnabled checks whether the certificates are generated by the operator.
+func (c *MysqlCluster) AutoTLSEnabled() bool {
+	return c.Spec.TlsSecretName == "" && c.Spec.AutoTLS != nil && c.Spec.AutoTLS.Enabled
+}
+
+// GetTLSSecretName returns the name of the secret contains the certificates for SSL,
+// returns empty if SSL is not enabled.
+func (c *MysqlCluster) GetTLSSecretName() string {
+	if c.AutoTLSEnabled() {
+		return c.GetNameForResource(utils.TLSSecret)
+	}
+	return c.Spec.TlsSecretName
+}
+
+// GetRestorePrepareMemory returns the memory used by xtrabackup to prepare the backup the cluster restore from.
+func (c *MysqlCluster) GetRestorePrepareMemory() string {
+	if len(c.Spec.RestorePrepareMemory) != 0 {
+		return c.Spec.RestorePrepareMemory
+	}
+	return utils.DefaultPrepareMemory
+}
+
+// CanReloadTLS checks whether mysqld of the version reloads the certificates online, which is only
+// supported by mysql 8.0. The version of the cluster is used if the version is empty.
+func (c *MysqlCluster) CanReloadTLS(version string) bool {
+	if version == "" {
+		version = c.Spec.MysqlVersion
+	}
+	return strings.HasPrefix(version, "8.0")
+}
+
+// EncryptInTransit checks whether the replication, the backup streaming and the xenon requests are encrypted.
+func (c *MysqlCluster) EncryptInTransit() bool {
+	return c.Spec.EncryptInTransit && c.GetTLSSecretName() != ""
+}
+
+// GetMasterSSLOptions returns the ssl options of `CHANGE MASTER` to replicate from the nodes of the cluster.
+func (c *MysqlCluster) GetMasterSSLOptions() string {
+	if !c.EncryptInTransit() {
+		return ""
+	}
+	return fmt.Sprintf(", MASTER_SSL=1, MASTER_SSL_CA='%s/%s'", utils.TlsMountPath, utils.TlsCAFile)
+}
+
+// GetCertValidity returns the validity and the renew window of the generated server certificate.
+func (c *MysqlCluster) GetCertValidity() (validity, renewBefore time.Duration) {
+	days, renewDays := int32(utils.DefaultCertValidityDays), int32(utils.DefaultCertRenewBeforeDays)
+	if c.Spec.AutoTLS != nil {
+		if c.Spec.AutoTLS.CertValidityDays > 0 {
+			days = c.Spec.AutoTLS.CertValidityDays
+		}
+		if c.Spec.AutoTLS.RenewBeforeDays > 0 {
+			renewDays = c.Spec.AutoTLS.RenewBeforeDays
+		}
+	}
+	validity, renewBefore = time.Duration(days)*24*time.Hour, time.Duration(renewDays)*24*time.Hour
+	// Renew the certificate at the half of the validity if the renew window is too large.
+	if renewBefore >= validity {
+		renewBefore = validity / 2
+	}
+	return validity, renewBefore
+}
+
 // EnsureMysqlConf set the mysql default configs.
 func (c *MysqlCluster) EnsureMysqlConf() {
 	if len(c.Spec.MysqlOpts.MysqlConf) == 0 {
@@ -541,6 +609,18 @@ func IsClusterKind(kind string) bool {
 	return false
 }
 
+// GetPromoteCatchUpTimeout returns the max time to wait for a candidate catching up the leader.
+func (c *MysqlCluster) GetPromoteCatchUpTimeout() time.Duration {
+	if c.Spec.XenonOpts.PromoteCatchUpTimeout == nil {
+		return utils.DefaultPromoteCatchUpTimeout
+	}
+	timeout := time.Duration(*c.Spec.XenonOpts.PromoteCatchUpTimeout) * time.Second
+	if timeout > utils.MaxPromoteCatchUpTimeout {
+		return utils.MaxPromoteCatchUpTimeout
+	}
+	return timeout
+}
+
 // GetClusterKey returns the MysqlUser's MySQLCluster key.
 func (c *MysqlCluster) GetClusterKey() client.ObjectKey {
 	return client.ObjectKey{
@@ -778,3 +778,20 @@ func TestGetImage(t *testing.T) {
 		assert.Equal(t, want, result)
 	}
 }
+
+func TestEncryptInTransit(t *testing.T) {
+	cluster := New(&mysqlv1alpha1.MysqlCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample"},
+		Spec:       mysqlv1alpha1.MysqlClusterSpec{EncryptInTransit: true},
+	})
+	// No certificates.
+	assert.False(t, cluster.EncryptInTransit())
+	assert.Equal(t, "", cluster.GetMasterSSLOptions())
+
+	cluster.Spec.AutoTLS = &mysqlv1alpha1.AutoTLSOpts{Enabled: true}
+	assert.True(t, cluster.EncryptInTransit())
+	assert.Equal(t, ", MASTER_SSL=1, MASTER_SSL_CA='/etc/mysql-ssl/ca.crt'", cluster.GetMasterSSLOptions())
+
+	cluster.Spec.EncryptInTransit = false
+	assert.False(t, cluster.EncryptInTransit())
+}
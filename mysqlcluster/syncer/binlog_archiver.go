@@ -0,0 +1,187 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+
+	"github.com/presslabs/controller-util/pkg/syncer"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlcluster"
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+const (
+	// The volume of the binlogs being streamed from the leader.
+	binlogSpoolVolumeName = "binlog-spool"
+	binlogSpoolMountPath  = "/var/lib/binlog-archiver"
+	// The volume of the password of the archiver user, it is mounted as a file so that
+	// the archiver picks up the rotated password when it reconnects.
+	binlogCredentialsVolumeName = "credentials"
+	binlogCredentialsMountPath  = "/etc/binlog-archiver"
+	binlogPasswordFile          = "password"
+)
+
+// NewBinlogArchiverSyncer returns the syncer of the deployment which archives the binlogs of the leader to S3.
+func NewBinlogArchiverSyncer(cli client.Client, c *mysqlcluster.MysqlCluster) syncer.Interface {
+	deploy := &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "apps/v1",
+			Kind:       "Deployment",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      c.GetNameForResource(utils.BinlogArchiver),
+			Namespace: c.Namespace,
+		},
+	}
+
+	return syncer.NewObjectSyncer("BinlogArchiver", c.Unwrap(), deploy, cli, func() error {
+		deploy.Labels = getBinlogArchiverLabels(c)
+		// The selector is immutable, only set it when the deployment is created.
+		if deploy.Spec.Selector == nil {
+			deploy.Spec.Selector = metav1.SetAsLabelSelector(getBinlogArchiverSelectorLabels(c))
+		}
+		replicas := int32(1)
+		deploy.Spec.Replicas = &replicas
+		// Only one archiver streams the binlogs at the same time, otherwise they would
+		// overwrite the state of each other.
+		deploy.Spec.Strategy = appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType}
+
+		template := &deploy.Spec.Template
+		template.Labels = getBinlogArchiverLabels(c)
+		template.Spec.Tolerations = c.Spec.PodPolicy.Tolerations
+		template.Spec.PriorityClassName = c.Spec.PodPolicy.PriorityClassName
+		template.Spec.Volumes = getBinlogArchiverVolumes(c)
+		template.Spec.Containers = []corev1.Container{getBinlogArchiverContainer(c)}
+		return nil
+	})
+}
+
+// getBinlogArchiverLabels returns the labels of the archiver, the name differs from the mysql
+// pods so that the services and the pdb of the cluster do not select it.
+func getBinlogArchiverLabels(c *mysqlcluster.MysqlCluster) labels.Set {
+	labels := c.GetLabels()
+	labels["app.kubernetes.io/name"] = utils.ContainerBinlogArchiverName
+	labels["app.kubernetes.io/component"] = utils.ContainerBinlogArchiverName
+	return labels
+}
+
+func getBinlogArchiverSelectorLabels(c *mysqlcluster.MysqlCluster) labels.Set {
+	labels := c.GetSelectorLabels()
+	labels["app.kubernetes.io/name"] = utils.ContainerBinlogArchiverName
+	return labels
+}
+
+// GetBinlogArchivePrefix returns the prefix of the archived binlogs in the bucket.
+func GetBinlogArchivePrefix(c *mysqlcluster.MysqlCluster) string {
+	if prefix := c.Spec.BinlogArchive.Prefix; len(prefix) != 0 {
+		return prefix
+	}
+	return fmt.Sprintf("%s-binlogs/", c.Name)
+}
+
+func getBinlogArchiverVolumes(c *mysqlcluster.MysqlCluster) []corev1.Volume {
+	return []corev1.Volume{
+		{
+			Name: binlogSpoolVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{},
+			},
+		},
+		{
+			Name: binlogCredentialsVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: c.GetNameForResource(utils.Secret),
+					Items: []corev1.KeyToPath{
+						{Key: "archiver-password", Path: binlogPasswordFile},
+					},
+				},
+			},
+		},
+	}
+}
+
+func getBinlogArchiverContainer(c *mysqlcluster.MysqlCluster) corev1.Container {
+	opts := c.Spec.BinlogArchive
+	s3Secret := opts.S3SecretName
+	secretEnv := func(name, key string, opt bool) corev1.EnvVar {
+		return corev1.EnvVar{
+			Name: name,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: s3Secret},
+					Key:                  key,
+					Optional:             &opt,
+				},
+			},
+		}
+	}
+
+	return corev1.Container{
+		Name:            utils.ContainerBinlogArchiverName,
+		Image:           c.Spec.PodPolicy.SidecarImage,
+		ImagePullPolicy: c.Spec.PodPolicy.ImagePullPolicy,
+		Args:            []string{"archive_binlogs"},
+		Env: []corev1.EnvVar{
+			{Name: "CONTAINER_TYPE", Value: utils.ContainerBinlogArchiverName},
+			{Name: "CLUSTER_NAME", Value: c.Name},
+			{Name: "LEADER_HOST", Value: fmt.Sprintf("%s.%s", c.GetNameForResource(utils.LeaderService), c.Namespace)},
+			{Name: "MYSQL_USER", Value: utils.BinlogArchiverUser},
+			{Name: "MYSQL_PASSWORD_FILE", Value: path.Join(binlogCredentialsMountPath, binlogPasswordFile)},
+			{Name: "ARCHIVE_PREFIX", Value: GetBinlogArchivePrefix(c)},
+			{Name: "ARCHIVE_SPOOL_DIR", Value: binlogSpoolMountPath},
+			{Name: "FLUSH_INTERVAL_SECONDS", Value: strconv.Itoa(int(opts.FlushIntervalSeconds))},
+			secretEnv("S3_ENDPOINT", "s3-endpoint", false),
+			secretEnv("S3_ACCESSKEY", "s3-access-key", true),
+			secretEnv("S3_SECRETKEY", "s3-secret-key", true),
+			secretEnv("S3_BUCKET", "s3-bucket", false),
+		},
+		Ports: []corev1.ContainerPort{
+			{
+				Name:          utils.BinlogArchiverPortName,
+				ContainerPort: utils.BinlogArchiverPort,
+			},
+		},
+		Resources: opts.Resources,
+		LivenessProbe: &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				HTTPGet: &corev1.HTTPGetAction{
+					Path: "/health",
+					Port: intstr.FromInt(utils.BinlogArchiverPort),
+				},
+			},
+			InitialDelaySeconds: 30,
+			TimeoutSeconds:      5,
+			PeriodSeconds:       10,
+			SuccessThreshold:    1,
+			FailureThreshold:    3,
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: binlogSpoolVolumeName, MountPath: binlogSpoolMountPath},
+			{Name: binlogCredentialsVolumeName, MountPath: binlogCredentialsMountPath, ReadOnly: true},
+		},
+	}
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlcluster"
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+func TestBinlogArchiver(t *testing.T) {
+	cluster := mysqlcluster.New(&apiv1alpha1.MysqlCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"},
+		Spec: apiv1alpha1.MysqlClusterSpec{
+			BinlogArchive: &apiv1alpha1.BinlogArchiveOpts{
+				Enabled:              true,
+				S3SecretName:         "sample-s3",
+				FlushIntervalSeconds: 30,
+			},
+		},
+	})
+	assert.Equal(t, "sample-binlogs/", GetBinlogArchivePrefix(cluster))
+	cluster.Spec.BinlogArchive.Prefix = "archive/sample/"
+	assert.Equal(t, "archive/sample/", GetBinlogArchivePrefix(cluster))
+
+	// The services and the pdb of the cluster must not select the archiver.
+	selector := getBinlogArchiverSelectorLabels(cluster)
+	assert.False(t, cluster.GetSelectorLabels().AsSelector().Matches(getBinlogArchiverLabels(cluster)))
+	assert.True(t, selector.AsSelector().Matches(getBinlogArchiverLabels(cluster)))
+
+	container := getBinlogArchiverContainer(cluster)
+	assert.Equal(t, []string{"archive_binlogs"}, container.Args)
+	env := map[string]string{}
+	for _, e := range container.Env {
+		env[e.Name] = e.Value
+		if e.ValueFrom != nil {
+			assert.Equal(t, "sample-s3", e.ValueFrom.SecretKeyRef.Name)
+		}
+	}
+	assert.Equal(t, utils.ContainerBinlogArchiverName, env["CONTAINER_TYPE"])
+	assert.Equal(t, "sample-leader.default", env["LEADER_HOST"])
+	assert.Equal(t, "archive/sample/", env["ARCHIVE_PREFIX"])
+	assert.Equal(t, "30", env["FLUSH_INTERVAL_SECONDS"])
+	assert.Equal(t, "/etc/binlog-archiver/password", env["MYSQL_PASSWORD_FILE"])
+	assert.Equal(t, utils.BinlogArchiverUser, env["MYSQL_USER"])
+
+	// Only the password of the archiver user is mounted.
+	volumes := getBinlogArchiverVolumes(cluster)
+	assert.Len(t, volumes, 2)
+	assert.Equal(t, "sample-secret", volumes[1].Secret.SecretName)
+	assert.Equal(t, []corev1.KeyToPath{{Key: "archiver-password", Path: "password"}}, volumes[1].Secret.Items)
+}
@@ -0,0 +1,553 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/internal"
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+// The timeout of waiting for the replicas to apply the new credentials.
+const credentialsCatchUpTimeout = 30 * time.Second
+
+// The timeout of waiting for the restarted xenon to rejoin the raft.
+const xenonRejoinTimeout = 2 * time.Minute
+
+// The error number of the access denied.
+const mysqlErrAccessDenied = 1045
+
+// The label of the backup jobs, see controllers/backup.
+const backupJobClusterLabel = "backups.mysql.radondb.com/cluster"
+
+// credentialUsers maps the keys of the internal credentials to the mysql users, the backup
+// password is only used by the http server of the backup container.
+var credentialUsers = map[string]string{
+	"operator-password":      utils.OperatorUser,
+	"replication-password":   utils.ReplicationUser,
+	"metrics-password":       utils.MetricsUser,
+	"donor-password":         utils.DonorCloneUser,
+	"archiver-password":      utils.BinlogArchiverUser,
+	"internal-root-password": utils.RootUser,
+}
+
+// credentialsRotationDue returns whether the internal credentials should be rotated, which is
+// requested by the annotation or the rotation interval.
+func credentialsRotationDue(cluster *apiv1alpha1.MysqlCluster, now time.Time) bool {
+	status := cluster.Status.CredentialsRotation
+	trigger := cluster.Annotations[utils.AnnotationRotateInternalCredentials]
+	if trigger != "" && (status == nil || status.Trigger != trigger) {
+		return true
+	}
+	if cluster.Spec.RotateInternalCredentials == nil {
+		return false
+	}
+	last := cluster.CreationTimestamp.Time
+	if status != nil && status.LastRotationTime != nil {
+		last = status.LastRotationTime.Time
+	}
+	interval := time.Duration(cluster.Spec.RotateInternalCredentials.IntervalDays) * 24 * time.Hour
+	return now.Sub(last) >= interval
+}
+
+// hasStagedCredentials returns whether a rotation is in progress.
+func hasStagedCredentials(secret *corev1.Secret) bool {
+	for _, key := range internalCredentialKeys {
+		if len(secret.Data[key+nextKeySuffix]) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// reconcileCredentialsRotation rotates the internal credentials online. The new credentials are
+// staged in the secret first, so an interrupted rotation is resumed with the same values. The
+// phase is kept in the status, and each sync runs one step of the rotation:
+// 1. change the passwords on the leader, retaining the current ones if the mysql supports it.
+// 2. CatchingUp: check whether the replicas have applied the change.
+// 3. Reconfiguring: update the replication channels and xenon of the pods one by one, waiting
+// for the restarted xenon to rejoin the raft before the next pod.
+// 4. update the client.conf of the probes, swap the secret, then restart the metrics and backup
+// containers to load it, and discard the retained passwords.
+// The client.conf is updated before step 1 if the mysql doesn't support the dual passwords.
+func (s *StatusSyncer) reconcileCredentialsRotation(ctx context.Context) error {
+	secret := &corev1.Secret{}
+	if err := s.cli.Get(ctx, types.NamespacedName{
+		Name:      s.GetNameForResource(utils.Secret),
+		Namespace: s.Namespace,
+	}, secret); err != nil {
+		return err
+	}
+	rotating := s.Status.CredentialsRotation != nil && s.Status.CredentialsRotation.Phase != apiv1alpha1.CredentialsRotationIdle
+	if !rotating && !hasStagedCredentials(secret) && !credentialsRotationDue(s.Unwrap(), time.Now()) {
+		return nil
+	}
+
+	pods, ready, err := s.getRotationPods(ctx)
+	if err != nil {
+		return err
+	}
+	var leader *corev1.Pod
+	for i := range pods {
+		if pods[i].Labels["role"] == string(utils.Leader) {
+			leader = &pods[i]
+		}
+	}
+	if !ready || leader == nil || s.Status.ReadyNodes != int(*s.Spec.Replicas) ||
+		(s.Status.RollingUpdate != nil && s.Status.RollingUpdate.Phase != apiv1alpha1.RollingUpdateIdle) ||
+		(s.Status.Upgrade != nil && s.Status.Upgrade.Phase == apiv1alpha1.UpgradeUpgrading) {
+		s.log.V(1).Info("wait for the cluster to be ready to rotate the internal credentials")
+		return nil
+	}
+	// The backup jobs use the backup password read when they started.
+	if running, err := s.hasRunningBackupJob(ctx); err != nil || running {
+		s.log.V(1).Info("wait for the backup jobs to rotate the internal credentials", "error", err)
+		return err
+	}
+
+	if s.Status.CredentialsRotation == nil {
+		s.Status.CredentialsRotation = &apiv1alpha1.CredentialsRotationStatus{}
+	}
+	status := s.Status.CredentialsRotation
+	done := false
+	switch status.Phase {
+	case apiv1alpha1.CredentialsRotationIdle:
+		err = s.changeCredentials(ctx, secret, leader, pods)
+	case apiv1alpha1.CredentialsRotationCatchingUp:
+		err = s.checkCredentialsCaughtUp(secret, leader, pods)
+	case apiv1alpha1.CredentialsRotationReconfiguring:
+		done, err = s.reconfigureCredentials(ctx, secret, leader, pods)
+	}
+	if err != nil {
+		status.Message = err.Error()
+		s.recorder.Eventf(s.Unwrap(), corev1.EventTypeWarning, "RotateCredentialsFailed",
+			"failed to rotate the internal credentials: %s", err)
+		return err
+	}
+	status.Message = ""
+	if !done {
+		return nil
+	}
+	now := metav1.Now()
+	status.LastRotationTime = &now
+	status.Trigger = s.Annotations[utils.AnnotationRotateInternalCredentials]
+	s.recorder.Event(s.Unwrap(), corev1.EventTypeNormal, "CredentialsRotated", "the internal credentials are rotated")
+	return nil
+}
+
+// getRotationPods returns all the pods of the cluster including the readonly ones, and whether
+// all of them are ready.
+func (s *StatusSyncer) getRotationPods(ctx context.Context) ([]corev1.Pod, bool, error) {
+	list := corev1.PodList{}
+	if err := s.cli.List(ctx, &list, &client.ListOptions{
+		Namespace:     s.Namespace,
+		LabelSelector: s.GetLabels().AsSelector(),
+	}); err != nil {
+		return nil, false, err
+	}
+	for _, pod := range list.Items {
+		ready := false
+		for _, cond := range pod.Status.Conditions {
+			if cond.Type == corev1.ContainersReady && cond.Status == corev1.ConditionTrue {
+				ready = true
+			}
+		}
+		if !ready {
+			return list.Items, false, nil
+		}
+	}
+	return list.Items, true, nil
+}
+
+func (s *StatusSyncer) hasRunningBackupJob(ctx context.Context) (bool, error) {
+	jobs := batchv1.JobList{}
+	if err := s.cli.List(ctx, &jobs, client.InNamespace(s.Namespace),
+		client.MatchingLabels{backupJobClusterLabel: s.Name}); err != nil {
+		return false, err
+	}
+	for _, job := range jobs.Items {
+		if job.Status.Active > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// rotationConns keeps the connections opened in a step of the rotation.
+type rotationConns struct {
+	s       *StatusSyncer
+	closers []func()
+}
+
+// connect connects to the pod as root with the first valid password.
+func (c *rotationConns) connect(pod *corev1.Pod, passwords ...string) (internal.SQLRunner, error) {
+	runner, closeConn, err := c.s.connectAsRoot(c.s.getRotationPodHost(pod), passwords)
+	if err != nil {
+		return nil, err
+	}
+	c.closers = append(c.closers, closeConn)
+	return runner, nil
+}
+
+func (c *rotationConns) close() {
+	for _, closeConn := range c.closers {
+		closeConn()
+	}
+}
+
+// changeCredentials stages the new credentials in the secret, and changes the passwords on the leader.
+func (s *StatusSyncer) changeCredentials(ctx context.Context, secret *corev1.Secret, leader *corev1.Pod, pods []corev1.Pod) error {
+	if !hasStagedCredentials(secret) {
+		s.log.Info("rotate the internal credentials")
+	}
+	for _, key := range internalCredentialKeys {
+		if err := addRandomPassword(secret.Data, key+nextKeySuffix); err != nil {
+			return err
+		}
+	}
+	if err := s.cli.Update(ctx, secret); err != nil {
+		return err
+	}
+	next := func(key string) string {
+		return string(secret.Data[key+nextKeySuffix])
+	}
+
+	conns := &rotationConns{s: s}
+	defer conns.close()
+	// The root password may have been changed by the interrupted rotation.
+	leaderRunner, err := conns.connect(leader, string(secret.Data["internal-root-password"]), next("internal-root-password"))
+	if err != nil {
+		return err
+	}
+	dual, err := internal.SupportsDualPassword(leaderRunner)
+	if err != nil {
+		return err
+	}
+
+	// Without the dual passwords, the probes are denied once the operator password is changed,
+	// so the client.conf is updated first.
+	if !dual {
+		executor, err := internal.NewPodExecutor()
+		if err != nil {
+			return err
+		}
+		if err := s.setClientPasswords(executor, pods, next("operator-password")); err != nil {
+			return err
+		}
+	}
+	for _, key := range internalCredentialKeys {
+		user, ok := credentialUsers[key]
+		if !ok {
+			continue
+		}
+		// The donor user only exists in mysql 8.0, and the archiver user only exists
+		// after the binlog archiving is enabled.
+		if exists, err := internal.CheckUserExists(leaderRunner, user); err != nil || !exists {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if dual {
+			err = internal.RetainCurrentPassword(leaderRunner, user, "%", next(key))
+		} else {
+			err = internal.SetUserPassword(leaderRunner, user, "%", next(key))
+		}
+		if err != nil {
+			return fmt.Errorf("failed to change the password of %s: %s", user, err)
+		}
+	}
+
+	now := metav1.Now()
+	s.Status.CredentialsRotation.Phase = apiv1alpha1.CredentialsRotationCatchingUp
+	s.Status.CredentialsRotation.StartedAt = &now
+	s.log.Info("the passwords are changed on the leader, wait for the replicas to catch up")
+	return nil
+}
+
+// checkCredentialsCaughtUp checks whether the replicas have applied the changed passwords, it
+// does not wait, and fails after credentialsCatchUpTimeout.
+func (s *StatusSyncer) checkCredentialsCaughtUp(secret *corev1.Secret, leader *corev1.Pod, pods []corev1.Pod) error {
+	status := s.Status.CredentialsRotation
+	// The pooled connections may be reopened, which requires the new root password.
+	passwords := []string{string(secret.Data["internal-root-password"+nextKeySuffix]), string(secret.Data["internal-root-password"])}
+	conns := &rotationConns{s: s}
+	defer conns.close()
+	leaderRunner, err := conns.connect(leader, passwords...)
+	if err != nil {
+		return err
+	}
+	lagging := []string{}
+	for i := range pods {
+		if pods[i].Name == leader.Name {
+			continue
+		}
+		runner, err := conns.connect(&pods[i], passwords...)
+		if err != nil {
+			return err
+		}
+		caughtUp, err := internal.CheckGtidCaughtUp(leaderRunner, runner)
+		if err != nil {
+			return fmt.Errorf("failed to check %s: %s", pods[i].Name, err)
+		}
+		if !caughtUp {
+			lagging = append(lagging, pods[i].Name)
+		}
+	}
+	if len(lagging) > 0 {
+		if status.StartedAt != nil && time.Since(status.StartedAt.Time) > credentialsCatchUpTimeout {
+			return fmt.Errorf("the replicas %v have not applied the new passwords in %s", lagging, credentialsCatchUpTimeout)
+		}
+		s.log.V(1).Info("wait for the replicas to apply the new passwords", "replicas", lagging)
+		return nil
+	}
+
+	now := metav1.Now()
+	status.Phase = apiv1alpha1.CredentialsRotationReconfiguring
+	status.StartedAt = &now
+	status.ReconfiguredPods = nil
+	return nil
+}
+
+// reconfigureCredentials updates the replication channel and xenon of the pods one by one, the
+// xenon of the followers is restarted to load the new replication password, and the next pod is
+// updated after the xenon rejoins the raft. It finishes the rotation after all the pods are updated.
+func (s *StatusSyncer) reconfigureCredentials(ctx context.Context, secret *corev1.Secret, leader *corev1.Pod, pods []corev1.Pod) (bool, error) {
+	status := s.Status.CredentialsRotation
+	if status.RestartingPod != "" {
+		for i := range pods {
+			if pods[i].Name != status.RestartingPod {
+				continue
+			}
+			if !s.xenonRejoined(&pods[i], status.XenonRestartCount) {
+				if status.StartedAt != nil && time.Since(status.StartedAt.Time) > xenonRejoinTimeout {
+					return false, fmt.Errorf("the xenon of %s has not rejoined the raft in %s", pods[i].Name, xenonRejoinTimeout)
+				}
+				return false, nil
+			}
+			s.log.Info("the xenon rejoined the raft", "pod", pods[i].Name)
+			status.ReconfiguredPods = append(status.ReconfiguredPods, pods[i].Name)
+		}
+		// Restart xenon one by one to keep the quorum of the raft.
+		status.RestartingPod = ""
+		status.XenonRestartCount = 0
+		return false, nil
+	}
+
+	next := func(key string) string {
+		return string(secret.Data[key+nextKeySuffix])
+	}
+	executor, err := internal.NewPodExecutor()
+	if err != nil {
+		return false, err
+	}
+	conns := &rotationConns{s: s}
+	defer conns.close()
+	for i := range pods {
+		pod := &pods[i]
+		if utils.StringInArray(pod.Name, status.ReconfiguredPods) {
+			continue
+		}
+		if pod.Name != leader.Name {
+			runner, err := conns.connect(pod, next("internal-root-password"))
+			if err != nil {
+				return false, err
+			}
+			isReplica, _, err := internal.GetReplicaIOErrno(runner)
+			if err != nil {
+				return false, err
+			}
+			if isReplica {
+				if err := internal.SetMasterCredentials(runner, utils.ReplicationUser, next("replication-password")); err != nil {
+					return false, fmt.Errorf("failed to change the replication credentials of %s: %s", pod.Name, err)
+				}
+			}
+		}
+		// The leader keeps running with the old password in memory, it's only used after the leader
+		// becomes a replica, when the replication is repaired by the status syncer.
+		if hasContainer(pod, utils.ContainerXenonName) {
+			restart := pod.Name != leader.Name
+			if err := executor.SetXenonReplicationPassword(s.Namespace, pod.Name, next("replication-password"), restart); err != nil {
+				return false, err
+			}
+			if restart {
+				now := metav1.Now()
+				status.RestartingPod = pod.Name
+				status.XenonRestartCount = xenonRestartCount(pod)
+				status.StartedAt = &now
+				return false, nil
+			}
+		}
+		status.ReconfiguredPods = append(status.ReconfiguredPods, pod.Name)
+	}
+
+	leaderRunner, err := conns.connect(leader, next("internal-root-password"))
+	if err != nil {
+		return false, err
+	}
+	dual, err := internal.SupportsDualPassword(leaderRunner)
+	if err != nil {
+		return false, err
+	}
+	if dual {
+		if err := s.setClientPasswords(executor, pods, next("operator-password")); err != nil {
+			return false, err
+		}
+	}
+
+	for _, key := range internalCredentialKeys {
+		secret.Data[key] = secret.Data[key+nextKeySuffix]
+		delete(secret.Data, key+nextKeySuffix)
+	}
+	if s.Spec.MetricsOpts.Enabled {
+		secret.Data["data-source"] = []byte(fmt.Sprintf("%s:%s@(localhost:3306)/", utils.MetricsUser, secret.Data["metrics-password"]))
+	}
+	if err := s.cli.Update(ctx, secret); err != nil {
+		return false, err
+	}
+	s.log.Info("the internal credentials are swapped in the secret")
+	status.Phase = apiv1alpha1.CredentialsRotationIdle
+	status.StartedAt = nil
+	status.ReconfiguredPods = nil
+
+	// The containers read the credentials from the environment variables.
+	for _, pod := range pods {
+		for _, name := range []string{utils.ContainerMetricsName, utils.ContainerBackupName} {
+			if !hasContainer(&pod, name) {
+				continue
+			}
+			if err := executor.RestartContainer(s.Namespace, pod.Name, name); err != nil {
+				s.log.Error(err, "failed to restart the container", "pod", pod.Name, "container", name)
+			}
+		}
+	}
+
+	if dual {
+		for _, key := range internalCredentialKeys {
+			user, ok := credentialUsers[key]
+			if !ok {
+				continue
+			}
+			if exists, err := internal.CheckUserExists(leaderRunner, user); err != nil || !exists {
+				continue
+			}
+			if err := internal.DiscardOldPassword(leaderRunner, user, "%"); err != nil {
+				s.log.Error(err, "failed to discard the old password", "user", user)
+			}
+		}
+	}
+	return true, nil
+}
+
+// setClientPasswords updates the operator password in the client.conf of the pods, which is used
+// by the probes. The readonly nodes don't use the client.conf.
+func (s *StatusSyncer) setClientPasswords(executor *internal.PodExecutor, pods []corev1.Pod, password string) error {
+	for _, pod := range pods {
+		if _, ok := pod.Labels["readonly"]; ok {
+			continue
+		}
+		if err := executor.SetClientPassword(s.Namespace, pod.Name, password); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// xenonRejoined checks whether the xenon container of the pod has been restarted since the
+// restart count, and the xenon has rejoined the raft as a follower of the leader.
+func (s *StatusSyncer) xenonRejoined(pod *corev1.Pod, restarts int32) bool {
+	if xenonRestartCount(pod) <= restarts {
+		return false
+	}
+	raftStatus, err := s.XenonExecutor.RaftStatus(s.getPodHost(pod))
+	if err != nil || raftStatus == nil {
+		return false
+	}
+	return raftStatus.Role == string(utils.Follower) && raftStatus.Leader != ""
+}
+
+func xenonRestartCount(pod *corev1.Pod) int32 {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == utils.ContainerXenonName {
+			return status.RestartCount
+		}
+	}
+	return 0
+}
+
+// connectAsRoot connects to the host with the first valid password.
+func (s *StatusSyncer) connectAsRoot(host string, passwords []string) (internal.SQLRunner, func(), error) {
+	var err error
+	for _, password := range passwords {
+		var runner internal.SQLRunner
+		var closeConn func()
+		runner, closeConn, err = s.SQLRunnerFactory(&internal.Config{
+			User:     utils.RootUser,
+			Password: password,
+			Host:     host,
+			Port:     utils.MysqlPort,
+		})
+		if err == nil {
+			return runner, closeConn, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("failed to connect to %s: %s", host, err)
+}
+
+func (s *StatusSyncer) getRotationPodHost(pod *corev1.Pod) string {
+	if _, ok := pod.Labels["readonly"]; ok {
+		return fmt.Sprintf("%s.%s.%s", pod.Name, s.GetNameForResource(utils.ReadOnlyHeadlessSVC), s.Namespace)
+	}
+	return s.getPodHost(pod)
+}
+
+// repairReplicationCredentials updates the replication credentials if the replica is denied by the
+// leader, which happens when a leader having the old password in xenon's memory becomes a replica.
+func (s *StatusSyncer) repairReplicationCredentials(ctx context.Context, sqlRunner internal.SQLRunner, node *apiv1alpha1.NodeStatus) error {
+	isReplica, errno, err := internal.GetReplicaIOErrno(sqlRunner)
+	if err != nil || !isReplica || errno != mysqlErrAccessDenied {
+		return err
+	}
+	secret := &corev1.Secret{}
+	if err := s.cli.Get(ctx, types.NamespacedName{
+		Name:      s.GetNameForResource(utils.Secret),
+		Namespace: s.Namespace,
+	}, secret); err != nil {
+		return err
+	}
+	s.log.Info("update the replication credentials", "node", node.Name)
+	return internal.SetMasterCredentials(sqlRunner, utils.ReplicationUser, string(secret.Data["replication-password"]))
+}
+
+func hasContainer(pod *corev1.Pod, name string) bool {
+	for _, c := range pod.Spec.Containers {
+		if c.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,193 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/internal"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlcluster"
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+func TestCredentialsRotationDue(t *testing.T) {
+	now := time.Now()
+	cluster := &apiv1alpha1.MysqlCluster{
+		ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(now.Add(-48 * time.Hour))},
+	}
+	assert.False(t, credentialsRotationDue(cluster, now))
+
+	// Requested by the annotation.
+	cluster.Annotations = map[string]string{utils.AnnotationRotateInternalCredentials: "1"}
+	assert.True(t, credentialsRotationDue(cluster, now))
+	last := metav1.NewTime(now.Add(-time.Hour))
+	cluster.Status.CredentialsRotation = &apiv1alpha1.CredentialsRotationStatus{LastRotationTime: &last, Trigger: "1"}
+	assert.False(t, credentialsRotationDue(cluster, now))
+
+	// Requested by the interval.
+	cluster.Spec.RotateInternalCredentials = &apiv1alpha1.CredentialsRotationOpts{IntervalDays: 1}
+	assert.False(t, credentialsRotationDue(cluster, now))
+	assert.True(t, credentialsRotationDue(cluster, now.Add(24*time.Hour)))
+}
+
+func TestUpdateSecretRev(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{ResourceVersion: "100"},
+		Data: map[string][]byte{
+			"root-password":     []byte("root"),
+			"operator-password": []byte("old"),
+		},
+	}
+	// Keep the revision recorded by the running pods.
+	assert.NoError(t, updateSecretRev(secret, copyData(secret.Data)))
+	assert.Equal(t, "100", secret.Annotations[utils.AnnotationSecretRev])
+
+	// The rotation of the internal credentials doesn't change the revision.
+	oldData := copyData(secret.Data)
+	secret.Data["operator-password"] = []byte("new")
+	secret.Data["operator-password"+nextKeySuffix] = []byte("next")
+	secret.Data["data-source"] = []byte("metrics:new@(localhost:3306)/")
+	assert.NoError(t, updateSecretRev(secret, oldData))
+	assert.Equal(t, "100", secret.Annotations[utils.AnnotationSecretRev])
+
+	oldData = copyData(secret.Data)
+	secret.Data["root-password"] = []byte("changed")
+	assert.NoError(t, updateSecretRev(secret, oldData))
+	assert.NotEqual(t, "100", secret.Annotations[utils.AnnotationSecretRev])
+}
+
+func copyData(data map[string][]byte) map[string][]byte {
+	res := make(map[string][]byte, len(data))
+	for key, val := range data {
+		res[key] = val
+	}
+	return res
+}
+
+// fakeRaftExecutor answers the raft status of the hosts, the other requests are not expected.
+type fakeRaftExecutor struct {
+	internal.XenonExecutor
+	raft map[string]*apiv1alpha1.RaftStatus
+}
+
+func (f *fakeRaftExecutor) RaftStatus(host string) (*apiv1alpha1.RaftStatus, error) {
+	if status, ok := f.raft[host]; ok {
+		return status, nil
+	}
+	return nil, fmt.Errorf("failed to connect to %s", host)
+}
+
+// newRotationSyncer returns the status syncer rotating the credentials of the leader and the follower.
+func newRotationSyncer(phase apiv1alpha1.CredentialsRotationPhase) *StatusSyncer {
+	cluster := &apiv1alpha1.MysqlCluster{ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"}}
+	now := metav1.Now()
+	cluster.Status.CredentialsRotation = &apiv1alpha1.CredentialsRotationStatus{Phase: phase, StartedAt: &now}
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	return &StatusSyncer{
+		MysqlCluster: mysqlcluster.New(cluster),
+		cli:          fake.NewClientBuilder().WithScheme(scheme).Build(),
+		log:          logr.Discard(),
+	}
+}
+
+func TestCheckCredentialsCaughtUp(t *testing.T) {
+	leader := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "sample-mysql-0", Namespace: "default"}}
+	follower := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "sample-mysql-1", Namespace: "default"}}
+	secret := &corev1.Secret{Data: map[string][]byte{
+		"internal-root-password":                 []byte("old"),
+		"internal-root-password" + nextKeySuffix: []byte("new"),
+	}}
+	variables := map[string]map[string]string{
+		tryLeaderLeader: {"gtid_executed": tryLeaderUUID + ":1-10"},
+		tryLeaderTarget: {"gtid_executed": tryLeaderUUID + ":1-8"},
+	}
+	s := newRotationSyncer(apiv1alpha1.CredentialsRotationCatchingUp)
+	s.SQLRunnerFactory = newFakeSQLRunnerFactory(variables, map[string][]string{})
+	status := s.Status.CredentialsRotation
+
+	// The lagging follower is checked again in the next sync.
+	assert.NoError(t, s.checkCredentialsCaughtUp(secret, &leader, []corev1.Pod{leader, follower}))
+	assert.Equal(t, apiv1alpha1.CredentialsRotationCatchingUp, status.Phase)
+
+	started := metav1.NewTime(time.Now().Add(-credentialsCatchUpTimeout - time.Second))
+	status.StartedAt = &started
+	assert.Error(t, s.checkCredentialsCaughtUp(secret, &leader, []corev1.Pod{leader, follower}))
+	assert.Equal(t, apiv1alpha1.CredentialsRotationCatchingUp, status.Phase)
+
+	variables[tryLeaderTarget]["gtid_executed"] = tryLeaderUUID + ":1-10"
+	assert.NoError(t, s.checkCredentialsCaughtUp(secret, &leader, []corev1.Pod{leader, follower}))
+	assert.Equal(t, apiv1alpha1.CredentialsRotationReconfiguring, status.Phase)
+	assert.True(t, status.StartedAt.After(started.Time))
+}
+
+func TestReconfigureWaitXenonRejoined(t *testing.T) {
+	host := "sample-mysql-1.sample-mysql.default"
+	leader := corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "sample-mysql-0", Namespace: "default"}}
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample-mysql-1", Namespace: "default"},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+			{Name: utils.ContainerXenonName, RestartCount: 1},
+		}},
+	}
+	xenon := &fakeRaftExecutor{raft: map[string]*apiv1alpha1.RaftStatus{
+		host: {Role: string(utils.Follower), Leader: "sample-mysql-0.sample-mysql.default:8801"},
+	}}
+	s := newRotationSyncer(apiv1alpha1.CredentialsRotationReconfiguring)
+	s.XenonExecutor = xenon
+	status := s.Status.CredentialsRotation
+	status.ReconfiguredPods = []string{"sample-mysql-0"}
+	status.RestartingPod, status.XenonRestartCount = "sample-mysql-1", 1
+	reconfigure := func() (bool, error) {
+		return s.reconfigureCredentials(context.TODO(), &corev1.Secret{}, &leader, []corev1.Pod{leader, pod})
+	}
+
+	// The xenon is not restarted yet, the old process still reports the raft status.
+	done, err := reconfigure()
+	assert.NoError(t, err)
+	assert.False(t, done)
+	assert.Equal(t, "sample-mysql-1", status.RestartingPod)
+
+	pod.Status.ContainerStatuses[0].RestartCount = 2
+	xenon.raft[host] = &apiv1alpha1.RaftStatus{Role: string(utils.Candidate)}
+	started := metav1.NewTime(time.Now().Add(-xenonRejoinTimeout - time.Second))
+	status.StartedAt = &started
+	_, err = reconfigure()
+	assert.Error(t, err)
+	assert.Equal(t, "sample-mysql-1", status.RestartingPod)
+
+	// The next pod is reconfigured in the next sync.
+	xenon.raft[host] = &apiv1alpha1.RaftStatus{Role: string(utils.Follower), Leader: "sample-mysql-0.sample-mysql.default:8801"}
+	done, err = reconfigure()
+	assert.NoError(t, err)
+	assert.False(t, done)
+	assert.Empty(t, status.RestartingPod)
+	assert.Equal(t, []string{"sample-mysql-0", "sample-mysql-1"}, status.ReconfiguredPods)
+	assert.Equal(t, apiv1alpha1.CredentialsRotationReconfiguring, status.Phase)
+}
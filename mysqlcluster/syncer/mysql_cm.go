@@ -34,6 +34,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/radondb/radondb-mysql-kubernetes/mysqlcluster"
@@ -129,6 +130,10 @@ func (s *mysqlCMSyncer) createOrUpdate(ctx context.Context) (controllerutil.Oper
 		if err = s.generateTemplate(ctx); err != nil {
 			return resultNone, err
 		}
+		s.setMysqlVersion()
+		if err = s.setStaticConfigRev(); err != nil {
+			return resultNone, err
+		}
 
 		if err = s.cli.Create(ctx, s.cm); err != nil {
 			return resultNone, err
@@ -137,9 +142,22 @@ func (s *mysqlCMSyncer) createOrUpdate(ctx context.Context) (controllerutil.Oper
 		}
 	}
 
+	// Regenerate the configs after the MySQL major version changes, the old configs may be invalid.
+	if version, ok := s.cm.Annotations[utils.AnnotationMysqlVersion]; ok && version != s.Spec.MysqlVersion &&
+		s.Spec.MysqlOpts.MysqlConfTemplate == "" {
+		s.log.Info("regenerate the configs for the new mysql version", "from", version, "to", s.Spec.MysqlVersion)
+		if err = s.generateTemplate(ctx); err != nil {
+			return resultNone, err
+		}
+	}
+	s.setMysqlVersion()
+
 	if err := s.appendConf(); err != nil {
 		return resultNone, err
 	}
+	if err := s.setStaticConfigRev(); err != nil {
+		return resultNone, err
+	}
 
 	if err := s.setControllerReference(); err != nil {
 		return resultNone, err
@@ -172,6 +190,25 @@ func (s *mysqlCMSyncer) generateTemplate(ctx context.Context) error {
 	return fmt.Errorf("MysqlConfTemplate is empty")
 }
 
+// setMysqlVersion records the MySQL version of the configs in the annotations.
+func (s *mysqlCMSyncer) setMysqlVersion() {
+	if s.cm.Annotations == nil {
+		s.cm.Annotations = map[string]string{}
+	}
+	s.cm.Annotations[utils.AnnotationMysqlVersion] = s.Spec.MysqlVersion
+}
+
+// setStaticConfigRev records the revision of the static configs in the annotations,
+// the changes of the dynamic variables are applied online without restarting the pods.
+func (s *mysqlCMSyncer) setStaticConfigRev() error {
+	rev, err := staticConfigRev(s.Spec.MysqlVersion, s.cm.Data)
+	if err != nil {
+		return err
+	}
+	s.cm.Annotations[utils.AnnotationStaticConfigRev] = rev
+	return nil
+}
+
 // Notice: The parameters will not be removed from the cm when its removed from the mysqlConf/pluginConf.
 func (s *mysqlCMSyncer) appendConf() error {
 	if err := s.createOrReplaceIniKey("my.cnf", s.Spec.MysqlOpts.MysqlConf); err != nil {
@@ -189,6 +226,9 @@ func (s *mysqlCMSyncer) appendConf() error {
 			s.Spec.MysqlOpts.PluginConf = map[string]string{"plugin-load": str}
 		}
 	}
+	if err := s.createOrReplaceIniKey("plugin.cnf", buildReplicationConfigs(s.MysqlCluster)); err != nil {
+		return err
+	}
 	if err := s.createOrReplaceIniKey("plugin.cnf", s.Spec.MysqlOpts.PluginConf); err != nil {
 		return err
 	}
@@ -319,7 +359,7 @@ func buildMysqlConf(c *mysqlcluster.MysqlCluster) (string, error) {
 			log.Error(err, "failed to add boolean key to config section", "key", key)
 		}
 	}
-	if len(c.Spec.TlsSecretName) != 0 {
+	if len(c.GetTLSSecretName()) != 0 {
 		addKVConfigsToSection(sec, mysqlSSLConfigs)
 	}
 	addKVConfigsToSection(sec, c.Spec.MysqlOpts.MysqlConf)
@@ -349,6 +389,7 @@ func buildMysqlPluginConf(c *mysqlcluster.MysqlCluster) (string, error) {
 		delete(pluginConfigsCpy, "audit_log_format")
 	}
 	addKVConfigsToSection(sec, pluginConfigsCpy)
+	addKVConfigsToSection(sec, buildReplicationConfigs(c))
 	addKVConfigsToSection(sec, c.Spec.MysqlOpts.PluginConf)
 	data, err := writeConfigs(cfg)
 	if err != nil {
@@ -358,6 +399,32 @@ func buildMysqlPluginConf(c *mysqlcluster.MysqlCluster) (string, error) {
 	return data, nil
 }
 
+// buildReplicationConfigs build the semi-sync configs according to the replication mode.
+func buildReplicationConfigs(c *mysqlcluster.MysqlCluster) map[string]string {
+	if utils.ReplicationMode(c.Spec.ReplicationMode) == utils.Async {
+		// The leader reverts to async immediately without semi-sync followers.
+		return map[string]string{
+			"rpl_semi_sync_slave_enabled":        "OFF",
+			"rpl_semi_sync_master_wait_no_slave": "OFF",
+		}
+	}
+
+	waitCount := int32(1)
+	if c.Spec.SemiSync.WaitForSlaveCount != nil {
+		waitCount = *c.Spec.SemiSync.WaitForSlaveCount
+	}
+	timeout := utils.SemiSyncNoTimeout
+	if utils.ReplicationMode(c.Spec.ReplicationMode) != utils.SemiSyncStrict && c.Spec.SemiSync.Timeout != nil {
+		timeout = strconv.FormatInt(*c.Spec.SemiSync.Timeout, 10)
+	}
+	return map[string]string{
+		"rpl_semi_sync_slave_enabled":               "ON",
+		"rpl_semi_sync_master_wait_no_slave":        "ON",
+		"rpl_semi_sync_master_wait_for_slave_count": strconv.Itoa(int(waitCount)),
+		"rpl_semi_sync_master_timeout":              timeout,
+	}
+}
+
 // addKVConfigsToSection add a map[string]string to a ini.Section
 func addKVConfigsToSection(s *ini.Section, extraMysqld ...map[string]string) {
 	var log = logf.Log.WithName("mysqlcluster.syncer.addKVConfigsToSection")
@@ -0,0 +1,88 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlcluster"
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+func TestBuildReplicationConfigs(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     utils.ReplicationMode
+		semiSync apiv1alpha1.SemiSyncOpts
+		want     map[string]string
+	}{
+		{
+			name: "semi-sync by default",
+			want: map[string]string{
+				"rpl_semi_sync_slave_enabled":               "ON",
+				"rpl_semi_sync_master_wait_no_slave":        "ON",
+				"rpl_semi_sync_master_wait_for_slave_count": "1",
+				"rpl_semi_sync_master_timeout":              utils.SemiSyncNoTimeout,
+			},
+		},
+		{
+			name:     "semi-sync with timeout",
+			mode:     utils.SemiSync,
+			semiSync: apiv1alpha1.SemiSyncOpts{WaitForSlaveCount: &[]int32{2}[0], Timeout: &[]int64{10000}[0]},
+			want: map[string]string{
+				"rpl_semi_sync_slave_enabled":               "ON",
+				"rpl_semi_sync_master_wait_no_slave":        "ON",
+				"rpl_semi_sync_master_wait_for_slave_count": "2",
+				"rpl_semi_sync_master_timeout":              "10000",
+			},
+		},
+		{
+			name:     "strict semi-sync never times out",
+			mode:     utils.SemiSyncStrict,
+			semiSync: apiv1alpha1.SemiSyncOpts{WaitForSlaveCount: &[]int32{2}[0], Timeout: &[]int64{10000}[0]},
+			want: map[string]string{
+				"rpl_semi_sync_slave_enabled":               "ON",
+				"rpl_semi_sync_master_wait_no_slave":        "ON",
+				"rpl_semi_sync_master_wait_for_slave_count": "2",
+				"rpl_semi_sync_master_timeout":              utils.SemiSyncNoTimeout,
+			},
+		},
+		{
+			name:     "async",
+			mode:     utils.Async,
+			semiSync: apiv1alpha1.SemiSyncOpts{WaitForSlaveCount: &[]int32{2}[0], Timeout: &[]int64{10000}[0]},
+			want: map[string]string{
+				"rpl_semi_sync_slave_enabled":        "OFF",
+				"rpl_semi_sync_master_wait_no_slave": "OFF",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := mysqlcluster.New(&apiv1alpha1.MysqlCluster{
+				Spec: apiv1alpha1.MysqlClusterSpec{
+					ReplicationMode: string(tt.mode),
+					SemiSync:        tt.semiSync,
+				},
+			})
+			assert.Equal(t, tt.want, buildReplicationConfigs(cluster))
+		})
+	}
+}
@@ -0,0 +1,526 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-ini/ini"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/internal"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlcluster"
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+// mysqlDynamicVariables are the variables can be changed online in both 5.7 and 8.0.
+var mysqlDynamicVariables = map[string]bool{
+	"binlog_cache_size":                true,
+	"binlog_rows_query_log_events":     true,
+	"bulk_insert_buffer_size":          true,
+	"connect_timeout":                  true,
+	"event_scheduler":                  true,
+	"general_log":                      true,
+	"group_concat_max_len":             true,
+	"innodb_adaptive_hash_index":       true,
+	"innodb_deadlock_detect":           true,
+	"innodb_flush_log_at_trx_commit":   true,
+	"innodb_io_capacity":               true,
+	"innodb_io_capacity_max":           true,
+	"innodb_lock_wait_timeout":         true,
+	"innodb_max_dirty_pages_pct":       true,
+	"innodb_online_alter_log_max_size": true,
+	"innodb_print_all_deadlocks":       true,
+	"innodb_stats_on_metadata":         true,
+	"innodb_thread_concurrency":        true,
+	"interactive_timeout":              true,
+	"join_buffer_size":                 true,
+	"key_buffer_size":                  true,
+	"lock_wait_timeout":                true,
+	"log_error_verbosity":              true,
+	"log_queries_not_using_indexes":    true,
+	"log_slow_admin_statements":        true,
+	"log_slow_slave_statements":        true,
+	"long_query_time":                  true,
+	"max_allowed_packet":               true,
+	"max_binlog_size":                  true,
+	"max_connect_errors":               true,
+	"max_connections":                  true,
+	"max_execution_time":               true,
+	"max_heap_table_size":              true,
+	"max_user_connections":             true,
+	"min_examined_row_limit":           true,
+	"net_read_timeout":                 true,
+	"net_write_timeout":                true,
+	"optimizer_switch":                 true,
+	"read_buffer_size":                 true,
+	"read_rnd_buffer_size":             true,
+	"slave_net_timeout":                true,
+	"slow_query_log":                   true,
+	"sort_buffer_size":                 true,
+	"sql_mode":                         true,
+	"sync_binlog":                      true,
+	"table_definition_cache":           true,
+	"table_open_cache":                 true,
+	"thread_cache_size":                true,
+	"tmp_table_size":                   true,
+	"wait_timeout":                     true,
+}
+
+// mysql57DynamicVariables are the variables can be changed online only in 5.7.
+var mysql57DynamicVariables = map[string]bool{
+	"expire_logs_days": true,
+}
+
+// mysql80DynamicVariables are the variables can be changed online only in 8.0.
+var mysql80DynamicVariables = map[string]bool{
+	"binlog_expire_logs_seconds":   true,
+	"innodb_parallel_read_threads": true,
+}
+
+// mysqlAdjustedVariables are the variables whose values are adjusted by mysql or changed
+// by xenon at runtime, so the live values can't be compared with the configs.
+var mysqlAdjustedVariables = map[string]bool{
+	"innodb_buffer_pool_size":                   true,
+	"log_bin":                                   true,
+	"open_files_limit":                          true,
+	"read_only":                                 true,
+	"super_read_only":                           true,
+	"rpl_semi_sync_master_enabled":              true,
+	"rpl_semi_sync_master_timeout":              true,
+	"rpl_semi_sync_master_wait_for_slave_count": true,
+	"rpl_semi_sync_master_wait_no_slave":        true,
+	"rpl_semi_sync_slave_enabled":               true,
+}
+
+// xenonManagedVariables are the variables set by xenon according to the raft role, see the
+// source-sysvars and replica-sysvars in the xenon config.
+var xenonManagedVariables = map[string]bool{
+	"innodb_flush_log_at_trx_commit": true,
+	"sync_binlog":                    true,
+}
+
+// xenonRoleVariables are the values xenon sets for each raft role, the same as the master-sysvars
+// and slave-sysvars in the xenon config, and `default` of the leader is the compiled default value.
+var xenonRoleVariables = map[string]map[string]string{
+	string(utils.Leader): {
+		"innodb_flush_log_at_trx_commit": "1",
+		"sync_binlog":                    "1",
+		"tokudb_fsync_log_period":        "0",
+	},
+	string(utils.Follower): {
+		"innodb_flush_log_at_trx_commit": "1",
+		"sync_binlog":                    "1000",
+		"tokudb_fsync_log_period":        "1000",
+	},
+}
+
+// isXenonManagedVariable checks whether the variable is set by xenon, tokudb_fsync_log_period
+// is also managed if the cluster uses TokuDB.
+func isXenonManagedVariable(name string, tokudb bool) bool {
+	return xenonManagedVariables[name] || (tokudb && name == "tokudb_fsync_log_period")
+}
+
+// xenonVariables returns the values of the variables set by xenon for the raft role,
+// returns nil if the role doesn't have fixed values, such as CANDIDATE.
+func xenonVariables(role string, tokudb bool) map[string]string {
+	vars, ok := xenonRoleVariables[role]
+	if !ok {
+		return nil
+	}
+	wants := map[string]string{}
+	for name, val := range vars {
+		if isXenonManagedVariable(name, tokudb) {
+			wants[name] = val
+		}
+	}
+	return wants
+}
+
+// variableName returns the variable name of the config key.
+func variableName(key string) string {
+	name := strings.ToLower(underscorekey(strings.TrimSpace(key)))
+	return strings.TrimPrefix(name, "loose_")
+}
+
+// isDynamicVariable checks whether the config key can be changed online in the mysql version.
+func isDynamicVariable(version, key string) bool {
+	name := variableName(key)
+	if mysqlDynamicVariables[name] {
+		return true
+	}
+	if strings.HasPrefix(version, "8.") {
+		return mysql80DynamicVariables[name]
+	}
+	return mysql57DynamicVariables[name]
+}
+
+// normalizeVariableValue converts the value into a comparable form,
+// such as `16M` to `16777216`, `ON` to `1` and `2.000000` to `2`.
+func normalizeVariableValue(val string) string {
+	val = strings.ToLower(strings.Trim(strings.TrimSpace(val), "\"'"))
+	switch val {
+	case "on", "true":
+		return "1"
+	case "off", "false":
+		return "0"
+	}
+	if bytes, ok := variableBytes(val); ok {
+		return strconv.FormatUint(bytes, 10)
+	}
+	if f, err := strconv.ParseFloat(val, 64); err == nil {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	if strings.Contains(val, ",") {
+		items := strings.Split(val, ",")
+		for i := range items {
+			items[i] = strings.TrimSpace(items[i])
+		}
+		sort.Strings(items)
+		return strings.Join(items, ",")
+	}
+	return val
+}
+
+// variableBytes parses the size with a unit of K, M or G as bytes.
+func variableBytes(val string) (uint64, bool) {
+	if len(val) < 2 {
+		return 0, false
+	}
+	var unit uint64
+	switch val[len(val)-1] {
+	case 'k':
+		unit = 1 << 10
+	case 'm':
+		unit = 1 << 20
+	case 'g':
+		unit = 1 << 30
+	default:
+		return 0, false
+	}
+	nums, err := strconv.ParseUint(val[:len(val)-1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return nums * unit, true
+}
+
+// variableArg returns the argument to set the variable, the numeric variables
+// can't be set with string.
+func variableArg(val string) interface{} {
+	val = strings.Trim(strings.TrimSpace(val), "\"'")
+	if bytes, ok := variableBytes(strings.ToLower(val)); ok {
+		return bytes
+	}
+	if i, err := strconv.ParseInt(val, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(val, 64); err == nil {
+		return f
+	}
+	return val
+}
+
+// staticConfigRev returns the hash of the configs without the dynamic variables,
+// the pods only need to restart when it changes.
+func staticConfigRev(version string, data map[string]string) (string, error) {
+	files := make([]string, 0, len(data))
+	for file := range data {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	var buf bytes.Buffer
+	for _, file := range files {
+		cfg, err := ini.LoadSources(ini.LoadOptions{IgnoreInlineComment: true, AllowBooleanKeys: true}, []byte(data[file]))
+		if err != nil {
+			return "", fmt.Errorf("failed to load %s, err: %s", file, err)
+		}
+		for _, sec := range cfg.Sections() {
+			for _, key := range sec.KeyStrings() {
+				if isDynamicVariable(version, key) {
+					sec.DeleteKey(key)
+				}
+			}
+		}
+		buf.WriteString(file)
+		if _, err := cfg.WriteTo(&buf); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(buf.Bytes()))[:16], nil
+}
+
+// applyDynamicVariables sets the changed dynamic variables of mysqlConf and pluginConf online,
+// resets the variables removed from the configs, and records the changed static variables
+// which take effect after restart.
+func (s *StatusSyncer) applyDynamicVariables(sqlRunner internal.SQLRunner, node *apiv1alpha1.NodeStatus) error {
+	version := node.Version
+	if version == "" {
+		version = s.Spec.MysqlVersion
+	}
+	wants := s.dynamicVariableWants()
+	xenonWants := xenonVariables(node.RaftStatus.Role, s.Spec.MysqlOpts.InitTokuDB)
+	names := make([]string, 0, len(wants)+len(xenonWants)+len(node.AppliedVariables))
+	for name := range wants {
+		names = append(names, name)
+	}
+	for name := range xenonWants {
+		names = append(names, name)
+	}
+	for name := range node.AppliedVariables {
+		if _, ok := wants[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	lives, err := internal.GetGlobalVariables(sqlRunner, names)
+	if err != nil {
+		return err
+	}
+	sets, resets, pending := variableChanges(version, wants, lives, node.AppliedVariables)
+	persist := strings.HasPrefix(version, "8.")
+	for _, name := range sets {
+		s.log.Info("apply the dynamic variable online", "node", node.Name, "name", name, "from", lives[name], "to", wants[name])
+		if err := internal.SetGlobalVariable(sqlRunner, name, variableArg(wants[name]), persist); err != nil {
+			return fmt.Errorf("failed to set %s: %s", name, err)
+		}
+	}
+	for _, name := range resets {
+		s.log.Info("reset the dynamic variable removed from the configs", "node", node.Name, "name", name)
+		if err := internal.ResetGlobalVariable(sqlRunner, name, persist); err != nil {
+			return fmt.Errorf("failed to reset %s: %s", name, err)
+		}
+	}
+	// The variables managed by xenon are not persisted, xenon sets them again on the next role change.
+	for _, name := range xenonVariableChanges(xenonWants, lives) {
+		s.log.Info("correct the variable managed by xenon", "node", node.Name, "name", name,
+			"role", node.RaftStatus.Role, "from", lives[name], "to", xenonWants[name])
+		if node.RaftStatus.Role == string(utils.Leader) {
+			err = internal.ResetGlobalVariable(sqlRunner, name, false)
+		} else {
+			err = internal.SetGlobalVariable(sqlRunner, name, variableArg(xenonWants[name]), false)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to set %s: %s", name, err)
+		}
+	}
+	node.AppliedVariables = appliedVariables(version, wants, lives)
+	node.PendingRestart = pending
+	return nil
+}
+
+// dynamicVariableWants returns the variables of mysqlConf and pluginConf which the operator sets online.
+func (s *StatusSyncer) dynamicVariableWants() map[string]string {
+	// The operator adjusts some configs, such as innodb_buffer_pool_size.
+	cluster := mysqlcluster.New(s.Unwrap().DeepCopy())
+	cluster.EnsureMysqlConf()
+
+	wants := map[string]string{}
+	for _, conf := range []apiv1alpha1.MysqlConf{cluster.Spec.MysqlOpts.MysqlConf, cluster.Spec.MysqlOpts.PluginConf} {
+		for key, val := range conf {
+			name := variableName(key)
+			// Setting them online would be reverted by xenon on the next role change.
+			if isXenonManagedVariable(name, s.Spec.MysqlOpts.InitTokuDB) {
+				continue
+			}
+			wants[name] = val
+		}
+	}
+	return wants
+}
+
+// variableChanges compares the live variables with the wanted ones, returns the dynamic variables
+// to set, the applied variables removed from the configs to reset, and the changed static variables.
+func variableChanges(version string, wants, lives, applied map[string]string) (sets, resets, pending []string) {
+	for name, want := range wants {
+		live, ok := lives[name]
+		if !ok || mysqlAdjustedVariables[name] || normalizeVariableValue(live) == normalizeVariableValue(want) {
+			continue
+		}
+		if !isDynamicVariable(version, name) {
+			pending = append(pending, name)
+			continue
+		}
+		sets = append(sets, name)
+	}
+	for name := range applied {
+		if _, ok := wants[name]; ok {
+			continue
+		}
+		if _, ok := lives[name]; ok && isDynamicVariable(version, name) {
+			resets = append(resets, name)
+		}
+	}
+	sort.Strings(sets)
+	sort.Strings(resets)
+	sort.Strings(pending)
+	return sets, resets, pending
+}
+
+// xenonVariableChanges returns the variables managed by xenon whose live values differ from the
+// values of the raft role.
+func xenonVariableChanges(xenonWants, lives map[string]string) []string {
+	var changes []string
+	for name, want := range xenonWants {
+		if live, ok := lives[name]; ok && normalizeVariableValue(live) != normalizeVariableValue(want) {
+			changes = append(changes, name)
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
+
+// appliedVariables returns the dynamic variables whose values are ensured by the operator.
+func appliedVariables(version string, wants, lives map[string]string) map[string]string {
+	applied := map[string]string{}
+	for name, want := range wants {
+		if _, ok := lives[name]; ok && !mysqlAdjustedVariables[name] && isDynamicVariable(version, name) {
+			applied[name] = want
+		}
+	}
+	if len(applied) == 0 {
+		return nil
+	}
+	return applied
+}
+
+// getMysqlConfigs returns the variables in the mysqld section of the configmap, and
+// the revision of the static configs.
+func (s *StatusSyncer) getMysqlConfigs(ctx context.Context) (map[string]string, string, error) {
+	cm := &corev1.ConfigMap{}
+	if err := s.cli.Get(ctx, client.ObjectKey{Name: s.GetNameForResource(utils.ConfigMap), Namespace: s.Namespace}, cm); err != nil {
+		return nil, "", err
+	}
+	confs := map[string]string{}
+	for _, file := range []string{"my.cnf", utils.PluginConfigs} {
+		data, ok := cm.Data[file]
+		if !ok {
+			continue
+		}
+		cfg, err := ini.LoadSources(ini.LoadOptions{IgnoreInlineComment: true, AllowBooleanKeys: true}, []byte(data))
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to load %s, err: %s", file, err)
+		}
+		for _, key := range cfg.Section("mysqld").Keys() {
+			confs[variableName(key.Name())] = key.Value()
+		}
+	}
+	return confs, cm.Annotations[utils.AnnotationStaticConfigRev], nil
+}
+
+// checkConfigDrift compares the live variables of the node with the configs, and records
+// the drifted variables in the ConfigDrift condition. It runs before the dynamic variables
+// are applied, so that the drifts corrected by the operator are still reported.
+func (s *StatusSyncer) checkConfigDrift(sqlRunner internal.SQLRunner, pod *corev1.Pod, node *apiv1alpha1.NodeStatus,
+	confs map[string]string, configRev string) error {
+	version := node.Version
+	if version == "" {
+		version = s.Spec.MysqlVersion
+	}
+	xenonWants := xenonVariables(node.RaftStatus.Role, s.Spec.MysqlOpts.InitTokuDB)
+	names := make([]string, 0, len(confs)+len(xenonWants))
+	for name := range confs {
+		names = append(names, name)
+	}
+	for name := range xenonWants {
+		if _, ok := confs[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	lives, err := internal.GetGlobalVariables(sqlRunner, names)
+	if err != nil {
+		return err
+	}
+	// The static configs changed after the pod started take effect after restart.
+	restartPending := pod.Annotations["config_rev"] != configRev
+	drifts := configDrifts(version, s.Spec.MysqlOpts.InitTokuDB, node.RaftStatus.Role, lives, confs,
+		node.AppliedVariables, restartPending, node.PendingRestart)
+
+	status, msg := corev1.ConditionFalse, ""
+	if len(drifts) > 0 {
+		status, msg = corev1.ConditionTrue, strings.Join(drifts, ", ")
+	}
+	if s.setNodeCondition(node, apiv1alpha1.NodeConditionConfigDrift, status, msg) && status == corev1.ConditionTrue {
+		s.recorder.Eventf(s.Unwrap(), corev1.EventTypeWarning, "ConfigDrift",
+			"the variables of node %s differ from the configs: %s", node.Name, msg)
+	}
+	return nil
+}
+
+// configDrifts returns the variables whose live values differ from the configs. The dynamic variables
+// are compared with the values applied by the operator last time, so the changed configs not applied
+// yet are not reported. The variables managed by xenon are compared with the values of the raft role.
+func configDrifts(version string, tokudb bool, role string, lives, confs, applied map[string]string,
+	restartPending bool, pendingRestart []string) []string {
+	xenonWants := xenonVariables(role, tokudb)
+	wants := make(map[string]string, len(confs)+len(xenonWants))
+	for name, val := range confs {
+		if isXenonManagedVariable(name, tokudb) {
+			continue
+		}
+		if appliedVal, ok := applied[name]; ok {
+			val = appliedVal
+		}
+		wants[name] = val
+	}
+	names := make([]string, 0, len(wants)+len(xenonWants))
+	for name := range wants {
+		names = append(names, name)
+	}
+	for name := range xenonWants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pending := map[string]bool{}
+	for _, name := range pendingRestart {
+		pending[name] = true
+	}
+
+	var drifts []string
+	for _, name := range names {
+		live, ok := lives[name]
+		if !ok || mysqlAdjustedVariables[name] {
+			continue
+		}
+		if want, ok := xenonWants[name]; ok {
+			if normalizeVariableValue(live) != normalizeVariableValue(want) {
+				drifts = append(drifts, fmt.Sprintf("%s(live: %s, %s: %s)", name, live, strings.ToLower(role), want))
+			}
+			continue
+		}
+		if normalizeVariableValue(live) == normalizeVariableValue(wants[name]) {
+			continue
+		}
+		if !isDynamicVariable(version, name) && (restartPending || pending[name]) {
+			continue
+		}
+		drifts = append(drifts, fmt.Sprintf("%s(live: %s, config: %s)", name, live, wants[name]))
+	}
+	return drifts
+}
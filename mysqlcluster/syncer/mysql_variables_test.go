@@ -0,0 +1,205 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeVariableValue(t *testing.T) {
+	tests := []struct {
+		live string
+		want string
+	}{
+		{"16777216", "16M"},
+		{"ON", "1"},
+		{"OFF", "false"},
+		{"2.000000", "2"},
+		{"STRICT_TRANS_TABLES,NO_ENGINE_SUBSTITUTION", "no_engine_substitution, strict_trans_tables"},
+	}
+	for _, tt := range tests {
+		if normalizeVariableValue(tt.live) != normalizeVariableValue(tt.want) {
+			t.Errorf("normalizeVariableValue() %s != %s", tt.live, tt.want)
+		}
+	}
+	if normalizeVariableValue("1000") == normalizeVariableValue("2000") {
+		t.Errorf("normalizeVariableValue() 1000 == 2000")
+	}
+}
+
+func TestStaticConfigRev(t *testing.T) {
+	base := map[string]string{"my.cnf": "[mysqld]\nmax_connections = 1000\ninnodb_log_file_size = 1G\n"}
+	dynamic := map[string]string{"my.cnf": "[mysqld]\nmax-connections = 2000\ninnodb_log_file_size = 1G\n"}
+	static := map[string]string{"my.cnf": "[mysqld]\nmax_connections = 1000\ninnodb_log_file_size = 2G\n"}
+
+	baseRev, err := staticConfigRev("8.0", base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rev, _ := staticConfigRev("8.0", dynamic); rev != baseRev {
+		t.Errorf("staticConfigRev() changed by the dynamic variable")
+	}
+	if rev, _ := staticConfigRev("8.0", static); rev == baseRev {
+		t.Errorf("staticConfigRev() not changed by the static variable")
+	}
+	if isDynamicVariable("5.7", "binlog_expire_logs_seconds") || !isDynamicVariable("8.0", "binlog-expire-logs-seconds") {
+		t.Errorf("isDynamicVariable() should be version aware")
+	}
+}
+
+func TestIsXenonManagedVariable(t *testing.T) {
+	for _, name := range []string{"sync_binlog", "innodb_flush_log_at_trx_commit"} {
+		if !isXenonManagedVariable(name, false) {
+			t.Errorf("isXenonManagedVariable() %s should be managed by xenon", name)
+		}
+	}
+	if isXenonManagedVariable("tokudb_fsync_log_period", false) || !isXenonManagedVariable("tokudb_fsync_log_period", true) {
+		t.Errorf("isXenonManagedVariable() tokudb_fsync_log_period should be managed only with TokuDB")
+	}
+	if isXenonManagedVariable("max_connections", true) {
+		t.Errorf("isXenonManagedVariable() max_connections should not be managed by xenon")
+	}
+}
+
+func TestConfigDrifts(t *testing.T) {
+	confs := map[string]string{
+		"sync_binlog":                    "1",
+		"innodb_flush_log_at_trx_commit": "1",
+		"max_connections":                "1024",
+		"innodb_log_file_size":           "1G",
+	}
+	tests := []struct {
+		name           string
+		role           string
+		lives          map[string]string
+		applied        map[string]string
+		restartPending bool
+		pendingRestart []string
+		want           []string
+	}{
+		{
+			name: "leader",
+			role: "LEADER",
+			lives: map[string]string{"sync_binlog": "1", "innodb_flush_log_at_trx_commit": "1",
+				"max_connections": "1024", "innodb_log_file_size": "1073741824"},
+		},
+		{
+			// xenon relaxes the durability of the followers.
+			name: "follower",
+			role: "FOLLOWER",
+			lives: map[string]string{"sync_binlog": "1000", "innodb_flush_log_at_trx_commit": "1",
+				"max_connections": "1024", "innodb_log_file_size": "1073741824"},
+		},
+		{
+			name: "leader with the follower's durability",
+			role: "LEADER",
+			lives: map[string]string{"sync_binlog": "1000", "innodb_flush_log_at_trx_commit": "2",
+				"max_connections": "1024", "innodb_log_file_size": "1073741824"},
+			want: []string{"innodb_flush_log_at_trx_commit(live: 2, leader: 1)", "sync_binlog(live: 1000, leader: 1)"},
+		},
+		{
+			name: "follower with drifts",
+			role: "FOLLOWER",
+			lives: map[string]string{"sync_binlog": "0", "innodb_flush_log_at_trx_commit": "1",
+				"max_connections": "512", "innodb_log_file_size": "536870912"},
+			want: []string{"innodb_log_file_size(live: 536870912, config: 1G)", "max_connections(live: 512, config: 1024)",
+				"sync_binlog(live: 0, follower: 1000)"},
+		},
+		{
+			// The role of the candidate is changing, the variables managed by xenon are skipped.
+			name: "candidate",
+			role: "CANDIDATE",
+			lives: map[string]string{"sync_binlog": "0", "innodb_flush_log_at_trx_commit": "2",
+				"max_connections": "1024", "innodb_log_file_size": "1073741824"},
+		},
+		{
+			// The changed config is not applied yet.
+			name: "config changed",
+			role: "FOLLOWER",
+			lives: map[string]string{"sync_binlog": "1000", "innodb_flush_log_at_trx_commit": "1",
+				"max_connections": "512", "innodb_log_file_size": "1073741824"},
+			applied: map[string]string{"max_connections": "512"},
+		},
+		{
+			name: "static variable pending restart",
+			role: "FOLLOWER",
+			lives: map[string]string{"sync_binlog": "1000", "innodb_flush_log_at_trx_commit": "1",
+				"max_connections": "512", "innodb_log_file_size": "536870912"},
+			pendingRestart: []string{"innodb_log_file_size"},
+			want:           []string{"max_connections(live: 512, config: 1024)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := configDrifts("8.0.25", false, tt.role, tt.lives, confs, tt.applied, tt.restartPending, tt.pendingRestart)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("configDrifts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestXenonVariableChanges(t *testing.T) {
+	lives := map[string]string{"sync_binlog": "1000", "innodb_flush_log_at_trx_commit": "1", "tokudb_fsync_log_period": "0"}
+	if got := xenonVariableChanges(xenonVariables("FOLLOWER", false), lives); len(got) != 0 {
+		t.Errorf("xenonVariableChanges() = %v, want nil", got)
+	}
+	want := []string{"sync_binlog"}
+	if got := xenonVariableChanges(xenonVariables("LEADER", false), lives); !reflect.DeepEqual(got, want) {
+		t.Errorf("xenonVariableChanges() = %v, want %v", got, want)
+	}
+	want = []string{"tokudb_fsync_log_period"}
+	if got := xenonVariableChanges(xenonVariables("FOLLOWER", true), lives); !reflect.DeepEqual(got, want) {
+		t.Errorf("xenonVariableChanges() = %v, want %v", got, want)
+	}
+	if got := xenonVariables("CANDIDATE", true); got != nil {
+		t.Errorf("xenonVariables() = %v, want nil", got)
+	}
+}
+
+func TestVariableChanges(t *testing.T) {
+	wants := map[string]string{
+		"max_connections":      "1024",
+		"long_query_time":      "2",
+		"innodb_log_file_size": "1G",
+	}
+	lives := map[string]string{
+		"max_connections":      "512",
+		"long_query_time":      "2.000000",
+		"innodb_log_file_size": "536870912",
+		"wait_timeout":         "60",
+	}
+	// wait_timeout was set online and removed from the configs.
+	applied := map[string]string{"long_query_time": "2", "wait_timeout": "60"}
+
+	sets, resets, pending := variableChanges("8.0.25", wants, lives, applied)
+	if !reflect.DeepEqual(sets, []string{"max_connections"}) {
+		t.Errorf("variableChanges() sets = %v, want [max_connections]", sets)
+	}
+	if !reflect.DeepEqual(resets, []string{"wait_timeout"}) {
+		t.Errorf("variableChanges() resets = %v, want [wait_timeout]", resets)
+	}
+	if !reflect.DeepEqual(pending, []string{"innodb_log_file_size"}) {
+		t.Errorf("variableChanges() pending = %v, want [innodb_log_file_size]", pending)
+	}
+
+	want := map[string]string{"max_connections": "1024", "long_query_time": "2"}
+	if got := appliedVariables("8.0.25", wants, lives); !reflect.DeepEqual(got, want) {
+		t.Errorf("appliedVariables() = %v, want %v", got, want)
+	}
+}
@@ -17,9 +17,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/go-test/deep"
 	"github.com/iancoleman/strcase"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -28,12 +31,16 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
 )
 
 const (
 	eventNormal  = "Normal"
 	eventWarning = "Warning"
 
+	redactedValue = "<redacted>"
+
 	resultNone    = controllerutil.OperationResultNone
 	resultCreated = controllerutil.OperationResultCreated
 	resultUpdated = controllerutil.OperationResultUpdated
@@ -130,7 +137,7 @@ func (s *ObjectSyncer) Sync(ctx context.Context) (SyncResult, error) {
 	}
 
 	// check deep diff
-	diff := deep.Equal(redact(s.previousObject), redact(s.Obj))
+	diff := deep.Equal(Redact(s.previousObject), Redact(s.Obj))
 
 	// don't pass to user error for owner deletion, just don't create the object
 	// nolint: gocritic
@@ -226,8 +233,8 @@ func basicEventReason(objKindName string, err error) string {
 	return fmt.Sprintf("%sSyncSuccessfull", strcase.ToCamel(objKindName))
 }
 
-// Redacts sensitive data from runtime.Object making them suitable for logging.
-func redact(obj runtime.Object) runtime.Object {
+// Redact redacts sensitive data from runtime.Object making them suitable for logging.
+func Redact(obj runtime.Object) runtime.Object {
 	switch exposed := obj.(type) {
 	case *corev1.Secret:
 		redacted := exposed.DeepCopy()
@@ -240,12 +247,58 @@ func redact(obj runtime.Object) runtime.Object {
 		redacted := exposed.DeepCopy()
 		redacted.Data = nil
 
+		return redacted
+	case *apiv1alpha1.MysqlCluster:
+		redacted := exposed.DeepCopy()
+		redacted.Spec.MysqlOpts.RootPassword = ""
+		redacted.Spec.MysqlOpts.Password = ""
+
+		return redacted
+	case *appsv1.StatefulSet:
+		redacted := exposed.DeepCopy()
+		redactPodSpec(&redacted.Spec.Template.Spec)
+
+		return redacted
+	case *batchv1.Job:
+		redacted := exposed.DeepCopy()
+		redactPodSpec(&redacted.Spec.Template.Spec)
+
+		return redacted
+	case *corev1.Pod:
+		redacted := exposed.DeepCopy()
+		redactPodSpec(&redacted.Spec)
+
 		return redacted
 	}
 
 	return obj
 }
 
+// redactPodSpec redacts the values of the sensitive env in the containers.
+func redactPodSpec(spec *corev1.PodSpec) {
+	for _, containers := range [][]corev1.Container{spec.InitContainers, spec.Containers} {
+		for i := range containers {
+			for j := range containers[i].Env {
+				env := &containers[i].Env[j]
+				if env.Value != "" && isSensitiveName(env.Name) {
+					env.Value = redactedValue
+				}
+			}
+		}
+	}
+}
+
+// isSensitiveName checks whether the name of the env or the key looks like a credential.
+func isSensitiveName(name string) bool {
+	name = strings.ToUpper(name)
+	for _, word := range []string{"PASSWORD", "PASSWD", "SECRET", "TOKEN", "ACCESSKEY", "ACCESS_KEY"} {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
+
 // Sync mutates the subject of the syncer interface using controller-runtime
 // CreateOrUpdate method, when obj is not nil. It takes care of setting owner
 // references and recording kubernetes events where appropriate.
@@ -0,0 +1,54 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+)
+
+func TestRedact(t *testing.T) {
+	secret := &corev1.Secret{Data: map[string][]byte{"root-password": []byte("pass")}}
+	assert.Nil(t, Redact(secret).(*corev1.Secret).Data)
+	assert.NotNil(t, secret.Data)
+
+	cluster := &apiv1alpha1.MysqlCluster{}
+	cluster.Spec.MysqlOpts.Password = "RadonDB@123"
+	assert.Equal(t, "", Redact(cluster).(*apiv1alpha1.MysqlCluster).Spec.MysqlOpts.Password)
+	assert.Equal(t, "RadonDB@123", cluster.Spec.MysqlOpts.Password)
+
+	sfs := &appsv1.StatefulSet{}
+	sfs.Spec.Template.Spec.Containers = []corev1.Container{{
+		Env: []corev1.EnvVar{
+			{Name: "MYSQL_ROOT_PASSWORD", Value: "pass"},
+			{Name: "S3_SECRETKEY", Value: "key"},
+			{Name: "BACKUP_PASSWORD", ValueFrom: &corev1.EnvVarSource{}},
+			{Name: "NAMESPACE", Value: "default"},
+		},
+	}}
+	env := Redact(sfs).(*appsv1.StatefulSet).Spec.Template.Spec.Containers[0].Env
+	assert.Equal(t, redactedValue, env[0].Value)
+	assert.Equal(t, redactedValue, env[1].Value)
+	assert.Equal(t, "", env[2].Value)
+	assert.Equal(t, "default", env[3].Value)
+	assert.Equal(t, "pass", sfs.Spec.Template.Spec.Containers[0].Env[0].Value)
+}
@@ -339,9 +339,9 @@ func putMySQLReadOnly(s *StatefulSetSyncer, host string) error {
 			if errStart := sqlRunner.QueryExec(internal.NewQuery("start slave;")); errStart != nil {
 				s.log.V(1).Info("start slave gotten error", "error", errStart)
 				// No2. change master and start
-				changeSql := fmt.Sprintf(`stop slave;CHANGE MASTER TO MASTER_HOST='%s', MASTER_PORT=%d, MASTER_USER='%s', MASTER_PASSWORD='%s',
-MASTER_AUTO_POSITION=1; start slave;`, buildMasterName(s), 3306, "root", cfg.Password)
-				if err2 := sqlRunner.QueryExec(internal.NewQuery(changeSql)); err2 != nil {
+				changeSql := fmt.Sprintf(`stop slave;CHANGE MASTER TO MASTER_HOST=?, MASTER_PORT=?, MASTER_USER=?, MASTER_PASSWORD=?,
+MASTER_AUTO_POSITION=1%s; start slave;`, s.GetMasterSSLOptions())
+				if err2 := sqlRunner.QueryExec(internal.NewQuery(changeSql, buildMasterName(s), utils.MysqlPort, utils.RootUser, cfg.Password)); err2 != nil {
 					s.log.V(1).Info("change master and start slave gotten error", "error", err2)
 				}
 			}
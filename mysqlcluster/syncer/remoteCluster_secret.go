@@ -0,0 +1,116 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/presslabs/controller-util/pkg/syncer"
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlcluster"
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// NewRemoteClusterSecretSyncer returns the syncer of the secret contains the script to restore from
+// the remote cluster and the credentials of the remote cluster. The script reads the credentials from
+// the mounted files, so they are never parsed as shell code.
+func NewRemoteClusterSecretSyncer(cli client.Client, c *mysqlcluster.MysqlCluster) syncer.Interface {
+
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      c.GetNameForResource(utils.RemoteCluster),
+			Namespace: c.Namespace,
+			Labels:    c.GetLabels(),
+		},
+	}
+
+	return syncer.NewObjectSyncer("Secret", c.Unwrap(), secret, cli, func() error {
+		if int(*c.Spec.Replicas) == 0 {
+			return nil
+		}
+
+		if c.Spec.RemoteCluster == nil {
+			return nil
+		}
+
+		data, err := buildRemoteClusterData(cli, c)
+		if err != nil {
+			return fmt.Errorf("failed to build remote cluster metadata: %s", err)
+		}
+
+		secret.Data = data
+		return nil
+	})
+}
+
+// buildRemoteClusterData returns the data of the remote cluster secret, which contains the
+// restore script and the files of the credentials and the CA read by the script.
+func buildRemoteClusterData(cli client.Client, c *mysqlcluster.MysqlCluster) (map[string][]byte, error) {
+	// get secret for remote cluster
+	secretName := fmt.Sprintf("%s-secret", c.Spec.RemoteCluster.Name)
+	// use secretName to get secret
+	secret := &corev1.Secret{}
+	if err := cli.Get(context.TODO(), client.ObjectKey{Namespace: c.Spec.RemoteCluster.NameSpace, Name: secretName}, secret); err != nil {
+		return nil, fmt.Errorf("failed to get secret: %s", err)
+	}
+	data := map[string][]byte{
+		"backup-user":     secret.Data["backup-user"],
+		"backup-password": secret.Data["backup-password"],
+	}
+
+	// generate the shell script from secret information
+	metaData := "#!/bin/bash\n"
+	// export Backup password to environment variable from the mounted files
+	metaData += fmt.Sprintf("export BACKUP_USER=\"$(cat %s)\"\n", path.Join(utils.RemoteClusterCMMountPath, "backup-user"))
+	metaData += fmt.Sprintf("export BACKUP_PASSWORD=\"$(cat %s)\"\n", path.Join(utils.RemoteClusterCMMountPath, "backup-password"))
+	// trust the CA of the remote cluster if it encrypts in transit
+	scheme, curlArgs := "http", ""
+	remote := mysqlcluster.New(&apiv1alpha1.MysqlCluster{})
+	if err := cli.Get(context.TODO(), client.ObjectKey{Namespace: c.Spec.RemoteCluster.NameSpace, Name: c.Spec.RemoteCluster.Name}, remote.Unwrap()); err != nil {
+		return nil, fmt.Errorf("failed to get remote cluster: %s", err)
+	}
+	if remote.EncryptInTransit() {
+		tlsSecret := &corev1.Secret{}
+		if err := cli.Get(context.TODO(), client.ObjectKey{Namespace: remote.Namespace, Name: remote.GetTLSSecretName()}, tlsSecret); err != nil {
+			return nil, fmt.Errorf("failed to get tls secret: %s", err)
+		}
+		data[utils.TlsCAFile] = tlsSecret.Data[utils.TlsCAFile]
+		scheme, curlArgs = "https", fmt.Sprintf("--cacert %s ", path.Join(utils.RemoteClusterCMMountPath, utils.TlsCAFile))
+	}
+	// use curl to download remote cluster backup script
+	serviceURL := fmt.Sprintf("%s://%s-leader.%s:%v", scheme,
+		c.Spec.RemoteCluster.Name, c.Spec.RemoteCluster.NameSpace, utils.XBackupPort)
+	metaData += fmt.Sprintf("curl %s%s %s/download|xbstream -x -C %s\n",
+		curlArgs, utils.CurlBackupAuth, serviceURL, utils.DataVolumeMountPath)
+	prepareMemoryArg := "--use-memory=" + c.GetRestorePrepareMemory()
+	metaData += strings.Join([]string{"xtrabackup", "--defaults-file=" + utils.MysqlConfVolumeMountPath + "/my.cnf", prepareMemoryArg, "--prepare", "--apply-log-only", "--target-dir=" + utils.DataVolumeMountPath}, " ")
+	metaData += "\n"
+	metaData += strings.Join([]string{"xtrabackup", "--defaults-file=" + utils.MysqlConfVolumeMountPath + "/my.cnf", prepareMemoryArg, "--prepare", "--target-dir=" + utils.DataVolumeMountPath}, " ")
+	metaData += "\nchown -R mysql.mysql " + utils.DataVolumeMountPath + "\n"
+	data["RemoteCluster.sh"] = []byte(metaData)
+	return data, nil
+}
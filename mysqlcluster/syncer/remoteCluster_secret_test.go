@@ -0,0 +1,63 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlcluster"
+)
+
+func TestBuildRemoteClusterData(t *testing.T) {
+	password := "it's'; rm -rf / #$(reboot)"
+	remote := &apiv1alpha1.MysqlCluster{ObjectMeta: metav1.ObjectMeta{Name: "remote", Namespace: "default"}}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "remote-secret", Namespace: "default"},
+		Data: map[string][]byte{
+			"backup-user":     []byte("sys_backup"),
+			"backup-password": []byte(password),
+		},
+	}
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = apiv1alpha1.AddToScheme(scheme)
+	cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(remote, secret).Build()
+	cluster := mysqlcluster.New(&apiv1alpha1.MysqlCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"},
+		Spec: apiv1alpha1.MysqlClusterSpec{
+			RemoteCluster: &apiv1alpha1.RemoteSourceStruct{Name: "remote", NameSpace: "default"},
+		},
+	})
+
+	data, err := buildRemoteClusterData(cli, cluster)
+	assert.NoError(t, err)
+	assert.Equal(t, password, string(data["backup-password"]))
+	assert.Equal(t, "sys_backup", string(data["backup-user"]))
+	// The script reads the credentials from the mounted files instead of embedding them.
+	script := string(data["RemoteCluster.sh"])
+	assert.NotContains(t, script, password)
+	assert.NotContains(t, script, "sys_backup")
+	assert.Contains(t, script, `export BACKUP_PASSWORD="$(cat /mnt/remote-cluster-cm/backup-password)"`)
+}
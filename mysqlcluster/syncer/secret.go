@@ -18,6 +18,8 @@ package syncer
 
 import (
 	"fmt"
+	"reflect"
+	"strings"
 
 	"github.com/presslabs/controller-util/pkg/rand"
 	"github.com/presslabs/controller-util/pkg/syncer"
@@ -32,8 +34,22 @@ import (
 const (
 	// The length of the secret string.
 	rStrLen = 12
+	// The suffix of the keys which store the new internal credentials during the rotation.
+	nextKeySuffix = "-next"
 )
 
+// internalCredentialKeys are the keys of the passwords which are only used inside the cluster,
+// they are rotated online without restarting the pods.
+var internalCredentialKeys = []string{
+	"operator-password",
+	"replication-password",
+	"metrics-password",
+	"donor-password",
+	"backup-password",
+	"archiver-password",
+	"internal-root-password",
+}
+
 // NewSecretSyncer returns secret syncer.
 func NewSecretSyncer(cli client.Client, c *mysqlcluster.MysqlCluster) syncer.Interface {
 	secret := &corev1.Secret{
@@ -51,6 +67,10 @@ func NewSecretSyncer(cli client.Client, c *mysqlcluster.MysqlCluster) syncer.Int
 		if secret.Data == nil {
 			secret.Data = make(map[string][]byte)
 		}
+		oldData := make(map[string][]byte, len(secret.Data))
+		for key, val := range secret.Data {
+			oldData[key] = val
+		}
 
 		secret.Data["operator-user"] = []byte(utils.OperatorUser)
 		if err := addRandomPassword(secret.Data, "operator-password"); err != nil {
@@ -83,6 +103,11 @@ func NewSecretSyncer(cli client.Client, c *mysqlcluster.MysqlCluster) syncer.Int
 			return err
 		}
 
+		secret.Data["archiver-user"] = []byte(utils.BinlogArchiverUser)
+		if err := addRandomPassword(secret.Data, "archiver-password"); err != nil {
+			return err
+		}
+
 		if err := addRandomPassword(secret.Data, "internal-root-password"); err != nil {
 			return err
 		}
@@ -98,10 +123,46 @@ func NewSecretSyncer(cli client.Client, c *mysqlcluster.MysqlCluster) syncer.Int
 		secret.Data["mysql-user"] = []byte(c.Spec.MysqlOpts.User)
 		secret.Data["mysql-password"] = []byte(c.Spec.MysqlOpts.Password)
 		secret.Data["mysql-database"] = []byte(c.Spec.MysqlOpts.Database)
-		return nil
+
+		return updateSecretRev(secret, oldData)
 	})
 }
 
+// updateSecretRev changes the revision of the secret if the data except the internal credentials
+// changed, the pods restart when the revision changes.
+func updateSecretRev(secret *corev1.Secret, oldData map[string][]byte) error {
+	if secret.Annotations == nil {
+		secret.Annotations = make(map[string]string)
+	}
+	rev := secret.Annotations[utils.AnnotationSecretRev]
+	if rev == "" {
+		// Keep the revision recorded by the running pods.
+		rev = secret.ResourceVersion
+	}
+	if rev == "" || !reflect.DeepEqual(restartData(oldData), restartData(secret.Data)) {
+		random, err := rand.AlphaNumericString(rStrLen)
+		if err != nil {
+			return err
+		}
+		rev = random
+	}
+	secret.Annotations[utils.AnnotationSecretRev] = rev
+	return nil
+}
+
+// restartData returns the secret data whose changes require restarting the pods, the internal
+// credentials and the data source derived from them are excluded as they are rotated online.
+func restartData(data map[string][]byte) map[string]string {
+	res := make(map[string]string, len(data))
+	for key, val := range data {
+		if key == "data-source" || strings.HasSuffix(key, nextKeySuffix) || utils.StringInArray(key, internalCredentialKeys) {
+			continue
+		}
+		res[key] = string(val)
+	}
+	return res
+}
+
 // addRandomPassword checks if a key exists and if not registers a random string for that key
 func addRandomPassword(data map[string][]byte, key string) error {
 	if len(data[key]) == 0 {
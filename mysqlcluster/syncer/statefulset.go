@@ -20,6 +20,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -44,6 +45,7 @@ import (
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
 	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	apiv1beta1 "github.com/radondb/radondb-mysql-kubernetes/api/v1beta1"
 	"github.com/radondb/radondb-mysql-kubernetes/internal"
 	"github.com/radondb/radondb-mysql-kubernetes/mysqlcluster"
 	"github.com/radondb/radondb-mysql-kubernetes/mysqlcluster/container"
@@ -53,6 +55,12 @@ import (
 // The wait time limit for pod upgrade.
 const waitLimit = 2 * 60 * 60
 
+// The period to check the pods during the rolling update.
+var rollingUpdateCheckPeriod = 10 * time.Second
+
+// checkSlaveStatus checks the replication of the canary, it is replaced in the tests.
+var checkSlaveStatus = internal.CheckSlaveStatus
+
 // StatefulSetSyncer used to operate statefulset.
 type StatefulSetSyncer struct {
 	*mysqlcluster.MysqlCluster
@@ -67,16 +75,28 @@ type StatefulSetSyncer struct {
 	// Secret resourceVersion.
 	sctRev string
 
+	// The resourceVersion of the secret contains the server certificate generated by the operator.
+	tlsRev string
+
 	// Mysql query runner.
 	internal.SQLRunnerFactory
 	// XenonExecutor is used to execute Xenon HTTP instructions.
 	internal.XenonExecutor
 	// logger
 	log logr.Logger
+
+	// The duration after which the cluster should be reconciled again to continue the rolling update.
+	requeueAfter time.Duration
+
+	// The backups the incremental backup the cluster restore from is based on, from the full base backup.
+	restoreBackupChain []string
+
+	// The ID of the key which decrypts the backup the cluster restore from.
+	restoreEncryptionKeyID string
 }
 
 // NewStatefulSetSyncer returns a pointer to StatefulSetSyncer.
-func NewStatefulSetSyncer(cli client.Client, c *mysqlcluster.MysqlCluster, cmRev, sctRev string, sqlRunnerFactory internal.SQLRunnerFactory, xenonExecutor internal.XenonExecutor) *StatefulSetSyncer {
+func NewStatefulSetSyncer(cli client.Client, c *mysqlcluster.MysqlCluster, cmRev, sctRev, tlsRev string, sqlRunnerFactory internal.SQLRunnerFactory, xenonExecutor internal.XenonExecutor) *StatefulSetSyncer {
 	return &StatefulSetSyncer{
 		MysqlCluster: c,
 		cli:          cli,
@@ -92,12 +112,17 @@ func NewStatefulSetSyncer(cli client.Client, c *mysqlcluster.MysqlCluster, cmRev
 		},
 		cmRev:            cmRev,
 		sctRev:           sctRev,
+		tlsRev:           tlsRev,
 		SQLRunnerFactory: sqlRunnerFactory,
 		XenonExecutor:    xenonExecutor,
 		log:              logf.Log.WithName("StatefulSetSyncer"),
 	}
 }
 
+// RequeueAfter returns the duration after which the cluster should be reconciled again,
+// zero means no need to requeue.
+func (s *StatefulSetSyncer) RequeueAfter() time.Duration { return s.requeueAfter }
+
 // Object returns the object for which sync applies.
 func (s *StatefulSetSyncer) Object() interface{} { return s.sfs }
 
@@ -268,6 +293,12 @@ func (s *StatefulSetSyncer) createOrUpdate(ctx context.Context) (controllerutil.
 			return controllerutil.OperationResultNone, err
 		}
 
+		if err = s.resolveRestoreEncryptionKeyID(ctx); err != nil {
+			return controllerutil.OperationResultNone, err
+		}
+		if err = s.resolveRestoreBackupChain(ctx); err != nil {
+			return controllerutil.OperationResultNone, err
+		}
 		if err = s.mutate(); err != nil {
 			return controllerutil.OperationResultNone, err
 		}
@@ -281,6 +312,12 @@ func (s *StatefulSetSyncer) createOrUpdate(ctx context.Context) (controllerutil.
 	// Deep copy the old statefulset from StatefulSetSyncer.
 	existing := s.sfs.DeepCopy()
 
+	if err = s.resolveRestoreEncryptionKeyID(ctx); err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+	if err = s.resolveRestoreBackupChain(ctx); err != nil {
+		return controllerutil.OperationResultNone, err
+	}
 	// Sync data from mysqlcluster.spec to statefulset.
 	if err = s.mutate(); err != nil {
 		return controllerutil.OperationResultNone, err
@@ -289,6 +326,7 @@ func (s *StatefulSetSyncer) createOrUpdate(ctx context.Context) (controllerutil.
 	// Check if statefulset changed.
 	if !s.sfsUpdated(existing) {
 		if s.podsAllUpdated(ctx) {
+			s.completeUpgrade()
 			if s.Spec.RemoteCluster != nil {
 
 				s.log.V(1).Info("update remote cluster replication")
@@ -328,6 +366,8 @@ func (s *StatefulSetSyncer) createOrUpdate(ctx context.Context) (controllerutil.
 
 // updatePod update the pods, update follower nodes first.
 // This can reduce the number of master-slave switching during the update process.
+// Every call runs the rolling update one step forward without blocking, the progress is
+// persisted in the status, and the cluster is requeued until the step finishes.
 func (s *StatefulSetSyncer) updatePod(ctx context.Context) error {
 	// currentRevision will not update with the updatedRevision when using `onDelete`.
 	// https://github.com/kubernetes/kubernetes/pull/106059
@@ -336,8 +376,53 @@ func (s *StatefulSetSyncer) updatePod(ctx context.Context) error {
 	}
 	s.log.Info("statefulSet was changed, run update")
 
+	if s.Spec.UpdateStrategy.Paused {
+		s.log.Info("can't start/continue 'update': the rolling update is paused")
+		return nil
+	}
+
+	// Reset the rolling update status when the statefulset changes again.
+	if s.Status.RollingUpdate == nil || s.Status.RollingUpdate.Revision != s.sfs.Status.UpdateRevision {
+		s.Status.RollingUpdate = &apiv1alpha1.RollingUpdateStatus{
+			Revision:     s.sfs.Status.UpdateRevision,
+			RetryTrigger: s.Annotations[utils.AnnotationRetryCanary],
+		}
+	}
+	status := s.Status.RollingUpdate
+	if status.CanaryPhase == apiv1alpha1.CanaryFailed && !s.retryCanary() {
+		s.log.Info("can't start/continue 'update': the canary failed",
+			"pod", status.CanaryPod, "message", status.Message)
+		return nil
+	}
+
+	// Continue the step in progress.
+	switch status.Phase {
+	case apiv1alpha1.RollingUpdateUpdating:
+		if done, err := s.checkUpdating(ctx); err != nil || !done {
+			return err
+		}
+		if status.CanaryPhase == apiv1alpha1.CanarySoaking {
+			s.log.Info("soaking the canary", "pod", status.CanaryPod)
+			s.startStep(apiv1alpha1.RollingUpdateSoaking, status.CurrentPods)
+			s.requeueAfter = rollingUpdateCheckPeriod
+			return nil
+		}
+		s.finishStep()
+	case apiv1alpha1.RollingUpdateSoaking:
+		if done, err := s.checkSoaking(ctx); err != nil || !done {
+			return err
+		}
+		s.finishStep()
+	case apiv1alpha1.RollingUpdateSwitching:
+		if done, err := s.checkSwitching(ctx); err != nil || !done {
+			return err
+		}
+		s.finishStep()
+	}
+
 	if s.sfs.Status.ReadyReplicas < s.sfs.Status.Replicas {
 		s.log.Info("can't start/continue 'update': waiting for all replicas are ready")
+		s.requeueAfter = rollingUpdateCheckPeriod
 		return nil
 	}
 
@@ -358,35 +443,250 @@ func (s *StatefulSetSyncer) updatePod(ctx context.Context) error {
 		return err
 	}
 	var leaderPod corev1.Pod
+	var followers []corev1.Pod
 	for _, pod := range pods.Items {
 		// Check if the pod is healthy.
-		err := wait.PollImmediate(time.Second*2, time.Second*30, func() (bool, error) {
-			s.cli.Get(ctx, client.ObjectKeyFromObject(&pod), &pod)
-			if pod.ObjectMeta.Labels["healthy"] == "yes" {
-				return true, nil
-			}
-			return false, nil
-		})
-		if err != nil {
-			return fmt.Errorf("can't start/continue 'update': pod[%s] is unhealthy", pod.Name)
+		if pod.ObjectMeta.Labels["healthy"] != "yes" {
+			s.log.Info("can't start/continue 'update': pod is unhealthy", "pod", pod.Name)
+			s.requeueAfter = rollingUpdateCheckPeriod
+			return nil
 		}
 		// Skip if pod is leader.
 		if pod.ObjectMeta.Labels["role"] == string(utils.Leader) && leaderPod.Name == "" {
 			leaderPod = pod
 			continue
 		}
-		// If pod is not leader, direct update.
-		if err := s.applyNWait(ctx, &pod); err != nil {
+		if s.needUpdate(&pod) {
+			followers = append(followers, pod)
+		}
+	}
+	// Update the followers with higher ordinal first, so the canary is chosen stably.
+	sort.SliceStable(followers, func(i, j int) bool {
+		oi, _ := utils.GetOrdinal(followers[i].Name)
+		oj, _ := utils.GetOrdinal(followers[j].Name)
+		return oi > oj
+	})
+
+	// Pre-check the data before the major version upgrade, and upgrade the pods one by one.
+	majorUpgrade := s.isMajorUpgrade(pods.Items)
+	if majorUpgrade {
+		if passed, err := s.preCheckUpgrade(&leaderPod); err != nil || !passed {
 			return err
 		}
+	} else {
+		s.resetUpgrade()
 	}
+
+	var next []corev1.Pod
+	switch {
+	case len(followers) > 0 && s.Spec.UpdateStrategy.Canary != nil && status.CanaryPhase != apiv1alpha1.CanaryPassed:
+		next = followers[:1]
+		status.CanaryPod = next[0].Name
+		status.CanaryPhase = apiv1alpha1.CanarySoaking
+		status.Message = ""
+	case len(followers) > 0:
+		// Update the followers in batches of maxUnavailable.
+		batchSize := 1
+		if !majorUpgrade && s.Spec.UpdateStrategy.MaxUnavailable != nil && *s.Spec.UpdateStrategy.MaxUnavailable > 1 {
+			batchSize = int(*s.Spec.UpdateStrategy.MaxUnavailable)
+		}
+		if batchSize > len(followers) {
+			batchSize = len(followers)
+		}
+		next = followers[:batchSize]
 	// There may be a case where Leader does not exist during the update process.
-	if leaderPod.Name != "" {
-		// Update the leader.
-		if err := s.applyNWait(ctx, &leaderPod); err != nil {
+	case leaderPod.Name != "" && s.needUpdate(&leaderPod):
+		// Switch the leader over to an upgraded follower before upgrading the old leader.
+		if majorUpgrade {
+			if target := s.getSwitchTarget(pods.Items, &leaderPod); target != nil {
+				return s.switchLeader(&leaderPod, target)
+			}
+		}
+		next = []corev1.Pod{leaderPod}
+	default:
+		return nil
+	}
+
+	names := make([]string, 0, len(next))
+	for _, pod := range next {
+		names = append(names, pod.Name)
+	}
+	s.startStep(apiv1alpha1.RollingUpdateUpdating, names)
+	for i := range next {
+		if err := s.applyUpdate(ctx, &next[i]); err != nil {
 			return err
 		}
 	}
+	s.requeueAfter = rollingUpdateCheckPeriod
+	return nil
+}
+
+// retryCanary resets the failed canary when the canary is disabled or retried by the annotation,
+// and returns whether the rolling update can continue.
+func (s *StatefulSetSyncer) retryCanary() bool {
+	status := s.Status.RollingUpdate
+	if s.Spec.UpdateStrategy.Canary == nil {
+		s.log.Info("the canary is disabled, continue the update", "pod", status.CanaryPod)
+		status.CanaryPod = ""
+		status.CanaryPhase = ""
+		status.Message = ""
+		return true
+	}
+	trigger := s.Annotations[utils.AnnotationRetryCanary]
+	if trigger == "" || trigger == status.RetryTrigger {
+		return false
+	}
+	// Watch the canary again for the soak period.
+	s.log.Info("retry the canary", "pod", status.CanaryPod)
+	status.RetryTrigger = trigger
+	status.CanaryPhase = apiv1alpha1.CanarySoaking
+	status.Message = ""
+	s.startStep(apiv1alpha1.RollingUpdateSoaking, []string{status.CanaryPod})
+	return true
+}
+
+// needUpdate checks whether the pod is not the latest revision and in the partition.
+func (s *StatefulSetSyncer) needUpdate(pod *corev1.Pod) bool {
+	return pod.ObjectMeta.Labels["controller-revision-hash"] != s.sfs.Status.UpdateRevision && s.inPartition(pod)
+}
+
+// inPartition checks whether the pod's ordinal is not less than the partition.
+func (s *StatefulSetSyncer) inPartition(pod *corev1.Pod) bool {
+	if s.Spec.UpdateStrategy.Partition == nil {
+		return true
+	}
+	ordinal, err := utils.GetOrdinal(pod.Name)
+	if err != nil {
+		return false
+	}
+	return ordinal >= int(*s.Spec.UpdateStrategy.Partition)
+}
+
+// startStep starts a rolling update step on the pods.
+func (s *StatefulSetSyncer) startStep(phase apiv1alpha1.RollingUpdatePhase, pods []string) {
+	now := metav1.Now()
+	s.Status.RollingUpdate.Phase = phase
+	s.Status.RollingUpdate.CurrentPods = pods
+	s.Status.RollingUpdate.StartedAt = &now
+}
+
+// finishStep marks the current rolling update step as finished.
+func (s *StatefulSetSyncer) finishStep() {
+	s.Status.RollingUpdate.Phase = apiv1alpha1.RollingUpdateIdle
+	s.Status.RollingUpdate.CurrentPods = nil
+	s.Status.RollingUpdate.StartedAt = nil
+	s.Status.RollingUpdate.SwitchedAt = nil
+}
+
+// checkUpdating checks whether the pods of the current step are healthy with the latest revision.
+func (s *StatefulSetSyncer) checkUpdating(ctx context.Context) (bool, error) {
+	status := s.Status.RollingUpdate
+	if status.StartedAt != nil && time.Since(status.StartedAt.Time) > time.Duration(waitLimit)*time.Second {
+		pods := status.CurrentPods
+		s.finishStep()
+		return false, fmt.Errorf("timeout waiting for pods %v updated", pods)
+	}
+	for _, name := range status.CurrentPods {
+		updated, err := s.podUpdated(ctx, name)
+		if err != nil {
+			return false, err
+		}
+		if !updated {
+			s.requeueAfter = rollingUpdateCheckPeriod
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// podUpdated checks whether the pod has restarted with the latest revision and is healthy.
+func (s *StatefulSetSyncer) podUpdated(ctx context.Context, name string) (bool, error) {
+	ordinal, err := utils.GetOrdinal(name)
+	if err != nil {
+		return false, err
+	}
+	if ordinal >= int(*s.Spec.Replicas) {
+		s.log.Info("replicas were changed, should skip", "pod", name)
+		return true, nil
+	}
+
+	pod := &corev1.Pod{}
+	if err := s.cli.Get(ctx, types.NamespacedName{Name: name, Namespace: s.sfs.Namespace}, pod); err != nil {
+		if k8serrors.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if pod.Status.Phase == corev1.PodFailed {
+		return false, fmt.Errorf("pod %s is in failed phase", pod.Name)
+	}
+
+	// fix issue#219. When 2->5 rolling update, Because of PDB, minAvaliable 50%, if Spec Replicas is 5, sfs Spec first be set to 3, then to be set 5
+	// the pod may come back with the old revision, so delete it again.
+	// https://kubernetes.io/zh/docs/tasks/run-application/configure-pdb/
+	if pod.ObjectMeta.Labels["controller-revision-hash"] != s.sfs.Status.UpdateRevision {
+		return false, s.applyUpdate(ctx, pod)
+	}
+	return pod.ObjectMeta.Labels["healthy"] == "yes", nil
+}
+
+// checkSoaking watches the canary, and returns true when the soak period ends.
+func (s *StatefulSetSyncer) checkSoaking(ctx context.Context) (bool, error) {
+	status := s.Status.RollingUpdate
+	opts := s.Spec.UpdateStrategy.Canary
+	if opts == nil {
+		// The canary is disabled during the soak period.
+		status.CanaryPhase = apiv1alpha1.CanaryPassed
+		return true, nil
+	}
+
+	pod := &corev1.Pod{}
+	if err := s.cli.Get(ctx, types.NamespacedName{Name: status.CanaryPod, Namespace: s.sfs.Namespace}, pod); err != nil {
+		return false, err
+	}
+	if err := s.checkCanary(pod, opts); err != nil {
+		status.CanaryPhase = apiv1alpha1.CanaryFailed
+		status.Message = err.Error()
+		s.finishStep()
+		return false, fmt.Errorf("canary %s failed: %s", pod.Name, err)
+	}
+
+	soak := time.Duration(opts.SoakSeconds) * time.Second
+	if status.StartedAt != nil && time.Since(status.StartedAt.Time) < soak {
+		s.requeueAfter = rollingUpdateCheckPeriod
+		return false, nil
+	}
+	status.CanaryPhase = apiv1alpha1.CanaryPassed
+	status.Message = fmt.Sprintf("canary is healthy for %ds", opts.SoakSeconds)
+	return true, nil
+}
+
+// checkCanary checks whether the canary is healthy, replicating and not lagged.
+func (s *StatefulSetSyncer) checkCanary(pod *corev1.Pod, opts *apiv1alpha1.CanaryOpts) error {
+	if pod.ObjectMeta.Labels["healthy"] != "yes" {
+		return fmt.Errorf("pod is unhealthy")
+	}
+
+	ordinal, err := utils.GetOrdinal(pod.Name)
+	if err != nil {
+		return err
+	}
+	sqlRunner, closeConn, err := s.SQLRunnerFactory(internal.NewConfigFromClusterKey(
+		s.cli, s.GetClusterKey(), utils.OperatorUser, s.GetPodHostName(ordinal)))
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+
+	maxLag := opts.MaxLagSeconds
+	isLagged, isReplicating, err := checkSlaveStatus(sqlRunner, &maxLag)
+	if isReplicating != corev1.ConditionTrue {
+		return fmt.Errorf("replication is not running: %v", err)
+	}
+	if isLagged != corev1.ConditionFalse {
+		return fmt.Errorf("replication lag is greater than %ds", maxLag)
+	}
 	return nil
 }
 
@@ -421,6 +721,10 @@ func (s *StatefulSetSyncer) mutate() error {
 	}
 	s.sfs.Spec.Template.ObjectMeta.Annotations["config_rev"] = s.cmRev
 	s.sfs.Spec.Template.ObjectMeta.Annotations["secret_rev"] = s.sctRev
+	// Mysql 5.7 cannot reload the certificates online, restart the pods to load the renewed ones.
+	if s.tlsRev != "" && !s.CanReloadTLS("") {
+		s.sfs.Spec.Template.ObjectMeta.Annotations[utils.AnnotationTLSRev] = s.tlsRev
+	}
 	if s.sfs.Labels["needUpdate"] == "true" {
 		s.NeedUpgrade = true
 	} else {
@@ -455,6 +759,25 @@ func (s *StatefulSetSyncer) mutate() error {
 // ensurePodSpec used to ensure the podspec.
 func (s *StatefulSetSyncer) ensurePodSpec() corev1.PodSpec {
 	initSidecar := container.EnsureContainer(utils.ContainerInitSidecarName, s.MysqlCluster)
+	if len(s.restoreEncryptionKeyID) != 0 {
+		optional := false
+		initSidecar.Env = append(initSidecar.Env, corev1.EnvVar{
+			Name: "RESTORE_ENCRYPTION_KEY",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: s.Spec.RestoreEncryptionKeySecret},
+					Key:                  s.restoreEncryptionKeyID,
+					Optional:             &optional,
+				},
+			},
+		})
+	}
+	if len(s.restoreBackupChain) != 0 {
+		initSidecar.Env = append(initSidecar.Env, corev1.EnvVar{
+			Name:  "RESTORE_BACKUP_CHAIN",
+			Value: strings.Join(s.restoreBackupChain, ","),
+		})
+	}
 	initMysql := container.EnsureContainer(utils.ContainerInitMysqlName, s.MysqlCluster)
 	initContainers := []corev1.Container{initSidecar, initMysql}
 
@@ -527,75 +850,22 @@ func (s *StatefulSetSyncer) updatePVC(ctx context.Context) error {
 	return nil
 }
 
-func (s *StatefulSetSyncer) applyNWait(ctx context.Context, pod *corev1.Pod) error {
+// applyUpdate deletes the pod if its revision is not the latest, the statefulset will recreate it.
+func (s *StatefulSetSyncer) applyUpdate(ctx context.Context, pod *corev1.Pod) error {
 	if s.sfs.Status.UpdateRevision == "" {
 		return fmt.Errorf("update revision is empty")
 	}
 	// Check version, if not latest, delete node.
 	if pod.ObjectMeta.Labels["controller-revision-hash"] == s.sfs.Status.UpdateRevision {
 		s.log.Info("pod is already updated", "pod name", pod.Name)
-	} else {
-		s.Status.State = apiv1alpha1.ClusterUpdateState
-		s.log.Info("updating pod", "pod", pod.Name)
-		// Try to delete pod and wait for pod restart.
-		err := wait.PollImmediate(time.Second*5, time.Minute*2, func() (bool, error) {
-			if err := s.cli.Get(ctx, types.NamespacedName{Name: pod.Name, Namespace: pod.Namespace}, pod); err != nil {
-				return false, nil
-			}
-			if *s.Spec.Replicas == 0 { // If replicas is 0, don't retry.
-				return true, nil
-			}
-			if pod.DeletionTimestamp != nil {
-				return false, nil
-			}
-			if pod.ObjectMeta.Labels["controller-revision-hash"] != s.sfs.Status.UpdateRevision {
-				if err := s.cli.Delete(ctx, pod); err != nil {
-					return false, err
-				}
-			} else {
-				return true, nil
-			}
-			return false, nil
-		})
-		if err != nil {
-			return err
-		}
+		return nil
 	}
-
-	// Wait the pod restart and healthy.
-	return wait.PollImmediate(time.Second*10, time.Duration(waitLimit)*time.Second, func() (bool, error) {
-		err := s.cli.Get(ctx, types.NamespacedName{Name: pod.Name, Namespace: pod.Namespace}, pod)
-		if err != nil && !k8serrors.IsNotFound(err) {
-			return false, err
-		}
-
-		ordinal, err := utils.GetOrdinal(pod.Name)
-		if err != nil {
-			return false, err
-		}
-		if ordinal >= int(*s.Spec.Replicas) {
-			s.log.Info("replicas were changed, should skip", "pod", pod.Name)
-			return true, nil
-		}
-
-		if pod.Status.Phase == corev1.PodFailed {
-			return false, fmt.Errorf("pod %s is in failed phase", pod.Name)
-		}
-
-		if pod.ObjectMeta.Labels["healthy"] == "yes" &&
-			pod.ObjectMeta.Labels["controller-revision-hash"] == s.sfs.Status.UpdateRevision {
-			return true, nil
-		}
-
-		// fix issue#219. When 2->5 rolling update, Because of PDB, minAvaliable 50%, if Spec Replicas is 5, sfs Spec first be set to 3, then to be set 5
-		// pod healthy is yes,but controller-revision-hash will never correct, it must return,otherwise wait for 2 hours.
-		// https://kubernetes.io/zh/docs/tasks/run-application/configure-pdb/
-		if pod.ObjectMeta.Labels["healthy"] == "yes" &&
-			pod.ObjectMeta.Labels["controller-revision-hash"] != s.sfs.Status.UpdateRevision {
-			return false, fmt.Errorf("pod %s is ready, wait next schedule", pod.Name)
-		}
-		return false, nil
-	})
+	if pod.DeletionTimestamp != nil {
+		return nil
+	}
+	s.Status.State = apiv1alpha1.ClusterUpdateState
+	s.log.Info("updating pod", "pod", pod.Name)
+	return client.IgnoreNotFound(s.cli.Delete(ctx, pod))
 }
 
 // check the backup is exist and running
@@ -714,10 +984,16 @@ func (s *StatefulSetSyncer) checkAndUpdateRemoteStatus(ctx context.Context) erro
 		//if sqlRunner.
 		if sqlRunner != nil {
 			var remoteInterPass string = ""
+			var remoteEncrypt bool
 			var errtmp error
-			if remoteInterPass, errtmp = s.getRemoteClusterInternalRootPass(ctx); errtmp != nil {
+			if remoteInterPass, remoteEncrypt, errtmp = s.getRemoteClusterInternalRootPass(ctx); errtmp != nil {
 				return errtmp
 			}
+			// The CA of the remote cluster is not mounted, so just encrypt the connection.
+			sslOptions := ""
+			if remoteEncrypt {
+				sslOptions = ", MASTER_SSL=1"
+			}
 			// change master
 			var isReplicating corev1.ConditionStatus
 			var err error
@@ -725,10 +1001,11 @@ func (s *StatefulSetSyncer) checkAndUpdateRemoteStatus(ctx context.Context) erro
 				//Notice!!! this has error, just show error message, can not return.
 				s.log.V(1).Info("slave status has gotten error", "error", err)
 				if strings.HasPrefix(err.Error(), "Slave_IO_State: connecting to master") {
-					changeSql := fmt.Sprintf(`stop slave;reset slave;CHANGE MASTER TO MASTER_HOST='%s', MASTER_PORT=%d, MASTER_USER='%s', MASTER_PASSWORD='%s',
-	MASTER_AUTO_POSITION=1; start slave;`, fmt.Sprintf("%s-leader.%s", s.Spec.RemoteCluster.Name, s.Spec.RemoteCluster.NameSpace), 3306, "root", remoteInterPass)
-					s.log.V(1).Info("change master and start slave", "sql", changeSql)
-					if err2 := sqlRunner.QueryExec(internal.NewQuery(changeSql)); err2 != nil {
+					masterHost := fmt.Sprintf("%s-leader.%s", s.Spec.RemoteCluster.Name, s.Spec.RemoteCluster.NameSpace)
+					changeSql := fmt.Sprintf(`stop slave;reset slave;CHANGE MASTER TO MASTER_HOST=?, MASTER_PORT=?, MASTER_USER=?, MASTER_PASSWORD=?,
+	MASTER_AUTO_POSITION=1%s; start slave;`, sslOptions)
+					s.log.V(1).Info("change master and start slave", "host", masterHost)
+					if err2 := sqlRunner.QueryExec(internal.NewQuery(changeSql, masterHost, utils.MysqlPort, utils.RootUser, remoteInterPass)); err2 != nil {
 						s.log.V(1).Info("change master and start slave gotten error", "error", err2)
 						return err2
 					}
@@ -740,10 +1017,11 @@ func (s *StatefulSetSyncer) checkAndUpdateRemoteStatus(ctx context.Context) erro
 				if errStart := sqlRunner.QueryExec(internal.NewQuery("start slave;")); errStart != nil {
 					s.log.V(1).Info("start slave gotten error", "error", errStart)
 					// No2. change master and start
-					changeSql := fmt.Sprintf(`stop slave;CHANGE MASTER TO MASTER_HOST='%s', MASTER_PORT=%d, MASTER_USER='%s', MASTER_PASSWORD='%s',
-	MASTER_AUTO_POSITION=1; start slave;`, fmt.Sprintf("%s-leader.%s", s.Spec.RemoteCluster.Name, s.Spec.RemoteCluster.NameSpace), 3306, "root", remoteInterPass)
-					s.log.V(1).Info("change master and start slave", "sql", changeSql)
-					if err2 := sqlRunner.QueryExec(internal.NewQuery(changeSql)); err2 != nil {
+					masterHost := fmt.Sprintf("%s-leader.%s", s.Spec.RemoteCluster.Name, s.Spec.RemoteCluster.NameSpace)
+					changeSql := fmt.Sprintf(`stop slave;CHANGE MASTER TO MASTER_HOST=?, MASTER_PORT=?, MASTER_USER=?, MASTER_PASSWORD=?,
+	MASTER_AUTO_POSITION=1%s; start slave;`, sslOptions)
+					s.log.V(1).Info("change master and start slave", "host", masterHost)
+					if err2 := sqlRunner.QueryExec(internal.NewQuery(changeSql, masterHost, utils.MysqlPort, utils.RootUser, remoteInterPass)); err2 != nil {
 						s.log.V(1).Info("change master and start slave gotten error", "error", err2)
 						return err2
 					}
@@ -757,22 +1035,136 @@ func (s *StatefulSetSyncer) checkAndUpdateRemoteStatus(ctx context.Context) erro
 }
 
 // Note!!! the remote cluster must exists.
-func (s *StatefulSetSyncer) getRemoteClusterInternalRootPass(ctx context.Context) (string, error) {
+// It also returns whether the remote cluster encrypts the replication.
+func (s *StatefulSetSyncer) getRemoteClusterInternalRootPass(ctx context.Context) (string, bool, error) {
 	cluster := &apiv1alpha1.MysqlCluster{}
 	clusterKey := types.NamespacedName{Namespace: s.Spec.RemoteCluster.NameSpace, Name: s.Spec.RemoteCluster.Name}
 	if err := s.cli.Get(context.TODO(), clusterKey, cluster); err != nil {
-		return "", err
+		return "", false, err
 	}
 
 	secret := &corev1.Secret{}
 	secretKey := client.ObjectKey{Name: mysqlcluster.New(cluster).GetNameForResource(utils.Secret), Namespace: cluster.Namespace}
 
 	if err := s.cli.Get(context.TODO(), secretKey, secret); err != nil {
-		return "", err
+		return "", false, err
 	}
 	password, ok := secret.Data["internal-root-password"]
 	if !ok {
-		return "", fmt.Errorf("internal-root-password cannot be empty")
+		return "", false, fmt.Errorf("internal-root-password cannot be empty")
+	}
+	return string(password), mysqlcluster.New(cluster).EncryptInTransit(), nil
+}
+
+// resolveRestoreEncryptionKeyID finds the ID of the key which decrypts the backup the cluster restore from,
+// the key ID is recorded in the status of the backup.
+func (s *StatefulSetSyncer) resolveRestoreEncryptionKeyID(ctx context.Context) error {
+	s.restoreEncryptionKeyID = ""
+	if len(s.Spec.RestoreEncryptionKeySecret) == 0 || len(s.Spec.RestoreFrom) == 0 {
+		return nil
+	}
+	// Keep the key used by the existing statefulset, avoid restarting the pods.
+	for _, container := range s.sfs.Spec.Template.Spec.InitContainers {
+		if container.Name != utils.ContainerInitSidecarName {
+			continue
+		}
+		for _, env := range container.Env {
+			if env.Name == "RESTORE_ENCRYPTION_KEY" && env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil &&
+				env.ValueFrom.SecretKeyRef.Name == s.Spec.RestoreEncryptionKeySecret {
+				s.restoreEncryptionKeyID = env.ValueFrom.SecretKeyRef.Key
+				return nil
+			}
+		}
+	}
+
+	backups := &apiv1beta1.BackupList{}
+	if err := s.cli.List(ctx, backups, client.InNamespace(s.Namespace)); err != nil {
+		return err
+	}
+	for _, backup := range backups.Items {
+		if keyID := getBackupEncryptionKeyID(&backup, s.Spec.RestoreFrom); len(keyID) != 0 {
+			s.restoreEncryptionKeyID = keyID
+			return nil
+		}
+	}
+
+	// The backup may be deleted, use the key if the secret contains only one.
+	secret := &corev1.Secret{}
+	if err := s.cli.Get(ctx, client.ObjectKey{Name: s.Spec.RestoreEncryptionKeySecret, Namespace: s.Namespace}, secret); err != nil {
+		return err
+	}
+	if len(secret.Data) == 1 {
+		for key := range secret.Data {
+			s.restoreEncryptionKeyID = key
+		}
+		return nil
+	}
+	return fmt.Errorf("cannot find the encryption key of the backup %s in the secret %s",
+		s.Spec.RestoreFrom, s.Spec.RestoreEncryptionKeySecret)
+}
+
+// resolveRestoreBackupChain finds the backups which the incremental backup the cluster restore from
+// is based on, the restore applies them in order before the backup.
+func (s *StatefulSetSyncer) resolveRestoreBackupChain(ctx context.Context) error {
+	s.restoreBackupChain = nil
+	if len(s.Spec.RestoreFrom) == 0 {
+		return nil
+	}
+	// Keep the chain used by the existing statefulset, avoid restarting the pods.
+	for _, container := range s.sfs.Spec.Template.Spec.InitContainers {
+		if container.Name != utils.ContainerInitSidecarName {
+			continue
+		}
+		for _, env := range container.Env {
+			if env.Name == "RESTORE_BACKUP_CHAIN" && len(env.Value) != 0 {
+				s.restoreBackupChain = strings.Split(env.Value, ",")
+				return nil
+			}
+		}
+	}
+
+	backups := &apiv1beta1.BackupList{}
+	if err := s.cli.List(ctx, backups, client.InNamespace(s.Namespace)); err != nil {
+		return err
+	}
+	for _, backup := range backups.Items {
+		if chain := getBackupChain(&backup, s.Spec.RestoreFrom); chain != nil {
+			s.restoreBackupChain = chain.Parents
+			return nil
+		}
+	}
+	return nil
+}
+
+// getBackupChain returns the backup chain of the backup with the name, nil means not found.
+func getBackupChain(backup *apiv1beta1.Backup, backupName string) *apiv1beta1.BackupChain {
+	if backup.Status.ManualBackup != nil && backup.Status.ManualBackup.BackupName == backupName {
+		return backup.Status.ManualBackup.Chain
+	}
+	for _, scheduled := range backup.Status.ScheduledBackups {
+		if scheduled.BackupName == backupName {
+			return scheduled.Chain
+		}
+	}
+	if backup.Status.BackupName == backupName {
+		return backup.Status.Chain
+	}
+	return nil
+}
+
+// getBackupEncryptionKeyID returns the encryption key ID of the backup with the name,
+// empty means not found.
+func getBackupEncryptionKeyID(backup *apiv1beta1.Backup, backupName string) string {
+	if backup.Status.ManualBackup != nil && backup.Status.ManualBackup.BackupName == backupName {
+		return backup.Status.ManualBackup.EncryptionKeyID
+	}
+	for _, scheduled := range backup.Status.ScheduledBackups {
+		if scheduled.BackupName == backupName {
+			return scheduled.EncryptionKeyID
+		}
+	}
+	if backup.Status.BackupName == backupName {
+		return backup.Status.EncryptionKeyID
 	}
-	return string(password), nil
+	return ""
 }
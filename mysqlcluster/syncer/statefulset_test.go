@@ -17,11 +17,27 @@ limitations under the License.
 package syncer
 
 import (
+	"context"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	apiv1beta1 "github.com/radondb/radondb-mysql-kubernetes/api/v1beta1"
+	"github.com/radondb/radondb-mysql-kubernetes/internal"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlcluster"
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
 )
 
 func TestStatefulSetSyncer_sfsUpdated(t *testing.T) {
@@ -145,3 +161,476 @@ func TestStatefulSetSyncer_sfsUpdated(t *testing.T) {
 		})
 	}
 }
+
+func TestStatefulSetSyncer_inPartition(t *testing.T) {
+	tests := []struct {
+		name      string
+		partition *int32
+		pod       string
+		want      bool
+	}{
+		{
+			name:      "no partition",
+			partition: nil,
+			pod:       "sample-mysql-0",
+			want:      true,
+		},
+		{
+			name:      "ordinal less than partition",
+			partition: &[]int32{2}[0],
+			pod:       "sample-mysql-1",
+			want:      false,
+		},
+		{
+			name:      "ordinal equal to partition",
+			partition: &[]int32{2}[0],
+			pod:       "sample-mysql-2",
+			want:      true,
+		},
+		{
+			name:      "invalid pod name",
+			partition: &[]int32{0}[0],
+			pod:       "sample-mysql",
+			want:      false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &StatefulSetSyncer{
+				MysqlCluster: mysqlcluster.New(&apiv1alpha1.MysqlCluster{
+					Spec: apiv1alpha1.MysqlClusterSpec{
+						UpdateStrategy: apiv1alpha1.UpdateStrategy{
+							Partition: tt.partition,
+						},
+					},
+				}),
+			}
+			pod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: tt.pod}}
+			if got := s.inPartition(pod); got != tt.want {
+				t.Errorf("StatefulSetSyncer.inPartition() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetBackupEncryptionKeyID(t *testing.T) {
+	backup := &apiv1beta1.Backup{
+		Status: apiv1beta1.BackupStatus{
+			BackupName:      "sample_2023",
+			EncryptionKeyID: "key-2023",
+			ScheduledBackups: []apiv1beta1.ScheduledBackupStatus{
+				{BackupName: "sample_2022", EncryptionKeyID: "key-2022"},
+				{BackupName: "sample_2023", EncryptionKeyID: "key-2023"},
+			},
+		},
+	}
+	tests := []struct {
+		name       string
+		backupName string
+		want       string
+	}{
+		{
+			name:       "latest backup",
+			backupName: "sample_2023",
+			want:       "key-2023",
+		},
+		{
+			name:       "scheduled backup",
+			backupName: "sample_2022",
+			want:       "key-2022",
+		},
+		{
+			name:       "not found",
+			backupName: "sample_2021",
+			want:       "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getBackupEncryptionKeyID(backup, tt.backupName); got != tt.want {
+				t.Errorf("getBackupEncryptionKeyID() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetBackupChain(t *testing.T) {
+	full := &apiv1beta1.BackupChain{Base: "sample_2022", FromLSN: "0", ToLSN: "100"}
+	incremental := &apiv1beta1.BackupChain{Base: "sample_2022", Parents: []string{"sample_2022"}, FromLSN: "100", ToLSN: "200"}
+	backup := &apiv1beta1.Backup{
+		Status: apiv1beta1.BackupStatus{
+			BackupName: "sample_2023",
+			Chain:      incremental,
+			ScheduledBackups: []apiv1beta1.ScheduledBackupStatus{
+				{BackupName: "sample_2022", Chain: full},
+				{BackupName: "sample_2023", Chain: incremental},
+			},
+		},
+	}
+	tests := []struct {
+		name       string
+		backupName string
+		want       *apiv1beta1.BackupChain
+	}{
+		{
+			name:       "incremental backup",
+			backupName: "sample_2023",
+			want:       incremental,
+		},
+		{
+			name:       "full backup",
+			backupName: "sample_2022",
+			want:       full,
+		},
+		{
+			name:       "not found",
+			backupName: "sample_2021",
+			want:       nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getBackupChain(backup, tt.backupName); got != tt.want {
+				t.Errorf("getBackupChain() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveRestoreBackupChain(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = apiv1beta1.AddToScheme(scheme)
+	backup := &apiv1beta1.Backup{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup-sample", Namespace: "default"},
+		Status: apiv1beta1.BackupStatus{
+			ScheduledBackups: []apiv1beta1.ScheduledBackupStatus{
+				{BackupName: "sample_2024", Chain: &apiv1beta1.BackupChain{
+					Base: "sample_2022", Parents: []string{"sample_2022", "sample_2023"}, FromLSN: "200", ToLSN: "300",
+				}},
+			},
+		},
+	}
+	s := &StatefulSetSyncer{
+		MysqlCluster: mysqlcluster.New(&apiv1alpha1.MysqlCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"},
+			Spec:       apiv1alpha1.MysqlClusterSpec{RestoreFrom: "sample_2024"},
+		}),
+		cli: fake.NewClientBuilder().WithScheme(scheme).WithObjects(backup).Build(),
+		sfs: &appsv1.StatefulSet{},
+	}
+	if err := s.resolveRestoreBackupChain(context.TODO()); err != nil {
+		t.Fatalf("resolveRestoreBackupChain() error = %v", err)
+	}
+	if got := strings.Join(s.restoreBackupChain, ","); got != "sample_2022,sample_2023" {
+		t.Errorf("resolveRestoreBackupChain() = %v, want %v", got, "sample_2022,sample_2023")
+	}
+
+	// The chain of the existing statefulset is kept.
+	s.sfs.Spec.Template.Spec.InitContainers = []v1.Container{{
+		Name: utils.ContainerInitSidecarName,
+		Env:  []v1.EnvVar{{Name: "RESTORE_BACKUP_CHAIN", Value: "sample_2022"}},
+	}}
+	if err := s.resolveRestoreBackupChain(context.TODO()); err != nil {
+		t.Fatalf("resolveRestoreBackupChain() error = %v", err)
+	}
+	if got := strings.Join(s.restoreBackupChain, ","); got != "sample_2022" {
+		t.Errorf("resolveRestoreBackupChain() = %v, want %v", got, "sample_2022")
+	}
+}
+
+func TestResolveRestoreEncryptionKeyID(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = apiv1beta1.AddToScheme(scheme)
+	_ = v1.AddToScheme(scheme)
+	backup := &apiv1beta1.Backup{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup-sample", Namespace: "default"},
+		Status: apiv1beta1.BackupStatus{
+			ScheduledBackups: []apiv1beta1.ScheduledBackupStatus{
+				{BackupName: "sample_2024", EncryptionKeyID: "key-2024"},
+			},
+		},
+	}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample-backup-key", Namespace: "default"},
+		Data:       map[string][]byte{"key-2023": []byte("old"), "key-2024": []byte("new")},
+	}
+	s := &StatefulSetSyncer{
+		MysqlCluster: mysqlcluster.New(&apiv1alpha1.MysqlCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"},
+			Spec:       apiv1alpha1.MysqlClusterSpec{RestoreFrom: "sample_2024", RestoreEncryptionKeySecret: "sample-backup-key"},
+		}),
+		cli: fake.NewClientBuilder().WithScheme(scheme).WithObjects(backup, secret).Build(),
+		sfs: &appsv1.StatefulSet{},
+	}
+	if err := s.resolveRestoreEncryptionKeyID(context.TODO()); err != nil {
+		t.Fatalf("resolveRestoreEncryptionKeyID() error = %v", err)
+	}
+	if s.restoreEncryptionKeyID != "key-2024" {
+		t.Errorf("resolveRestoreEncryptionKeyID() = %v, want %v", s.restoreEncryptionKeyID, "key-2024")
+	}
+
+	// The key is not recorded, and the secret contains more than one key.
+	s.Spec.RestoreFrom = "sample_2023"
+	if err := s.resolveRestoreEncryptionKeyID(context.TODO()); err == nil {
+		t.Errorf("resolveRestoreEncryptionKeyID() should fail without the key ID")
+	}
+
+	// The key of the existing statefulset is kept.
+	s.sfs.Spec.Template.Spec.InitContainers = []v1.Container{{
+		Name: utils.ContainerInitSidecarName,
+		Env: []v1.EnvVar{{Name: "RESTORE_ENCRYPTION_KEY", ValueFrom: &v1.EnvVarSource{
+			SecretKeyRef: &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: "sample-backup-key"}, Key: "key-2023"},
+		}}},
+	}}
+	if err := s.resolveRestoreEncryptionKeyID(context.TODO()); err != nil {
+		t.Fatalf("resolveRestoreEncryptionKeyID() error = %v", err)
+	}
+	if s.restoreEncryptionKeyID != "key-2023" {
+		t.Errorf("resolveRestoreEncryptionKeyID() = %v, want %v", s.restoreEncryptionKeyID, "key-2023")
+	}
+}
+
+// newRollingUpdatePod returns a healthy pod of the cluster with the revision.
+func newRollingUpdatePod(name, role, revision string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels: map[string]string{
+				"mysql.radondb.com/cluster":    "sample",
+				"app.kubernetes.io/name":       "mysql",
+				"app.kubernetes.io/instance":   "sample",
+				"app.kubernetes.io/component":  "database",
+				"app.kubernetes.io/managed-by": "mysql.radondb.com",
+				"role":                         role,
+				"healthy":                      "yes",
+				"controller-revision-hash":     revision,
+			},
+		},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{{Name: "mysql", Image: "percona/percona-server:5.7.34"}},
+		},
+	}
+}
+
+func TestUpdatePod(t *testing.T) {
+	origin := checkSlaveStatus
+	checkSlaveStatus = func(sqlRunner internal.SQLRunner, replicaLag *int32) (v1.ConditionStatus, v1.ConditionStatus, error) {
+		return v1.ConditionFalse, v1.ConditionTrue, nil
+	}
+	defer func() { checkSlaveStatus = origin }()
+
+	canary := &apiv1alpha1.CanaryOpts{SoakSeconds: 300, MaxLagSeconds: 30}
+	now := metav1.Now()
+	soaked := metav1.NewTime(now.Add(-time.Hour))
+	tests := []struct {
+		name        string
+		canary      *apiv1alpha1.CanaryOpts
+		paused      bool
+		annotations map[string]string
+		status      *apiv1alpha1.RollingUpdateStatus
+		// The pods are healthy followers with the old revision by default.
+		pods        map[string]*v1.Pod
+		want        *apiv1alpha1.RollingUpdateStatus
+		wantDeleted []string
+		wantErr     bool
+	}{
+		{
+			name:        "start the canary",
+			canary:      canary,
+			want:        &apiv1alpha1.RollingUpdateStatus{Phase: apiv1alpha1.RollingUpdateUpdating, CurrentPods: []string{"sample-mysql-2"}, CanaryPod: "sample-mysql-2", CanaryPhase: apiv1alpha1.CanarySoaking},
+			wantDeleted: []string{"sample-mysql-2"},
+		},
+		{
+			name:        "update the followers one by one",
+			want:        &apiv1alpha1.RollingUpdateStatus{Phase: apiv1alpha1.RollingUpdateUpdating, CurrentPods: []string{"sample-mysql-2"}},
+			wantDeleted: []string{"sample-mysql-2"},
+		},
+		{
+			name:   "paused",
+			paused: true,
+		},
+		{
+			name: "wait for the pods healthy",
+			pods: map[string]*v1.Pod{"sample-mysql-1": func() *v1.Pod {
+				pod := newRollingUpdatePod("sample-mysql-1", string(utils.Follower), "old")
+				pod.Labels["healthy"] = "no"
+				return pod
+			}()},
+			want: &apiv1alpha1.RollingUpdateStatus{},
+		},
+		{
+			name:   "wait for the canary updated",
+			canary: canary,
+			status: &apiv1alpha1.RollingUpdateStatus{Phase: apiv1alpha1.RollingUpdateUpdating, CurrentPods: []string{"sample-mysql-2"}, StartedAt: &now, CanaryPod: "sample-mysql-2", CanaryPhase: apiv1alpha1.CanarySoaking},
+			pods: map[string]*v1.Pod{"sample-mysql-2": func() *v1.Pod {
+				pod := newRollingUpdatePod("sample-mysql-2", string(utils.Follower), "new")
+				pod.Labels["healthy"] = "no"
+				return pod
+			}()},
+			want: &apiv1alpha1.RollingUpdateStatus{Phase: apiv1alpha1.RollingUpdateUpdating, CurrentPods: []string{"sample-mysql-2"}, CanaryPod: "sample-mysql-2", CanaryPhase: apiv1alpha1.CanarySoaking},
+		},
+		{
+			name:   "soak the canary",
+			canary: canary,
+			status: &apiv1alpha1.RollingUpdateStatus{Phase: apiv1alpha1.RollingUpdateUpdating, CurrentPods: []string{"sample-mysql-2"}, StartedAt: &now, CanaryPod: "sample-mysql-2", CanaryPhase: apiv1alpha1.CanarySoaking},
+			pods:   map[string]*v1.Pod{"sample-mysql-2": newRollingUpdatePod("sample-mysql-2", string(utils.Follower), "new")},
+			want:   &apiv1alpha1.RollingUpdateStatus{Phase: apiv1alpha1.RollingUpdateSoaking, CurrentPods: []string{"sample-mysql-2"}, CanaryPod: "sample-mysql-2", CanaryPhase: apiv1alpha1.CanarySoaking},
+		},
+		{
+			name:   "soaking",
+			canary: canary,
+			status: &apiv1alpha1.RollingUpdateStatus{Phase: apiv1alpha1.RollingUpdateSoaking, CurrentPods: []string{"sample-mysql-2"}, StartedAt: &now, CanaryPod: "sample-mysql-2", CanaryPhase: apiv1alpha1.CanarySoaking},
+			pods:   map[string]*v1.Pod{"sample-mysql-2": newRollingUpdatePod("sample-mysql-2", string(utils.Follower), "new")},
+			want:   &apiv1alpha1.RollingUpdateStatus{Phase: apiv1alpha1.RollingUpdateSoaking, CurrentPods: []string{"sample-mysql-2"}, CanaryPod: "sample-mysql-2", CanaryPhase: apiv1alpha1.CanarySoaking},
+		},
+		{
+			name:        "canary passed",
+			canary:      canary,
+			status:      &apiv1alpha1.RollingUpdateStatus{Phase: apiv1alpha1.RollingUpdateSoaking, CurrentPods: []string{"sample-mysql-2"}, StartedAt: &soaked, CanaryPod: "sample-mysql-2", CanaryPhase: apiv1alpha1.CanarySoaking},
+			pods:        map[string]*v1.Pod{"sample-mysql-2": newRollingUpdatePod("sample-mysql-2", string(utils.Follower), "new")},
+			want:        &apiv1alpha1.RollingUpdateStatus{Phase: apiv1alpha1.RollingUpdateUpdating, CurrentPods: []string{"sample-mysql-1"}, CanaryPod: "sample-mysql-2", CanaryPhase: apiv1alpha1.CanaryPassed, Message: "canary is healthy for 300s"},
+			wantDeleted: []string{"sample-mysql-1"},
+		},
+		{
+			name:   "canary failed",
+			canary: canary,
+			status: &apiv1alpha1.RollingUpdateStatus{Phase: apiv1alpha1.RollingUpdateSoaking, CurrentPods: []string{"sample-mysql-2"}, StartedAt: &now, CanaryPod: "sample-mysql-2", CanaryPhase: apiv1alpha1.CanarySoaking},
+			pods: map[string]*v1.Pod{"sample-mysql-2": func() *v1.Pod {
+				pod := newRollingUpdatePod("sample-mysql-2", string(utils.Follower), "new")
+				pod.Labels["healthy"] = "no"
+				return pod
+			}()},
+			want:    &apiv1alpha1.RollingUpdateStatus{CanaryPod: "sample-mysql-2", CanaryPhase: apiv1alpha1.CanaryFailed, Message: "pod is unhealthy"},
+			wantErr: true,
+		},
+		{
+			name:   "stopped by the failed canary",
+			canary: canary,
+			status: &apiv1alpha1.RollingUpdateStatus{CanaryPod: "sample-mysql-2", CanaryPhase: apiv1alpha1.CanaryFailed, Message: "pod is unhealthy"},
+			pods:   map[string]*v1.Pod{"sample-mysql-2": newRollingUpdatePod("sample-mysql-2", string(utils.Follower), "new")},
+			want:   &apiv1alpha1.RollingUpdateStatus{CanaryPod: "sample-mysql-2", CanaryPhase: apiv1alpha1.CanaryFailed, Message: "pod is unhealthy"},
+		},
+		{
+			name:        "retry the failed canary",
+			canary:      canary,
+			annotations: map[string]string{utils.AnnotationRetryCanary: "1"},
+			status:      &apiv1alpha1.RollingUpdateStatus{CanaryPod: "sample-mysql-2", CanaryPhase: apiv1alpha1.CanaryFailed, Message: "pod is unhealthy"},
+			pods:        map[string]*v1.Pod{"sample-mysql-2": newRollingUpdatePod("sample-mysql-2", string(utils.Follower), "new")},
+			want:        &apiv1alpha1.RollingUpdateStatus{Phase: apiv1alpha1.RollingUpdateSoaking, CurrentPods: []string{"sample-mysql-2"}, CanaryPod: "sample-mysql-2", CanaryPhase: apiv1alpha1.CanarySoaking, RetryTrigger: "1"},
+		},
+		{
+			name:        "failed canary retried already",
+			canary:      canary,
+			annotations: map[string]string{utils.AnnotationRetryCanary: "1"},
+			status:      &apiv1alpha1.RollingUpdateStatus{CanaryPod: "sample-mysql-2", CanaryPhase: apiv1alpha1.CanaryFailed, Message: "pod is unhealthy", RetryTrigger: "1"},
+			pods:        map[string]*v1.Pod{"sample-mysql-2": newRollingUpdatePod("sample-mysql-2", string(utils.Follower), "new")},
+			want:        &apiv1alpha1.RollingUpdateStatus{CanaryPod: "sample-mysql-2", CanaryPhase: apiv1alpha1.CanaryFailed, Message: "pod is unhealthy", RetryTrigger: "1"},
+		},
+		{
+			name:        "canary disabled after failure",
+			status:      &apiv1alpha1.RollingUpdateStatus{CanaryPod: "sample-mysql-2", CanaryPhase: apiv1alpha1.CanaryFailed, Message: "pod is unhealthy"},
+			pods:        map[string]*v1.Pod{"sample-mysql-2": newRollingUpdatePod("sample-mysql-2", string(utils.Follower), "new")},
+			want:        &apiv1alpha1.RollingUpdateStatus{Phase: apiv1alpha1.RollingUpdateUpdating, CurrentPods: []string{"sample-mysql-1"}},
+			wantDeleted: []string{"sample-mysql-1"},
+		},
+		{
+			name:        "reset by the new revision",
+			canary:      canary,
+			annotations: map[string]string{utils.AnnotationRetryCanary: "1"},
+			status:      &apiv1alpha1.RollingUpdateStatus{Revision: "older", CanaryPod: "sample-mysql-2", CanaryPhase: apiv1alpha1.CanaryFailed, Message: "pod is unhealthy"},
+			want:        &apiv1alpha1.RollingUpdateStatus{Phase: apiv1alpha1.RollingUpdateUpdating, CurrentPods: []string{"sample-mysql-2"}, CanaryPod: "sample-mysql-2", CanaryPhase: apiv1alpha1.CanarySoaking, RetryTrigger: "1"},
+			wantDeleted: []string{"sample-mysql-2"},
+		},
+		{
+			name: "update the leader last",
+			pods: map[string]*v1.Pod{
+				"sample-mysql-1": newRollingUpdatePod("sample-mysql-1", string(utils.Follower), "new"),
+				"sample-mysql-2": newRollingUpdatePod("sample-mysql-2", string(utils.Follower), "new"),
+			},
+			want:        &apiv1alpha1.RollingUpdateStatus{Phase: apiv1alpha1.RollingUpdateUpdating, CurrentPods: []string{"sample-mysql-0"}},
+			wantDeleted: []string{"sample-mysql-0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cluster := &apiv1alpha1.MysqlCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default", Annotations: tt.annotations},
+				Spec: apiv1alpha1.MysqlClusterSpec{
+					Replicas:       &[]int32{3}[0],
+					MysqlOpts:      apiv1alpha1.MysqlOpts{Image: "percona/percona-server:5.7.34"},
+					UpdateStrategy: apiv1alpha1.UpdateStrategy{Canary: tt.canary, Paused: tt.paused},
+				},
+			}
+			if tt.status != nil {
+				cluster.Status.RollingUpdate = tt.status.DeepCopy()
+				if cluster.Status.RollingUpdate.Revision == "" {
+					cluster.Status.RollingUpdate.Revision = "new"
+				}
+			}
+			objs := []client.Object{
+				cluster.DeepCopy(),
+				&v1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "sample-secret", Namespace: "default"},
+					Data:       map[string][]byte{"operator-password": []byte("operator")},
+				},
+			}
+			pods := map[string]*v1.Pod{
+				"sample-mysql-0": newRollingUpdatePod("sample-mysql-0", string(utils.Leader), "old"),
+				"sample-mysql-1": newRollingUpdatePod("sample-mysql-1", string(utils.Follower), "old"),
+				"sample-mysql-2": newRollingUpdatePod("sample-mysql-2", string(utils.Follower), "old"),
+			}
+			for name, pod := range tt.pods {
+				pods[name] = pod
+			}
+			for _, pod := range pods {
+				objs = append(objs, pod)
+			}
+			scheme := runtime.NewScheme()
+			_ = clientgoscheme.AddToScheme(scheme)
+			_ = apiv1alpha1.AddToScheme(scheme)
+			s := &StatefulSetSyncer{
+				MysqlCluster: mysqlcluster.New(cluster),
+				cli:          fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build(),
+				sfs: &appsv1.StatefulSet{
+					ObjectMeta: metav1.ObjectMeta{Name: "sample-mysql", Namespace: "default"},
+					Spec:       appsv1.StatefulSetSpec{Replicas: &[]int32{3}[0]},
+					Status: appsv1.StatefulSetStatus{
+						Replicas:        3,
+						ReadyReplicas:   3,
+						UpdatedReplicas: 1,
+						UpdateRevision:  "new",
+					},
+				},
+				SQLRunnerFactory: func(cfg *internal.Config, errs ...error) (internal.SQLRunner, internal.CloseFunc, error) {
+					return nil, func() {}, nil
+				},
+				log: logr.Discard(),
+			}
+
+			err := s.updatePod(context.TODO())
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			got := s.Status.RollingUpdate
+			if tt.want == nil {
+				assert.Nil(t, got)
+			} else {
+				assert.NotNil(t, got)
+				tt.want.Revision = "new"
+				got.StartedAt = nil
+				assert.Equal(t, tt.want, got)
+			}
+
+			var deleted []string
+			for name := range pods {
+				if err := s.cli.Get(context.TODO(), client.ObjectKey{Name: name, Namespace: "default"}, &v1.Pod{}); err != nil {
+					deleted = append(deleted, name)
+				}
+			}
+			assert.ElementsMatch(t, tt.wantDeleted, deleted)
+		})
+	}
+}
@@ -18,7 +18,9 @@ package syncer
 
 import (
 	"context"
+	"crypto/x509"
 	"fmt"
+	"path"
 	"sort"
 	"strconv"
 	"strings"
@@ -26,11 +28,13 @@ import (
 
 	"github.com/presslabs/controller-util/pkg/syncer"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/selection"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
@@ -48,6 +52,9 @@ import (
 // The retry time for check node status.
 const checkNodeStatusRetry = 3
 
+// The max time to wait for xenon promoting the candidate, the old leader is made writable again after it.
+const tryLeaderSwitchTimeout = time.Minute
+
 // StatusSyncer used to update the status.
 type StatusSyncer struct {
 	*mysqlcluster.MysqlCluster
@@ -60,16 +67,19 @@ type StatusSyncer struct {
 	internal.XenonExecutor
 	// Logger
 	log logr.Logger
+	// recorder is used to emit the events of the nodes.
+	recorder record.EventRecorder
 }
 
 // NewStatusSyncer returns a pointer to StatusSyncer.
-func NewStatusSyncer(c *mysqlcluster.MysqlCluster, cli client.Client, sqlRunnerFactory internal.SQLRunnerFactory, xenonExecutor internal.XenonExecutor) *StatusSyncer {
+func NewStatusSyncer(c *mysqlcluster.MysqlCluster, cli client.Client, sqlRunnerFactory internal.SQLRunnerFactory, xenonExecutor internal.XenonExecutor, recorder record.EventRecorder) *StatusSyncer {
 	return &StatusSyncer{
 		MysqlCluster:     c,
 		cli:              cli,
 		SQLRunnerFactory: sqlRunnerFactory,
 		XenonExecutor:    xenonExecutor,
 		log:              logf.Log.WithName("syncer.StatusSyncer"),
+		recorder:         recorder,
 	}
 }
 
@@ -134,6 +144,11 @@ func (s *StatusSyncer) Sync(ctx context.Context) (syncer.SyncResult, error) {
 				Status:             corev1.ConditionFalse,
 				LastTransitionTime: metav1.NewTime(time.Now()),
 			},
+			{
+				Type:               apiv1alpha1.ConditionPromoteBlocked,
+				Status:             corev1.ConditionFalse,
+				LastTransitionTime: metav1.NewTime(time.Now()),
+			},
 		}
 	} else {
 		errCond := apiv1alpha1.ClusterCondition{
@@ -177,6 +192,8 @@ func (s *StatusSyncer) Sync(ctx context.Context) (syncer.SyncResult, error) {
 	var PodLeader, PodTryLeader *corev1.Pod
 	PodLeader, PodTryLeader = nil, nil
 	for _, pod := range list.Items {
+		// The leader and the candidate refer to the pod, don't share the loop variable.
+		pod := pod
 		if len(pod.ObjectMeta.Labels[utils.LableRebuild]) > 0 {
 			if err := s.AutoRebuild(ctx, &pod, list.Items); err != nil {
 				s.log.Error(err, "failed to AutoRebuild", "pod", pod.Name, "namespace", pod.Namespace)
@@ -217,14 +234,9 @@ func (s *StatusSyncer) Sync(ctx context.Context) (syncer.SyncResult, error) {
 	}
 	// try leader
 	if PodTryLeader != nil {
-		if PodLeader != nil {
-			if err := s.SetLeaderReadOnly(PodLeader); err != nil {
-				s.log.Info("set leader readonly", "error", err.Error())
-			}
-		}
-		if err := s.TryLeader(ctx, PodTryLeader); err != nil {
-			s.log.Error(err, "failed to Try leader", "pod", PodTryLeader.Name, "namespace", PodTryLeader.Namespace)
-		}
+		s.reconcileTryLeader(ctx, PodLeader, PodTryLeader)
+	} else if s.Status.TryLeader != nil {
+		s.finishTryLeader(ctx, PodLeader)
 	}
 	s.Status.ReadyNodes = len(readyNodes)
 	if s.Status.ReadyNodes == int(*s.Spec.Replicas) && int(*s.Spec.Replicas) != 0 {
@@ -246,6 +258,13 @@ func (s *StatusSyncer) Sync(ctx context.Context) (syncer.SyncResult, error) {
 		}
 	}
 
+	// Don't rotate the internal credentials during the switchover.
+	if PodTryLeader == nil && s.Status.TryLeader == nil {
+		if err := s.reconcileCredentialsRotation(ctx); err != nil {
+			s.log.Error(err, "failed to rotate the internal credentials", "namespace", s.Namespace)
+		}
+	}
+
 	//update read slave status for remote cluster
 	if err := s.clusterSlaveCheck(); err != nil {
 		//Notice!!! remote Cluster slave  node fail, just show the error log, do not return here!
@@ -404,6 +423,21 @@ func (s *StatusSyncer) AutoRebuild(ctx context.Context, pod *corev1.Pod, items [
 // updateNodeStatus update the node status.
 func (s *StatusSyncer) updateNodeStatus(ctx context.Context, cli client.Client, pods []corev1.Pod) error {
 	closeCh := make(chan func())
+	confs, configRev, err := s.getMysqlConfigs(ctx)
+	if err != nil {
+		s.log.V(1).Info("failed to get mysql configs", "error", err)
+	}
+	var tlsSecret *corev1.Secret
+	var serverCert *x509.Certificate
+	if s.AutoTLSEnabled() {
+		if tlsSecret, serverCert, err = s.getTLSSecret(ctx); err != nil {
+			s.log.V(1).Info("failed to get the server certificate", "error", err)
+		}
+	}
+	// Whether all the nodes have loaded the certificates in the secret.
+	tlsLoaded := serverCert != nil
+	// Whether all the replicas connect to the leader as expected by spec.encryptInTransit.
+	replSSLReady := true
 	for _, pod := range pods {
 		podName := pod.Name
 		host := fmt.Sprintf("%s.%s.%s", podName, s.GetNameForResource(utils.HeadlessSVC), s.Namespace)
@@ -443,6 +477,7 @@ func (s *StatusSyncer) updateNodeStatus(ctx context.Context, cli client.Client,
 			defer closeConn()
 		case <-time.After(time.Second * 5):
 		}
+		tlsLoaded = tlsLoaded && sqlRunner != nil
 		if sqlRunner != nil {
 			isLagged, isReplicating, err = internal.CheckSlaveStatusWithRetry(sqlRunner, checkNodeStatusRetry, s.Spec.ReplicaLag)
 			if err != nil {
@@ -455,6 +490,42 @@ func (s *StatusSyncer) updateNodeStatus(ctx context.Context, cli client.Client,
 				s.log.V(1).Info("failed to check read only", "node", node.Name, "error", err)
 				node.Message = err.Error()
 			}
+
+			var version string
+			if err = internal.GetGlobalVariable(sqlRunner, "version", &version); err != nil {
+				s.log.V(1).Info("failed to get mysql version", "node", node.Name, "error", err)
+			} else {
+				node.Version = version
+			}
+
+			// Report the drifts before correcting them.
+			if confs != nil {
+				if err = s.checkConfigDrift(sqlRunner, &pod, node, confs, configRev); err != nil {
+					s.log.V(1).Info("failed to check config drift", "node", node.Name, "error", err)
+				}
+			}
+			if err = s.applyDynamicVariables(sqlRunner, node); err != nil {
+				s.log.V(1).Info("failed to apply dynamic variables", "node", node.Name, "error", err)
+				node.Message = err.Error()
+			}
+
+			if serverCert != nil {
+				loaded, err := s.reloadTLS(sqlRunner, &pod, node, serverCert, tlsSecret.Data[caCertKey])
+				if err != nil {
+					s.log.V(1).Info("failed to reload tls", "node", node.Name, "error", err)
+				}
+				tlsLoaded = tlsLoaded && loaded
+			}
+
+			if err = s.reconcileMasterSSL(sqlRunner, node); err != nil {
+				s.log.V(1).Info("failed to reconcile the replication ssl", "node", node.Name, "error", err)
+				replSSLReady = false
+			}
+
+			if err = s.repairReplicationCredentials(ctx, sqlRunner, node); err != nil {
+				s.log.V(1).Info("failed to repair the replication credentials", "node", node.Name, "error", err)
+			}
+
 			// move it to mysql readiness
 			// if !utils.ExistUpdateFile() &&
 			// 	node.RaftStatus.Role == string(utils.Leader) &&
@@ -477,6 +548,26 @@ func (s *StatusSyncer) updateNodeStatus(ctx context.Context, cli client.Client,
 		}
 	}
 
+	// Require ssl for the replication user after all the replicas switched to ssl.
+	if replSSLReady {
+		if err := s.reconcileReplUserSSL(); err != nil {
+			s.log.V(1).Info("failed to reconcile the ssl requirement of the replication user", "error", err)
+		}
+	}
+
+	if opts := s.Spec.BinlogArchive; opts != nil && opts.Enabled {
+		if err := s.reconcileArchiverUser(ctx); err != nil {
+			s.log.V(1).Info("failed to reconcile the binlog archiver user", "error", err)
+		}
+	}
+
+	// Move the CA rotation forward after all the nodes have loaded the certificates.
+	if tlsLoaded {
+		if err := s.trimCABundle(ctx, tlsSecret, serverCert); err != nil {
+			s.log.V(1).Info("failed to trim the CA bundle", "error", err)
+		}
+	}
+
 	// Delete node status of nodes that have been deleted.
 	if len(s.Status.Nodes) > len(pods) {
 		trimNodes := s.Status.Nodes[:len(pods)]
@@ -491,6 +582,84 @@ func (s *StatusSyncer) updateNodeStatus(ctx context.Context, cli client.Client,
 	return nil
 }
 
+// reconcileMasterSSL makes the replica connect to the leader with ssl if the cluster encrypts in transit.
+func (s *StatusSyncer) reconcileMasterSSL(sqlRunner internal.SQLRunner, node *apiv1alpha1.NodeStatus) error {
+	if node.RaftStatus.Role == string(utils.Leader) {
+		return nil
+	}
+	isReplica, allowed, err := internal.GetMasterSSLAllowed(sqlRunner)
+	if err != nil {
+		return err
+	}
+	encrypt := s.EncryptInTransit()
+	// The replica not started yet may connect to the leader without ssl.
+	if !isReplica {
+		if encrypt {
+			return fmt.Errorf("the replication of %s is not started", node.Name)
+		}
+		return nil
+	}
+	if allowed == encrypt {
+		return nil
+	}
+	s.log.Info("switch the replication ssl", "node", node.Name, "ssl", encrypt)
+	return internal.SetMasterSSL(sqlRunner, encrypt, path.Join(utils.TlsMountPath, utils.TlsCAFile))
+}
+
+// reconcileReplUserSSL requires the replication user to connect with ssl if the cluster encrypts in transit.
+func (s *StatusSyncer) reconcileReplUserSSL() error {
+	host := fmt.Sprintf("%s.%s", s.GetNameForResource(utils.LeaderService), s.Namespace)
+	sqlRunner, closeConn, err := s.SQLRunnerFactory(internal.NewConfigFromClusterKey(
+		s.cli, s.MysqlCluster.GetClusterKey(), utils.RootUser, host))
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+
+	sslType, err := internal.GetUserSSLType(sqlRunner, utils.ReplicationUser, "%")
+	if err != nil {
+		return err
+	}
+	encrypt := s.EncryptInTransit()
+	if (sslType != "") == encrypt {
+		return nil
+	}
+	s.log.Info("update the ssl requirement of the replication user", "require", encrypt)
+	return internal.SetUserRequireSSL(sqlRunner, utils.ReplicationUser, "%", encrypt)
+}
+
+// binlogArchiverPrivileges are the privileges of the binlog archiver user, which streams the
+// binlogs and flushes the current one of the leader.
+var binlogArchiverPrivileges = []string{"REPLICATION SLAVE", "REPLICATION CLIENT", "RELOAD"}
+
+// reconcileArchiverUser creates the user of the binlog archiver on the leader, the user is
+// replicated to the replicas. Its password is changed by the credentials rotation.
+func (s *StatusSyncer) reconcileArchiverUser(ctx context.Context) error {
+	secret := &corev1.Secret{}
+	if err := s.cli.Get(ctx, client.ObjectKey{Name: s.GetNameForResource(utils.Secret), Namespace: s.Namespace}, secret); err != nil {
+		return err
+	}
+	password := secret.Data["archiver-password"]
+	if len(password) == 0 {
+		return fmt.Errorf("archiver-password cannot be empty")
+	}
+
+	host := fmt.Sprintf("%s.%s", s.GetNameForResource(utils.LeaderService), s.Namespace)
+	sqlRunner, closeConn, err := s.SQLRunnerFactory(internal.NewConfigFromClusterKey(
+		s.cli, s.MysqlCluster.GetClusterKey(), utils.RootUser, host))
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+
+	exists, err := internal.CheckUserExists(sqlRunner, utils.BinlogArchiverUser)
+	if err != nil || exists {
+		return err
+	}
+	s.log.Info("create the binlog archiver user", "user", utils.BinlogArchiverUser)
+	return internal.CreateUserWithPrivileges(sqlRunner, utils.BinlogArchiverUser, "%", string(password), binlogArchiverPrivileges)
+}
+
 // getNodeStatusIndex get the node index in the status.
 func (s *StatusSyncer) getNodeStatusIndex(name string) int {
 	len := len(s.Status.Nodes)
@@ -541,6 +710,29 @@ func (s *StatusSyncer) updateNodeCondition(node *apiv1alpha1.NodeStatus, idx int
 	}
 }
 
+// setNodeCondition sets the condition found by type, appends it if not exist,
+// returns true if the status changed.
+func (s *StatusSyncer) setNodeCondition(node *apiv1alpha1.NodeStatus, condType apiv1alpha1.NodeConditionType,
+	status corev1.ConditionStatus, msg string) bool {
+	for i := range node.Conditions {
+		if node.Conditions[i].Type == condType {
+			node.Conditions[i].Message = msg
+			if node.Conditions[i].Status == status {
+				return false
+			}
+			s.updateNodeCondition(node, i, status)
+			return true
+		}
+	}
+	node.Conditions = append(node.Conditions, apiv1alpha1.NodeCondition{
+		Type:               condType,
+		Status:             status,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+		Message:            msg,
+	})
+	return true
+}
+
 // updateNodeRaftStatus Update Node RaftStatus.
 func (s *StatusSyncer) updateNodeRaftStatus(node *apiv1alpha1.NodeStatus) error {
 	isLeader := corev1.ConditionFalse
@@ -893,28 +1085,192 @@ func (s *StatusSyncer) DoRoRebuild(ctx context.Context, pod *corev1.Pod, items [
 	return nil
 }
 
-func (s *StatusSyncer) TryLeader(ctx context.Context, pod *corev1.Pod) error {
-
-	// 1. close the xenon's SemiCheck.
+// xenonTryLeader asks the xenon of the pod to be the leader, it can be replaced in tests.
+var xenonTryLeader = func(namespace, podName string) error {
 	executor, err := internal.NewPodExecutor()
 	if err != nil {
 		return err
 	}
+	return executor.XenonTryLeader(namespace, podName)
+}
 
-	err = executor.XenonTryLeader(s.Namespace, pod.Name)
-	s.log.Info("the xenon's tryleader", "pod", pod.Name)
-	delete(pod.ObjectMeta.Labels, utils.LabelTryLeader)
+// reconcileTryLeader makes the leader read-only, and promotes the candidate after it has applied
+// all the transactions of the leader, so that no committed transaction will be lost. The catch up
+// is checked in every sync instead of waiting for it, and the promotion is aborted after timeout.
+func (s *StatusSyncer) reconcileTryLeader(ctx context.Context, leader, candidate *corev1.Pod) {
+	if leader == nil {
+		s.abortTryLeader(ctx, nil, candidate, fmt.Errorf("leader not found, cannot check the gtid of %s", candidate.Name))
+		return
+	}
+	if s.Status.TryLeader == nil && len(leader.Labels[utils.LabelFenced]) != 0 {
+		s.abortTryLeader(ctx, leader, candidate, fmt.Errorf("the leader %s is fenced by a switchover", leader.Name))
+		return
+	}
+	if s.Status.TryLeader == nil || s.Status.TryLeader.Pod != candidate.Name {
+		now := metav1.NewTime(time.Now())
+		s.Status.TryLeader = &apiv1alpha1.TryLeaderStatus{Pod: candidate.Name, Leader: leader.Name, FenceTime: &now}
+	}
+	if s.Status.TryLeader.SwitchTime != nil {
+		// The tryleader label was not removed after the candidate was promoted.
+		delete(candidate.ObjectMeta.Labels, utils.LabelTryLeader)
+		if err := s.cli.Update(ctx, candidate); err != nil {
+			s.log.Error(err, "failed to remove tryleader label", "pod", candidate.Name)
+		}
+		return
+	}
+	if leader.Name != s.Status.TryLeader.Leader {
+		s.abortTryLeader(ctx, leader, candidate, fmt.Errorf("the leader changed to %s", leader.Name))
+		return
+	}
+
+	if leader.Name != candidate.Name {
+		// The readiness check of the fenced leader does not make it writable again.
+		if err := s.setFenced(ctx, leader, true); err != nil {
+			s.log.Error(err, "failed to fence the leader", "pod", leader.Name)
+		}
+		if err := s.SetLeaderReadOnly(leader); err != nil {
+			s.log.Info("set leader readonly", "error", err.Error())
+		}
+
+		caughtUp, err := s.checkCandidateCaughtUp(leader, candidate)
+		if err != nil || !caughtUp {
+			timeout := s.GetPromoteCatchUpTimeout()
+			if time.Since(s.Status.TryLeader.FenceTime.Time) <= timeout {
+				s.log.V(1).Info("waiting for the candidate catching up the leader", "pod", candidate.Name, "error", err)
+				return
+			}
+			if err == nil {
+				err = fmt.Errorf("the candidate has not applied the leader's transactions in %s", timeout)
+			}
+			s.abortTryLeader(ctx, leader, candidate, err)
+			return
+		}
+	}
+
+	s.updateClusterCondition(int(apiv1alpha1.ClIndexPromoteBlocked), apiv1alpha1.ClusterCondition{
+		Type:               apiv1alpha1.ConditionPromoteBlocked,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+	}, false)
+	now := metav1.NewTime(time.Now())
+	s.Status.TryLeader.SwitchTime = &now
+	if err := xenonTryLeader(s.Namespace, candidate.Name); err != nil {
+		s.log.Error(err, "failed to Try leader", "pod", candidate.Name, "namespace", candidate.Namespace)
+		s.abortTryLeader(ctx, leader, candidate, err)
+		return
+	}
+	s.log.Info("the xenon's tryleader", "pod", candidate.Name)
+	delete(candidate.ObjectMeta.Labels, utils.LabelTryLeader)
+	if err := s.cli.Update(ctx, candidate); err != nil {
+		s.log.Error(err, "failed to remove tryleader label", "pod", candidate.Name)
+	}
+}
+
+// finishTryLeader unfences the old leader once it is no longer the leader, or xenon does not
+// promote the candidate in time.
+func (s *StatusSyncer) finishTryLeader(ctx context.Context, leader *corev1.Pod) {
+	status := s.Status.TryLeader
+	if leader != nil && leader.Name == status.Leader && status.Leader != status.Pod &&
+		status.SwitchTime != nil && time.Since(status.SwitchTime.Time) <= tryLeaderSwitchTimeout {
+		return
+	}
+	s.unfenceLeader(ctx, leader)
+}
+
+// unfenceLeader makes the leader fenced for the candidate writable again if it is still the leader,
+// and removes its fenced label.
+func (s *StatusSyncer) unfenceLeader(ctx context.Context, leader *corev1.Pod) {
+	status := s.Status.TryLeader
+	s.Status.TryLeader = nil
+	if status == nil || status.Leader == "" || status.Leader == status.Pod {
+		return
+	}
+	if leader != nil && leader.Name == status.Leader {
+		if err := s.ResetLeaderWritable(leader); err != nil {
+			s.log.Error(err, "failed to reset leader writable", "pod", leader.Name)
+		}
+	}
+	pod := &corev1.Pod{}
+	if err := s.cli.Get(ctx, client.ObjectKey{Name: status.Leader, Namespace: s.Namespace}, pod); err != nil {
+		if !errors.IsNotFound(err) {
+			s.log.Error(err, "failed to get the fenced pod", "pod", status.Leader)
+		}
+		return
+	}
+	if err := s.setFenced(ctx, pod, false); err != nil {
+		s.log.Error(err, "failed to unfence the pod", "pod", pod.Name)
+	}
+}
+
+// checkCandidateCaughtUp returns true if the candidate has applied all the transactions of the leader.
+func (s *StatusSyncer) checkCandidateCaughtUp(leader, candidate *corev1.Pod) (bool, error) {
+	leaderRunner, closeLeader, err := s.SQLRunnerFactory(internal.NewConfigFromClusterKey(
+		s.cli, s.MysqlCluster.GetClusterKey(), utils.RootUser, s.getPodHost(leader)))
 	if err != nil {
-		return err
+		return false, err
 	}
+	defer closeLeader()
 
-	if err := s.cli.Update(ctx, pod); err != nil {
-		return err
+	candidateRunner, closeCandidate, err := s.SQLRunnerFactory(internal.NewConfigFromClusterKey(
+		s.cli, s.MysqlCluster.GetClusterKey(), utils.RootUser, s.getPodHost(candidate)))
+	if err != nil {
+		return false, err
 	}
-	return nil
+	defer closeCandidate()
+
+	return internal.CheckGtidCaughtUp(leaderRunner, candidateRunner)
+}
+
+// abortTryLeader gives up promoting the candidate, makes the leader writable again
+// and removes the tryleader label of the candidate.
+func (s *StatusSyncer) abortTryLeader(ctx context.Context, leader, candidate *corev1.Pod, reason error) {
+	s.log.Error(reason, "abort try leader", "pod", candidate.Name, "namespace", candidate.Namespace)
+	s.updateClusterCondition(int(apiv1alpha1.ClIndexPromoteBlocked), apiv1alpha1.ClusterCondition{
+		Type:               apiv1alpha1.ConditionPromoteBlocked,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.NewTime(time.Now()),
+		Reason:             "GtidNotCaughtUp",
+		Message:            fmt.Sprintf("abort promoting %s: %s", candidate.Name, reason),
+	}, true)
+
+	s.unfenceLeader(ctx, leader)
+	delete(candidate.ObjectMeta.Labels, utils.LabelTryLeader)
+	if err := s.cli.Update(ctx, candidate); err != nil {
+		s.log.Error(err, "failed to remove tryleader label", "pod", candidate.Name)
+	}
+}
+
+// setFenced adds or removes the fenced label of the pod.
+func (s *StatusSyncer) setFenced(ctx context.Context, pod *corev1.Pod, fenced bool) error {
+	if _, ok := pod.Labels[utils.LabelFenced]; ok == fenced {
+		return nil
+	}
+	if fenced {
+		if pod.Labels == nil {
+			pod.Labels = map[string]string{}
+		}
+		pod.Labels[utils.LabelFenced] = "true"
+	} else {
+		delete(pod.Labels, utils.LabelFenced)
+	}
+	return s.cli.Update(ctx, pod)
+}
+
+// getPodHost returns the host of the pod in the headless service.
+func (s *StatusSyncer) getPodHost(pod *corev1.Pod) string {
+	return fmt.Sprintf("%s.%s.%s", pod.Name, s.GetNameForResource(utils.HeadlessSVC), s.Namespace)
 }
 
 func (s *StatusSyncer) SetLeaderReadOnly(pod *corev1.Pod) error {
+	return s.setReadOnly(pod, "SET GLOBAL super_read_only=on")
+}
+
+// ResetLeaderWritable turns off the read_only and super_read_only of the leader.
+func (s *StatusSyncer) ResetLeaderWritable(pod *corev1.Pod) error {
+	return s.setReadOnly(pod, "SET GLOBAL read_only=off")
+}
+
+func (s *StatusSyncer) setReadOnly(pod *corev1.Pod, query string) error {
 	var sqlRunner internal.SQLRunner
 	closeCh := make(chan func())
 
@@ -946,7 +1302,7 @@ func (s *StatusSyncer) SetLeaderReadOnly(pod *corev1.Pod) error {
 	case <-time.After(time.Second * 5):
 	}
 	if sqlRunner != nil {
-		return sqlRunner.QueryExec(internal.NewQuery("SET GLOBAL super_read_only=on"))
+		return sqlRunner.QueryExec(internal.NewQuery(query))
 	}
 	return nil
 }
@@ -0,0 +1,244 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/internal"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlcluster"
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+// fakeSQLRunner answers the global variables of the hosts, and records the executed queries.
+type fakeSQLRunner struct {
+	host      string
+	variables map[string]map[string]string
+	execs     map[string][]string
+}
+
+// newFakeSQLRunnerFactory returns a factory of the runners which answer the global variables of the hosts.
+func newFakeSQLRunnerFactory(variables map[string]map[string]string, execs map[string][]string) internal.SQLRunnerFactory {
+	return func(cfg *internal.Config, errs ...error) (internal.SQLRunner, internal.CloseFunc, error) {
+		if len(errs) > 0 && errs[0] != nil {
+			return nil, nil, errs[0]
+		}
+		if _, ok := variables[cfg.Host]; !ok {
+			return nil, nil, fmt.Errorf("failed to connect to %s", cfg.Host)
+		}
+		return &fakeSQLRunner{host: cfg.Host, variables: variables, execs: execs}, func() {}, nil
+	}
+}
+
+func (f *fakeSQLRunner) QueryExec(query internal.Query) error {
+	f.execs[f.host] = append(f.execs[f.host], query.String())
+	return nil
+}
+
+func (f *fakeSQLRunner) QueryRow(query internal.Query, dest ...interface{}) error {
+	return f.QueryRowContext(context.TODO(), query, dest...)
+}
+
+func (f *fakeSQLRunner) QueryRowContext(ctx context.Context, query internal.Query, dest ...interface{}) error {
+	args := query.Args()
+	if query.String() != "select @@global.?;" || len(args) != 1 || len(dest) != 1 {
+		return fmt.Errorf("unexpected query %s", query.String())
+	}
+	val, ok := f.variables[f.host][args[0].(string)]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	*dest[0].(*string) = val
+	return nil
+}
+
+func (f *fakeSQLRunner) QueryRows(query internal.Query) (*sql.Rows, error) {
+	return nil, fmt.Errorf("unexpected query %s", query.String())
+}
+
+func (f *fakeSQLRunner) QueryRowsContext(ctx context.Context, query internal.Query) (*sql.Rows, error) {
+	return nil, fmt.Errorf("unexpected query %s", query.String())
+}
+
+const (
+	tryLeaderUUID   = "3e11fa47-71ca-11e1-9e33-c80aa9429562"
+	tryLeaderLeader = "sample-mysql-0.sample-mysql.default"
+	tryLeaderTarget = "sample-mysql-1.sample-mysql.default"
+)
+
+// tryLeaderFixture is the status syncer with a leader and a candidate labeled tryleader.
+type tryLeaderFixture struct {
+	*StatusSyncer
+	variables  map[string]map[string]string
+	execs      map[string][]string
+	tryLeaders []string
+}
+
+func newTryLeaderFixture(t *testing.T, candidateGtid string) *tryLeaderFixture {
+	cluster := &apiv1alpha1.MysqlCluster{ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"}}
+	for i := 0; i <= int(apiv1alpha1.ClIndexPromoteBlocked); i++ {
+		cluster.Status.Conditions = append(cluster.Status.Conditions, apiv1alpha1.ClusterCondition{Status: corev1.ConditionFalse})
+	}
+	leader := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "sample-mysql-0", Namespace: "default",
+		Labels: map[string]string{"role": string(utils.Leader)}}}
+	candidate := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "sample-mysql-1", Namespace: "default",
+		Labels: map[string]string{"role": string(utils.Follower), utils.LabelTryLeader: "true"}}}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample-secret", Namespace: "default"},
+		Data:       map[string][]byte{"internal-root-password": []byte("root")},
+	}
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = apiv1alpha1.AddToScheme(scheme)
+
+	f := &tryLeaderFixture{
+		variables: map[string]map[string]string{
+			tryLeaderLeader: {"gtid_executed": tryLeaderUUID + ":1-10"},
+			tryLeaderTarget: {"gtid_executed": candidateGtid},
+		},
+		execs: map[string][]string{},
+	}
+	f.StatusSyncer = &StatusSyncer{
+		MysqlCluster:     mysqlcluster.New(cluster),
+		cli:              fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster.DeepCopy(), leader, candidate, secret).Build(),
+		SQLRunnerFactory: newFakeSQLRunnerFactory(f.variables, f.execs),
+		log:              logr.Discard(),
+	}
+
+	oldTryLeader := xenonTryLeader
+	xenonTryLeader = func(namespace, podName string) error {
+		f.tryLeaders = append(f.tryLeaders, podName)
+		return nil
+	}
+	t.Cleanup(func() { xenonTryLeader = oldTryLeader })
+	return f
+}
+
+// reconcile runs the try leader step with the pods in the fake client.
+func (f *tryLeaderFixture) reconcile(t *testing.T) {
+	leader, candidate := f.getPod(t, "sample-mysql-0"), f.getPod(t, "sample-mysql-1")
+	if _, ok := candidate.Labels[utils.LabelTryLeader]; ok {
+		f.reconcileTryLeader(context.TODO(), leader, candidate)
+	} else if f.Status.TryLeader != nil {
+		f.finishTryLeader(context.TODO(), leader)
+	}
+}
+
+func (f *tryLeaderFixture) getPod(t *testing.T, name string) *corev1.Pod {
+	pod := &corev1.Pod{}
+	assert.NoError(t, f.cli.Get(context.TODO(), client.ObjectKey{Name: name, Namespace: "default"}, pod))
+	return pod
+}
+
+func (f *tryLeaderFixture) promoteBlocked() apiv1alpha1.ClusterCondition {
+	return f.Status.Conditions[apiv1alpha1.ClIndexPromoteBlocked]
+}
+
+func TestTryLeaderWaitCatchUp(t *testing.T) {
+	f := newTryLeaderFixture(t, tryLeaderUUID+":1-8")
+
+	f.reconcile(t)
+	assert.NotNil(t, f.Status.TryLeader)
+	assert.Equal(t, "sample-mysql-1", f.Status.TryLeader.Pod)
+	assert.Equal(t, "sample-mysql-0", f.Status.TryLeader.Leader)
+	assert.NotNil(t, f.Status.TryLeader.FenceTime)
+	assert.Nil(t, f.Status.TryLeader.SwitchTime)
+	assert.Empty(t, f.tryLeaders)
+	assert.Contains(t, f.getPod(t, "sample-mysql-0").Labels, utils.LabelFenced)
+	assert.Equal(t, []string{"SET GLOBAL super_read_only=on;"}, f.execs[tryLeaderLeader])
+	assert.Equal(t, corev1.ConditionFalse, f.promoteBlocked().Status)
+
+	// The candidate catches up in the following sync.
+	f.variables[tryLeaderTarget]["gtid_executed"] = tryLeaderUUID + ":1-10"
+	f.reconcile(t)
+	assert.Equal(t, []string{"sample-mysql-1"}, f.tryLeaders)
+	assert.NotNil(t, f.Status.TryLeader.SwitchTime)
+	assert.NotContains(t, f.getPod(t, "sample-mysql-1").Labels, utils.LabelTryLeader)
+	assert.Equal(t, corev1.ConditionFalse, f.promoteBlocked().Status)
+
+	// The old leader is kept fenced until it is no longer the leader.
+	f.reconcile(t)
+	assert.NotNil(t, f.Status.TryLeader)
+	assert.Contains(t, f.getPod(t, "sample-mysql-0").Labels, utils.LabelFenced)
+
+	leader := f.getPod(t, "sample-mysql-0")
+	leader.Labels["role"] = string(utils.Follower)
+	assert.NoError(t, f.cli.Update(context.TODO(), leader))
+	f.finishTryLeader(context.TODO(), f.getPod(t, "sample-mysql-1"))
+	assert.Nil(t, f.Status.TryLeader)
+	assert.NotContains(t, f.getPod(t, "sample-mysql-0").Labels, utils.LabelFenced)
+	assert.Len(t, f.execs[tryLeaderLeader], 2)
+}
+
+func TestTryLeaderAbort(t *testing.T) {
+	f := newTryLeaderFixture(t, tryLeaderUUID+":1-8")
+
+	f.reconcile(t)
+	assert.NotNil(t, f.Status.TryLeader)
+
+	// Pretend the leader was fenced before the catch up timeout.
+	fenceTime := metav1.NewTime(time.Now().Add(-utils.DefaultPromoteCatchUpTimeout - time.Second))
+	f.Status.TryLeader.FenceTime = &fenceTime
+	f.reconcile(t)
+	assert.Nil(t, f.Status.TryLeader)
+	assert.Empty(t, f.tryLeaders)
+	assert.Equal(t, corev1.ConditionTrue, f.promoteBlocked().Status)
+	assert.Equal(t, "GtidNotCaughtUp", f.promoteBlocked().Reason)
+	assert.NotContains(t, f.getPod(t, "sample-mysql-1").Labels, utils.LabelTryLeader)
+	// The leader is made writable again.
+	assert.NotContains(t, f.getPod(t, "sample-mysql-0").Labels, utils.LabelFenced)
+	assert.Equal(t, []string{"SET GLOBAL super_read_only=on;", "SET GLOBAL super_read_only=on;", "SET GLOBAL read_only=off;"},
+		f.execs[tryLeaderLeader])
+
+	// The condition is cleared by the next promotion.
+	candidate := f.getPod(t, "sample-mysql-1")
+	candidate.Labels[utils.LabelTryLeader] = "true"
+	assert.NoError(t, f.cli.Update(context.TODO(), candidate))
+	f.variables[tryLeaderTarget]["gtid_executed"] = tryLeaderUUID + ":1-10"
+	f.reconcile(t)
+	assert.Equal(t, []string{"sample-mysql-1"}, f.tryLeaders)
+	assert.Equal(t, corev1.ConditionFalse, f.promoteBlocked().Status)
+}
+
+func TestTryLeaderFencedBySwitchover(t *testing.T) {
+	f := newTryLeaderFixture(t, tryLeaderUUID+":1-10")
+	leader := f.getPod(t, "sample-mysql-0")
+	leader.Labels[utils.LabelFenced] = "true"
+	assert.NoError(t, f.cli.Update(context.TODO(), leader))
+
+	f.reconcile(t)
+	assert.Nil(t, f.Status.TryLeader)
+	assert.Empty(t, f.tryLeaders)
+	assert.Equal(t, corev1.ConditionTrue, f.promoteBlocked().Status)
+	// The fence of the switchover is kept.
+	assert.Contains(t, f.getPod(t, "sample-mysql-0").Labels, utils.LabelFenced)
+	assert.Empty(t, f.execs[tryLeaderLeader])
+}
@@ -0,0 +1,450 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/presslabs/controller-util/pkg/syncer"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/internal"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlcluster"
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+const (
+	// The keys of the certificates in the secrets.
+	caCertKey  = "ca.crt"
+	caKeyKey   = "ca.key"
+	tlsCertKey = "tls.crt"
+	tlsKeyKey  = "tls.key"
+
+	rsaKeySize = 2048
+	// Tolerate the clock skew between the operator and the mysql nodes.
+	certBackdate = time.Hour
+	// The minimum interval to check the certificates again.
+	minRenewInterval = time.Minute
+)
+
+// NewTLSCASyncer returns the syncer of the CA secret generated by the operator.
+func NewTLSCASyncer(cli client.Client, c *mysqlcluster.MysqlCluster) syncer.Interface {
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      c.GetNameForResource(utils.TLSCASecret),
+			Namespace: c.Namespace,
+		},
+	}
+
+	return syncer.NewObjectSyncer("TLSCASecret", c.Unwrap(), secret, cli, func() error {
+		if secret.Data == nil {
+			secret.Data = make(map[string][]byte)
+		}
+		validity, _ := c.GetCertValidity()
+		// Renew the CA before the server certificates issued by it outlive it.
+		if ca, _, err := parseKeyPair(secret.Data[caCertKey], secret.Data[caKeyKey]); err == nil &&
+			time.Until(ca.NotAfter) > validity {
+			return nil
+		}
+
+		certPEM, keyPEM, err := generateCA(c.Name, utils.CAValidityDays*24*time.Hour)
+		if err != nil {
+			return err
+		}
+		// Publish the old and new CAs together, the nodes keep trusting the server certificates issued
+		// by the old CA until every node has loaded the new one, see trimCABundle.
+		bundle := certPEM
+		if certs := parseCertificates(secret.Data[caCertKey]); len(certs) > 0 && time.Now().Before(certs[0].NotAfter) {
+			bundle = append(bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certs[0].Raw})...)
+		}
+		secret.Data[caCertKey] = bundle
+		secret.Data[caKeyKey] = keyPEM
+		return nil
+	})
+}
+
+// NewTLSSecretSyncer returns the syncer of the server certificate secret generated by the operator,
+// the certificate is issued by the CA in the ca secret.
+func NewTLSSecretSyncer(cli client.Client, c *mysqlcluster.MysqlCluster, ca *corev1.Secret) syncer.Interface {
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      c.GetNameForResource(utils.TLSSecret),
+			Namespace: c.Namespace,
+		},
+	}
+
+	return syncer.NewObjectSyncer("TLSSecret", c.Unwrap(), secret, cli, func() error {
+		caCert, caKey, err := parseKeyPair(ca.Data[caCertKey], ca.Data[caKeyKey])
+		if err != nil {
+			return fmt.Errorf("failed to parse the CA: %s", err)
+		}
+		caBundle := ca.Data[caCertKey]
+		// Publish the CA bundle before issuing the server certificate by the new CA, otherwise the
+		// nodes which have not reloaded yet reject the certificates of the reloaded ones.
+		if !bytes.Equal(secret.Data[caCertKey], caBundle) && isIssuedByBundle(secret.Data, caBundle) {
+			secret.Data[caCertKey] = caBundle
+			return nil
+		}
+		validity, renewBefore := c.GetCertValidity()
+		dnsNames := getServerDNSNames(c)
+		if !needRenewCert(secret.Data, caCert, dnsNames, renewBefore) {
+			return nil
+		}
+		// Wait for every node loading the CA bundle before replacing the certificate issued by the old
+		// CA, unless it is expired or its names are changed.
+		if !isCABundleLoaded(ca) && isIssuedByBundle(secret.Data, caBundle) &&
+			!needRenewCert(secret.Data, nil, dnsNames, 0) {
+			return nil
+		}
+
+		certPEM, keyPEM, err := generateServerCert(caCert, caKey, c.Name, dnsNames, validity)
+		if err != nil {
+			return err
+		}
+		secret.Data = map[string][]byte{
+			caCertKey:  caBundle,
+			tlsCertKey: certPEM,
+			tlsKeyKey:  keyPEM,
+		}
+		return nil
+	})
+}
+
+// GetTLSRenewAfter returns the duration after which the server certificate in the secret should be renewed.
+func GetTLSRenewAfter(c *mysqlcluster.MysqlCluster, secret *corev1.Secret) time.Duration {
+	_, renewBefore := c.GetCertValidity()
+	cert, err := parseCertificate(secret.Data[tlsCertKey])
+	if err != nil {
+		return minRenewInterval
+	}
+	renewAfter := time.Until(cert.NotAfter.Add(-renewBefore))
+	if renewAfter < minRenewInterval {
+		return minRenewInterval
+	}
+	return renewAfter
+}
+
+// getServerDNSNames returns the sorted names of the services and pods for the server certificate.
+func getServerDNSNames(c *mysqlcluster.MysqlCluster) []string {
+	services := []string{
+		c.GetNameForResource(utils.LeaderService),
+		c.GetNameForResource(utils.FollowerService),
+		c.GetNameForResource(utils.HeadlessSVC),
+	}
+	if c.Spec.ReadOnlys != nil {
+		services = append(services, c.GetNameForResource(utils.ReadOnlyHeadlessSVC))
+	}
+
+	names := []string{"localhost"}
+	for _, svc := range services {
+		names = append(names, svc,
+			fmt.Sprintf("%s.%s", svc, c.Namespace),
+			fmt.Sprintf("%s.%s.svc", svc, c.Namespace),
+		)
+	}
+	// The pods are accessed by the headless services, such as `sample-mysql-0.sample-mysql.default`.
+	for _, svc := range services[2:] {
+		names = append(names,
+			fmt.Sprintf("*.%s.%s", svc, c.Namespace),
+			fmt.Sprintf("*.%s.%s.svc", svc, c.Namespace),
+		)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// needRenewCert checks whether the server certificate is missing, not issued by the CA,
+// expiring or its names are changed. The issuer is not checked if caCert is nil.
+func needRenewCert(data map[string][]byte, caCert *x509.Certificate, dnsNames []string, renewBefore time.Duration) bool {
+	cert, _, err := parseKeyPair(data[tlsCertKey], data[tlsKeyKey])
+	if err != nil {
+		return true
+	}
+	if caCert != nil && cert.CheckSignatureFrom(caCert) != nil {
+		return true
+	}
+	if time.Until(cert.NotAfter) < renewBefore {
+		return true
+	}
+	names := append([]string{}, cert.DNSNames...)
+	sort.Strings(names)
+	return !reflect.DeepEqual(names, dnsNames)
+}
+
+// isIssuedByBundle checks whether the server certificate is issued by any CA in the bundle.
+func isIssuedByBundle(data map[string][]byte, caBundle []byte) bool {
+	cert, _, err := parseKeyPair(data[tlsCertKey], data[tlsKeyKey])
+	if err != nil {
+		return false
+	}
+	for _, ca := range parseCertificates(caBundle) {
+		if cert.CheckSignatureFrom(ca) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// isCABundleLoaded checks whether every node has loaded the CA bundle, it is always true
+// if the CA is not rotating.
+func isCABundleLoaded(ca *corev1.Secret) bool {
+	bundle := ca.Data[caCertKey]
+	if len(parseCertificates(bundle)) < 2 {
+		return true
+	}
+	hash, err := utils.Hash(string(bundle))
+	return err == nil && ca.Annotations[utils.AnnotationCABundleLoaded] == hash
+}
+
+// generateCA returns the PEM encoded self-signed CA and its private key.
+func generateCA(name string, validity time.Duration) ([]byte, []byte, error) {
+	key, err := rsa.GenerateKey(rand.Reader, rsaKeySize)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate the CA key: %s", err)
+	}
+	template, err := newCertTemplate(fmt.Sprintf("%s-ca", name), validity)
+	if err != nil {
+		return nil, nil, err
+	}
+	template.IsCA = true
+	template.BasicConstraintsValid = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create the CA: %s", err)
+	}
+	return encodeKeyPair(der, key)
+}
+
+// generateServerCert returns the PEM encoded server certificate issued by the CA and its private key.
+func generateServerCert(caCert *x509.Certificate, caKey *rsa.PrivateKey, name string, dnsNames []string,
+	validity time.Duration) ([]byte, []byte, error) {
+	key, err := rsa.GenerateKey(rand.Reader, rsaKeySize)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate the server key: %s", err)
+	}
+	template, err := newCertTemplate(name, validity)
+	if err != nil {
+		return nil, nil, err
+	}
+	template.DNSNames = dnsNames
+	template.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+	template.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create the server certificate: %s", err)
+	}
+	return encodeKeyPair(der, key)
+}
+
+func newCertTemplate(commonName string, validity time.Duration) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate the serial number: %s", err)
+	}
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			CommonName:   commonName,
+			Organization: []string{"RadonDB"},
+		},
+		NotBefore: now.Add(-certBackdate),
+		NotAfter:  now.Add(validity),
+	}, nil
+}
+
+func encodeKeyPair(der []byte, key *rsa.PrivateKey) ([]byte, []byte, error) {
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if certPEM == nil || keyPEM == nil {
+		return nil, nil, fmt.Errorf("failed to encode the certificate")
+	}
+	return certPEM, keyPEM, nil
+}
+
+func parseCertificate(certPEM []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("failed to decode the certificate")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// parseCertificates parses the PEM encoded certificates in the bundle, the invalid ones are skipped.
+func parseCertificates(bundle []byte) []*x509.Certificate {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		if block, bundle = pem.Decode(bundle); block == nil {
+			return certs
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+			certs = append(certs, cert)
+		}
+	}
+}
+
+// parseKeyPair parses the PEM encoded certificate and its RSA private key.
+func parseKeyPair(certPEM, keyPEM []byte) (*x509.Certificate, *rsa.PrivateKey, error) {
+	cert, err := parseCertificate(certPEM)
+	if err != nil {
+		return nil, nil, err
+	}
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, nil, fmt.Errorf("failed to decode the private key")
+	}
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !key.PublicKey.Equal(cert.PublicKey) {
+		return nil, nil, fmt.Errorf("the private key does not match the certificate")
+	}
+	return cert, key, nil
+}
+
+// getTLSSecret returns the server certificate secret generated by the operator.
+func (s *StatusSyncer) getTLSSecret(ctx context.Context) (*corev1.Secret, *x509.Certificate, error) {
+	secret := &corev1.Secret{}
+	if err := s.cli.Get(ctx, client.ObjectKey{Name: s.GetNameForResource(utils.TLSSecret), Namespace: s.Namespace}, secret); err != nil {
+		return nil, nil, err
+	}
+	cert, err := parseCertificate(secret.Data[tlsCertKey])
+	if err != nil {
+		return nil, nil, err
+	}
+	return secret, cert, nil
+}
+
+// reloadTLS copies the renewed certificates to the node and reloads them online, if the ones loaded
+// by mysqld are not the latest. It returns whether the node has loaded the certificates in the secret.
+// Reloading only works on mysql 8.0, the pods of mysql 5.7 are restarted to load the renewed ones.
+func (s *StatusSyncer) reloadTLS(sqlRunner internal.SQLRunner, pod *corev1.Pod, node *apiv1alpha1.NodeStatus,
+	cert *x509.Certificate, caBundle []byte) (bool, error) {
+	loaded, err := s.isTLSLoaded(sqlRunner, pod, cert, caBundle)
+	if err != nil || loaded || !s.CanReloadTLS(node.Version) {
+		return loaded, err
+	}
+
+	executor, err := internal.NewPodExecutor()
+	if err != nil {
+		return false, err
+	}
+	if err := executor.CopyTLSFiles(s.Namespace, pod.Name); err != nil {
+		return false, fmt.Errorf("failed to copy the certificates: %s", err)
+	}
+	if err := internal.ReloadTLS(sqlRunner); err != nil {
+		return false, fmt.Errorf("failed to reload tls: %s", err)
+	}
+	// The renewed secret may not be updated in the pod yet, try again in the next round.
+	if loaded, err = s.isTLSLoaded(sqlRunner, pod, cert, caBundle); err != nil || !loaded {
+		s.log.V(1).Info("the renewed certificates are not mounted yet", "node", node.Name)
+		return false, err
+	}
+	s.log.Info("reloaded the renewed certificate", "node", node.Name, "notAfter", cert.NotAfter)
+	s.recorder.Eventf(s.Unwrap(), corev1.EventTypeNormal, "TLSReloaded",
+		"reloaded the renewed certificate on %s, expires at %s", node.Name, cert.NotAfter.Format(time.RFC3339))
+	return true, nil
+}
+
+// isTLSLoaded checks whether mysqld has loaded the server certificate, and the CA bundle while
+// rotating the CA.
+func (s *StatusSyncer) isTLSLoaded(sqlRunner internal.SQLRunner, pod *corev1.Pod, cert *x509.Certificate,
+	caBundle []byte) (bool, error) {
+	notAfter, err := internal.GetServerCertNotAfter(sqlRunner)
+	if err != nil || notAfter.Unix() != cert.NotAfter.Unix() {
+		return false, err
+	}
+	if len(parseCertificates(caBundle)) < 2 {
+		return true, nil
+	}
+	executor, err := internal.NewPodExecutor()
+	if err != nil {
+		return false, err
+	}
+	ca, err := executor.GetLoadedCA(s.Namespace, pod.Name)
+	if err != nil {
+		return false, err
+	}
+	return ca == string(caBundle), nil
+}
+
+// trimCABundle moves the CA rotation forward after every node has loaded the certificates in the
+// server certificate secret. It records the CA bundle is loaded to issue the server certificate
+// by the new CA, and removes the old CA from the bundle once that certificate is loaded.
+func (s *StatusSyncer) trimCABundle(ctx context.Context, tlsSecret *corev1.Secret, cert *x509.Certificate) error {
+	ca := &corev1.Secret{}
+	if err := s.cli.Get(ctx, client.ObjectKey{Name: s.GetNameForResource(utils.TLSCASecret), Namespace: s.Namespace}, ca); err != nil {
+		return err
+	}
+	bundle := ca.Data[caCertKey]
+	certs := parseCertificates(bundle)
+	if len(certs) < 2 || !bytes.Equal(tlsSecret.Data[caCertKey], bundle) {
+		return nil
+	}
+
+	if cert.CheckSignatureFrom(certs[0]) == nil {
+		ca.Data[caCertKey] = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certs[0].Raw})
+		delete(ca.Annotations, utils.AnnotationCABundleLoaded)
+		s.log.Info("removed the old CA from the bundle")
+	} else {
+		if isCABundleLoaded(ca) {
+			return nil
+		}
+		hash, err := utils.Hash(string(bundle))
+		if err != nil {
+			return err
+		}
+		if ca.Annotations == nil {
+			ca.Annotations = make(map[string]string)
+		}
+		ca.Annotations[utils.AnnotationCABundleLoaded] = hash
+		s.log.Info("the CA bundle is loaded by every node")
+	}
+	return s.cli.Update(ctx, ca)
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/presslabs/controller-util/pkg/syncer"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlcluster"
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+func TestServerCertRenewal(t *testing.T) {
+	cluster := mysqlcluster.New(&apiv1alpha1.MysqlCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"},
+		Spec: apiv1alpha1.MysqlClusterSpec{
+			AutoTLS: &apiv1alpha1.AutoTLSOpts{Enabled: true, CertValidityDays: 90, RenewBeforeDays: 30},
+		},
+	})
+	assert.Equal(t, "sample-tls", cluster.GetTLSSecretName())
+	validity, renewBefore := cluster.GetCertValidity()
+	dnsNames := getServerDNSNames(cluster)
+	assert.Contains(t, dnsNames, "sample-leader.default.svc")
+	assert.Contains(t, dnsNames, "*.sample-mysql.default")
+
+	caPEM, caKeyPEM, err := generateCA(cluster.Name, 10*validity)
+	assert.NoError(t, err)
+	caCert, caKey, err := parseKeyPair(caPEM, caKeyPEM)
+	assert.NoError(t, err)
+	assert.True(t, caCert.IsCA)
+
+	// Missing certificate.
+	data := map[string][]byte{}
+	assert.True(t, needRenewCert(data, caCert, dnsNames, renewBefore))
+
+	certPEM, keyPEM, err := generateServerCert(caCert, caKey, cluster.Name, dnsNames, validity)
+	assert.NoError(t, err)
+	data = map[string][]byte{tlsCertKey: certPEM, tlsKeyKey: keyPEM}
+	assert.False(t, needRenewCert(data, caCert, dnsNames, renewBefore))
+
+	// The names of the services changed.
+	assert.True(t, needRenewCert(data, caCert, append(dnsNames, "sample-ro.default"), renewBefore))
+
+	// Issued by another CA.
+	otherPEM, otherKeyPEM, err := generateCA("other", validity)
+	assert.NoError(t, err)
+	otherCA, _, err := parseKeyPair(otherPEM, otherKeyPEM)
+	assert.NoError(t, err)
+	assert.True(t, needRenewCert(data, otherCA, dnsNames, renewBefore))
+
+	// Expiring in the renew window.
+	certPEM, keyPEM, err = generateServerCert(caCert, caKey, cluster.Name, dnsNames, renewBefore-time.Hour)
+	assert.NoError(t, err)
+	data = map[string][]byte{tlsCertKey: certPEM, tlsKeyKey: keyPEM}
+	assert.True(t, needRenewCert(data, caCert, dnsNames, renewBefore))
+
+	// The renew window is larger than the validity.
+	cluster.Spec.AutoTLS.RenewBeforeDays = 100
+	validity, renewBefore = cluster.GetCertValidity()
+	assert.Equal(t, validity/2, renewBefore)
+
+	// The secret specified by the user takes precedence.
+	cluster.Spec.TlsSecretName = "custom-tls"
+	assert.False(t, cluster.AutoTLSEnabled())
+	assert.Equal(t, "custom-tls", cluster.GetTLSSecretName())
+}
+
+func TestCARotation(t *testing.T) {
+	cluster := mysqlcluster.New(&apiv1alpha1.MysqlCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"},
+		Spec: apiv1alpha1.MysqlClusterSpec{
+			AutoTLS: &apiv1alpha1.AutoTLSOpts{Enabled: true},
+		},
+	})
+	validity, _ := cluster.GetCertValidity()
+	caSyncer := NewTLSCASyncer(nil, cluster).(*syncer.ObjectSyncer)
+	ca := caSyncer.Object().(*corev1.Secret)
+	tlsSyncer := NewTLSSecretSyncer(nil, cluster, ca).(*syncer.ObjectSyncer)
+	tlsSecret := tlsSyncer.Object().(*corev1.Secret)
+
+	// The CA expires before the server certificate issued by it.
+	oldPEM, oldKeyPEM, err := generateCA(cluster.Name, validity/2)
+	assert.NoError(t, err)
+	ca.Data = map[string][]byte{caCertKey: oldPEM, caKeyKey: oldKeyPEM}
+	assert.NoError(t, tlsSyncer.SyncFn())
+	assert.Equal(t, oldPEM, tlsSecret.Data[caCertKey])
+	oldCert := tlsSecret.Data[tlsCertKey]
+
+	// The renewed CA is published together with the old one.
+	assert.NoError(t, caSyncer.SyncFn())
+	bundle := ca.Data[caCertKey]
+	certs := parseCertificates(bundle)
+	assert.Len(t, certs, 2)
+	assert.True(t, bytes.HasSuffix(bundle, oldPEM))
+	assert.False(t, isCABundleLoaded(ca))
+
+	// The bundle is published before issuing the server certificate by the new CA.
+	assert.NoError(t, tlsSyncer.SyncFn())
+	assert.Equal(t, bundle, tlsSecret.Data[caCertKey])
+	assert.Equal(t, oldCert, tlsSecret.Data[tlsCertKey])
+	assert.NoError(t, tlsSyncer.SyncFn())
+	assert.Equal(t, oldCert, tlsSecret.Data[tlsCertKey])
+
+	// All the nodes have loaded the bundle.
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	ca.Name, ca.Namespace = cluster.GetNameForResource(utils.TLSCASecret), cluster.Namespace
+	cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(ca.DeepCopy()).Build()
+	s := &StatusSyncer{MysqlCluster: cluster, cli: cli, log: logr.Discard()}
+	cert, err := parseCertificate(tlsSecret.Data[tlsCertKey])
+	assert.NoError(t, err)
+	assert.NoError(t, s.trimCABundle(context.TODO(), tlsSecret, cert))
+	assert.NoError(t, cli.Get(context.TODO(), client.ObjectKeyFromObject(ca), ca))
+	assert.True(t, isCABundleLoaded(ca))
+	assert.Equal(t, bundle, ca.Data[caCertKey])
+
+	// The server certificate is issued by the new CA.
+	assert.NoError(t, tlsSyncer.SyncFn())
+	assert.NotEqual(t, oldCert, tlsSecret.Data[tlsCertKey])
+	assert.False(t, needRenewCert(tlsSecret.Data, certs[0], getServerDNSNames(cluster), 0))
+
+	// The old CA is removed after all the nodes have loaded the new certificate.
+	cert, err = parseCertificate(tlsSecret.Data[tlsCertKey])
+	assert.NoError(t, err)
+	assert.NoError(t, s.trimCABundle(context.TODO(), tlsSecret, cert))
+	assert.NoError(t, cli.Get(context.TODO(), client.ObjectKeyFromObject(ca), ca))
+	assert.Len(t, parseCertificates(ca.Data[caCertKey]), 1)
+	assert.True(t, bytes.HasPrefix(bundle, ca.Data[caCertKey]))
+	newCert := tlsSecret.Data[tlsCertKey]
+	assert.NoError(t, tlsSyncer.SyncFn())
+	assert.Equal(t, ca.Data[caCertKey], tlsSecret.Data[caCertKey])
+	assert.Equal(t, newCert, tlsSecret.Data[tlsCertKey])
+}
@@ -0,0 +1,341 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blang/semver"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/internal"
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+// The time limit for an upgraded follower becoming the leader.
+var switchLeaderTimeout = 2 * time.Minute
+
+// getPodMySQLVersion returns the MySQL version of the pod's mysql container image.
+func getPodMySQLVersion(pod *corev1.Pod) string {
+	for _, container := range pod.Spec.Containers {
+		if container.Name != utils.ContainerMysqlName {
+			continue
+		}
+		if _, _, tag, err := utils.ParseImageName(container.Image); err == nil {
+			return tag
+		}
+	}
+	return utils.InvalidMySQLVersion
+}
+
+// isMajorUpgrade checks whether some pods run a MySQL major version lower than the spec. The
+// versions which are not semver can't be compared, the change of them is treated as a major
+// upgrade, so that it is pre-checked and the pods are upgraded one by one.
+func (s *StatefulSetSyncer) isMajorUpgrade(pods []corev1.Pod) bool {
+	target := s.GetMySQLVersion()
+	targetVer, targetErr := parseMySQLVersion(target)
+	for i := range pods {
+		version := getPodMySQLVersion(&pods[i])
+		if version == target {
+			continue
+		}
+		ver, err := parseMySQLVersion(version)
+		if targetErr != nil || err != nil || ver.Major < targetVer.Major {
+			return true
+		}
+	}
+	return false
+}
+
+// parseMySQLVersion parses the MySQL version of the image tag.
+func parseMySQLVersion(version string) (semver.Version, error) {
+	if version == utils.InvalidMySQLVersion {
+		return semver.Version{}, fmt.Errorf("invalid mysql version")
+	}
+	return semver.Parse(version)
+}
+
+// upgradeCheckResult is the result of the pre-check running in the background.
+type upgradeCheckResult struct {
+	done     bool
+	problems []string
+	warnings []string
+	err      error
+}
+
+var (
+	upgradeChecksLock sync.Mutex
+	// upgradeChecks are the pre-checks of the clusters by the target version. The check scans all the
+	// tables and takes a long time, so it runs in the background and runs once for a target version.
+	upgradeChecks = map[string]*upgradeCheckResult{}
+	// checkUpgrade checks the incompatible schemas and tables on the host.
+	checkUpgrade = checkUpgradeOnHost
+)
+
+// upgradeCheckKey returns the key of the pre-check of the cluster to the target version.
+func upgradeCheckKey(cluster client.ObjectKey, target string) string {
+	return fmt.Sprintf("%s@%s", cluster, target)
+}
+
+// preCheckUpgrade checks the leader's data before the major version upgrade,
+// returns true if the pods can be upgraded. The result of the check is kept in
+// the status, the failed check is retried after the spec goes back to the current
+// version and then forward again.
+func (s *StatefulSetSyncer) preCheckUpgrade(leader *corev1.Pod) (bool, error) {
+	target := s.GetMySQLVersion()
+	upgrade := s.Status.Upgrade
+	if upgrade != nil && upgrade.ToVersion == target {
+		switch upgrade.Phase {
+		case apiv1alpha1.UpgradeUpgrading:
+			return true, nil
+		case apiv1alpha1.UpgradePreCheckFailed:
+			s.log.Info("can't start 'upgrade': the pre-check failed", "to", target, "message", upgrade.Message)
+			return false, nil
+		}
+	}
+	if leader.Name == "" {
+		s.log.Info("can't start 'upgrade': waiting for the leader")
+		s.requeueAfter = rollingUpdateCheckPeriod
+		return false, nil
+	}
+	if upgrade == nil || upgrade.ToVersion != target || upgrade.Phase != apiv1alpha1.UpgradePreChecking {
+		s.resetUpgrade()
+		s.Status.Upgrade = &apiv1alpha1.UpgradeStatus{
+			FromVersion: getPodMySQLVersion(leader),
+			ToVersion:   target,
+			Phase:       apiv1alpha1.UpgradePreChecking,
+		}
+	}
+
+	result, err := s.startUpgradeCheck(leader, target)
+	if err != nil {
+		return false, err
+	}
+	if !result.done {
+		s.requeueAfter = rollingUpdateCheckPeriod
+		return false, nil
+	}
+	if result.err != nil {
+		return false, fmt.Errorf("failed to pre-check the upgrade: %s", result.err)
+	}
+	if len(result.problems) > 0 {
+		s.Status.Upgrade.Phase = apiv1alpha1.UpgradePreCheckFailed
+		s.Status.Upgrade.Message = strings.Join(result.problems, "; ")
+		return false, fmt.Errorf("upgrade pre-check failed: %s", s.Status.Upgrade.Message)
+	}
+	s.Status.Upgrade.Phase = apiv1alpha1.UpgradeUpgrading
+	s.Status.Upgrade.Message = strings.Join(result.warnings, "; ")
+	return true, nil
+}
+
+// startUpgradeCheck starts the pre-check on the leader in the background if it is not started,
+// and returns its result. The finished result is handed over to the status, and the failed one
+// is dropped so that the check is retried.
+func (s *StatefulSetSyncer) startUpgradeCheck(leader *corev1.Pod, target string) (upgradeCheckResult, error) {
+	key := upgradeCheckKey(s.GetClusterKey(), target)
+	upgradeChecksLock.Lock()
+	defer upgradeChecksLock.Unlock()
+	if result, ok := upgradeChecks[key]; ok {
+		if result.done {
+			delete(upgradeChecks, key)
+		}
+		return *result, nil
+	}
+
+	ordinal, err := utils.GetOrdinal(leader.Name)
+	if err != nil {
+		return upgradeCheckResult{}, err
+	}
+	cfg, err := internal.NewConfigFromClusterKey(s.cli, s.GetClusterKey(), utils.OperatorUser, s.GetPodHostName(ordinal))
+	if err != nil {
+		return upgradeCheckResult{}, err
+	}
+	s.log.Info("pre-check the upgrade", "pod", leader.Name, "to", target)
+	result := &upgradeCheckResult{}
+	upgradeChecks[key] = result
+	go func(factory internal.SQLRunnerFactory, namespace, podName string) {
+		problems, warnings, err := checkUpgrade(factory, cfg, namespace, podName)
+		upgradeChecksLock.Lock()
+		defer upgradeChecksLock.Unlock()
+		result.problems, result.warnings, result.err = problems, warnings, err
+		result.done = true
+	}(s.SQLRunnerFactory, leader.Namespace, leader.Name)
+	return *result, nil
+}
+
+// resetUpgrade drops the pre-check after the spec goes back to the current version.
+func (s *StatefulSetSyncer) resetUpgrade() {
+	upgrade := s.Status.Upgrade
+	if upgrade == nil || (upgrade.Phase != apiv1alpha1.UpgradePreChecking && upgrade.Phase != apiv1alpha1.UpgradePreCheckFailed) {
+		return
+	}
+	s.log.Info("the upgrade is canceled", "to", upgrade.ToVersion)
+	upgradeChecksLock.Lock()
+	delete(upgradeChecks, upgradeCheckKey(s.GetClusterKey(), upgrade.ToVersion))
+	upgradeChecksLock.Unlock()
+	s.Status.Upgrade = nil
+}
+
+// checkUpgradeOnHost checks the incompatible schemas and tables on the host of the pod.
+func checkUpgradeOnHost(factory internal.SQLRunnerFactory, cfg *internal.Config, namespace, podName string) (problems, warnings []string, err error) {
+	sqlRunner, closeConn, err := factory(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer closeConn()
+
+	if problems, warnings, err = internal.CheckUpgradeToMySQL80(sqlRunner); err != nil {
+		return nil, nil, err
+	}
+
+	executor, err := internal.NewPodExecutor()
+	if err != nil {
+		return nil, nil, err
+	}
+	tables, err := executor.CheckTablesForUpgrade(namespace, podName)
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(problems, tables...), warnings, nil
+}
+
+// completeUpgrade marks the upgrade completed after all the pods are updated.
+func (s *StatefulSetSyncer) completeUpgrade() {
+	if s.Status.Upgrade != nil && s.Status.Upgrade.Phase == apiv1alpha1.UpgradeUpgrading {
+		s.log.Info("the upgrade is completed", "to", s.Status.Upgrade.ToVersion)
+		s.Status.Upgrade.Phase = apiv1alpha1.UpgradeCompleted
+		return
+	}
+	s.resetUpgrade()
+}
+
+// getSwitchTarget returns an updated and healthy follower to take over the leader.
+func (s *StatefulSetSyncer) getSwitchTarget(pods []corev1.Pod, leader *corev1.Pod) *corev1.Pod {
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Name != leader.Name &&
+			pod.ObjectMeta.Labels["controller-revision-hash"] == s.sfs.Status.UpdateRevision &&
+			pod.ObjectMeta.Labels["healthy"] == "yes" {
+			return pod
+		}
+	}
+	return nil
+}
+
+// switchLeader starts switching the leader over to the target, the target is promoted after
+// it catches up the leader in the following syncs.
+func (s *StatefulSetSyncer) switchLeader(leader, target *corev1.Pod) error {
+	s.log.Info("switch the leader over before updating it", "from", leader.Name, "to", target.Name)
+	s.startStep(apiv1alpha1.RollingUpdateSwitching, []string{target.Name})
+	s.requeueAfter = rollingUpdateCheckPeriod
+	return nil
+}
+
+// checkSwitching checks whether the target of the current step has become the leader. The target
+// is asked to become the leader once it has applied all the transactions of the leader, the catch
+// up is checked in every sync instead of waiting for it.
+func (s *StatefulSetSyncer) checkSwitching(ctx context.Context) (bool, error) {
+	status := s.Status.RollingUpdate
+	if len(status.CurrentPods) == 0 {
+		return true, nil
+	}
+	pod := &corev1.Pod{}
+	if err := s.cli.Get(ctx, types.NamespacedName{Name: status.CurrentPods[0], Namespace: s.sfs.Namespace}, pod); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	if pod.ObjectMeta.Labels["role"] == string(utils.Leader) {
+		return true, nil
+	}
+
+	if status.SwitchedAt != nil {
+		if time.Since(status.SwitchedAt.Time) > switchLeaderTimeout {
+			s.finishStep()
+			return false, fmt.Errorf("timeout waiting for %s to become the leader", pod.Name)
+		}
+		s.requeueAfter = rollingUpdateCheckPeriod
+		return false, nil
+	}
+
+	caughtUp, err := s.checkSwitchTargetCaughtUp(ctx, pod)
+	if err != nil || !caughtUp {
+		if status.StartedAt != nil && time.Since(status.StartedAt.Time) > s.GetPromoteCatchUpTimeout() {
+			s.finishStep()
+			if err == nil {
+				err = fmt.Errorf("the candidate has not applied the leader's transactions in %s", s.GetPromoteCatchUpTimeout())
+			}
+			return false, fmt.Errorf("failed to switch the leader to %s: %s", pod.Name, err)
+		}
+		s.log.V(1).Info("waiting for the target catching up the leader", "pod", pod.Name, "error", err)
+		s.requeueAfter = rollingUpdateCheckPeriod
+		return false, nil
+	}
+
+	ordinal, err := utils.GetOrdinal(pod.Name)
+	if err != nil {
+		return false, err
+	}
+	if err := s.XenonExecutor.RaftTryToLeader(s.GetPodHostName(ordinal)); err != nil {
+		s.finishStep()
+		return false, err
+	}
+	now := metav1.Now()
+	status.SwitchedAt = &now
+	s.requeueAfter = rollingUpdateCheckPeriod
+	return false, nil
+}
+
+// checkSwitchTargetCaughtUp returns true if the target has applied all the transactions of the leader.
+func (s *StatefulSetSyncer) checkSwitchTargetCaughtUp(ctx context.Context, target *corev1.Pod) (bool, error) {
+	pods := corev1.PodList{}
+	if err := s.cli.List(ctx, &pods, client.InNamespace(s.sfs.Namespace),
+		client.MatchingLabels(s.GetLabels()), client.MatchingLabels{"role": string(utils.Leader)}); err != nil {
+		return false, err
+	}
+	if len(pods.Items) != 1 {
+		return false, fmt.Errorf("found %d leaders", len(pods.Items))
+	}
+	leaderOrdinal, err := utils.GetOrdinal(pods.Items[0].Name)
+	if err != nil {
+		return false, err
+	}
+	targetOrdinal, err := utils.GetOrdinal(target.Name)
+	if err != nil {
+		return false, err
+	}
+
+	leaderRunner, closeLeader, err := s.SQLRunnerFactory(internal.NewConfigFromClusterKey(
+		s.cli, s.GetClusterKey(), utils.OperatorUser, s.GetPodHostName(leaderOrdinal)))
+	if err != nil {
+		return false, err
+	}
+	defer closeLeader()
+	targetRunner, closeTarget, err := s.SQLRunnerFactory(internal.NewConfigFromClusterKey(
+		s.cli, s.GetClusterKey(), utils.OperatorUser, s.GetPodHostName(targetOrdinal)))
+	if err != nil {
+		return false, err
+	}
+	defer closeTarget()
+	return internal.CheckGtidCaughtUp(leaderRunner, targetRunner)
+}
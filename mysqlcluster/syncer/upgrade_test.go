@@ -0,0 +1,327 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package syncer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	"github.com/radondb/radondb-mysql-kubernetes/internal"
+	"github.com/radondb/radondb-mysql-kubernetes/mysqlcluster"
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+func newMySQLPod(name, image string) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "mysql", Image: image}},
+		},
+	}
+}
+
+func newUpgradeSyncer(image string) *StatefulSetSyncer {
+	cluster := &apiv1alpha1.MysqlCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: "default"},
+		Spec:       apiv1alpha1.MysqlClusterSpec{MysqlOpts: apiv1alpha1.MysqlOpts{Image: image}},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample-secret", Namespace: "default"},
+		Data:       map[string][]byte{"operator-password": []byte("operator")},
+	}
+	scheme := runtime.NewScheme()
+	_ = clientgoscheme.AddToScheme(scheme)
+	_ = apiv1alpha1.AddToScheme(scheme)
+	return &StatefulSetSyncer{
+		MysqlCluster: mysqlcluster.New(cluster),
+		cli:          fake.NewClientBuilder().WithScheme(scheme).WithObjects(cluster.DeepCopy(), secret).Build(),
+		log:          logr.Discard(),
+	}
+}
+
+func TestIsMajorUpgrade(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		pods   []string
+		want   bool
+	}{
+		{
+			name:   "major upgrade",
+			target: "percona/percona-server:8.0.25",
+			pods:   []string{"percona/percona-server:8.0.25", "percona/percona-server:5.7.34"},
+			want:   true,
+		},
+		{
+			name:   "minor upgrade",
+			target: "percona/percona-server:5.7.36",
+			pods:   []string{"percona/percona-server:5.7.34"},
+			want:   false,
+		},
+		{
+			name:   "same version",
+			target: "percona/percona-server:latest",
+			pods:   []string{"percona/percona-server:latest"},
+			want:   false,
+		},
+		{
+			name:   "target not semver",
+			target: "percona/percona-server:latest",
+			pods:   []string{"percona/percona-server:5.7.34"},
+			want:   true,
+		},
+		{
+			name:   "pod not semver",
+			target: "percona/percona-server:8.0.25",
+			pods:   []string{"percona/percona-server:stable"},
+			want:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newUpgradeSyncer(tt.target)
+			var pods []corev1.Pod
+			for i, image := range tt.pods {
+				pods = append(pods, newMySQLPod(fmt.Sprintf("sample-mysql-%d", i), image))
+			}
+			assert.Equal(t, tt.want, s.isMajorUpgrade(pods))
+		})
+	}
+}
+
+// stubCheckUpgrade replaces the pre-check, it waits for the release and returns the problems.
+func stubCheckUpgrade(t *testing.T, problems []string, err error) (release chan struct{}, runs *int) {
+	release = make(chan struct{})
+	runs = new(int)
+	origin := checkUpgrade
+	checkUpgrade = func(factory internal.SQLRunnerFactory, cfg *internal.Config, namespace, podName string) ([]string, []string, error) {
+		upgradeChecksLock.Lock()
+		*runs++
+		upgradeChecksLock.Unlock()
+		<-release
+		return problems, nil, err
+	}
+	t.Cleanup(func() { checkUpgrade = origin })
+	return release, runs
+}
+
+// waitUpgradeCheck waits for the pre-check of the syncer finished in the background.
+func waitUpgradeCheck(t *testing.T, s *StatefulSetSyncer) {
+	key := upgradeCheckKey(s.GetClusterKey(), s.GetMySQLVersion())
+	assert.Eventually(t, func() bool {
+		upgradeChecksLock.Lock()
+		defer upgradeChecksLock.Unlock()
+		result, ok := upgradeChecks[key]
+		return ok && result.done
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestPreCheckUpgrade(t *testing.T) {
+	leader := newMySQLPod("sample-mysql-0", "percona/percona-server:5.7.34")
+
+	t.Run("passed", func(t *testing.T) {
+		release, runs := stubCheckUpgrade(t, nil, nil)
+		s := newUpgradeSyncer("percona/percona-server:8.0.25")
+
+		// The check runs in the background.
+		passed, err := s.preCheckUpgrade(&leader)
+		assert.NoError(t, err)
+		assert.False(t, passed)
+		assert.Equal(t, rollingUpdateCheckPeriod, s.requeueAfter)
+		assert.Equal(t, apiv1alpha1.UpgradePreChecking, s.Status.Upgrade.Phase)
+		assert.Equal(t, "5.7.34", s.Status.Upgrade.FromVersion)
+
+		passed, err = s.preCheckUpgrade(&leader)
+		assert.NoError(t, err)
+		assert.False(t, passed)
+
+		close(release)
+		waitUpgradeCheck(t, s)
+		passed, err = s.preCheckUpgrade(&leader)
+		assert.NoError(t, err)
+		assert.True(t, passed)
+		assert.Equal(t, apiv1alpha1.UpgradeUpgrading, s.Status.Upgrade.Phase)
+
+		passed, err = s.preCheckUpgrade(&leader)
+		assert.NoError(t, err)
+		assert.True(t, passed)
+		assert.Equal(t, 1, *runs)
+	})
+
+	t.Run("failed", func(t *testing.T) {
+		release, runs := stubCheckUpgrade(t, []string{"db.t: Table upgrade required"}, nil)
+		close(release)
+		s := newUpgradeSyncer("percona/percona-server:8.0.25")
+
+		passed, err := s.preCheckUpgrade(&leader)
+		assert.NoError(t, err)
+		assert.False(t, passed)
+		waitUpgradeCheck(t, s)
+		passed, err = s.preCheckUpgrade(&leader)
+		assert.Error(t, err)
+		assert.False(t, passed)
+		assert.Equal(t, apiv1alpha1.UpgradePreCheckFailed, s.Status.Upgrade.Phase)
+		assert.Equal(t, "db.t: Table upgrade required", s.Status.Upgrade.Message)
+
+		// The failed check is not repeated for the same version.
+		passed, err = s.preCheckUpgrade(&leader)
+		assert.NoError(t, err)
+		assert.False(t, passed)
+		assert.Equal(t, 1, *runs)
+
+		// It is retried after the spec goes back to the current version.
+		s.completeUpgrade()
+		assert.Nil(t, s.Status.Upgrade)
+		passed, err = s.preCheckUpgrade(&leader)
+		assert.NoError(t, err)
+		assert.False(t, passed)
+		waitUpgradeCheck(t, s)
+		assert.Equal(t, 2, *runs)
+		s.resetUpgrade()
+	})
+
+	t.Run("error", func(t *testing.T) {
+		release, runs := stubCheckUpgrade(t, nil, errors.New("connection refused"))
+		close(release)
+		s := newUpgradeSyncer("percona/percona-server:8.0.25")
+
+		_, err := s.preCheckUpgrade(&leader)
+		assert.NoError(t, err)
+		waitUpgradeCheck(t, s)
+		_, err = s.preCheckUpgrade(&leader)
+		assert.Error(t, err)
+		assert.Equal(t, apiv1alpha1.UpgradePreChecking, s.Status.Upgrade.Phase)
+
+		// The check is started again.
+		_, err = s.preCheckUpgrade(&leader)
+		assert.NoError(t, err)
+		waitUpgradeCheck(t, s)
+		assert.Equal(t, 2, *runs)
+		s.resetUpgrade()
+	})
+
+	t.Run("waiting for the leader", func(t *testing.T) {
+		_, runs := stubCheckUpgrade(t, nil, nil)
+		s := newUpgradeSyncer("percona/percona-server:8.0.25")
+		passed, err := s.preCheckUpgrade(&corev1.Pod{})
+		assert.NoError(t, err)
+		assert.False(t, passed)
+		assert.Nil(t, s.Status.Upgrade)
+		assert.Equal(t, 0, *runs)
+	})
+}
+
+// fakeTryLeaderExecutor records the leader transfers, the other requests are not expected.
+type fakeTryLeaderExecutor struct {
+	internal.XenonExecutor
+	tryLeaders []string
+}
+
+func (f *fakeTryLeaderExecutor) RaftTryToLeader(host string) error {
+	f.tryLeaders = append(f.tryLeaders, host)
+	return nil
+}
+
+func TestCheckSwitching(t *testing.T) {
+	leaderHost, targetHost := "sample-mysql-0.sample-mysql.default", "sample-mysql-2.sample-mysql.default"
+	variables := map[string]map[string]string{
+		leaderHost: {"gtid_executed": "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-10"},
+		targetHost: {"gtid_executed": "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-8"},
+	}
+	xenon := &fakeTryLeaderExecutor{}
+	s := newUpgradeSyncer("percona/percona-server:8.0.25")
+	s.sfs = &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "sample-mysql", Namespace: "default"}}
+	s.SQLRunnerFactory = newFakeSQLRunnerFactory(variables, map[string][]string{})
+	s.XenonExecutor = xenon
+	s.Status.RollingUpdate = &apiv1alpha1.RollingUpdateStatus{}
+	assert.NoError(t, s.cli.Create(context.TODO(), newRollingUpdatePod("sample-mysql-0", string(utils.Leader), "old")))
+	assert.NoError(t, s.cli.Create(context.TODO(), newRollingUpdatePod("sample-mysql-2", string(utils.Follower), "new")))
+
+	leader := newRollingUpdatePod("sample-mysql-0", string(utils.Leader), "old")
+	target := newRollingUpdatePod("sample-mysql-2", string(utils.Follower), "new")
+	assert.NoError(t, s.switchLeader(leader, target))
+	assert.Equal(t, apiv1alpha1.RollingUpdateSwitching, s.Status.RollingUpdate.Phase)
+	assert.Equal(t, []string{"sample-mysql-2"}, s.Status.RollingUpdate.CurrentPods)
+
+	// The target is promoted after it catches up the leader.
+	done, err := s.checkSwitching(context.TODO())
+	assert.NoError(t, err)
+	assert.False(t, done)
+	assert.Nil(t, s.Status.RollingUpdate.SwitchedAt)
+	assert.Empty(t, xenon.tryLeaders)
+	assert.Equal(t, rollingUpdateCheckPeriod, s.requeueAfter)
+
+	variables[targetHost]["gtid_executed"] = "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-10"
+	done, err = s.checkSwitching(context.TODO())
+	assert.NoError(t, err)
+	assert.False(t, done)
+	assert.NotNil(t, s.Status.RollingUpdate.SwitchedAt)
+	assert.Equal(t, []string{targetHost}, xenon.tryLeaders)
+
+	// Wait for the target becoming the leader without asking xenon again.
+	done, err = s.checkSwitching(context.TODO())
+	assert.NoError(t, err)
+	assert.False(t, done)
+	assert.Len(t, xenon.tryLeaders, 1)
+
+	pod := &corev1.Pod{}
+	assert.NoError(t, s.cli.Get(context.TODO(), client.ObjectKey{Name: "sample-mysql-2", Namespace: "default"}, pod))
+	pod.Labels["role"] = string(utils.Leader)
+	assert.NoError(t, s.cli.Update(context.TODO(), pod))
+	done, err = s.checkSwitching(context.TODO())
+	assert.NoError(t, err)
+	assert.True(t, done)
+}
+
+func TestCheckSwitchingCatchUpTimeout(t *testing.T) {
+	xenon := &fakeTryLeaderExecutor{}
+	s := newUpgradeSyncer("percona/percona-server:8.0.25")
+	s.sfs = &appsv1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "sample-mysql", Namespace: "default"}}
+	s.SQLRunnerFactory = newFakeSQLRunnerFactory(map[string]map[string]string{
+		"sample-mysql-0.sample-mysql.default": {"gtid_executed": "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-10"},
+		"sample-mysql-2.sample-mysql.default": {"gtid_executed": "3e11fa47-71ca-11e1-9e33-c80aa9429562:1-8"},
+	}, map[string][]string{})
+	s.XenonExecutor = xenon
+	assert.NoError(t, s.cli.Create(context.TODO(), newRollingUpdatePod("sample-mysql-0", string(utils.Leader), "old")))
+	assert.NoError(t, s.cli.Create(context.TODO(), newRollingUpdatePod("sample-mysql-2", string(utils.Follower), "new")))
+
+	startedAt := metav1.NewTime(time.Now().Add(-utils.DefaultPromoteCatchUpTimeout - time.Second))
+	s.Status.RollingUpdate = &apiv1alpha1.RollingUpdateStatus{
+		Phase:       apiv1alpha1.RollingUpdateSwitching,
+		CurrentPods: []string{"sample-mysql-2"},
+		StartedAt:   &startedAt,
+	}
+	done, err := s.checkSwitching(context.TODO())
+	assert.Error(t, err)
+	assert.False(t, done)
+	assert.Empty(t, xenon.tryLeaders)
+	assert.Equal(t, apiv1alpha1.RollingUpdateIdle, s.Status.RollingUpdate.Phase)
+}
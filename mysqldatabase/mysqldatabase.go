@@ -0,0 +1,73 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysqldatabase
+
+import (
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+)
+
+const (
+	// ProvisionFailedReason is the condition reason when MysqlDatabase provisioning
+	// has failed.
+	ProvisionFailedReason = "ProvisionFailed"
+	// ProvisionSucceededReason the reason used when provision was successful.
+	ProvisionSucceededReason = "ProvisionSucceeded"
+	// NamespaceNotAllowedReason is the reason when the namespace of the MysqlDatabase
+	// is not allowed by the cluster.
+	NamespaceNotAllowedReason = "NamespaceNotAllowed"
+)
+
+// MysqlDatabase is a type wrapper over MysqlDatabase that contains the Business logic.
+type MysqlDatabase struct {
+	*apiv1alpha1.MysqlDatabase
+}
+
+// New returns a wraper object over MysqlDatabase.
+func New(database *apiv1alpha1.MysqlDatabase) *MysqlDatabase {
+	return &MysqlDatabase{
+		MysqlDatabase: database,
+	}
+}
+
+// Unwrap returns the api MysqlDatabase object.
+func (d *MysqlDatabase) Unwrap() *apiv1alpha1.MysqlDatabase {
+	return d.MysqlDatabase
+}
+
+// GetClusterKey returns the MysqlDatabase's MySQLCluster key.
+func (d *MysqlDatabase) GetClusterKey() client.ObjectKey {
+	ns := d.Spec.ClusterRef.NameSpace
+	if ns == "" {
+		ns = d.Namespace
+	}
+
+	return client.ObjectKey{
+		Name:      d.Spec.ClusterRef.ClusterName,
+		Namespace: ns,
+	}
+}
+
+// GetKey return the database key. Usually used for logging or for runtime.Client.Get as key.
+func (d *MysqlDatabase) GetKey() client.ObjectKey {
+	return types.NamespacedName{
+		Namespace: d.Namespace,
+		Name:      d.Name,
+	}
+}
@@ -0,0 +1,43 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysqldatabase
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+)
+
+// UpdateStatusCondition sets the condition to a status.
+// for example Ready condition to True, or False.
+func (d *MysqlDatabase) UpdateStatusCondition(
+	condType apiv1alpha1.ResourceConditionType,
+	status corev1.ConditionStatus, reason, message string,
+) (
+	cond *apiv1alpha1.ResourceCondition, changed bool,
+) {
+	return apiv1alpha1.SetResourceCondition(&d.Status.Conditions, condType, status, reason, message)
+}
+
+// ConditionExists returns a condition and whether it exists.
+func (d *MysqlDatabase) ConditionExists(
+	ct apiv1alpha1.ResourceConditionType,
+) (
+	*apiv1alpha1.ResourceCondition, bool,
+) {
+	return apiv1alpha1.FindResourceCondition(d.Status.Conditions, ct)
+}
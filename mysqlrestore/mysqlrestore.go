@@ -0,0 +1,260 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysqlrestore
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	apiv1beta1 "github.com/radondb/radondb-mysql-kubernetes/api/v1beta1"
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+const (
+	// InvalidSourceReason is the reason when the backup to restore cannot be resolved.
+	InvalidSourceReason = "InvalidSource"
+	// ClusterExistsReason is the reason when the target cluster was not created by the restore.
+	ClusterExistsReason = "ClusterExists"
+	// CreateClusterFailedReason is the reason when the target cluster cannot be created.
+	CreateClusterFailedReason = "CreateClusterFailed"
+	// RestoreFailedReason is the reason when the init containers failed to restore the data.
+	RestoreFailedReason = "RestoreFailed"
+	// VerificationFailedReason is the reason when the restored data does not match the target.
+	VerificationFailedReason = "VerificationFailed"
+	// RestoreSucceededReason is the reason when the cluster is ready with the restored data.
+	RestoreSucceededReason = "RestoreSucceeded"
+)
+
+type MysqlRestore struct {
+	*apiv1alpha1.MysqlRestore
+}
+
+func New(restore *apiv1alpha1.MysqlRestore) *MysqlRestore {
+	return &MysqlRestore{
+		MysqlRestore: restore,
+	}
+}
+
+func (r *MysqlRestore) Unwrap() *apiv1alpha1.MysqlRestore {
+	return r.MysqlRestore
+}
+
+// GetClusterKey returns the key of the cluster the backup is restored into.
+func (r *MysqlRestore) GetClusterKey() client.ObjectKey {
+	return client.ObjectKey{
+		Name:      r.Spec.ClusterName,
+		Namespace: r.Namespace,
+	}
+}
+
+func (r *MysqlRestore) GetKey() client.ObjectKey {
+	return types.NamespacedName{
+		Namespace: r.Namespace,
+		Name:      r.Name,
+	}
+}
+
+// IsFinished returns true if the restore has failed or succeeded.
+func (r *MysqlRestore) IsFinished() bool {
+	return r.Status.Phase == apiv1alpha1.RestoreSucceeded ||
+		r.Status.Phase == apiv1alpha1.RestoreFailed
+}
+
+// UpdatePhase moves the restore to the phase and records the reason.
+func (r *MysqlRestore) UpdatePhase(phase apiv1alpha1.RestorePhase, progress, reason, message string) {
+	t := metav1.NewTime(time.Now())
+	if r.Status.StartTime == nil {
+		r.Status.StartTime = &t
+	}
+	if phase == apiv1alpha1.RestoreSucceeded || phase == apiv1alpha1.RestoreFailed {
+		r.Status.CompletionTime = &t
+	}
+	r.Status.Phase = phase
+	r.Status.Progress = progress
+	r.Status.Reason = reason
+	r.Status.Message = message
+}
+
+// BackupSource is the backup to restore, resolved from the Backup resource or the S3 source.
+type BackupSource struct {
+	// BackupName is the name of the backup in the storage.
+	BackupName string
+	// Gtid is the GTID set of the backup, empty if unknown.
+	Gtid string
+	// S3SecretName is the name of the secret of the bucket, empty for NFS.
+	S3SecretName string
+	// NFSServerAddress is the address of the NFS server in the form of "ip:path".
+	NFSServerAddress string
+	// EncryptionKeySecret is the name of the secret that contains the decryption key.
+	EncryptionKeySecret string
+	// Compression is the algorithm which compresses the backup.
+	Compression string
+	// ClusterName is the cluster which took the backup, empty if unknown.
+	ClusterName string
+}
+
+// GetS3Source returns the backup source of the S3 bucket.
+func (r *MysqlRestore) GetS3Source() *BackupSource {
+	return &BackupSource{
+		BackupName:          r.Spec.S3.BackupName,
+		S3SecretName:        r.Spec.S3.SecretName,
+		EncryptionKeySecret: r.Spec.S3.EncryptionKeySecret,
+		Compression:         r.Spec.S3.Compression,
+	}
+}
+
+// GetBackupSource returns the backup source of the Backup resource, the backup is the one named
+// in the spec or the last succeeded one.
+func (r *MysqlRestore) GetBackupSource(backup *apiv1beta1.Backup) (*BackupSource, error) {
+	source := &BackupSource{
+		ClusterName: backup.Spec.ClusterName,
+		Compression: backup.Spec.BackupOpts.Compression,
+	}
+	opts := backup.Spec.BackupOpts
+	switch {
+	case opts.S3 != nil:
+		source.S3SecretName = opts.S3.BackupSecretName
+	case opts.NFS != nil:
+		source.NFSServerAddress = fmt.Sprintf("%s:%s", opts.NFS.Volume.Server, opts.NFS.Volume.Path)
+	default:
+		return nil, fmt.Errorf("backup %s has neither S3 nor NFS storage", backup.Name)
+	}
+	if opts.Encryption != nil {
+		source.EncryptionKeySecret = opts.Encryption.KeySecret.Name
+	}
+
+	var last time.Time
+	pick := func(name, gtid string, state apiv1beta1.BackupConditionType, completion *metav1.Time) {
+		if len(name) == 0 || state != apiv1beta1.BackupSucceeded {
+			return
+		}
+		if want := r.Spec.Backup.BackupName; len(want) != 0 {
+			if name == want {
+				source.BackupName, source.Gtid = name, gtid
+			}
+			return
+		}
+		var t time.Time
+		if completion != nil {
+			t = completion.Time
+		}
+		if len(source.BackupName) == 0 || t.After(last) {
+			source.BackupName, source.Gtid = name, gtid
+			last = t
+		}
+	}
+	status := backup.Status
+	pick(status.BackupName, status.Gtid, status.State, status.CompletionTime)
+	if manual := status.ManualBackup; manual != nil {
+		pick(manual.BackupName, manual.Gtid, manual.State, manual.CompletionTime)
+	}
+	for _, scheduled := range status.ScheduledBackups {
+		pick(scheduled.BackupName, scheduled.Gtid, scheduled.State, scheduled.CompletionTime)
+	}
+	if len(source.BackupName) == 0 {
+		if len(r.Spec.Backup.BackupName) != 0 {
+			return nil, fmt.Errorf("backup %s is not a succeeded backup of %s", r.Spec.Backup.BackupName, backup.Name)
+		}
+		return nil, fmt.Errorf("backup %s has no succeeded backup", backup.Name)
+	}
+	return source, nil
+}
+
+// BuildClusterSpec returns the spec of the new cluster, it copies the spec of the source cluster
+// except the restore, backup and replication source settings.
+func (r *MysqlRestore) BuildClusterSpec(sourceSpec *apiv1alpha1.MysqlClusterSpec, source *BackupSource) apiv1alpha1.MysqlClusterSpec {
+	spec := apiv1alpha1.MysqlClusterSpec{}
+	if sourceSpec != nil {
+		spec = *sourceSpec.DeepCopy()
+	}
+	spec.RestorePoint = r.Spec.RestorePoint
+	spec.RestoreTarget = r.Spec.Target.DeepCopy()
+	spec.RestoreFrom = source.BackupName
+	spec.BackupSecretName = source.S3SecretName
+	spec.NFSServerAddress = source.NFSServerAddress
+	spec.RestoreEncryptionKeySecret = source.EncryptionKeySecret
+	spec.RestoreCompression = source.Compression
+	// The new cluster must not write into the backups and the binlog archive of the source cluster.
+	spec.BackupSchedule = ""
+	spec.BackupScheduleJobsHistoryLimit = nil
+	spec.BinlogArchive = nil
+	spec.RemoteCluster = nil
+	spec.SourceConfig = nil
+	if r.Spec.Replicas != nil {
+		replicas := *r.Spec.Replicas
+		spec.Replicas = &replicas
+	}
+	if len(r.Spec.MysqlVersion) != 0 {
+		spec.MysqlVersion = r.Spec.MysqlVersion
+	}
+	return spec
+}
+
+// GetSourceClusterName returns the cluster whose spec the new cluster copies.
+func (r *MysqlRestore) GetSourceClusterName(source *BackupSource) string {
+	if len(r.Spec.SourceCluster) != 0 {
+		return r.Spec.SourceCluster
+	}
+	return source.ClusterName
+}
+
+// Verify checks the gtid_executed of the restored cluster contains the backup,
+// and matches the GTID target and the skipped transactions.
+func (r *MysqlRestore) Verify(restoredGtid string) error {
+	restored, err := utils.ParseGtidSet(restoredGtid)
+	if err != nil {
+		return err
+	}
+	if len(r.Status.BackupGtid) != 0 {
+		backup, err := utils.ParseGtidSet(r.Status.BackupGtid)
+		if err != nil {
+			return err
+		}
+		if !backup.IsSubsetOf(restored) {
+			return fmt.Errorf("the restored gtid %s does not contain the backup gtid %s", restoredGtid, r.Status.BackupGtid)
+		}
+	}
+	target := r.Spec.Target
+	if target == nil {
+		return nil
+	}
+	if len(target.StopGTID) != 0 {
+		uuid, gno, err := utils.ParseGtid(target.StopGTID)
+		if err != nil {
+			return err
+		}
+		if contains := restored.Contains(uuid, gno); contains != target.Inclusive {
+			return fmt.Errorf("the restored gtid %s does not stop at %s, inclusive: %t",
+				restoredGtid, target.StopGTID, target.Inclusive)
+		}
+	}
+	if len(target.SkipGTIDs) != 0 {
+		skipped, err := utils.ParseGtidSet(target.SkipGTIDs)
+		if err != nil {
+			return err
+		}
+		if restored.Intersects(skipped) {
+			return fmt.Errorf("the restored gtid %s contains the skipped gtid %s", restoredGtid, target.SkipGTIDs)
+		}
+	}
+	return nil
+}
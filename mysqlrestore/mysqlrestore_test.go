@@ -0,0 +1,127 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysqlrestore
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+	apiv1beta1 "github.com/radondb/radondb-mysql-kubernetes/api/v1beta1"
+)
+
+const testUUID = "3e11fa47-71ca-11e1-9e33-c80aa9429562"
+
+func newTestRestore() *MysqlRestore {
+	return New(&apiv1alpha1.MysqlRestore{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample-restore", Namespace: "default"},
+		Spec: apiv1alpha1.MysqlRestoreSpec{
+			ClusterName: "sample-restored",
+			Backup:      &apiv1alpha1.RestoreBackupSource{Name: "backup-sample"},
+		},
+	})
+}
+
+func TestGetBackupSource(t *testing.T) {
+	early := metav1.NewTime(time.Now().Add(-time.Hour))
+	late := metav1.NewTime(time.Now())
+	backup := &apiv1beta1.Backup{
+		ObjectMeta: metav1.ObjectMeta{Name: "backup-sample", Namespace: "default"},
+		Spec: apiv1beta1.BackupSpec{
+			ClusterName: "sample",
+			BackupOpts: apiv1beta1.BackupOps{
+				S3:          &apiv1beta1.S3{BackupSecretName: "sample-s3"},
+				Compression: "zstd",
+				Encryption: &apiv1beta1.BackupEncryption{
+					KeySecret: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "sample-key"}},
+				},
+			},
+		},
+		Status: apiv1beta1.BackupStatus{
+			ScheduledBackups: []apiv1beta1.ScheduledBackupStatus{
+				{BackupName: "sample_1", Gtid: testUUID + ":1-10", State: apiv1beta1.BackupSucceeded, CompletionTime: &early},
+				{BackupName: "sample_2", Gtid: testUUID + ":1-20", State: apiv1beta1.BackupSucceeded, CompletionTime: &late},
+				{BackupName: "sample_3", State: apiv1beta1.BackupFailed, CompletionTime: &late},
+			},
+		},
+	}
+	restore := newTestRestore()
+
+	source, err := restore.GetBackupSource(backup)
+	assert.NoError(t, err)
+	assert.Equal(t, "sample_2", source.BackupName)
+	assert.Equal(t, testUUID+":1-20", source.Gtid)
+	assert.Equal(t, "sample-s3", source.S3SecretName)
+	assert.Equal(t, "sample-key", source.EncryptionKeySecret)
+	assert.Equal(t, "zstd", source.Compression)
+	assert.Equal(t, "sample", restore.GetSourceClusterName(source))
+
+	restore.Spec.Backup.BackupName = "sample_1"
+	source, err = restore.GetBackupSource(backup)
+	assert.NoError(t, err)
+	assert.Equal(t, "sample_1", source.BackupName)
+
+	restore.Spec.Backup.BackupName = "sample_3"
+	_, err = restore.GetBackupSource(backup)
+	assert.Error(t, err)
+}
+
+func TestBuildClusterSpec(t *testing.T) {
+	restore := newTestRestore()
+	replicas := int32(1)
+	restore.Spec.Replicas = &replicas
+	restore.Spec.Target = &apiv1alpha1.RestoreTarget{StopGTID: testUUID + ":15"}
+	sourceReplicas := int32(3)
+	sourceSpec := &apiv1alpha1.MysqlClusterSpec{
+		Replicas:       &sourceReplicas,
+		MysqlVersion:   "8.0",
+		BackupSchedule: "0 0 * * *",
+		BinlogArchive:  &apiv1alpha1.BinlogArchiveOpts{Enabled: true, S3SecretName: "sample-s3"},
+	}
+	source := &BackupSource{BackupName: "sample_2", NFSServerAddress: "10.0.0.1:/backup"}
+
+	spec := restore.BuildClusterSpec(sourceSpec, source)
+	assert.Equal(t, int32(1), *spec.Replicas)
+	assert.Equal(t, "8.0", spec.MysqlVersion)
+	assert.Equal(t, "sample_2", spec.RestoreFrom)
+	assert.Equal(t, "10.0.0.1:/backup", spec.NFSServerAddress)
+	assert.Equal(t, testUUID+":15", spec.RestoreTarget.StopGTID)
+	assert.Empty(t, spec.BackupSchedule)
+	assert.Nil(t, spec.BinlogArchive)
+	// The source cluster is not changed.
+	assert.Equal(t, int32(3), *sourceSpec.Replicas)
+	assert.NotNil(t, sourceSpec.BinlogArchive)
+}
+
+func TestVerify(t *testing.T) {
+	restore := newTestRestore()
+	restore.Status.BackupGtid = testUUID + ":1-10"
+	assert.NoError(t, restore.Verify(testUUID+":1-14"))
+	assert.Error(t, restore.Verify(testUUID+":1-9"))
+
+	restore.Spec.Target = &apiv1alpha1.RestoreTarget{StopGTID: testUUID + ":15", SkipGTIDs: testUUID + ":12"}
+	assert.NoError(t, restore.Verify(testUUID+":1-11:13-14"))
+	assert.Error(t, restore.Verify(testUUID+":1-14"))
+	assert.Error(t, restore.Verify(testUUID+":1-11:13-15"))
+
+	restore.Spec.Target.Inclusive = true
+	assert.NoError(t, restore.Verify(testUUID+":1-11:13-15"))
+}
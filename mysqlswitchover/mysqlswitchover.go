@@ -0,0 +1,97 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysqlswitchover
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+)
+
+const (
+	// ValidationFailedReason is the reason when the target is not qualified to be leader.
+	ValidationFailedReason = "ValidationFailed"
+	// TryLeaderFailedReason is the reason when xenon refused the leader transfer.
+	TryLeaderFailedReason = "TryLeaderFailed"
+	// TimeoutReason is the reason when the target did not become leader in time.
+	TimeoutReason = "Timeout"
+	// SwitchoverSucceededReason is the reason when the target has become the leader.
+	SwitchoverSucceededReason = "SwitchoverSucceeded"
+	// AlreadyLeaderReason is the reason when the target is the leader before switchover.
+	AlreadyLeaderReason = "AlreadyLeader"
+	// ConflictReason is the reason when another leader switch of the cluster is in progress.
+	ConflictReason = "Conflict"
+)
+
+type MysqlSwitchover struct {
+	*apiv1alpha1.MysqlSwitchover
+}
+
+func New(switchover *apiv1alpha1.MysqlSwitchover) *MysqlSwitchover {
+	return &MysqlSwitchover{
+		MysqlSwitchover: switchover,
+	}
+}
+
+func (s *MysqlSwitchover) Unwrap() *apiv1alpha1.MysqlSwitchover {
+	return s.MysqlSwitchover
+}
+
+// GetClusterKey returns the key of the cluster to be switched over.
+func (s *MysqlSwitchover) GetClusterKey() client.ObjectKey {
+	return client.ObjectKey{
+		Name:      s.Spec.ClusterName,
+		Namespace: s.Namespace,
+	}
+}
+
+func (s *MysqlSwitchover) GetKey() client.ObjectKey {
+	return types.NamespacedName{
+		Namespace: s.Namespace,
+		Name:      s.Name,
+	}
+}
+
+// IsFinished returns true if the switchover has failed or succeeded.
+func (s *MysqlSwitchover) IsFinished() bool {
+	return s.Status.Phase == apiv1alpha1.SwitchoverSucceeded ||
+		s.Status.Phase == apiv1alpha1.SwitchoverFailed
+}
+
+// UpdatePhase moves the switchover to the phase and records the reason.
+func (s *MysqlSwitchover) UpdatePhase(phase apiv1alpha1.SwitchoverPhase, reason, message string) {
+	t := metav1.NewTime(time.Now())
+	switch phase {
+	case apiv1alpha1.SwitchoverValidating:
+		if s.Status.StartTime == nil {
+			s.Status.StartTime = &t
+		}
+	case apiv1alpha1.SwitchoverFencing:
+		s.Status.FenceTime = &t
+	case apiv1alpha1.SwitchoverSwitching:
+		s.Status.SwitchTime = &t
+	case apiv1alpha1.SwitchoverSucceeded, apiv1alpha1.SwitchoverFailed:
+		s.Status.CompletionTime = &t
+	}
+	s.Status.Phase = phase
+	s.Status.Reason = reason
+	s.Status.Message = message
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package mysqluser
 
 import (
+	"time"
+
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -33,6 +35,9 @@ const (
 
 	// ProvisionSucceededReason the reason used when provision was successful.
 	ProvisionSucceededReason = "ProvisionSucceeded"
+	// NamespaceNotAllowedReason is the reason when the namespace of the MysqlUser
+	// is not allowed by the cluster.
+	NamespaceNotAllowedReason = "NamespaceNotAllowed"
 )
 
 // MysqlUser is a type wrapper over MysqlUser that contains the Business logic.
@@ -72,3 +77,31 @@ func (u *MysqlUser) GetKey() client.ObjectKey {
 		Name:      u.Name,
 	}
 }
+
+// RotationDue checks whether the generated password should be rotated as the interval.
+func (u *MysqlUser) RotationDue() bool {
+	rotation, status := u.Spec.PasswordRotation, u.Status.PasswordRotation
+	if rotation == nil || rotation.IntervalDays <= 0 || status == nil || status.LastRotationTime == nil ||
+		status.Phase == apiv1alhpa1.PasswordRotationRetaining {
+		return false
+	}
+	interval := time.Duration(rotation.IntervalDays) * 24 * time.Hour
+	return time.Since(status.LastRotationTime.Time) >= interval
+}
+
+// RetainedPasswordRemaining returns the remaining time of the retained old password,
+// returns 0 if there is no retained password.
+func (u *MysqlUser) RetainedPasswordRemaining() time.Duration {
+	rotation, status := u.Spec.PasswordRotation, u.Status.PasswordRotation
+	if rotation == nil || status == nil || status.LastRotationTime == nil ||
+		status.Phase != apiv1alhpa1.PasswordRotationRetaining {
+		return 0
+	}
+	grace := time.Duration(rotation.GracePeriodSeconds) * time.Second
+	return time.Until(status.LastRotationTime.Add(grace))
+}
+
+// RetainedPasswordExpired checks whether the grace period of the retained old password is over.
+func (u *MysqlUser) RetainedPasswordExpired() bool {
+	return u.RetainedPasswordRemaining() <= 0
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mysqluser
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	apiv1alpha1 "github.com/radondb/radondb-mysql-kubernetes/api/v1alpha1"
+)
+
+func newRotationUser(phase apiv1alpha1.PasswordRotationPhase, rotatedAgo time.Duration) *MysqlUser {
+	last := metav1.NewTime(time.Now().Add(-rotatedAgo))
+	return New(&apiv1alpha1.MysqlUser{
+		Spec: apiv1alpha1.UserSpec{
+			PasswordRotation: &apiv1alpha1.PasswordRotation{
+				GeneratePassword:   true,
+				IntervalDays:       7,
+				GracePeriodSeconds: 3600,
+			},
+		},
+		Status: apiv1alpha1.UserStatus{
+			PasswordRotation: &apiv1alpha1.PasswordRotationStatus{Phase: phase, LastRotationTime: &last},
+		},
+	})
+}
+
+func TestRotationDue(t *testing.T) {
+	day := 24 * time.Hour
+	tests := []struct {
+		name string
+		user *MysqlUser
+		want bool
+	}{
+		{
+			name: "interval not reached",
+			user: newRotationUser(apiv1alpha1.PasswordRotationCompleted, 6*day),
+			want: false,
+		},
+		{
+			name: "interval reached",
+			user: newRotationUser(apiv1alpha1.PasswordRotationCompleted, 7*day),
+			want: true,
+		},
+		{
+			name: "retaining the old password",
+			user: newRotationUser(apiv1alpha1.PasswordRotationRetaining, 8*day),
+			want: false,
+		},
+		{
+			name: "never rotated",
+			user: func() *MysqlUser {
+				u := newRotationUser(apiv1alpha1.PasswordRotationCompleted, 8*day)
+				u.Status.PasswordRotation = nil
+				return u
+			}(),
+			want: false,
+		},
+		{
+			name: "interval disabled",
+			user: func() *MysqlUser {
+				u := newRotationUser(apiv1alpha1.PasswordRotationCompleted, 8*day)
+				u.Spec.PasswordRotation.IntervalDays = 0
+				return u
+			}(),
+			want: false,
+		},
+		{
+			name: "rotation disabled",
+			user: func() *MysqlUser {
+				u := newRotationUser(apiv1alpha1.PasswordRotationCompleted, 8*day)
+				u.Spec.PasswordRotation = nil
+				return u
+			}(),
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.user.RotationDue())
+		})
+	}
+}
+
+func TestRetainedPasswordRemaining(t *testing.T) {
+	// The old password is retained for the grace period after the rotation.
+	user := newRotationUser(apiv1alpha1.PasswordRotationRetaining, 10*time.Minute)
+	remaining := user.RetainedPasswordRemaining()
+	assert.True(t, remaining > 49*time.Minute && remaining <= 50*time.Minute, "remaining %s", remaining)
+	assert.False(t, user.RetainedPasswordExpired())
+
+	user = newRotationUser(apiv1alpha1.PasswordRotationRetaining, 2*time.Hour)
+	assert.True(t, user.RetainedPasswordRemaining() < 0)
+	assert.True(t, user.RetainedPasswordExpired())
+
+	// There is no retained password.
+	user = newRotationUser(apiv1alpha1.PasswordRotationCompleted, 10*time.Minute)
+	assert.Equal(t, time.Duration(0), user.RetainedPasswordRemaining())
+	user.Spec.PasswordRotation = nil
+	assert.Equal(t, time.Duration(0), user.RetainedPasswordRemaining())
+}
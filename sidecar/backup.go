@@ -54,6 +54,25 @@ type BackupClientConfig struct {
 	XtrabackupTargetDir string `json:"xtrabackup_target_dir"`
 	// BackupType is a backup type for xtrabackup. s3 or disk
 	BackupType BkType `json:"backup_type"`
+	// EncryptInTransit requests the backup http server over https.
+	EncryptInTransit bool `json:"encrypt_in_transit"`
+	// EncryptionAlgorithm is the algorithm to encrypt the backup, empty means not encrypted.
+	EncryptionAlgorithm string `json:"encryption_algorithm"`
+	// EncryptionKey is the key to encrypt the backup.
+	EncryptionKey string `json:"encryption_key"`
+	// EncryptionKeyID is the ID of the encryption key, which is recorded in the backup status.
+	EncryptionKeyID string `json:"encryption_key_id"`
+	// Compression is the algorithm to compress the backup, empty means not compressed.
+	Compression string `json:"compression"`
+	// Parallel is the number of threads to copy and compress the files by xtrabackup.
+	Parallel int `json:"parallel"`
+	// UploadParallel is the number of threads to upload the backup by xbcloud.
+	UploadParallel int `json:"upload_parallel"`
+	// IncrementalLSN is the LSN the incremental backup starts from, empty means a full backup.
+	IncrementalLSN string `json:"incremental_lsn"`
+	// BackupChain is the backups the incremental backup is based on, from the full base backup
+	// to the direct parent.
+	BackupChain []string `json:"backup_chain"`
 }
 
 type BkType string
@@ -82,19 +101,31 @@ func NewReqBackupConfig() *BackupClientConfig {
 		XCloudS3SecretKey: getEnvValue("S3_SECRETKEY"),
 		XCloudS3Bucket:    getEnvValue("S3_BUCKET"),
 		BackupType:        BkType(getEnvValue("BACKUP_TYPE")),
+		EncryptInTransit:  getEnvValue("ENCRYPT_IN_TRANSIT") == "true",
+
+		EncryptionAlgorithm: getEnvValue("BACKUP_ENCRYPTION_ALGORITHM"),
+		EncryptionKey:       getEnvValue("BACKUP_ENCRYPTION_KEY"),
+		EncryptionKeyID:     getEnvValue("BACKUP_ENCRYPTION_KEY_ID"),
+
+		Compression:    getEnvValue("BACKUP_COMPRESSION"),
+		Parallel:       getEnvIntValue("BACKUP_PARALLEL", 0),
+		UploadParallel: getEnvIntValue("BACKUP_UPLOAD_PARALLEL", defaultTransferParallel),
+
+		IncrementalLSN: getEnvValue("BACKUP_INCREMENTAL_LSN"),
+		BackupChain:    getEnvListValue("BACKUP_CHAIN"),
 	}
 }
 
 // Build xbcloud arguments
-func (cfg *BackupClientConfig) XCloudArgs(backupName string) []string {
+func (cfg *BackupClientConfig) XCloudArgs(backupName, optionFile string) []string {
 	xcloudArgs := []string{
+		// The credentials are in the option file, which must be the first argument.
+		fmt.Sprintf("--defaults-file=%s", optionFile),
 		"put",
 		"--storage=S3",
 		fmt.Sprintf("--s3-endpoint=%s", cfg.XCloudS3EndPoint),
-		fmt.Sprintf("--s3-access-key=%s", cfg.XCloudS3AccessKey),
-		fmt.Sprintf("--s3-secret-key=%s", cfg.XCloudS3SecretKey),
 		fmt.Sprintf("--s3-bucket=%s", cfg.XCloudS3Bucket),
-		"--parallel=10",
+		fmt.Sprintf("--parallel=%d", cfg.UploadParallel),
 		// utils.BuildBackupName(cfg.ClusterName),
 		backupName,
 		"--insecure",
@@ -102,29 +133,78 @@ func (cfg *BackupClientConfig) XCloudArgs(backupName string) []string {
 	return xcloudArgs
 }
 
-func (cfg *BackupClientConfig) XtrabackupArgs() []string {
+func (cfg *BackupClientConfig) XtrabackupArgs(optionFile string) []string {
 	// xtrabackup --backup <args> --target-dir=<backup-dir> <extra-args>
 	tmpdir := "/root/backup/"
 	if len(cfg.XtrabackupTargetDir) != 0 {
 		tmpdir = cfg.XtrabackupTargetDir
 	}
 	xtrabackupArgs := []string{
+		// The credentials are in the option file, which must be the first argument.
+		fmt.Sprintf("--defaults-extra-file=%s", optionFile),
 		"--backup",
 		"--stream=xbstream",
 		"--host=127.0.0.1",
-		fmt.Sprintf("--user=%s", utils.RootUser),
-		fmt.Sprintf("--password=%s", cfg.RootPassword),
 		fmt.Sprintf("--target-dir=%s", tmpdir),
 	}
+	xtrabackupArgs = append(xtrabackupArgs, cfg.XtrabackupTuningArgs()...)
+	xtrabackupArgs = append(xtrabackupArgs, cfg.XtrabackupIncrementalArgs()...)
 
 	return append(xtrabackupArgs, cfg.XtrabackupExtraArgs...)
 }
 
+// XtrabackupTuningArgs returns the arguments of xtrabackup to compress the backup and copy the files in parallel.
+func (cfg *BackupClientConfig) XtrabackupTuningArgs() []string {
+	var args []string
+	if cfg.Parallel > 0 {
+		args = append(args, fmt.Sprintf("--parallel=%d", cfg.Parallel))
+	}
+	if len(cfg.Compression) != 0 {
+		args = append(args, fmt.Sprintf("--compress=%s", xtrabackupCompressAlgorithm(cfg.Compression)))
+		if cfg.Parallel > 0 {
+			args = append(args, fmt.Sprintf("--compress-threads=%d", cfg.Parallel))
+		}
+	}
+	return args
+}
+
+// XtrabackupIncrementalArgs returns the arguments of xtrabackup to take the changes since the LSN
+// of the parent backup. The parent is not available locally, so --incremental-lsn is used instead
+// of --incremental-basedir.
+func (cfg *BackupClientConfig) XtrabackupIncrementalArgs() []string {
+	if len(cfg.IncrementalLSN) == 0 {
+		return nil
+	}
+	return []string{"--incremental-lsn=" + cfg.IncrementalLSN}
+}
+
+// XtrabackupEncryptArgs writes the encryption key to a temporary file, returns the arguments
+// of xtrabackup to encrypt the backup and the key file, which should be removed by the caller.
+// It returns nothing if the backup is not encrypted.
+func (cfg *BackupClientConfig) XtrabackupEncryptArgs() ([]string, string, error) {
+	if len(cfg.EncryptionAlgorithm) == 0 {
+		return nil, "", nil
+	}
+	keyFile, err := writeEncryptionKeyFile(cfg.EncryptionKey)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to write the encryption key: %s", err)
+	}
+	return encryptionArgs("encrypt", cfg.EncryptionAlgorithm, keyFile), keyFile, nil
+}
+
+// checkEncryptionInTransit refuses to send the encryption key to the backup container over http.
+func (cfg *BackupClientConfig) checkEncryptionInTransit() error {
+	if len(cfg.EncryptionAlgorithm) != 0 && !cfg.EncryptInTransit {
+		return fmt.Errorf("the encryption of the backup requires the encryptInTransit of the cluster")
+	}
+	return nil
+}
+
 func (cfg *BackupClientConfig) XBackupName() (string, string) {
 	return utils.BuildBackupName(cfg.ClusterName)
 }
 
-func setAnnonations(cfg *BackupClientConfig, backname string, DateTime string, BackupType string, BackupSize int64, Gtid string) error {
+func setAnnonations(cfg *BackupClientConfig, backname string, DateTime string, BackupType string, BackupSize int64, Gtid string, checkpoints *backupCheckpoints) error {
 	config, err := rest.InClusterConfig()
 	if err != nil {
 		return err
@@ -147,6 +227,18 @@ func setAnnonations(cfg *BackupClientConfig, backname string, DateTime string, B
 	job.Annotations[utils.JobAnonationType] = BackupType
 	job.Annotations[utils.JobAnonationSize] = strconv.FormatInt(BackupSize, 10)
 	job.Annotations[utils.JobAnonationGtid] = Gtid
+	if len(cfg.EncryptionAlgorithm) != 0 {
+		job.Annotations[utils.JobAnonationEncryptionKeyID] = cfg.EncryptionKeyID
+	}
+	// The operator builds the backup chain from the LSNs and the parents of the backup.
+	if checkpoints != nil {
+		job.Annotations[utils.JobAnonationFromLSN] = checkpoints.FromLSN
+		job.Annotations[utils.JobAnonationToLSN] = checkpoints.ToLSN
+		job.Annotations[utils.JobAnonationBackupHost] = checkpoints.Host
+	}
+	if len(cfg.IncrementalLSN) != 0 {
+		job.Annotations[utils.JobAnonationBackupChain] = strings.Join(cfg.BackupChain, ",")
+	}
 	_, err = clientset.BatchV1().Jobs(cfg.NameSpace).Update(context.TODO(), job, metav1.UpdateOptions{})
 	if err != nil {
 		return err
@@ -155,13 +247,30 @@ func setAnnonations(cfg *BackupClientConfig, backname string, DateTime string, B
 }
 
 func RunTakeS3BackupCommand(cfg *BackupClientConfig) (string, string, int64, string, error) {
+	xtrabackupOptionFile, err := writeOptionFile("xtrabackup", xtrabackupOptions(cfg.RootPassword))
+	if err != nil {
+		return "", "", 0, "", err
+	}
+	defer os.Remove(xtrabackupOptionFile)
+	xcloudOptionFile, err := writeOptionFile("xbcloud", xcloudOptions(cfg.XCloudS3AccessKey, cfg.XCloudS3SecretKey))
+	if err != nil {
+		return "", "", 0, "", err
+	}
+	defer os.Remove(xcloudOptionFile)
+	encryptArgs, keyFile, err := cfg.XtrabackupEncryptArgs()
+	if err != nil {
+		return "", "", 0, "", err
+	}
+	if len(keyFile) != 0 {
+		defer os.Remove(keyFile)
+	}
+
 	// cfg->XtrabackupArgs()
-	xtrabackup := exec.Command(xtrabackupCommand, cfg.XtrabackupArgs()...)
+	xtrabackup := exec.Command(xtrabackupCommand, append(cfg.XtrabackupArgs(xtrabackupOptionFile), encryptArgs...)...)
 
-	var err error
 	backupName, DateTime := cfg.XBackupName()
-	xcloud := exec.Command(xcloudCommand, cfg.XCloudArgs(backupName)...)
-	log.Info("xargs ", "xargs", strings.Join(cfg.XCloudArgs(backupName), " "))
+	xcloud := exec.Command(xcloudCommand, cfg.XCloudArgs(backupName, xcloudOptionFile)...)
+	log.Info("xargs ", "xargs", strings.Join(cfg.XCloudArgs(backupName, xcloudOptionFile), " "))
 
 	// Create a pipe between xtrabackup and xcloud
 	r, w := io.Pipe()
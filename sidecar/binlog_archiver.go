@@ -0,0 +1,673 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/minio/minio-go/v7"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+const (
+	// binlogArchiveStateObject is the object under the prefix which saves the progress of the archive.
+	binlogArchiveStateObject = "archive-state.json"
+	// binlogArchiveTick is the interval to check the binlogs of the leader.
+	binlogArchiveTick = 5 * time.Second
+	// binlogArchiveRetryInterval is the interval to reconnect the leader after the streaming breaks.
+	binlogArchiveRetryInterval = 5 * time.Second
+	// defaultBinlogFlushInterval is the default interval to flush the binlogs of the leader.
+	defaultBinlogFlushInterval = 30
+	// maxGtidSnapshots limits the snapshots kept to calculate the lag.
+	maxGtidSnapshots = 1000
+)
+
+// binlogNameRegexp matches the valid binlog file names.
+var binlogNameRegexp = regexp.MustCompile(`^[\w.-]+$`)
+
+// BinlogArchiverConfig is the config of the binlog archiver.
+type BinlogArchiverConfig struct {
+	// The name of the cluster.
+	ClusterName string
+	// The host of the leader service.
+	LeaderHost string
+	// The user to stream the binlogs.
+	User string
+	// The file contains the password of the user, it is read on every connection
+	// so that the rotated password takes effect.
+	PasswordFile string
+
+	// The prefix of the archived objects in the bucket.
+	Prefix string
+	// The directory to save the binlogs being streamed.
+	SpoolDir string
+	// The longest time a transaction waits in the current binlog of the leader.
+	FlushInterval time.Duration
+
+	XCloudS3EndPoint  string
+	XCloudS3AccessKey string
+	XCloudS3SecretKey string
+	XCloudS3Bucket    string
+}
+
+// NewBinlogArchiverConfig returns the binlog archiver config.
+func NewBinlogArchiverConfig() *BinlogArchiverConfig {
+	return &BinlogArchiverConfig{
+		ClusterName:   getEnvValue("CLUSTER_NAME"),
+		LeaderHost:    getEnvValue("LEADER_HOST"),
+		User:          getEnvValue("MYSQL_USER"),
+		PasswordFile:  getEnvValue("MYSQL_PASSWORD_FILE"),
+		Prefix:        getEnvValue("ARCHIVE_PREFIX"),
+		SpoolDir:      getEnvValue("ARCHIVE_SPOOL_DIR"),
+		FlushInterval: time.Duration(getEnvIntValue("FLUSH_INTERVAL_SECONDS", defaultBinlogFlushInterval)) * time.Second,
+
+		XCloudS3EndPoint:  getEnvValue("S3_ENDPOINT"),
+		XCloudS3AccessKey: getEnvValue("S3_ACCESSKEY"),
+		XCloudS3SecretKey: getEnvValue("S3_SECRETKEY"),
+		XCloudS3Bucket:    getEnvValue("S3_BUCKET"),
+	}
+}
+
+// BinlogArchiveState is the progress of the archive saved in the bucket, the archiver resumes
+// from it after restarts and failovers.
+type BinlogArchiveState struct {
+	// GtidSet is the set of the transactions in the archived binlogs.
+	GtidSet string `json:"gtidSet"`
+	// Sequence is the sequence number of the last archived binlog.
+	Sequence int64 `json:"sequence"`
+	// LastObject is the key of the last archived binlog.
+	LastObject string `json:"lastObject,omitempty"`
+}
+
+// BinlogManifest describes an archived binlog, it is uploaded beside the binlog as <key>.json.
+type BinlogManifest struct {
+	// File is the name of the binlog on the server.
+	File string `json:"file"`
+	// ServerUUID is the uuid of the leader which wrote the binlog.
+	ServerUUID string `json:"serverUUID"`
+	Size       int64  `json:"size"`
+	SHA256     string `json:"sha256"`
+	// PreviousGtids is the set of the transactions executed before the binlog.
+	PreviousGtids string `json:"previousGtids"`
+	// GtidSet is the set of the transactions executed at the end of the binlog.
+	GtidSet    string `json:"gtidSet"`
+	ArchivedAt string `json:"archivedAt"`
+}
+
+// binlogFile is a binlog listed by `SHOW BINARY LOGS`.
+type binlogFile struct {
+	name string
+	size int64
+}
+
+// gtidSnapshot is the gtid_executed of the leader at a time.
+type gtidSnapshot struct {
+	time    time.Time
+	gtidSet string
+}
+
+type binlogArchiver struct {
+	cfg *BinlogArchiverConfig
+	s3  *S3struct
+
+	state BinlogArchiveState
+	// The snapshots of the leader which are not archived yet, the oldest one is
+	// the age of the archive lag.
+	snapshots    []gtidSnapshot
+	lastExecuted string
+	lastFlush    time.Time
+
+	// The leader and progress of the current streaming session.
+	source     binlogSource
+	serverUUID string
+	next       string
+
+	lag           prometheus.Gauge
+	uploadedFiles prometheus.Counter
+	uploadedBytes prometheus.Counter
+	failures      prometheus.Counter
+	registry      *prometheus.Registry
+}
+
+func newBinlogArchiver(cfg *BinlogArchiverConfig, s3 *S3struct) *binlogArchiver {
+	labels := prometheus.Labels{"cluster": cfg.ClusterName}
+	a := &binlogArchiver{
+		cfg: cfg,
+		s3:  s3,
+		lag: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        "mysql_binlog_archive_lag_seconds",
+			Help:        "The age of the oldest transaction of the leader which is not archived.",
+			ConstLabels: labels,
+		}),
+		uploadedFiles: prometheus.NewCounter(prometheus.CounterOpts{
+			Name:        "mysql_binlog_archive_uploaded_files_total",
+			Help:        "The number of the archived binlogs.",
+			ConstLabels: labels,
+		}),
+		uploadedBytes: prometheus.NewCounter(prometheus.CounterOpts{
+			Name:        "mysql_binlog_archive_uploaded_bytes_total",
+			Help:        "The size of the archived binlogs.",
+			ConstLabels: labels,
+		}),
+		failures: prometheus.NewCounter(prometheus.CounterOpts{
+			Name:        "mysql_binlog_archive_failures_total",
+			Help:        "The number of the interrupted streaming sessions.",
+			ConstLabels: labels,
+		}),
+		registry: prometheus.NewRegistry(),
+	}
+	a.registry.MustRegister(a.lag, a.uploadedFiles, a.uploadedBytes, a.failures)
+	return a
+}
+
+// RunBinlogArchiver streams the binlogs from the leader and archives the closed ones to S3 until
+// stopped. The streaming restarts from the last archived transactions after it breaks, such as the
+// leader changes.
+func RunBinlogArchiver(cfg *BinlogArchiverConfig, stop <-chan struct{}) error {
+	s3, err := NewS3(strings.TrimPrefix(strings.TrimPrefix(cfg.XCloudS3EndPoint, "https://"), "http://"),
+		cfg.XCloudS3AccessKey, cfg.XCloudS3SecretKey, cfg.XCloudS3Bucket,
+		strings.HasPrefix(cfg.XCloudS3EndPoint, "https"))
+	if err != nil {
+		return fmt.Errorf("failed to new s3: %s", err)
+	}
+	archiver := newBinlogArchiver(cfg, s3)
+
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", utils.BinlogArchiverPort))
+	if err != nil {
+		return err
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(archiver.registry, promhttp.HandlerOpts{}))
+	mux.HandleFunc(serverProbeEndpoint, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("OK"))
+	})
+	go func() {
+		if err := http.Serve(listener, mux); err != nil {
+			log.Error(err, "failed to serve the metrics of the binlog archiver")
+		}
+	}()
+
+	for {
+		if err := archiver.run(stop); err != nil {
+			log.Error(err, "binlog archiving is interrupted, resume it later")
+			archiver.failures.Inc()
+		}
+		select {
+		case <-stop:
+			return nil
+		case <-time.After(binlogArchiveRetryInterval):
+		}
+	}
+}
+
+// run streams the binlogs from the current leader until the streaming breaks or stopped.
+func (a *binlogArchiver) run(stop <-chan struct{}) error {
+	password, err := os.ReadFile(a.cfg.PasswordFile)
+	if err != nil {
+		return fmt.Errorf("failed to read the password: %s", err)
+	}
+	db, err := a.connect(string(password))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	a.source = &sqlBinlogSource{db: db}
+
+	if err = a.checkLeader(); err != nil {
+		return err
+	}
+	if a.serverUUID, err = a.source.serverUUID(); err != nil {
+		return err
+	}
+	if err = a.loadState(); err != nil {
+		return fmt.Errorf("failed to load the archive state: %s", err)
+	}
+	binlogs, err := a.source.showBinaryLogs()
+	if err != nil {
+		return err
+	}
+	if a.next, err = a.findStartBinlog(binlogs); err != nil {
+		return err
+	}
+
+	// The binlogs left by the previous session are streamed again.
+	if err = os.RemoveAll(a.cfg.SpoolDir); err != nil {
+		return err
+	}
+	if err = os.MkdirAll(a.cfg.SpoolDir, 0755); err != nil {
+		return err
+	}
+	optionFile, err := writeOptionFile("client", map[string]string{"user": a.cfg.User, "password": string(password)})
+	if err != nil {
+		return err
+	}
+	defer os.Remove(optionFile)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd := exec.CommandContext(ctx, "mysqlbinlog",
+		fmt.Sprintf("--defaults-extra-file=%s", optionFile),
+		fmt.Sprintf("--host=%s", a.cfg.LeaderHost),
+		fmt.Sprintf("--port=%d", utils.MysqlPort),
+		"--read-from-remote-server", "--raw", "--stop-never",
+		fmt.Sprintf("--result-file=%s/", a.cfg.SpoolDir),
+		a.next,
+	)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err = cmd.Start(); err != nil {
+		cancel()
+		return fmt.Errorf("failed to start mysqlbinlog: %s", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+	log.Info("streaming the binlogs", "host", a.cfg.LeaderHost, "serverUUID", a.serverUUID, "from", a.next)
+
+	ticker := time.NewTicker(binlogArchiveTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case err := <-done:
+			// Keep the channel readable for the deferred wait.
+			done <- err
+			return fmt.Errorf("mysqlbinlog exited: %v", err)
+		case <-ticker.C:
+			if err := a.archive(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (a *binlogArchiver) connect(password string) (*sql.DB, error) {
+	cfg := mysql.NewConfig()
+	cfg.User = a.cfg.User
+	cfg.Passwd = password
+	cfg.Net = "tcp"
+	cfg.Addr = net.JoinHostPort(a.cfg.LeaderHost, strconv.Itoa(utils.MysqlPort))
+	cfg.Timeout = 10 * time.Second
+	cfg.ReadTimeout = 30 * time.Second
+	cfg.TLSConfig = "preferred"
+	db, err := sql.Open("mysql", cfg.FormatDSN())
+	if err != nil {
+		return nil, err
+	}
+	// Keep a single connection, so that all the queries go to the same server.
+	db.SetMaxOpenConns(1)
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to connect the leader: %s", err)
+	}
+	return db, nil
+}
+
+// checkLeader returns error if the server is not writable, the leader service may still route
+// to the old leader for a while after the failover.
+func (a *binlogArchiver) checkLeader() error {
+	readOnly, err := a.source.readOnly()
+	if err != nil {
+		return err
+	}
+	if readOnly {
+		return errors.New("the server is not the leader")
+	}
+	return nil
+}
+
+// archive uploads the closed binlogs which are streamed completely, then flushes the binlogs
+// of the leader if the current binlog holds the transactions for too long.
+func (a *binlogArchiver) archive() error {
+	if err := a.checkLeader(); err != nil {
+		return err
+	}
+	binlogs, err := a.source.showBinaryLogs()
+	if err != nil {
+		return err
+	}
+	start := -1
+	for i, f := range binlogs {
+		if f.name == a.next {
+			start = i
+			break
+		}
+	}
+	if start == -1 {
+		return fmt.Errorf("the binlog %s is purged before it is archived", a.next)
+	}
+	for i := start; i < len(binlogs)-1; i++ {
+		info, err := os.Stat(filepath.Join(a.cfg.SpoolDir, binlogs[i].name))
+		if err != nil || info.Size() < binlogs[i].size {
+			// Not streamed completely yet.
+			break
+		}
+		previousGtids, err := a.source.previousGtids(binlogs[i].name)
+		if err != nil {
+			return err
+		}
+		gtidSet, err := a.source.previousGtids(binlogs[i+1].name)
+		if err != nil {
+			return err
+		}
+		if err = a.upload(binlogs[i].name, previousGtids, gtidSet); err != nil {
+			return fmt.Errorf("failed to archive %s: %s", binlogs[i].name, err)
+		}
+		a.next = binlogs[i+1].name
+	}
+
+	executed, err := a.source.gtidExecuted()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if executed != a.lastExecuted {
+		a.lastExecuted = executed
+		a.snapshots = append(a.snapshots, gtidSnapshot{time: now, gtidSet: executed})
+		if len(a.snapshots) > maxGtidSnapshots {
+			// Keep the oldest one, which is the age of the lag.
+			a.snapshots = append(a.snapshots[:1], a.snapshots[2:]...)
+		}
+	}
+	if err = a.updateLag(now); err != nil {
+		return err
+	}
+
+	// Only the current binlog is left, flush it so that its transactions get archived.
+	if a.next == binlogs[len(binlogs)-1].name && len(a.snapshots) != 0 &&
+		now.Sub(a.snapshots[0].time) >= a.cfg.FlushInterval && now.Sub(a.lastFlush) >= a.cfg.FlushInterval {
+		log.V(1).Info("flushing the binlogs of the leader", "binlog", a.next)
+		if err = a.source.flushBinaryLogs(); err != nil {
+			return err
+		}
+		a.lastFlush = now
+	}
+	return nil
+}
+
+// updateLag drops the snapshots covered by the archive, the lag is the age of the oldest
+// snapshot left. The snapshots are in order and each one contains the previous ones, so
+// search the first one not covered.
+func (a *binlogArchiver) updateLag(now time.Time) error {
+	lo, hi := 0, len(a.snapshots)
+	for lo < hi {
+		mid := (lo + hi) / 2
+		covered, err := gtidSubset(a.snapshots[mid].gtidSet, a.state.GtidSet)
+		if err != nil {
+			return err
+		}
+		if covered {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	a.snapshots = a.snapshots[lo:]
+	if len(a.snapshots) == 0 {
+		a.lag.Set(0)
+	} else {
+		a.lag.Set(now.Sub(a.snapshots[0].time).Seconds())
+	}
+	return nil
+}
+
+// findStartBinlog returns the last binlog of the leader whose previous transactions are all
+// archived, the transactions archived again are skipped when they are applied by GTID. It starts
+// from the first binlog if there is no such one, which means the binlogs are purged before archived.
+func (a *binlogArchiver) findStartBinlog(binlogs []binlogFile) (string, error) {
+	if len(binlogs) == 0 {
+		return "", errors.New("binary logging is not enabled")
+	}
+	start := ""
+	for _, f := range binlogs {
+		previousGtids, err := a.source.previousGtids(f.name)
+		if err != nil {
+			return "", err
+		}
+		covered, err := gtidSubset(previousGtids, a.state.GtidSet)
+		if err != nil {
+			return "", err
+		}
+		if !covered {
+			break
+		}
+		start = f.name
+	}
+	if start == "" {
+		log.Info("the transactions between the archive and the binlogs of the leader are lost",
+			"archived", a.state.GtidSet, "first", binlogs[0].name)
+		start = binlogs[0].name
+	}
+	return start, nil
+}
+
+// binlogSource is the server which the binlogs are streamed from.
+type binlogSource interface {
+	readOnly() (bool, error)
+	serverUUID() (string, error)
+	// showBinaryLogs returns the binlogs of the server in order.
+	showBinaryLogs() ([]binlogFile, error)
+	// previousGtids returns the set of the transactions executed before the binlog.
+	previousGtids(name string) (string, error)
+	gtidExecuted() (string, error)
+	flushBinaryLogs() error
+}
+
+// sqlBinlogSource queries the binlogs of the server by the connection.
+type sqlBinlogSource struct {
+	db *sql.DB
+}
+
+func (s *sqlBinlogSource) readOnly() (bool, error) {
+	var readOnly bool
+	err := s.db.QueryRow("SELECT @@GLOBAL.read_only").Scan(&readOnly)
+	return readOnly, err
+}
+
+func (s *sqlBinlogSource) serverUUID() (string, error) {
+	var uuid string
+	err := s.db.QueryRow("SELECT @@server_uuid").Scan(&uuid)
+	return uuid, err
+}
+
+func (s *sqlBinlogSource) showBinaryLogs() ([]binlogFile, error) {
+	rows, err := s.db.Query("SHOW BINARY LOGS")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	var binlogs []binlogFile
+	for rows.Next() {
+		// MySQL 8.0 has an extra column Encrypted.
+		values := make([]sql.RawBytes, len(columns))
+		dest := make([]interface{}, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err = rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		size, err := strconv.ParseInt(string(values[1]), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		binlogs = append(binlogs, binlogFile{name: string(values[0]), size: size})
+	}
+	return binlogs, rows.Err()
+}
+
+// previousGtids returns the Previous_gtids event of the binlog.
+func (s *sqlBinlogSource) previousGtids(name string) (string, error) {
+	if !binlogNameRegexp.MatchString(name) {
+		return "", fmt.Errorf("invalid binlog name %q", name)
+	}
+	rows, err := s.db.Query(fmt.Sprintf("SHOW BINLOG EVENTS IN '%s' LIMIT 4", name))
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var logName, eventType, info string
+		var pos, serverID, endPos int64
+		if err = rows.Scan(&logName, &pos, &eventType, &serverID, &endPos, &info); err != nil {
+			return "", err
+		}
+		if eventType == "Previous_gtids" {
+			return strings.ReplaceAll(info, "\n", ""), nil
+		}
+	}
+	return "", rows.Err()
+}
+
+func (s *sqlBinlogSource) gtidExecuted() (string, error) {
+	var executed string
+	if err := s.db.QueryRow("SELECT @@GLOBAL.gtid_executed").Scan(&executed); err != nil {
+		return "", err
+	}
+	return strings.ReplaceAll(executed, "\n", ""), nil
+}
+
+func (s *sqlBinlogSource) flushBinaryLogs() error {
+	_, err := s.db.Exec("FLUSH BINARY LOGS")
+	return err
+}
+
+// gtidSubset returns whether all the transactions of set1 are in set2.
+func gtidSubset(set1, set2 string) (bool, error) {
+	subset, err := utils.ParseGtidSet(set1)
+	if err != nil {
+		return false, err
+	}
+	set, err := utils.ParseGtidSet(set2)
+	if err != nil {
+		return false, err
+	}
+	return subset.IsSubsetOf(set), nil
+}
+
+// upload uploads the binlog with its manifest, then saves the state.
+func (a *binlogArchiver) upload(name, previousGtids, gtidSet string) error {
+	path := filepath.Join(a.cfg.SpoolDir, name)
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	size, err := io.Copy(hash, f)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	sum := hex.EncodeToString(hash.Sum(nil))
+
+	sequence := a.state.Sequence + 1
+	key := fmt.Sprintf("%s%010d_%s", a.cfg.Prefix, sequence, name)
+	if _, err = a.s3.minioClient.PutObject(a.s3.ctx, a.s3.bucketName, key, f, size, minio.PutObjectOptions{
+		ContentType:    "application/octet-stream",
+		SendContentMd5: true,
+		UserMetadata:   map[string]string{"sha256": sum},
+	}); err != nil {
+		return err
+	}
+	manifest := BinlogManifest{
+		File:          name,
+		ServerUUID:    a.serverUUID,
+		Size:          size,
+		SHA256:        sum,
+		PreviousGtids: previousGtids,
+		GtidSet:       gtidSet,
+		ArchivedAt:    time.Now().UTC().Format(time.RFC3339),
+	}
+	if err = a.putJSON(key+".json", &manifest); err != nil {
+		return err
+	}
+	state := BinlogArchiveState{GtidSet: gtidSet, Sequence: sequence, LastObject: key}
+	if err = a.putJSON(a.cfg.Prefix+binlogArchiveStateObject, &state); err != nil {
+		return err
+	}
+	a.state = state
+	a.uploadedFiles.Inc()
+	a.uploadedBytes.Add(float64(size))
+	log.Info("archived the binlog", "binlog", name, "object", key, "size", size)
+	return os.Remove(path)
+}
+
+func (a *binlogArchiver) putJSON(key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = a.s3.minioClient.PutObject(a.s3.ctx, a.s3.bucketName, key, bytes.NewReader(data), int64(len(data)),
+		minio.PutObjectOptions{ContentType: "application/json", SendContentMd5: true})
+	return err
+}
+
+// loadState loads the state saved in the bucket, the state is empty if nothing is archived.
+func (a *binlogArchiver) loadState() error {
+	obj, err := a.s3.minioClient.GetObject(a.s3.ctx, a.s3.bucketName, a.cfg.Prefix+binlogArchiveStateObject, minio.GetObjectOptions{})
+	if err != nil {
+		return err
+	}
+	defer obj.Close()
+	data, err := io.ReadAll(obj)
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			a.state = BinlogArchiveState{}
+			return nil
+		}
+		return err
+	}
+	state := BinlogArchiveState{}
+	if err = json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	a.state = state
+	return nil
+}
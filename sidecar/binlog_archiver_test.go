@@ -0,0 +1,347 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	oldLeaderUUID = "3e11fa47-71ca-11e1-9e33-c80aa9429562"
+	newLeaderUUID = "4e11fa47-71ca-11e1-9e33-c80aa9429562"
+)
+
+// fakeBinlogSource is a leader with the binlogs, previous maps the binlogs to their Previous_gtids.
+type fakeBinlogSource struct {
+	readOnlyServer bool
+	binlogs        []binlogFile
+	previous       map[string]string
+	executed       string
+	flushes        int
+}
+
+func (f *fakeBinlogSource) readOnly() (bool, error) { return f.readOnlyServer, nil }
+
+func (f *fakeBinlogSource) serverUUID() (string, error) { return newLeaderUUID, nil }
+
+func (f *fakeBinlogSource) showBinaryLogs() ([]binlogFile, error) { return f.binlogs, nil }
+
+func (f *fakeBinlogSource) previousGtids(name string) (string, error) {
+	previous, ok := f.previous[name]
+	if !ok {
+		return "", fmt.Errorf("binlog %s not found", name)
+	}
+	return previous, nil
+}
+
+func (f *fakeBinlogSource) gtidExecuted() (string, error) { return f.executed, nil }
+
+func (f *fakeBinlogSource) flushBinaryLogs() error {
+	f.flushes++
+	return nil
+}
+
+// fakeS3 is a bucket which keeps the objects in memory.
+type fakeS3 struct {
+	sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+	if _, ok := r.URL.Query()["location"]; ok {
+		fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?><LocationConstraint xmlns="http://s3.amazonaws.com/doc/2006-03-01/">us-east-1</LocationConstraint>`)
+		return
+	}
+	switch r.Method {
+	case http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		f.objects[r.URL.Path] = data
+		sum := md5.Sum(data)
+		w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:])+`"`)
+	case http.MethodGet, http.MethodHead:
+		data, ok := f.objects[r.URL.Path]
+		if !ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			if r.Method == http.MethodGet {
+				fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>NoSuchKey</Code><Message>The specified key does not exist.</Message></Error>`)
+			}
+			return
+		}
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		w.Header().Set("Content-Length", fmt.Sprint(len(data)))
+		w.Write(data)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeS3) object(key string) []byte {
+	f.Lock()
+	defer f.Unlock()
+	return f.objects["/bucket/"+key]
+}
+
+func newTestBinlogArchiver(t *testing.T, source *fakeBinlogSource) (*binlogArchiver, *fakeS3) {
+	bucket := &fakeS3{objects: map[string][]byte{}}
+	server := httptest.NewServer(bucket)
+	t.Cleanup(server.Close)
+	// Anonymous requests are not signed, so the fake bucket receives the plain objects.
+	s3, err := NewS3(strings.TrimPrefix(server.URL, "http://"), "", "", "bucket", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &BinlogArchiverConfig{
+		ClusterName:   "sample",
+		Prefix:        "sample-binlogs/",
+		SpoolDir:      t.TempDir(),
+		FlushInterval: time.Minute,
+	}
+	a := newBinlogArchiver(cfg, s3)
+	a.source = source
+	return a, bucket
+}
+
+func TestFindStartBinlog(t *testing.T) {
+	binlogs := []binlogFile{{name: "mysql-bin.000001"}, {name: "mysql-bin.000002"}, {name: "mysql-bin.000003"}}
+	tests := []struct {
+		name     string
+		archived string
+		binlogs  []binlogFile
+		previous map[string]string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:    "binary logging disabled",
+			wantErr: true,
+		},
+		{
+			name:    "nothing archived",
+			binlogs: binlogs,
+			previous: map[string]string{
+				"mysql-bin.000001": "",
+				"mysql-bin.000002": oldLeaderUUID + ":1-10",
+				"mysql-bin.000003": oldLeaderUUID + ":1-20",
+			},
+			want: "mysql-bin.000001",
+		},
+		{
+			name:     "resume",
+			archived: oldLeaderUUID + ":1-10",
+			binlogs:  binlogs,
+			previous: map[string]string{
+				"mysql-bin.000001": "",
+				"mysql-bin.000002": oldLeaderUUID + ":1-10",
+				"mysql-bin.000003": oldLeaderUUID + ":1-20",
+			},
+			want: "mysql-bin.000002",
+		},
+		{
+			// The new leader has different binlogs, it starts from the one containing the
+			// transactions of the old leader which are not archived.
+			name:     "resume after failover",
+			archived: oldLeaderUUID + ":1-10",
+			binlogs:  binlogs,
+			previous: map[string]string{
+				"mysql-bin.000001": "",
+				"mysql-bin.000002": oldLeaderUUID + ":1-8",
+				"mysql-bin.000003": oldLeaderUUID + ":1-12," + newLeaderUUID + ":1-3",
+			},
+			want: "mysql-bin.000002",
+		},
+		{
+			name:     "binlogs purged before archived",
+			archived: oldLeaderUUID + ":1-5",
+			binlogs:  binlogs[1:],
+			previous: map[string]string{
+				"mysql-bin.000002": oldLeaderUUID + ":1-10",
+				"mysql-bin.000003": oldLeaderUUID + ":1-20",
+			},
+			want: "mysql-bin.000002",
+		},
+		{
+			name:     "invalid gtid set",
+			binlogs:  binlogs[:1],
+			previous: map[string]string{"mysql-bin.000001": "invalid"},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, _ := newTestBinlogArchiver(t, &fakeBinlogSource{previous: tt.previous})
+			a.state.GtidSet = tt.archived
+			got, err := a.findStartBinlog(tt.binlogs)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestArchive(t *testing.T) {
+	source := &fakeBinlogSource{
+		binlogs: []binlogFile{{name: "mysql-bin.000001", size: 4}, {name: "mysql-bin.000002", size: 8}, {name: "mysql-bin.000003", size: 4}},
+		previous: map[string]string{
+			"mysql-bin.000001": oldLeaderUUID + ":1-10",
+			"mysql-bin.000002": oldLeaderUUID + ":1-12," + newLeaderUUID + ":1-3",
+			"mysql-bin.000003": oldLeaderUUID + ":1-12," + newLeaderUUID + ":1-5",
+		},
+		executed: oldLeaderUUID + ":1-12," + newLeaderUUID + ":1-6",
+	}
+	a, bucket := newTestBinlogArchiver(t, source)
+	a.serverUUID = newLeaderUUID
+	a.state = BinlogArchiveState{GtidSet: oldLeaderUUID + ":1-10", Sequence: 5}
+	a.next = "mysql-bin.000001"
+
+	spool := func(name, data string) {
+		assert.NoError(t, os.WriteFile(filepath.Join(a.cfg.SpoolDir, name), []byte(data), 0644))
+	}
+	spool("mysql-bin.000001", "bin1")
+	// Not streamed completely yet.
+	spool("mysql-bin.000002", "bin2")
+
+	assert.NoError(t, a.archive())
+	assert.Equal(t, "mysql-bin.000002", a.next)
+	assert.Equal(t, []byte("bin1"), bucket.object("sample-binlogs/0000000006_mysql-bin.000001"))
+	assert.NoFileExists(t, filepath.Join(a.cfg.SpoolDir, "mysql-bin.000001"))
+	assert.FileExists(t, filepath.Join(a.cfg.SpoolDir, "mysql-bin.000002"))
+
+	manifest := BinlogManifest{}
+	assert.NoError(t, json.Unmarshal(bucket.object("sample-binlogs/0000000006_mysql-bin.000001.json"), &manifest))
+	assert.Equal(t, "mysql-bin.000001", manifest.File)
+	assert.Equal(t, newLeaderUUID, manifest.ServerUUID)
+	assert.Equal(t, int64(4), manifest.Size)
+	assert.Equal(t, oldLeaderUUID+":1-10", manifest.PreviousGtids)
+	assert.Equal(t, source.previous["mysql-bin.000002"], manifest.GtidSet)
+
+	state := BinlogArchiveState{}
+	assert.NoError(t, json.Unmarshal(bucket.object("sample-binlogs/archive-state.json"), &state))
+	want := BinlogArchiveState{
+		GtidSet:    source.previous["mysql-bin.000002"],
+		Sequence:   6,
+		LastObject: "sample-binlogs/0000000006_mysql-bin.000001",
+	}
+	assert.Equal(t, want, state)
+	assert.Equal(t, want, a.state)
+	assert.Equal(t, float64(1), testutil.ToFloat64(a.uploadedFiles))
+	assert.Equal(t, float64(4), testutil.ToFloat64(a.uploadedBytes))
+	assert.Len(t, a.snapshots, 1)
+
+	// The state is loaded after restarts.
+	a.state = BinlogArchiveState{}
+	assert.NoError(t, a.loadState())
+	assert.Equal(t, want, a.state)
+
+	// The rest binlogs are archived in order, then the current binlog is flushed.
+	a.cfg.FlushInterval = 0
+	spool("mysql-bin.000002", "binlog2!")
+	assert.NoError(t, a.archive())
+	assert.Equal(t, "mysql-bin.000003", a.next)
+	assert.Equal(t, []byte("binlog2!"), bucket.object("sample-binlogs/0000000007_mysql-bin.000002"))
+	assert.Equal(t, int64(7), a.state.Sequence)
+	assert.Equal(t, 1, source.flushes)
+
+	// The binlog not archived is purged.
+	source.binlogs = source.binlogs[2:]
+	a.next = "mysql-bin.000002"
+	assert.Error(t, a.archive())
+
+	// The leader changed.
+	source.readOnlyServer = true
+	assert.Error(t, a.archive())
+}
+
+func TestLoadStateNotArchived(t *testing.T) {
+	a, _ := newTestBinlogArchiver(t, &fakeBinlogSource{})
+	a.state = BinlogArchiveState{GtidSet: oldLeaderUUID + ":1-10", Sequence: 1}
+	assert.NoError(t, a.loadState())
+	assert.Equal(t, BinlogArchiveState{}, a.state)
+}
+
+func TestUpdateLag(t *testing.T) {
+	now := time.Now()
+	snapshots := []gtidSnapshot{
+		{time: now.Add(-30 * time.Second), gtidSet: oldLeaderUUID + ":1-5"},
+		{time: now.Add(-20 * time.Second), gtidSet: oldLeaderUUID + ":1-8"},
+		// The snapshots of the new leader after the failover.
+		{time: now.Add(-10 * time.Second), gtidSet: oldLeaderUUID + ":1-8," + newLeaderUUID + ":1-2"},
+	}
+	tests := []struct {
+		name     string
+		archived string
+		want     []gtidSnapshot
+		wantLag  float64
+	}{
+		{
+			name:    "nothing archived",
+			want:    snapshots,
+			wantLag: 30,
+		},
+		{
+			name:     "partially archived",
+			archived: oldLeaderUUID + ":1-6",
+			want:     snapshots[1:],
+			wantLag:  20,
+		},
+		{
+			name:     "archived before the failover",
+			archived: oldLeaderUUID + ":1-8",
+			want:     snapshots[2:],
+			wantLag:  10,
+		},
+		{
+			name:     "all archived",
+			archived: oldLeaderUUID + ":1-8," + newLeaderUUID + ":1-3",
+			want:     []gtidSnapshot{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, _ := newTestBinlogArchiver(t, &fakeBinlogSource{})
+			a.snapshots = append([]gtidSnapshot{}, snapshots...)
+			a.state.GtidSet = tt.archived
+			assert.NoError(t, a.updateLag(now))
+			assert.Equal(t, tt.want, a.snapshots)
+			assert.Equal(t, tt.wantLag, testutil.ToFloat64(a.lag))
+		})
+	}
+}
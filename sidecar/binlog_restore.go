@@ -0,0 +1,447 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+const (
+	// binlogRestorePlanFile is the plan written by the init-sidecar and executed by the init-mysql container.
+	binlogRestorePlanFile = utils.RadonDBBinDir + "/restore.json"
+	// binlogRestoreCommand is the sidecar binary copied for the init-mysql container.
+	binlogRestoreCommand = utils.RadonDBBinDir + "/sidecar"
+
+	// The binlog format, see https://dev.mysql.com/doc/internals/en/binlog-event-header.html.
+	binlogEventHeaderSize = 19
+	binlogGtidEventType   = 33
+	// The body of the gtid event: the commit flag, the server uuid and the transaction number.
+	binlogGtidEventBodySize = 1 + 16 + 8
+)
+
+var (
+	// binlogMagic is the header of the binlog files.
+	binlogMagic = []byte{0xfe, 'b', 'i', 'n'}
+	// archivedBinlogPrefixRegexp matches the sequence prefixed to the archived binlogs.
+	archivedBinlogPrefixRegexp = regexp.MustCompile(`^[0-9]{10}_`)
+)
+
+// binlogRestorePlan is the plan to apply the binlogs after the backup is restored. It is resolved
+// by the init-sidecar and executed in the init-mysql container, where mysqld is installed.
+type binlogRestorePlan struct {
+	Steps []binlogRestoreStep `json:"steps"`
+	// ExcludeGtids is the set of the transactions not applied, which are in the backup or skipped.
+	ExcludeGtids string `json:"excludeGtids,omitempty"`
+	// StopDatetime stops applying the binlogs at the time, in the format of "2006-01-02 15:04:05".
+	StopDatetime string `json:"stopDatetime,omitempty"`
+}
+
+// binlogRestoreStep is a binlog to apply.
+type binlogRestoreStep struct {
+	File string `json:"file"`
+	// StopPosition stops applying the binlog at the position, zero applies the whole binlog.
+	StopPosition int64 `json:"stopPosition,omitempty"`
+}
+
+// binlogGtidEvent is the gtid event which starts a transaction in the binlog.
+type binlogGtidEvent struct {
+	uuid string
+	gno  int64
+	// pos is the position the event starts at.
+	pos int64
+}
+
+// pitrRequested returns true if the binlogs should be applied after the backup is restored.
+func (cfg *Config) pitrRequested() bool {
+	return len(cfg.RestorePoint) != 0 || len(cfg.RestoreStopGTID) != 0 || len(cfg.RestoreStopFile) != 0 ||
+		len(cfg.RestoreSkipGTIDs) != 0 || len(cfg.RestoreBinlogArchivePrefix) != 0
+}
+
+// planBinlogRestore resolves the binlogs in the directory to apply after the backup which contains
+// the backupGtid, then writes the plan and the sidecar binary for the init-mysql container.
+func (cfg *Config) planBinlogRestore(backupGtid, binlogDir string) error {
+	exclude, err := utils.ParseGtidSet(backupGtid)
+	if err != nil {
+		return fmt.Errorf("failed to parse the gtid of the backup: %s", err)
+	}
+	skip, err := utils.ParseGtidSet(cfg.RestoreSkipGTIDs)
+	if err != nil {
+		return fmt.Errorf("failed to parse the skipped gtids: %s", err)
+	}
+	exclude.Union(skip)
+
+	plan := binlogRestorePlan{ExcludeGtids: exclude.String()}
+	if len(cfg.RestorePoint) != 0 {
+		if _, err := time.Parse(RestoreTimeSample, cfg.RestorePoint); err != nil {
+			return fmt.Errorf("restore point parse error: %s", err)
+		}
+		plan.StopDatetime = cfg.RestorePoint
+	}
+	files, err := listBinlogFiles(binlogDir)
+	if err != nil {
+		return err
+	}
+	if plan.Steps, err = cfg.resolveBinlogSteps(files); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(&plan)
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(binlogRestorePlanFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write the restore plan: %s", err)
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if err = copyFile(executable, binlogRestoreCommand); err != nil {
+		return fmt.Errorf("failed to copy the sidecar: %s", err)
+	}
+	if err = os.Chmod(binlogRestoreCommand, 0755); err != nil {
+		return err
+	}
+	log.Info("planned to apply the binlogs", "binlogs", len(plan.Steps), "exclude", plan.ExcludeGtids,
+		"stopDatetime", plan.StopDatetime)
+	return nil
+}
+
+// resolveBinlogSteps returns the binlogs to apply until the stop GTID or the stop position,
+// all the binlogs are applied if neither is set.
+func (cfg *Config) resolveBinlogSteps(files []string) ([]binlogRestoreStep, error) {
+	var steps []binlogRestoreStep
+	switch {
+	case len(cfg.RestoreStopGTID) != 0:
+		uuid, gno, err := utils.ParseGtid(cfg.RestoreStopGTID)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			events, err := readBinlogGtidEvents(file)
+			if err != nil {
+				return nil, err
+			}
+			for i, event := range events {
+				if event.uuid != uuid || event.gno != gno {
+					continue
+				}
+				step := binlogRestoreStep{File: file, StopPosition: event.pos}
+				if cfg.RestoreStopInclusive {
+					// Stop at the next transaction, or apply the rest of the binlog.
+					step.StopPosition = 0
+					if i+1 < len(events) {
+						step.StopPosition = events[i+1].pos
+					}
+				}
+				return append(steps, step), nil
+			}
+			steps = append(steps, binlogRestoreStep{File: file})
+		}
+		return nil, fmt.Errorf("the stop gtid %s is not found in the binlogs", cfg.RestoreStopGTID)
+	case len(cfg.RestoreStopFile) != 0:
+		for _, file := range files {
+			if binlogServerName(file) == cfg.RestoreStopFile {
+				return append(steps, binlogRestoreStep{File: file, StopPosition: cfg.RestoreStopPosition}), nil
+			}
+			steps = append(steps, binlogRestoreStep{File: file})
+		}
+		return nil, fmt.Errorf("the stop file %s is not found in the binlogs", cfg.RestoreStopFile)
+	default:
+		for _, file := range files {
+			steps = append(steps, binlogRestoreStep{File: file})
+		}
+		return steps, nil
+	}
+}
+
+// listBinlogFiles returns the binlogs in the directory in order, the archived binlogs are
+// ordered by the sequence prefixed.
+func listBinlogFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasSuffix(name, ".json") || strings.HasSuffix(name, ".index") {
+			continue
+		}
+		files = append(files, path.Join(dir, name))
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// binlogServerName returns the name of the binlog on the server which wrote it.
+func binlogServerName(file string) string {
+	return archivedBinlogPrefixRegexp.ReplaceAllString(filepath.Base(file), "")
+}
+
+// readBinlogGtidEvents reads the gtid events in the binlog, which start the transactions.
+func readBinlogGtidEvents(file string) ([]binlogGtidEvent, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+
+	magic := make([]byte, len(binlogMagic))
+	if _, err = io.ReadFull(r, magic); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %s", file, err)
+	}
+	if !bytes.Equal(magic, binlogMagic) {
+		return nil, fmt.Errorf("%s is not a binlog or is encrypted", file)
+	}
+	var events []binlogGtidEvent
+	pos := int64(len(binlogMagic))
+	header := make([]byte, binlogEventHeaderSize)
+	for {
+		if _, err = io.ReadFull(r, header); err != nil {
+			if err == io.EOF {
+				return events, nil
+			}
+			return nil, fmt.Errorf("failed to read the event at %d of %s: %s", pos, file, err)
+		}
+		size := int64(binary.LittleEndian.Uint32(header[9:13]))
+		if size < binlogEventHeaderSize {
+			return nil, fmt.Errorf("invalid event size %d at %d of %s", size, pos, file)
+		}
+		body := size - binlogEventHeaderSize
+		if header[4] == binlogGtidEventType && body >= binlogGtidEventBodySize {
+			data := make([]byte, binlogGtidEventBodySize)
+			if _, err = io.ReadFull(r, data); err != nil {
+				return nil, fmt.Errorf("failed to read the gtid event at %d of %s: %s", pos, file, err)
+			}
+			events = append(events, binlogGtidEvent{
+				uuid: formatUUID(data[1:17]),
+				gno:  int64(binary.LittleEndian.Uint64(data[17:25])),
+				pos:  pos,
+			})
+			body -= binlogGtidEventBodySize
+		}
+		if _, err = r.Discard(int(body)); err != nil {
+			return nil, fmt.Errorf("failed to skip the event at %d of %s: %s", pos, file, err)
+		}
+		pos += size
+	}
+}
+
+// formatUUID formats the 16 bytes uuid like "3e11fa47-71ca-11e1-9e33-c80aa9429562".
+func formatUUID(b []byte) string {
+	s := hex.EncodeToString(b)
+	return fmt.Sprintf("%s-%s-%s-%s-%s", s[0:8], s[8:12], s[12:16], s[16:20], s[20:32])
+}
+
+// downloadBinlogArchive downloads the archived binlogs which contain the transactions after
+// the backup, and verifies their checksums.
+func (cfg *Config) downloadBinlogArchive(s3 *S3struct, dir, backupGtid string) error {
+	backupSet, err := utils.ParseGtidSet(backupGtid)
+	if err != nil {
+		return fmt.Errorf("failed to parse the gtid of the backup: %s", err)
+	}
+	prefix := cfg.RestoreBinlogArchivePrefix
+	var manifests []string
+	for object := range s3.minioClient.ListObjects(s3.ctx, s3.bucketName, minio.ListObjectsOptions{Prefix: prefix}) {
+		if object.Err != nil {
+			return object.Err
+		}
+		if strings.HasSuffix(object.Key, ".json") && object.Key != prefix+binlogArchiveStateObject {
+			manifests = append(manifests, object.Key)
+		}
+	}
+	sort.Strings(manifests)
+
+	downloaded := 0
+	for _, key := range manifests {
+		manifest := BinlogManifest{}
+		if err := s3.getJSON(key, &manifest); err != nil {
+			return fmt.Errorf("failed to read the manifest %s: %s", key, err)
+		}
+		gtidSet, err := utils.ParseGtidSet(manifest.GtidSet)
+		if err != nil {
+			return fmt.Errorf("failed to parse the manifest %s: %s", key, err)
+		}
+		if gtidSet.IsSubsetOf(backupSet) {
+			// All the transactions are in the backup.
+			continue
+		}
+		binlogKey := strings.TrimSuffix(key, ".json")
+		if err := s3.downloadVerified(binlogKey, path.Join(dir, path.Base(binlogKey)), manifest.SHA256); err != nil {
+			return fmt.Errorf("failed to download %s: %s", binlogKey, err)
+		}
+		downloaded++
+	}
+	log.Info("downloaded the archived binlogs", "prefix", prefix, "binlogs", downloaded)
+	return nil
+}
+
+func (s3 *S3struct) getJSON(key string, v interface{}) error {
+	obj, err := s3.minioClient.GetObject(s3.ctx, s3.bucketName, key, minio.GetObjectOptions{})
+	if err != nil {
+		return err
+	}
+	defer obj.Close()
+	data, err := io.ReadAll(obj)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// downloadVerified downloads the object to the file, and checks the sha256 of the content.
+func (s3 *S3struct) downloadVerified(key, file, sum string) error {
+	obj, err := s3.minioClient.GetObject(s3.ctx, s3.bucketName, key, minio.GetObjectOptions{})
+	if err != nil {
+		return err
+	}
+	defer obj.Close()
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err = io.Copy(io.MultiWriter(f, hash), obj); err != nil {
+		return err
+	}
+	if actual := hex.EncodeToString(hash.Sum(nil)); actual != sum {
+		return fmt.Errorf("checksum mismatch, expected %s, got %s", sum, actual)
+	}
+	return nil
+}
+
+// ApplyBinlogs starts mysqld and applies the binlogs in the plan, it runs in the init-mysql container.
+func ApplyBinlogs(planFile string) error {
+	data, err := os.ReadFile(planFile)
+	if err != nil {
+		return err
+	}
+	plan := binlogRestorePlan{}
+	if err = json.Unmarshal(data, &plan); err != nil {
+		return fmt.Errorf("failed to parse the restore plan: %s", err)
+	}
+
+	mysqld := exec.Command("mysqld")
+	mysqld.Stdout = os.Stdout
+	mysqld.Stderr = os.Stderr
+	if err = mysqld.Start(); err != nil {
+		return fmt.Errorf("failed to start mysqld: %s", err)
+	}
+	exited := make(chan error, 1)
+	go func() {
+		exited <- mysqld.Wait()
+	}()
+	if err = waitMysqld(exited); err != nil {
+		return err
+	}
+
+	applyErr := applyBinlogSteps(&plan)
+	if err = mysqld.Process.Signal(syscall.SIGTERM); err != nil {
+		return fmt.Errorf("failed to stop mysqld: %s", err)
+	}
+	if err = <-exited; err != nil {
+		return fmt.Errorf("mysqld exited with error: %s", err)
+	}
+	if applyErr != nil {
+		return applyErr
+	}
+	// Do not apply the binlogs again if the container restarts.
+	return os.Remove(planFile)
+}
+
+// waitMysqld waits until mysqld accepts the connections.
+func waitMysqld(exited <-chan error) error {
+	for i := 0; i < 120; i++ {
+		if err := exec.Command("mysql", "-uroot", "-e", "SELECT 1").Run(); err == nil {
+			return nil
+		}
+		log.Info("waiting for mysqld to start")
+		select {
+		case err := <-exited:
+			return fmt.Errorf("mysqld exited: %v", err)
+		case <-time.After(time.Second):
+		}
+	}
+	return errors.New("timeout waiting for mysqld to start")
+}
+
+// applyBinlogSteps pipes the events decoded by mysqlbinlog to mysql binlog by binlog.
+func applyBinlogSteps(plan *binlogRestorePlan) error {
+	for i, step := range plan.Steps {
+		reportRestoreProgress(restorePhaseApplyingBinlogs, fmt.Sprintf("%d/%d", i, len(plan.Steps)))
+		args := []string{}
+		if len(plan.ExcludeGtids) != 0 {
+			args = append(args, fmt.Sprintf("--exclude-gtids=%s", plan.ExcludeGtids))
+		}
+		if len(plan.StopDatetime) != 0 {
+			args = append(args, fmt.Sprintf("--stop-datetime=%s", plan.StopDatetime))
+		}
+		if step.StopPosition > 0 {
+			args = append(args, fmt.Sprintf("--stop-position=%d", step.StopPosition))
+		}
+		args = append(args, step.File)
+		log.Info("applying the binlog", "binlog", step.File, "stopPosition", step.StopPosition)
+
+		decode := exec.Command("mysqlbinlog", args...)
+		decode.Stderr = os.Stderr
+		apply := exec.Command("mysql", "-uroot")
+		apply.Stdout = os.Stdout
+		apply.Stderr = os.Stderr
+		stdout, err := decode.StdoutPipe()
+		if err != nil {
+			return err
+		}
+		apply.Stdin = stdout
+		if err = decode.Start(); err != nil {
+			return fmt.Errorf("failed to start mysqlbinlog: %s", err)
+		}
+		if err = apply.Run(); err != nil {
+			decode.Process.Kill()
+			decode.Wait()
+			return fmt.Errorf("failed to apply %s: %s", step.File, err)
+		}
+		if err = decode.Wait(); err != nil {
+			return fmt.Errorf("failed to decode %s: %s", step.File, err)
+		}
+	}
+	reportRestoreProgress(restorePhaseApplyingBinlogs, fmt.Sprintf("%d/%d", len(plan.Steps), len(plan.Steps)))
+	return nil
+}
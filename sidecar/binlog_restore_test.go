@@ -0,0 +1,197 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const fixtureUUID = "3e11fa47-71ca-11e1-9e33-c80aa9429562"
+
+// binlogEvent returns the event of the type with the body, in the binlog format v4.
+func binlogEvent(eventType byte, body []byte) []byte {
+	event := make([]byte, binlogEventHeaderSize, binlogEventHeaderSize+len(body))
+	event[4] = eventType
+	binary.LittleEndian.PutUint32(event[9:13], uint32(binlogEventHeaderSize+len(body)))
+	return append(event, body...)
+}
+
+// writeFixtureBinlog writes a binlog of the transactions of fixtureUUID with the gnos, each one
+// has a gtid, a query and a xid event like the binlogs of mysql 8.0. It returns the positions
+// of the gtid events.
+func writeFixtureBinlog(t *testing.T, file string, gnos ...int64) []int64 {
+	uuid, err := hex.DecodeString(strings.ReplaceAll(fixtureUUID, "-", ""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := append([]byte{}, binlogMagic...)
+	// The format description and the previous gtids events.
+	data = append(data, binlogEvent(15, make([]byte, 100))...)
+	data = append(data, binlogEvent(35, make([]byte, 8))...)
+	var positions []int64
+	for _, gno := range gnos {
+		positions = append(positions, int64(len(data)))
+		// The commit flag, the uuid, the gno, then the logical and the commit timestamps.
+		body := make([]byte, binlogGtidEventBodySize+17)
+		body[0] = 1
+		copy(body[1:17], uuid)
+		binary.LittleEndian.PutUint64(body[17:25], uint64(gno))
+		data = append(data, binlogEvent(binlogGtidEventType, body)...)
+		data = append(data, binlogEvent(2, []byte("BEGIN"))...)
+		data = append(data, binlogEvent(16, make([]byte, 8))...)
+	}
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return positions
+}
+
+func TestReadBinlogGtidEvents(t *testing.T) {
+	dir := t.TempDir()
+	binlog := filepath.Join(dir, "mysql-bin.000001")
+	positions := writeFixtureBinlog(t, binlog, 1, 2, 3)
+	empty := filepath.Join(dir, "mysql-bin.000002")
+	writeFixtureBinlog(t, empty)
+
+	data, err := os.ReadFile(binlog)
+	assert.NoError(t, err)
+	truncated := filepath.Join(dir, "truncated")
+	assert.NoError(t, os.WriteFile(truncated, data[:len(data)-4], 0644))
+	encrypted := filepath.Join(dir, "encrypted")
+	assert.NoError(t, os.WriteFile(encrypted, append([]byte{0xfd, 'b', 'i', 'n'}, data[4:]...), 0644))
+
+	tests := []struct {
+		name    string
+		file    string
+		want    []binlogGtidEvent
+		wantErr bool
+	}{
+		{
+			name: "transactions",
+			file: binlog,
+			want: []binlogGtidEvent{
+				{uuid: fixtureUUID, gno: 1, pos: positions[0]},
+				{uuid: fixtureUUID, gno: 2, pos: positions[1]},
+				{uuid: fixtureUUID, gno: 3, pos: positions[2]},
+			},
+		},
+		{name: "no transaction", file: empty},
+		{name: "truncated", file: truncated, wantErr: true},
+		{name: "encrypted", file: encrypted, wantErr: true},
+		{name: "not found", file: filepath.Join(dir, "missing"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readBinlogGtidEvents(tt.file)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestResolveBinlogSteps(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "0000000001_mysql-bin.000001")
+	second := filepath.Join(dir, "0000000002_mysql-bin.000002")
+	firstPos := writeFixtureBinlog(t, first, 1, 2, 3)
+	secondPos := writeFixtureBinlog(t, second, 4, 5)
+	files := []string{first, second}
+
+	tests := []struct {
+		name    string
+		cfg     Config
+		want    []binlogRestoreStep
+		wantErr bool
+	}{
+		{
+			name: "all",
+			want: []binlogRestoreStep{{File: first}, {File: second}},
+		},
+		{
+			name: "exclusive",
+			cfg:  Config{RestoreStopGTID: fixtureUUID + ":2"},
+			want: []binlogRestoreStep{{File: first, StopPosition: firstPos[1]}},
+		},
+		{
+			name: "inclusive",
+			cfg:  Config{RestoreStopGTID: fixtureUUID + ":2", RestoreStopInclusive: true},
+			want: []binlogRestoreStep{{File: first, StopPosition: firstPos[2]}},
+		},
+		{
+			name: "exclusive in the next file",
+			cfg:  Config{RestoreStopGTID: fixtureUUID + ":4"},
+			want: []binlogRestoreStep{{File: first}, {File: second, StopPosition: secondPos[0]}},
+		},
+		{
+			name: "inclusive last event in file",
+			cfg:  Config{RestoreStopGTID: fixtureUUID + ":3", RestoreStopInclusive: true},
+			want: []binlogRestoreStep{{File: first}},
+		},
+		{
+			name: "inclusive last event",
+			cfg:  Config{RestoreStopGTID: fixtureUUID + ":5", RestoreStopInclusive: true},
+			want: []binlogRestoreStep{{File: first}, {File: second}},
+		},
+		{
+			name:    "gtid not found",
+			cfg:     Config{RestoreStopGTID: fixtureUUID + ":6"},
+			wantErr: true,
+		},
+		{
+			name:    "gtid of another server",
+			cfg:     Config{RestoreStopGTID: "3e11fa47-71ca-11e1-9e33-c80aa9429563:2"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid gtid",
+			cfg:     Config{RestoreStopGTID: fixtureUUID},
+			wantErr: true,
+		},
+		{
+			name: "stop file",
+			cfg:  Config{RestoreStopFile: "mysql-bin.000002", RestoreStopPosition: 120},
+			want: []binlogRestoreStep{{File: first}, {File: second, StopPosition: 120}},
+		},
+		{
+			name:    "stop file not found",
+			cfg:     Config{RestoreStopFile: "mysql-bin.000003", RestoreStopPosition: 120},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.cfg.resolveBinlogSteps(files)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -21,9 +21,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
 
 	"github.com/radondb/radondb-mysql-kubernetes/utils"
 )
@@ -31,13 +33,16 @@ import (
 func requestS3Backup(cfg *BackupClientConfig, host string, endpoint string) (*http.Response, error) {
 
 	log.Info("initialize a backup", "host", host, "endpoint", endpoint)
+	if err := cfg.checkEncryptionInTransit(); err != nil {
+		return nil, err
+	}
 	reqBody, err := json.Marshal(cfg)
 	if err != nil {
 		log.Error(err, "fail to marshal request body")
 		return nil, fmt.Errorf("fail to marshal request body: %s", err)
 	}
 
-	req, err := http.NewRequest("POST", prepareURL(host, endpoint), bytes.NewBuffer(reqBody))
+	req, err := http.NewRequest("POST", prepareURL(host, endpoint, cfg.EncryptInTransit), bytes.NewBuffer(reqBody))
 	if err != nil {
 		return nil, fmt.Errorf("fail to create request: %s", err)
 	}
@@ -45,8 +50,12 @@ func requestS3Backup(cfg *BackupClientConfig, host string, endpoint string) (*ht
 	// set authentication user and password
 	req.SetBasicAuth(cfg.BackupUser, cfg.BackupPassword)
 
+	transport, err := newTransport(cfg.EncryptInTransit)
+	if err != nil {
+		return nil, fmt.Errorf("fail to create transport: %s", err)
+	}
 	client := &http.Client{}
-	client.Transport = transportWithTimeout(serverConnectTimeout)
+	client.Transport = transport
 
 	resp, err := client.Do(req)
 	if err != nil || resp.StatusCode != 200 {
@@ -60,7 +69,11 @@ func requestS3Backup(cfg *BackupClientConfig, host string, endpoint string) (*ht
 	var result utils.JsonResult
 	json.NewDecoder(resp.Body).Decode(&result)
 	log.Info("recive json", "json", result)
-	err = setAnnonations(cfg, result.BackupName, result.Date, "S3", result.BackupSize, result.Gtid) // set annotation
+	var checkpoints *backupCheckpoints
+	if len(result.ToLSN) != 0 {
+		checkpoints = &backupCheckpoints{FromLSN: result.FromLSN, ToLSN: result.ToLSN, Host: backupHostName(host)}
+	}
+	err = setAnnonations(cfg, result.BackupName, result.Date, "S3", result.BackupSize, result.Gtid, checkpoints) // set annotation
 	if err != nil {
 		return nil, fmt.Errorf("fail to set annotation: %s", err)
 	}
@@ -72,19 +85,26 @@ func requestNFSBackup(cfg *BackupClientConfig, host string, endpoint string) err
 
 	backupName, DateTime := cfg.XBackupName()
 
+	if err := cfg.checkEncryptionInTransit(); err != nil {
+		return err
+	}
 	reqBody, err := json.Marshal(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to marshal request body: %w", err)
 	}
 
-	req, err := http.NewRequest("GET", prepareURL(host, endpoint), bytes.NewBuffer(reqBody))
+	req, err := http.NewRequest("GET", prepareURL(host, endpoint, cfg.EncryptInTransit), bytes.NewBuffer(reqBody))
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
 	req.SetBasicAuth(cfg.BackupUser, cfg.BackupPassword)
 
+	transport, err := newTransport(cfg.EncryptInTransit)
+	if err != nil {
+		return fmt.Errorf("failed to create transport: %w", err)
+	}
 	client := &http.Client{
-		Transport: transportWithTimeout(serverConnectTimeout),
+		Transport: transport,
 	}
 
 	resp, err := client.Do(req)
@@ -138,11 +158,21 @@ func requestNFSBackup(cfg *BackupClientConfig, host string, endpoint string) err
 		return fmt.Errorf("xbstream command failed: %w", err)
 	}
 	// Get backup gtid
-	gtid, _ := GetXtrabackupGTIDPurged(backupPath)
+	gtid, err := cfg.getBackupGTIDPurged(backupPath)
+	if err != nil {
+		log.Error(err, "failed to get the gtid of the backup")
+	}
 
 	log.Info("get restore gtid:", "gtid", gtid)
+	// Get the LSNs to chain the incremental backups.
+	checkpoints, err := cfg.getBackupCheckpoints(backupPath)
+	if err != nil {
+		log.Error(err, "failed to get the checkpoints of the backup")
+	} else {
+		checkpoints.Host = backupHostName(host)
+	}
 	// add gtid for nfs backup
-	if err := setAnnonations(cfg, backupName, DateTime, "nfs", n, gtid); err != nil {
+	if err := setAnnonations(cfg, backupName, DateTime, "nfs", n, gtid, checkpoints); err != nil {
 		return fmt.Errorf("failed to set annotation: %w", err)
 	}
 	// TODO pitr
@@ -150,3 +180,70 @@ func requestNFSBackup(cfg *BackupClientConfig, host string, endpoint string) err
 
 	return nil
 }
+
+// backupHostName returns the name of the node in the address of the backup server,
+// such as sample-mysql-0.sample-mysql.default:8082.
+func backupHostName(host string) string {
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		return name
+	}
+	return host
+}
+
+// getBackupGTIDPurged gets the gtid of the backup, the backup may be encrypted or compressed.
+func (cfg *BackupClientConfig) getBackupGTIDPurged(backupPath string) (string, error) {
+	data, err := cfg.readBackupFile(backupPath, "xtrabackup_binlog_info")
+	if err != nil {
+		return "", err
+	}
+	return parseXtrabackupBinlogInfo(data)
+}
+
+// getBackupCheckpoints gets the LSNs of the backup, the backup may be encrypted or compressed.
+func (cfg *BackupClientConfig) getBackupCheckpoints(backupPath string) (*backupCheckpoints, error) {
+	data, err := cfg.readBackupFile(backupPath, "xtrabackup_checkpoints")
+	if err != nil {
+		return nil, err
+	}
+	return parseXtrabackupCheckpoints(data)
+}
+
+// readBackupFile reads the metadata file of the backup, the backup may be encrypted or compressed.
+func (cfg *BackupClientConfig) readBackupFile(backupPath, name string) ([]byte, error) {
+	if len(cfg.EncryptionAlgorithm) == 0 && len(cfg.Compression) == 0 {
+		return os.ReadFile(filepath.Join(backupPath, name))
+	}
+	// Unpack a copy of the file, keep the backup packed.
+	tmpDir, err := os.MkdirTemp("", "backup-file-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+	files, err := filepath.Glob(filepath.Join(backupPath, name+"*"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if err := copyFile(file, filepath.Join(tmpDir, filepath.Base(file))); err != nil {
+			return nil, err
+		}
+	}
+	args := []string{"--remove-original", "--target-dir=" + tmpDir}
+	if len(cfg.EncryptionAlgorithm) != 0 {
+		keyFile, err := writeEncryptionKeyFile(cfg.EncryptionKey)
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(keyFile)
+		args = append(args, encryptionArgs("decrypt", cfg.EncryptionAlgorithm, keyFile)...)
+	}
+	if len(cfg.Compression) != 0 {
+		args = append(args, "--decompress")
+	}
+	cmd := exec.Command(xtrabackupCommand, args...)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to unpack %s: %s", name, err)
+	}
+	return os.ReadFile(filepath.Join(tmpDir, name))
+}
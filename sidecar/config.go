@@ -24,7 +24,6 @@ import (
 	"path"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/blang/semver"
 	"github.com/go-ini/ini"
@@ -83,6 +82,8 @@ type Config struct {
 	AdmitDefeatHearbeatCount int32
 	// The parameter in xenon means election timeout(ms).
 	ElectionTimeout int32
+	// The replication mode between the leader and the followers.
+	ReplicationMode utils.ReplicationMode
 
 	// Whether the MySQL data exists.
 	existMySQLData bool
@@ -97,6 +98,9 @@ type Config struct {
 	// Backup Password to htpp Server
 	BackupPassword string
 
+	// EncryptInTransit serves the backup http server over https and proxies the xenon apis with mTLS.
+	EncryptInTransit bool
+
 	// XbstreamExtraArgs is a list of extra command line arguments to pass to xbstream.
 	XbstreamExtraArgs []string
 
@@ -131,6 +135,26 @@ type Config struct {
 	XCloudS3SecretKey string
 	XCloudS3Bucket    string
 	XRemoteDateSource string
+	// The key to decrypt the backup which restore from, empty means not encrypted.
+	RestoreEncryptionKey string
+	// The algorithm which compresses the backup restore from, empty means not compressed.
+	RestoreCompression string
+	// The number of threads to download, decrypt and decompress the backup.
+	RestoreParallel int
+	// The memory used by xtrabackup to prepare the backup.
+	RestorePrepareMemory string
+	// The backups the incremental backup restore from is based on, from the full base backup.
+	RestoreBackupChain []string
+	// The transaction to stop applying the binlogs at, and whether it is applied.
+	RestoreStopGTID      string
+	RestoreStopInclusive bool
+	// The binlog file and the position to stop applying the binlogs at.
+	RestoreStopFile     string
+	RestoreStopPosition int64
+	// The transactions not applied when applying the binlogs.
+	RestoreSkipGTIDs string
+	// The prefix of the binlog archive to apply the binlogs from.
+	RestoreBinlogArchivePrefix string
 	// Need Upgrade
 	NeedUpgrade bool
 
@@ -208,6 +232,7 @@ func NewInitConfig() *Config {
 
 		AdmitDefeatHearbeatCount: int32(admitDefeatHearbeatCount),
 		ElectionTimeout:          int32(electionTimeout),
+		ReplicationMode:          utils.ReplicationMode(getEnvValue("REPLICATION_MODE")),
 
 		existMySQLData:    existMySQLData,
 		XRestoreFrom:      getEnvValue("RESTORE_FROM"),
@@ -227,6 +252,22 @@ func NewInitConfig() *Config {
 		RemoteClusterNamespace: getEnvValue("REMOTE_CLUSTER_NAMESPACE"),
 		// SERVER_ID_OFFSET
 		ServerIDStartOffset: getEnvValue("SERVER_ID_OFFSET"),
+		EncryptInTransit:    getEnvValue("ENCRYPT_IN_TRANSIT") == "true",
+		// The key to decrypt the backup
+		RestoreEncryptionKey: getEnvValue("RESTORE_ENCRYPTION_KEY"),
+		// The compression and the parallelism to restore the backup
+		RestoreCompression:   getEnvValue("RESTORE_COMPRESSION"),
+		RestoreParallel:      getEnvIntValue("RESTORE_PARALLEL", 0),
+		RestorePrepareMemory: getEnvValue("RESTORE_PREPARE_MEMORY"),
+		// The backup chain of the incremental backup
+		RestoreBackupChain: getEnvListValue("RESTORE_BACKUP_CHAIN"),
+		// The point to apply the binlogs to
+		RestoreStopGTID:            getEnvValue("RESTORE_STOP_GTID"),
+		RestoreStopInclusive:       getEnvValue("RESTORE_STOP_INCLUSIVE") == "true",
+		RestoreStopFile:            getEnvValue("RESTORE_STOP_FILE"),
+		RestoreStopPosition:        int64(getEnvIntValue("RESTORE_STOP_POSITION", 0)),
+		RestoreSkipGTIDs:           getEnvValue("RESTORE_SKIP_GTIDS"),
+		RestoreBinlogArchivePrefix: getEnvValue("RESTORE_BINLOG_ARCHIVE_PREFIX"),
 	}
 }
 
@@ -241,6 +282,8 @@ func NewBackupConfig() *Config {
 		BackupUser:     getEnvValue("BACKUP_USER"),
 		BackupPassword: getEnvValue("BACKUP_PASSWORD"),
 
+		EncryptInTransit: getEnvValue("ENCRYPT_IN_TRANSIT") == "true",
+
 		// XCloudS3EndPoint:  getEnvValue("S3_ENDPOINT"),
 		// XCloudS3AccessKey: getEnvValue("S3_ACCESSKEY"),
 		// XCloudS3SecretKey: getEnvValue("S3_SECRETKEY"),
@@ -267,18 +310,18 @@ func GetContainerType() string {
 }
 
 // build Xtrabackup arguments
-func (cfg *Config) XtrabackupArgs() []string {
+func (cfg *Config) XtrabackupArgs(optionFile string) []string {
 	// xtrabackup --backup <args> --target-dir=<backup-dir> <extra-args>
 	tmpdir := "/root/backup/"
 	if len(cfg.XtrabackupTargetDir) != 0 {
 		tmpdir = cfg.XtrabackupTargetDir
 	}
 	xtrabackupArgs := []string{
+		// The credentials are in the option file, which must be the first argument.
+		fmt.Sprintf("--defaults-extra-file=%s", optionFile),
 		"--backup",
 		"--stream=xbstream",
 		"--host=127.0.0.1",
-		fmt.Sprintf("--user=%s", utils.RootUser),
-		fmt.Sprintf("--password=%s", cfg.RootPassword),
 		fmt.Sprintf("--target-dir=%s", tmpdir),
 	}
 
@@ -286,13 +329,13 @@ func (cfg *Config) XtrabackupArgs() []string {
 }
 
 // Build xbcloud arguments
-func (cfg *Config) XCloudArgs(backupName string) []string {
+func (cfg *Config) XCloudArgs(backupName, optionFile string) []string {
 	xcloudArgs := []string{
+		// The credentials are in the option file, which must be the first argument.
+		fmt.Sprintf("--defaults-file=%s", optionFile),
 		"put",
 		"--storage=S3",
 		fmt.Sprintf("--s3-endpoint=%s", cfg.XCloudS3EndPoint),
-		fmt.Sprintf("--s3-access-key=%s", cfg.XCloudS3AccessKey),
-		fmt.Sprintf("--s3-secret-key=%s", cfg.XCloudS3SecretKey),
 		fmt.Sprintf("--s3-bucket=%s", cfg.XCloudS3Bucket),
 		"--parallel=10",
 		// utils.BuildBackupName(cfg.ClusterName),
@@ -348,6 +391,15 @@ func (cfg *Config) buildExtraConfig(filePath string) (*ini.File, error) {
 	return conf, nil
 }
 
+// xenonPeerAddress returns the address of the xenon http apis. When encrypting in transit, the apis
+// only listen on the loopback, the others request them through the mTLS proxy.
+func (cfg *Config) xenonPeerAddress(hostName string) string {
+	if cfg.EncryptInTransit {
+		return fmt.Sprintf("127.0.0.1:%d", utils.XenonPeerPort)
+	}
+	return fmt.Sprintf("%s:%d", hostName, utils.XenonPeerPort)
+}
+
 // buildXenonConf build a config file for xenon.
 func (cfg *Config) buildXenonConf() []byte {
 	pingTimeout := cfg.ElectionTimeout / cfg.AdmitDefeatHearbeatCount
@@ -368,6 +420,9 @@ func (cfg *Config) buildXenonConf() []byte {
 		replicaSysVars = "sync_binlog=1000;innodb_flush_log_at_trx_commit=1"
 	}
 
+	// In semiSyncStrict mode, xenon must not degrade the leader to async.
+	semiSyncDegrade := cfg.ReplicationMode != utils.SemiSyncStrict
+
 	hostName := fmt.Sprintf("%s.%s.%s", cfg.HostName, cfg.ServiceName, cfg.NameSpace)
 	// Because go-sql-driver will translate localhost to 127.0.0.1 or ::1, but never set the hostname
 	// so the host is set to "127.0.0.1" in config file.
@@ -377,7 +432,7 @@ func (cfg *Config) buildXenonConf() []byte {
 		},
 		"server": {
 			"endpoint": "%s:%d",
-			"peer-address": "%s:%d",
+			"peer-address": "%s",
 			"enable-apis": true
 		},
 		"replication": {
@@ -405,17 +460,17 @@ func (cfg *Config) buildXenonConf() []byte {
 			"admit-defeat-hearbeat-count": %d,
 			"heartbeat-timeout": %d,
 			"meta-datadir": "%s",
-			"semi-sync-degrade": true,
+			"semi-sync-degrade": %t,
 			"purge-binlog-disabled": true,
 			"super-idle": false,
 			"leader-start-command": "/xenonchecker leaderStart",
 			"leader-stop-command": "/xenonchecker leaderStop"
 		}
 	}
-	`, hostName, utils.XenonPort, hostName, utils.XenonPeerPort, cfg.ReplicationPassword, cfg.ReplicationUser,
+	`, hostName, utils.XenonPort, cfg.xenonPeerAddress(hostName), cfg.ReplicationPassword, cfg.ReplicationUser,
 		cfg.GtidPurged, requestTimeout,
 		pingTimeout, cfg.RootPassword, version, srcSysVars, replicaSysVars, cfg.ElectionTimeout,
-		cfg.AdmitDefeatHearbeatCount, heartbeatTimeout, xenonConfigPath)
+		cfg.AdmitDefeatHearbeatCount, heartbeatTimeout, xenonConfigPath, semiSyncDegrade)
 
 	return utils.StringToBytes(str)
 }
@@ -625,22 +680,87 @@ func (cfg *Config) executeS3Restore(pathArg string) error {
 			return fmt.Errorf("failed to create data directory : %s", err)
 		}
 	}
+	// Download the full base backup, the incremental backups are downloaded when preparing.
+	chain := cfg.restoreChain()
+	reportRestoreProgress(restorePhaseDownloading, "0/1")
+	if err := cfg.downloadS3Backup(chain[0], utils.DataVolumeMountPath); err != nil {
+		return err
+	}
+	reportRestoreProgress(restorePhaseDownloading, "1/1")
+	if err := cfg.prepareBackup(utils.DataVolumeMountPath, cfg.downloadS3Backup); err != nil {
+		return err
+	}
+	// Do not need to Xtrabackup copy-back to /var/lib/mysql.
+	// Execute chown -R mysql.mysql /var/lib/mysql.
+	log.Info("chown -R mysql.mysql /var/lib/mysql")
+	if err := exec.Command("chown", "-R", "mysql.mysql", utils.DataVolumeMountPath).Run(); err != nil {
+		return fmt.Errorf("failed to chown mysql.mysql %s  : %s", utils.DataVolumeMountPath, err)
+	}
+	if !cfg.pitrRequested() {
+		return nil
+	}
+	// Get backup gtid
+	gtid, err := GetXtrabackupGTIDPurged(utils.DataVolumeMountPath)
+	if err != nil {
+		return fmt.Errorf("failed to get the gtid of the backup: %s", err)
+	}
+	log.Info("get restore gtid:", "gtid", gtid)
+
+	log.Info("downloading S3 binlog")
+	reportRestoreProgress(restorePhaseApplyingBinlogs, "downloading")
+	s3, err := NewS3(strings.TrimPrefix(strings.TrimPrefix(cfg.XCloudS3EndPoint, "https://"), "http://"),
+		cfg.XCloudS3AccessKey, cfg.XCloudS3SecretKey, cfg.XCloudS3Bucket,
+		strings.HasPrefix(cfg.XCloudS3EndPoint, "https"))
+	if err != nil {
+		return fmt.Errorf("failed to new s3 : %s", err)
+	}
+	binlogDir := path.Join(utils.InitFileVolumeMountPath, buildBinlogDir(cfg.XRestoreFrom))
+	if err = os.Mkdir(binlogDir, os.FileMode(0755)); err != nil && !os.IsExist(err) {
+		return fmt.Errorf("error mkdir %s: %s", buildBinlogDir(cfg.XRestoreFrom), err)
+	}
+	if len(cfg.RestoreBinlogArchivePrefix) != 0 {
+		err = cfg.downloadBinlogArchive(s3, binlogDir, gtid)
+	} else {
+		err = s3.S3Download(cfg, buildBinlogDir(cfg.XRestoreFrom))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to download the binlogs: %s", err)
+	}
+	return cfg.planBinlogRestore(gtid, binlogDir)
+}
+
+// downloadS3Backup downloads the backup from S3, decrypts and decompresses it in the directory.
+func (cfg *Config) downloadS3Backup(backupName, dir string) error {
+	optionFile, err := writeOptionFile("xbcloud", xcloudOptions(cfg.XCloudS3AccessKey, cfg.XCloudS3SecretKey))
+	if err != nil {
+		return fmt.Errorf("failed to write the option file of xbcloud: %s", err)
+	}
+	defer os.Remove(optionFile)
 	// Execute xbcloud get.
 	args := []string{
+		// The credentials are in the option file, which must be the first argument.
+		"--defaults-file=" + optionFile,
 		"get",
 		"--storage=S3",
 		"--s3-endpoint=" + cfg.XCloudS3EndPoint,
-		"--s3-access-key=" + cfg.XCloudS3AccessKey,
-		"--s3-secret-key=" + cfg.XCloudS3SecretKey,
 		"--s3-bucket=" + cfg.XCloudS3Bucket,
-		"--parallel=10",
-		cfg.XRestoreFrom,
+		fmt.Sprintf("--parallel=%d", cfg.restoreParallel()),
+		backupName,
 		"--insecure",
 	}
 	log.Info(fmt.Sprintf("run args %v", args))
-	xcloud := exec.Command(xcloudCommand, args...)                               //nolint
-	xbstream := exec.Command("xbstream", "-xv", "-C", utils.DataVolumeMountPath) //nolint
-	var err error
+	xbstreamArgs := []string{"-xv", "-C", dir}
+	// Decrypt the backup when extracting it.
+	if len(cfg.RestoreEncryptionKey) != 0 {
+		keyFile, err := writeEncryptionKeyFile(cfg.RestoreEncryptionKey)
+		if err != nil {
+			return fmt.Errorf("failed to write the encryption key: %s", err)
+		}
+		defer os.Remove(keyFile)
+		xbstreamArgs = append(xbstreamArgs, encryptionArgs("decrypt", restoreEncryptionAlgorithm, keyFile)...)
+	}
+	xcloud := exec.Command(xcloudCommand, args...)        //nolint
+	xbstream := exec.Command("xbstream", xbstreamArgs...) //nolint
 	if xbstream.Stdin, err = xcloud.StdoutPipe(); err != nil {
 		return fmt.Errorf("failed to xbstream and xcloud piped")
 	}
@@ -666,91 +786,56 @@ func (cfg *Config) executeS3Restore(pathArg string) error {
 			return err
 		}
 	}
-	// Get backup gtid
-	gtid, _ := GetXtrabackupGTIDPurged("/root/backup/")
+	// The backup is decrypted by xbstream, decompress it.
+	if cfg.isBackupCompressed(dir) {
+		if err := cfg.unpackBackup(dir, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	log.Info("get restore gtid:", "gtid", gtid)
-	// Xtrabackup prepare and apply-log-only.
+// prepareBackup prepares the full base backup in the target directory. The incremental backups
+// of the chain are fetched into a temporary directory one by one, and applied in order.
+func (cfg *Config) prepareBackup(targetDir string, fetch func(backupName, dir string) error) error {
+	prepareArgs := []string{"--defaults-file=" + utils.MysqlConfVolumeMountPath + "/my.cnf", cfg.prepareMemoryArg(), "--prepare"}
+	// Xtrabackup prepare and apply-log-only, the uncommitted transactions are rolled back
+	// by the last prepare, after all the incremental backups are applied.
 	log.Info("Xtrabackup prepare and apply-log-only")
-	cmd := exec.Command(xtrabackupCommand, "--defaults-file="+utils.MysqlConfVolumeMountPath+"/my.cnf", "--prepare", "--apply-log-only", "--target-dir="+utils.DataVolumeMountPath)
+	chain := cfg.restoreChain()
+	reportRestoreProgress(restorePhasePreparing, fmt.Sprintf("0/%d", len(chain)))
+	cmd := exec.Command(xtrabackupCommand, append(prepareArgs, "--apply-log-only", "--target-dir="+targetDir)...)
 	cmd.Stderr = os.Stderr
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to xtrabackup prepare and apply-log-only : %s", err)
 	}
+	for i, backupName := range chain[1:] {
+		reportRestoreProgress(restorePhasePreparing, fmt.Sprintf("%d/%d", i+1, len(chain)))
+		incrementalDir, err := os.MkdirTemp("", "incremental-")
+		if err != nil {
+			return err
+		}
+		if err := fetch(backupName, incrementalDir); err != nil {
+			os.RemoveAll(incrementalDir)
+			return fmt.Errorf("failed to fetch the incremental backup %s : %s", backupName, err)
+		}
+		log.Info("Xtrabackup apply the incremental backup", "backup", backupName)
+		cmd = exec.Command(xtrabackupCommand, append(prepareArgs, "--apply-log-only", "--target-dir="+targetDir, "--incremental-dir="+incrementalDir)...)
+		cmd.Stderr = os.Stderr
+		err = cmd.Run()
+		os.RemoveAll(incrementalDir)
+		if err != nil {
+			return fmt.Errorf("failed to xtrabackup apply the incremental backup %s : %s", backupName, err)
+		}
+	}
 	// Xtrabackup prepare.
 	log.Info("Xtrabackup prepare")
-	cmd = exec.Command(xtrabackupCommand, "--defaults-file="+utils.MysqlConfVolumeMountPath+"/my.cnf", "--prepare", "--target-dir="+utils.DataVolumeMountPath)
+	reportRestoreProgress(restorePhasePreparing, fmt.Sprintf("%d/%d", len(chain), len(chain)))
+	cmd = exec.Command(xtrabackupCommand, append(prepareArgs, "--target-dir="+targetDir)...)
 	cmd.Stderr = os.Stderr
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to xtrabackup prepare : %s", err)
 	}
-	// Do not need to Xtrabackup copy-back to /var/lib/mysql.
-	// Execute chown -R mysql.mysql /var/lib/mysql.
-	log.Info("chown -R mysql.mysql /var/lib/mysql")
-	if err := exec.Command("chown", "-R", "mysql.mysql", utils.DataVolumeMountPath).Run(); err != nil {
-		return fmt.Errorf("failed to chown mysql.mysql %s  : %s", utils.DataVolumeMountPath, err)
-	}
-	// Do not need Xtrabackup copy-back.
-	// S3 download
-
-	log.Info("downloading S3 binlog")
-	if s3, err := NewS3(strings.TrimPrefix(strings.TrimPrefix(cfg.XCloudS3EndPoint, "https://"), "http://"),
-		cfg.XCloudS3AccessKey, cfg.XCloudS3SecretKey, cfg.XCloudS3Bucket,
-		strings.HasPrefix(cfg.XCloudS3EndPoint, "https")); err != nil {
-		return fmt.Errorf("failed to new s3 : %s", err)
-	} else {
-		if err = os.Mkdir(path.Join(utils.InitFileVolumeMountPath, buildBinlogDir(cfg.XRestoreFrom)), os.FileMode(0755)); err != nil {
-			if !os.IsExist(err) {
-				return fmt.Errorf("error mkdir %s: %s", buildBinlogDir(cfg.XRestoreFrom), err)
-			}
-		}
-		if len(cfg.RestorePoint) != 0 {
-			restorePoint, err := time.Parse("2006-01-02 15:04:05", cfg.RestorePoint)
-			if err != nil {
-				log.Info("restore point parse error : %s", err)
-			} else {
-				s3.S3Download(cfg, buildBinlogDir(cfg.XRestoreFrom))
-				cfg.BuildScript(gtid, restorePoint)
-			}
-
-		}
-
-	}
-	return nil
-}
-
-// Build Script in InitFileVolumeMountPath
-func (cfg *Config) BuildScript(skipGtid string, restorePoint time.Time) error {
-	//restorePoint, err := time.Parse("20060102-150405", string)
-	binlogPathDir := path.Join(utils.InitFileVolumeMountPath, buildBinlogDir(cfg.XRestoreFrom))
-	script := fmt.Sprintf(`#!/bin/bash
-mysqld&
-pid="$!"
-echo "now to restore binlog"
-mysql=( mysql -uroot )
-for i in {120..0}; do
-			if echo 'SELECT 1' | "${mysql[@]}" &> /dev/null; then
-				break
-			fi
-			echo 'MySQL init process in progress...'
-			sleep 1
-done
-echo "now mysqlstart!"
-if [ "$i" = 0 ]; then
-			echo >&2 'MySQL init process failed.'
-			exit 1
-fi
-ls %s/mysql-bin*|awk '{print "cat   "$1  "|mysqlbinlog --exclude-gtids=\"%s\" --stop-datetime=\"%s\" -|mysql -uroot"}'|bash
-if ! kill -s TERM "$pid" || ! wait "$pid"; then
-	echo >&2 'MySQL init process failed.'
-	exit 1
-fi
-`, binlogPathDir, skipGtid, restorePoint.Format(RestoreTimeSample))
-	fmt.Println(script)
-	if err := ioutil.WriteFile(utils.RadonDBBinDir+"/restore.sh", []byte(script), 0755); err != nil {
-		return err
-	}
-
 	return nil
 }
 
@@ -771,14 +856,14 @@ func (cfg *Config) executeCloneRestore() error {
 
 	// Xtrabackup prepare and apply-log-only.
 	log.Info("xtrabackup prepare apply-log only")
-	cmd := exec.Command(xtrabackupCommand, "--defaults-file="+utils.MysqlConfVolumeMountPath+"/my.cnf", "--use-memory=3072M", "--prepare", "--apply-log-only", "--target-dir="+utils.DataVolumeMountPath)
+	cmd := exec.Command(xtrabackupCommand, "--defaults-file="+utils.MysqlConfVolumeMountPath+"/my.cnf", cfg.prepareMemoryArg(), "--prepare", "--apply-log-only", "--target-dir="+utils.DataVolumeMountPath)
 	cmd.Stderr = os.Stderr
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to xtrabackup prepare apply-log-only : %s", err)
 	}
 	// Xtrabackup Prepare.
 	log.Info("xtrabackup prepare")
-	cmd = exec.Command(xtrabackupCommand, "--defaults-file="+utils.MysqlConfVolumeMountPath+"/my.cnf", "--use-memory=3072M", "--prepare", "--target-dir="+utils.DataVolumeMountPath)
+	cmd = exec.Command(xtrabackupCommand, "--defaults-file="+utils.MysqlConfVolumeMountPath+"/my.cnf", cfg.prepareMemoryArg(), "--prepare", "--target-dir="+utils.DataVolumeMountPath)
 	cmd.Stderr = os.Stderr
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to xtrabackup prepare : %s", err)
@@ -819,6 +904,10 @@ func GetXtrabackupGTIDPurged(backuppath string) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	return parseXtrabackupBinlogInfo(byteStream)
+}
+
+func parseXtrabackupBinlogInfo(byteStream []byte) (string, error) {
 	line := strings.TrimSuffix(string(byteStream), "\n")
 	ss := strings.Split(line, "\t")
 	if len(ss) != 3 {
@@ -828,6 +917,47 @@ func GetXtrabackupGTIDPurged(backuppath string) (string, error) {
 	return strings.Replace(ss[2], "\n", "", -1), nil
 }
 
+// backupCheckpoints are the LSNs of the backup, which chain the incremental backups.
+type backupCheckpoints struct {
+	FromLSN string
+	ToLSN   string
+	// Host is the node the backup was taken from, the LSNs are only meaningful on it.
+	Host string
+}
+
+// getXtrabackupCheckpoints reads the xtrabackup_checkpoints in the directory.
+func getXtrabackupCheckpoints(dir string) (*backupCheckpoints, error) {
+	byteStream, err := ioutil.ReadFile(fmt.Sprintf("%s/xtrabackup_checkpoints", dir))
+	if err != nil {
+		return nil, err
+	}
+	return parseXtrabackupCheckpoints(byteStream)
+}
+
+// Parse the xtrabackup_checkpoints, the format is key = value per line, for example:
+// backup_type = incremental
+// from_lsn = 18509970
+// to_lsn = 18510288
+func parseXtrabackupCheckpoints(byteStream []byte) (*backupCheckpoints, error) {
+	checkpoints := &backupCheckpoints{}
+	for _, line := range strings.Split(string(byteStream), "\n") {
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch strings.TrimSpace(kv[0]) {
+		case "from_lsn":
+			checkpoints.FromLSN = strings.TrimSpace(kv[1])
+		case "to_lsn":
+			checkpoints.ToLSN = strings.TrimSpace(kv[1])
+		}
+	}
+	if len(checkpoints.ToLSN) == 0 {
+		return nil, fmt.Errorf("checkpoints.content.invalid[%v]", string(byteStream))
+	}
+	return checkpoints, nil
+}
+
 /*
 `#!/bin/sh
 
@@ -873,23 +1003,28 @@ func (cfg *Config) ExecuteNFSRestore() error {
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to rm -rf %s : %s", utils.DataVolumeMountPath, err)
 	}
-	// Prepare the append-only file
-	cmd = exec.Command("xtrabackup", "--defaults-file="+utils.MysqlConfVolumeMountPath+"/my.cnf", "--use-memory=3072M", "--prepare", "--apply-log-only", "--target-dir=/backup/"+cfg.XRestoreFrom)
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to xtrabackup prepare append-only: %s", err)
+	chain := cfg.restoreChain()
+	targetDir := "/backup/" + chain[0]
+	reportRestoreProgress(restorePhaseDownloading, "0/1")
+	// The encrypted or compressed backup is unpacked in the data directory, keep the backup on NFS packed.
+	// The base of the incremental backups is prepared in the data directory too, keep it reusable.
+	if len(cfg.RestoreEncryptionKey) != 0 || cfg.isBackupCompressed(targetDir) || len(chain) > 1 {
+		if err := cfg.copyNFSBackup(chain[0], utils.DataVolumeMountPath); err != nil {
+			return err
+		}
+		targetDir = utils.DataVolumeMountPath
 	}
-	// Prepare the data directory
-	cmd = exec.Command("xtrabackup", "--defaults-file="+utils.MysqlConfVolumeMountPath+"/my.cnf", "--use-memory=3072M", "--prepare", "--target-dir=/backup/"+cfg.XRestoreFrom)
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to xtrabackup prepare: %s", err)
+	reportRestoreProgress(restorePhaseDownloading, "1/1")
+	if err := cfg.prepareBackup(targetDir, cfg.copyNFSBackup); err != nil {
+		return err
 	}
-	// Copy the data directory.
-	cmd = exec.Command("xtrabackup", "--defaults-file="+utils.MysqlConfVolumeMountPath+"/my.cnf", "--datadir="+utils.DataVolumeMountPath, "--copy-back", "--target-dir=/backup/"+cfg.XRestoreFrom)
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to xtrabackup copy-back: %s", err)
+	// Copy the data directory, the decrypted backup is prepared in it already.
+	if targetDir != utils.DataVolumeMountPath {
+		cmd = exec.Command("xtrabackup", "--defaults-file="+utils.MysqlConfVolumeMountPath+"/my.cnf", "--datadir="+utils.DataVolumeMountPath, "--copy-back", "--target-dir="+targetDir)
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to xtrabackup copy-back: %s", err)
+		}
 	}
 	// Change owner of data directory
 	log.Info(fmt.Sprintf("change owner of data directory %s", utils.DataVolumeMountPath))
@@ -899,29 +1034,126 @@ func (cfg *Config) ExecuteNFSRestore() error {
 		return fmt.Errorf("failed to chown -R mysql.mysql : %s", err)
 	}
 
-	gtid, _ := GetXtrabackupGTIDPurged(path.Join("/backup/", cfg.XRestoreFrom))
-
+	if !cfg.pitrRequested() {
+		return nil
+	}
+	gtid, err := GetXtrabackupGTIDPurged(targetDir)
+	if err != nil {
+		return fmt.Errorf("failed to get the gtid of the backup: %s", err)
+	}
 	log.Info("get restore gtid:", "gtid", gtid)
 
-	binDir := "/backup/" + cfg.XRestoreFrom + "bin"
-	cmd = exec.Command("cp", "-rf", binDir, utils.InitFileVolumeMountPath)
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to copy binlog to docker-entrypoint-initdb.d : %s", err)
+	binlogDir := path.Join(utils.InitFileVolumeMountPath, buildBinlogDir(cfg.XRestoreFrom))
+	reportRestoreProgress(restorePhaseApplyingBinlogs, "downloading")
+	if len(cfg.RestoreBinlogArchivePrefix) != 0 {
+		s3, err := NewS3(strings.TrimPrefix(strings.TrimPrefix(cfg.XCloudS3EndPoint, "https://"), "http://"),
+			cfg.XCloudS3AccessKey, cfg.XCloudS3SecretKey, cfg.XCloudS3Bucket,
+			strings.HasPrefix(cfg.XCloudS3EndPoint, "https"))
+		if err != nil {
+			return fmt.Errorf("failed to new s3 : %s", err)
+		}
+		if err = os.Mkdir(binlogDir, os.FileMode(0755)); err != nil && !os.IsExist(err) {
+			return fmt.Errorf("error mkdir %s: %s", binlogDir, err)
+		}
+		if err = cfg.downloadBinlogArchive(s3, binlogDir, gtid); err != nil {
+			return fmt.Errorf("failed to download the binlogs: %s", err)
+		}
+	} else {
+		binDir := "/backup/" + cfg.XRestoreFrom + "bin"
+		cmd = exec.Command("cp", "-rf", binDir, utils.InitFileVolumeMountPath)
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to copy binlog to docker-entrypoint-initdb.d : %s", err)
+		}
 	}
 	cmd = exec.Command("chown", "-R", "mysql.mysql", utils.InitFileVolumeMountPath)
 	cmd.Stderr = os.Stderr
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to chown -R mysql.mysql : %s", err)
 	}
-	restorePoint, err := time.Parse("2006-01-02 15:04:05", cfg.RestorePoint)
-	if err != nil {
-		return fmt.Errorf("restore point parse error : %s", err)
+	return cfg.planBinlogRestore(gtid, binlogDir)
+}
+
+// copyNFSBackup copies the backup on NFS to the directory, decrypts and decompresses it.
+func (cfg *Config) copyNFSBackup(backupName, dir string) error {
+	log.Info("copy the backup", "backup", backupName, "dir", dir)
+	cmd := exec.Command("cp", "-r", "/backup/"+backupName+"/.", dir)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to copy the backup: %s", err)
+	}
+	if len(cfg.RestoreEncryptionKey) != 0 || cfg.isBackupCompressed(dir) {
+		return cfg.unpackBackup(dir, len(cfg.RestoreEncryptionKey) != 0)
+	}
+	return nil
+}
+
+// unpackBackup decrypts and decompresses the backup in the directory.
+func (cfg *Config) unpackBackup(dir string, decrypt bool) error {
+	args := []string{
+		fmt.Sprintf("--parallel=%d", cfg.restoreParallel()),
+		"--remove-original",
+		"--target-dir=" + dir,
+	}
+	if decrypt {
+		keyFile, err := writeEncryptionKeyFile(cfg.RestoreEncryptionKey)
+		if err != nil {
+			return fmt.Errorf("failed to write the encryption key: %s", err)
+		}
+		defer os.Remove(keyFile)
+		args = append(args, encryptionArgs("decrypt", restoreEncryptionAlgorithm, keyFile)...)
+	}
+	compressed := cfg.isBackupCompressed(dir)
+	if compressed {
+		args = append(args, "--decompress")
+	}
+	log.Info("unpack the backup", "dir", dir, "decrypt", decrypt, "compressed", compressed)
+	cmd := exec.Command(xtrabackupCommand, args...)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to xtrabackup unpack: %s", err)
 	}
-	cfg.BuildScript(gtid, restorePoint)
 	return nil
 }
 
+// isBackupCompressed checks whether the backup in the directory is compressed. The compression is
+// detected from the xtrabackup_info compressed by xtrabackup if it is not specified.
+func (cfg *Config) isBackupCompressed(dir string) bool {
+	if len(cfg.RestoreCompression) != 0 {
+		return true
+	}
+	for _, suffix := range compressedFileSuffixes {
+		for _, name := range []string{"xtrabackup_info" + suffix, "xtrabackup_info" + suffix + ".xbcrypt"} {
+			if _, err := os.Stat(path.Join(dir, name)); err == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// restoreChain returns the backups to restore in order, from the full base backup to the backup
+// restore from.
+func (cfg *Config) restoreChain() []string {
+	return append(append([]string{}, cfg.RestoreBackupChain...), cfg.XRestoreFrom)
+}
+
+// restoreParallel returns the number of threads to download and unpack the backup.
+func (cfg *Config) restoreParallel() int {
+	if cfg.RestoreParallel > 0 {
+		return cfg.RestoreParallel
+	}
+	return defaultTransferParallel
+}
+
+// prepareMemoryArg returns the argument of the memory used by xtrabackup to prepare the backup.
+func (cfg *Config) prepareMemoryArg() string {
+	if len(cfg.RestorePrepareMemory) != 0 {
+		return "--use-memory=" + cfg.RestorePrepareMemory
+	}
+	return "--use-memory=" + utils.DefaultPrepareMemory
+}
+
 func (cfg *Config) ExecuteRemoteSource() error {
 	// Check /var/lib/mysql exists or not.
 	log.Info("now get data from remote source")
@@ -952,13 +1184,13 @@ func (cfg *Config) ExecuteRemoteSource() error {
 		return fmt.Errorf("failed to rm -rf %s : %s", utils.DataVolumeMountPath, err)
 	}
 	// Prepare the append-only file
-	cmd = exec.Command("xtrabackup", "--defaults-file="+utils.MysqlConfVolumeMountPath+"/my.cnf", "--use-memory=3072M", "--prepare", "--apply-log-only", "--target-dir="+utils.DataVolumeMountPath)
+	cmd = exec.Command("xtrabackup", "--defaults-file="+utils.MysqlConfVolumeMountPath+"/my.cnf", cfg.prepareMemoryArg(), "--prepare", "--apply-log-only", "--target-dir="+utils.DataVolumeMountPath)
 	cmd.Stderr = os.Stderr
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to xtrabackup prepare append-only: %s", err)
 	}
 	// Prepare the data directory
-	cmd = exec.Command("xtrabackup", "--defaults-file="+utils.MysqlConfVolumeMountPath+"/my.cnf", "--use-memory=3072M", "--prepare", "--target-dir="+utils.DataVolumeMountPath)
+	cmd = exec.Command("xtrabackup", "--defaults-file="+utils.MysqlConfVolumeMountPath+"/my.cnf", cfg.prepareMemoryArg(), "--prepare", "--target-dir="+utils.DataVolumeMountPath)
 	cmd.Stderr = os.Stderr
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to xtrabackup prepare: %s", err)
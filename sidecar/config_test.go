@@ -0,0 +1,182 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseXtrabackupCheckpoints(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    *backupCheckpoints
+		wantErr bool
+	}{
+		{
+			name: "full backup",
+			content: "backup_type = full-backuped\nfrom_lsn = 0\nto_lsn = 18509970\n" +
+				"last_lsn = 18509979\nflushed_lsn = 18509970\n",
+			want: &backupCheckpoints{FromLSN: "0", ToLSN: "18509970"},
+		},
+		{
+			name:    "incremental backup",
+			content: "backup_type = incremental\nfrom_lsn = 18509970\nto_lsn = 18510288\nlast_lsn = 18510297\n",
+			want:    &backupCheckpoints{FromLSN: "18509970", ToLSN: "18510288"},
+		},
+		{
+			name:    "no trailing newline and spaces",
+			content: "from_lsn=100\nto_lsn=200",
+			want:    &backupCheckpoints{FromLSN: "100", ToLSN: "200"},
+		},
+		{
+			name:    "missing to_lsn",
+			content: "backup_type = full-backuped\nfrom_lsn = 0\n",
+			wantErr: true,
+		},
+		{
+			name:    "empty",
+			content: "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseXtrabackupCheckpoints([]byte(tt.content))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// fakeXtrabackup replaces xtrabackup by a script which records the arguments of each call.
+func fakeXtrabackup(t *testing.T) string {
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "calls")
+	script := filepath.Join(dir, "xtrabackup")
+	content := "#!/bin/sh\necho \"$@\" >> " + logFile + "\n"
+	if err := os.WriteFile(script, []byte(content), 0755); err != nil {
+		t.Fatal(err)
+	}
+	old := xtrabackupCommand
+	xtrabackupCommand = script
+	t.Cleanup(func() { xtrabackupCommand = old })
+	return logFile
+}
+
+func TestPrepareBackup(t *testing.T) {
+	logFile := fakeXtrabackup(t)
+	cfg := &Config{
+		XRestoreFrom:         "sample_2024",
+		RestoreBackupChain:   []string{"sample_2022", "sample_2023"},
+		RestorePrepareMemory: "512M",
+	}
+	var fetched []string
+	fetchedDirs := map[string]string{}
+	fetch := func(backupName, dir string) error {
+		fetched = append(fetched, backupName)
+		fetchedDirs[backupName] = dir
+		return nil
+	}
+
+	assert.NoError(t, cfg.prepareBackup("/backup/sample_2022", fetch))
+	// The full base backup is in the target dir, the incremental backups are fetched in order.
+	assert.Equal(t, []string{"sample_2023", "sample_2024"}, fetched)
+
+	data, err := os.ReadFile(logFile)
+	assert.NoError(t, err)
+	calls := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Len(t, calls, 4)
+	for _, call := range calls {
+		assert.Contains(t, call, "--use-memory=512M --prepare")
+		assert.Contains(t, call, "--target-dir=/backup/sample_2022")
+	}
+	// Apply the base and the incremental backups with --apply-log-only, then prepare once.
+	assert.Contains(t, calls[0], "--apply-log-only")
+	assert.NotContains(t, calls[0], "--incremental-dir")
+	assert.Contains(t, calls[1], "--apply-log-only --target-dir=/backup/sample_2022 --incremental-dir="+fetchedDirs["sample_2023"])
+	assert.Contains(t, calls[2], "--apply-log-only --target-dir=/backup/sample_2022 --incremental-dir="+fetchedDirs["sample_2024"])
+	assert.NotContains(t, calls[3], "--apply-log-only")
+	assert.NotContains(t, calls[3], "--incremental-dir")
+	// The incremental dirs are removed once applied.
+	for _, dir := range fetchedDirs {
+		_, err := os.Stat(dir)
+		assert.True(t, os.IsNotExist(err))
+	}
+}
+
+func TestPrepareFullBackup(t *testing.T) {
+	logFile := fakeXtrabackup(t)
+	cfg := &Config{XRestoreFrom: "sample_2022"}
+	fetch := func(backupName, dir string) error {
+		t.Errorf("unexpected fetch of %s", backupName)
+		return nil
+	}
+
+	assert.NoError(t, cfg.prepareBackup("/backup/sample_2022", fetch))
+	data, err := os.ReadFile(logFile)
+	assert.NoError(t, err)
+	calls := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Len(t, calls, 2)
+	assert.Contains(t, calls[0], "--use-memory=3072M --prepare --apply-log-only")
+	assert.NotContains(t, calls[1], "--apply-log-only")
+}
+
+func TestXenonPeerAddress(t *testing.T) {
+	cfg := &Config{}
+	assert.Equal(t, "sample-mysql-0.sample-mysql.default:6601", cfg.xenonPeerAddress("sample-mysql-0.sample-mysql.default"))
+
+	// The plain xenon apis are only reachable from the mTLS proxy in the pod.
+	cfg.EncryptInTransit = true
+	assert.Equal(t, "127.0.0.1:6601", cfg.xenonPeerAddress("sample-mysql-0.sample-mysql.default"))
+}
+
+func TestUnpackDetectedCompression(t *testing.T) {
+	logFile := fakeXtrabackup(t)
+	cfg := &Config{}
+	plain, compressed, encrypted := t.TempDir(), t.TempDir(), t.TempDir()
+	for dir, name := range map[string]string{
+		plain:      "xtrabackup_info",
+		compressed: "xtrabackup_info.zst",
+		encrypted:  "xtrabackup_info.qp.xbcrypt",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assert.False(t, cfg.isBackupCompressed(plain))
+	assert.True(t, cfg.isBackupCompressed(compressed))
+	assert.True(t, cfg.isBackupCompressed(encrypted))
+
+	assert.NoError(t, cfg.unpackBackup(compressed, false))
+	data, err := os.ReadFile(logFile)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "--decompress")
+
+	// The specified compression takes precedence.
+	cfg.RestoreCompression = "qpress"
+	assert.True(t, cfg.isBackupCompressed(plain))
+}
@@ -17,9 +17,8 @@ limitations under the License.
 package sidecar
 
 import (
-	"bytes"
 	"context"
-	"io"
+	"encoding/json"
 	"path/filepath"
 
 	"errors"
@@ -68,15 +67,20 @@ func NewInitCommand(cfg *Config) *cobra.Command {
 
 // Check leader or follower backup status is ok.
 func CheckServiceExist(cfg *Config, service string) bool {
-	serviceURL := fmt.Sprintf("http://%s-%s:%v%s", cfg.ClusterName, service, utils.XBackupPort, "/health")
+	serviceURL := prepareURL(fmt.Sprintf("%s-%s", cfg.ClusterName, service), serverProbeEndpoint, cfg.EncryptInTransit)
 	req, err := http.NewRequest("GET", serviceURL, nil)
 	if err != nil {
 		log.Info("failed to check available service", "service", serviceURL, "error", err)
 		return false
 	}
 
+	transport, err := newTransport(cfg.EncryptInTransit)
+	if err != nil {
+		log.Info("failed to check available service", "service", serviceURL, "error", err)
+		return false
+	}
 	client := &http.Client{}
-	client.Transport = transportWithTimeout(serverConnectTimeout)
+	client.Transport = transport
 	resp, err := client.Do(req)
 	if err != nil {
 		log.Info("service was not available", "service", serviceURL, "error", err)
@@ -109,12 +113,12 @@ func runCloneAndInit(cfg *Config) (bool, error) {
 		log.Info("found the rebuild-from pod", "error", err.Error())
 	}
 	if len(serviceURL) == 0 && CheckServiceExist(cfg, "follower") {
-		serviceURL = fmt.Sprintf("http://%s-%s:%v", cfg.ClusterName, "follower", utils.XBackupPort)
+		serviceURL = prepareURL(fmt.Sprintf("%s-%s", cfg.ClusterName, "follower"), "", cfg.EncryptInTransit)
 		server = fmt.Sprintf("%s-%s", cfg.ClusterName, "follower")
 	}
 	//check leader is exist?
 	if len(serviceURL) == 0 && CheckServiceExist(cfg, "leader") {
-		serviceURL = fmt.Sprintf("http://%s-%s:%v", cfg.ClusterName, "leader", utils.XBackupPort)
+		serviceURL = prepareURL(fmt.Sprintf("%s-%s", cfg.ClusterName, "leader"), "", cfg.EncryptInTransit)
 		server = fmt.Sprintf("%s-%s", cfg.ClusterName, "leader")
 	}
 	// Check has initialized. If so just return.
@@ -140,8 +144,8 @@ func runCloneAndInit(cfg *Config) (bool, error) {
 		}
 
 		// backup at first
-		Args := fmt.Sprintf("curl --user $BACKUP_USER:$BACKUP_PASSWORD %s/download|xbstream -x -C %s; exit ${PIPESTATUS[0]}",
-			serviceURL, utils.DataVolumeMountPath)
+		Args := fmt.Sprintf("curl %s%s %s/download|xbstream -x -C %s; exit ${PIPESTATUS[0]}",
+			curlTLSArgs(cfg.EncryptInTransit), utils.CurlBackupAuth, serviceURL, utils.DataVolumeMountPath)
 		cmd := exec.Command("/bin/bash", "-c", "--", Args)
 		log.Info("runCloneAndInit", "cmd", Args)
 		cmd.Stderr = os.Stderr
@@ -412,58 +416,59 @@ func backupBinLogs(cfg *BackupClientConfig, lastBackup, lastgtid string) error {
 }
 
 // Get Last Backup and Gtid
+// It requests the api server in process, so that the token of the service account does not appear
+// in the command line.
 func getLastBackupInfo(cfg *Config) (string, string) {
-	str := `curl -sX GET -H "Authorization: Bearer $(cat /var/run/secrets/kubernetes.io/serviceaccount/token)" -H "Content-Type: application/json"  --cacert /var/run/secrets/kubernetes.io/serviceaccount/ca.crt https://$KUBERNETES_SERVICE_HOST:$KUBERNETES_PORT_443_TCP_PORT/apis/mysql.radondb.com/v1alpha1/namespaces/%s/mysqlclusters/%s |jq '.status.%s'`
-	arg := fmt.Sprintf(str, cfg.NameSpace, cfg.ClusterName, "lastbackup")
-	cmd := exec.Command("sh", "-c", arg)
-	cmd.Stderr = os.Stderr
-	stdout, err := cmd.StdoutPipe()
-	log.Info("getLastBackupInfo", "args", arg)
+	config, err := rest.InClusterConfig()
 	if err != nil {
-		log.Error(err, "failed to create stdout pipe")
-
+		log.Error(err, "failed to get the in cluster config")
 		return "", ""
 	}
-	if err := cmd.Start(); err != nil {
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		log.Error(err, "failed to create the clientset")
 		return "", ""
 	}
-	defer func() {
-		// don't care
-		_ = stdout.Close()
-	}()
-
-	var buf bytes.Buffer
-	io.Copy(&buf, stdout)
-	lastbackup := strings.Trim(buf.String(), "\"\n")
-	log.Info("getLastBackupInfo", "lastbackup", lastbackup)
-	arg = fmt.Sprintf(str, cfg.NameSpace, cfg.ClusterName, "lastbackupGtid")
-
-	cmd = exec.Command("sh", "-c", arg)
-	cmd.Stderr = os.Stderr
-	if stdout, err = cmd.StdoutPipe(); err != nil {
-		log.Info("getLastBackupInfo", "StdoutPipe Error", err.Error())
-		return lastbackup, ""
+	data, err := clientset.Discovery().RESTClient().Get().
+		AbsPath("/apis/mysql.radondb.com/v1alpha1/namespaces", cfg.NameSpace, "mysqlclusters", cfg.ClusterName).
+		DoRaw(context.TODO())
+	if err != nil {
+		log.Error(err, "failed to get the mysql cluster")
+		return "", ""
 	}
-	if err := cmd.Start(); err != nil {
-		return lastbackup, ""
+	var cluster struct {
+		Status struct {
+			LastBackup     string `json:"lastbackup"`
+			LastBackupGtid string `json:"lastbackupGtid"`
+		} `json:"status"`
 	}
-	defer func() {
-		// don't care
-		_ = stdout.Close()
-	}()
-
-	var buf2 bytes.Buffer
-	io.Copy(&buf2, stdout)
-	lastbackupGtid := strings.Trim(buf2.String(), "\"\n")
-	log.Info("getLastBackupInfo", "lastgtid", lastbackupGtid)
-	return lastbackup, lastbackupGtid
+	if err := json.Unmarshal(data, &cluster); err != nil {
+		log.Error(err, "failed to parse the mysql cluster")
+		return "", ""
+	}
+	log.Info("getLastBackupInfo", "lastbackup", cluster.Status.LastBackup, "lastgtid", cluster.Status.LastBackupGtid)
+	return cluster.Status.LastBackup, cluster.Status.LastBackupGtid
 }
 
 // start the backup http server.
 func RunHttpServer(cfg *Config, stop <-chan struct{}) error {
 	//go RunPitr(cfg)
 	srv := newServer(cfg, stop)
-	return srv.ListenAndServe()
+	if !cfg.EncryptInTransit {
+		return srv.ListenAndServe()
+	}
+
+	proxy, err := newXenonProxy(cfg, stop)
+	if err != nil {
+		return fmt.Errorf("failed to create xenon proxy: %s", err)
+	}
+	go func() {
+		if err := proxy.ListenAndServeTLS("", ""); err != nil && err != http.ErrServerClosed {
+			log.Error(err, "xenon proxy server stopped")
+		}
+	}()
+	srv.TLSConfig = newServerTLSConfig(false)
+	return srv.ListenAndServeTLS("", "")
 }
 
 // request a backup command.
@@ -535,7 +540,7 @@ func buildDefaultXenonMeta(uid, gid int) error {
 
 func buildSSLdata() error {
 	// cp -rp /tmp/myssl/* /etc/mysql/ssl/ Refer https://stackoverflow.com/questions/31467153/golang-failed-exec-command-that-works-in-terminal
-	shellCmd := "cp  /tmp/mysql-ssl/* " + utils.TlsMountPath
+	shellCmd := "cp  " + utils.TlsSecretMountPath + "/* " + utils.TlsMountPath
 	cmd := exec.Command("sh", "-c", shellCmd)
 	if err := cmd.Run(); err != nil {
 		return fmt.Errorf("failed to copy ssl: %s", err)
@@ -589,7 +594,7 @@ func getPod(cfg *Config) (string, string, error) {
 		if err := removeRebuildFrom(clientset, cfg, pod.Name); err != nil {
 			log.Info("remove rebuild from", "error", err.Error())
 		}
-		return fmt.Sprintf("%s.%s-mysql.%s:%v", pod.Name, cfg.ClusterName, cfg.NameSpace, utils.XBackupPort),
+		return prepareURL(fmt.Sprintf("%s.%s-mysql.%s", pod.Name, cfg.ClusterName, cfg.NameSpace), "", cfg.EncryptInTransit),
 			fmt.Sprintf("%s.%s-mysql.%s", pod.Name, cfg.ClusterName, cfg.NameSpace), nil
 	} else {
 		return "", "", fmt.Errorf("not correct pod choose")
@@ -605,10 +610,13 @@ func removeRebuildFrom(clientset *kubernetes.Clientset, cfg *Config, podName str
 }
 
 func DoCLone(cfg *Config, server string) error {
+	// The sql contains the password of the donor, so write it to a file only readable by mysql,
+	// instead of the command line of the shell.
+	cloneSQLPath := utils.RadonDBBinDir + "/clone.sql"
 	sql := fmt.Sprintf(`RESET SLAVE ALL;SET GLOBAL clone_valid_donor_list = '%s:3306' ;
 	CLONE INSTANCE FROM '%s'@'%s':3306
-	IDENTIFIED BY '%s';
-`, server, cfg.DonorClone, server, cfg.DonorClonePassword)
+	IDENTIFIED BY '%s'%s;
+`, server, cfg.DonorClone, server, cfg.DonorClonePassword, cloneSSLOption(cfg.EncryptInTransit))
 	cloneSh := fmt.Sprintf(`#!/bin/bash
 echo 'now is doing clone.'
 while true; do
@@ -637,12 +645,16 @@ for i in {500..0}; do
 	echo 'check plugin whether is installed...'
 	sleep 1
 done
-mysql -uroot -hlocalhost  --password="" -e "%s"
+"${mysql[@]}" < %s
+rm -f %s
 wait $pid
 echo "now delete socks file"
 rm -rf /var/lib/mysql/*.sock
 rm -rf /var/lib/mysql/mysql.sock.lock
-`, sql)
+`, cloneSQLPath, cloneSQLPath)
+	if err := writeMysqlOwnedFile(cloneSQLPath, []byte(sql)); err != nil {
+		return fmt.Errorf("failed to write clone.sql: %s", err)
+	}
 	log.Info("write clone shell", "shell", cloneSh)
 	//write to docker.
 	if err := ioutil.WriteFile(utils.RadonDBBinDir+"/clone.sh", []byte(cloneSh), 0755); err != nil {
@@ -40,7 +40,7 @@ type S3struct struct {
 
 // NewS3 return new Manager, useSSL using ssl for connection with storage
 func NewS3(endpoint, accessKeyID, secretAccessKey, bucketName string, sec bool) (*S3struct, error) {
-	log.Info("NewS3", "endpoint", endpoint, "accesskeyId", accessKeyID, "secret", secretAccessKey, "bucketName", bucketName)
+	log.Info("NewS3", "endpoint", endpoint, "bucketName", bucketName)
 	minioClient, err := minio.New(strings.TrimRight(endpoint, "/"), &minio.Options{
 		Creds:  credentials.NewStaticV4(accessKeyID, secretAccessKey, ""),
 		Secure: sec,
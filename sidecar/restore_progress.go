@@ -0,0 +1,83 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+// The phases of the restore reported to the MysqlRestore, they match the phases of its status.
+const (
+	restorePhaseDownloading     = "Downloading"
+	restorePhasePreparing       = "Preparing"
+	restorePhaseApplyingBinlogs = "ApplyingBinlogs"
+)
+
+// serviceAccountNamespaceFile contains the namespace of the pod, the init-mysql container
+// has no NAMESPACE env.
+const serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// reportRestoreProgress records the phase and the progress of the restore in the annotations of
+// the pod, such as "Preparing" and "2/5". The restore goes on if the report fails.
+func reportRestoreProgress(phase, progress string) {
+	if err := patchRestoreAnnotations(phase, progress); err != nil {
+		log.Info("failed to report the restore progress", "phase", phase, "progress", progress, "error", err.Error())
+	}
+}
+
+func patchRestoreAnnotations(phase, progress string) error {
+	podName, err := os.Hostname()
+	if err != nil {
+		return err
+	}
+	namespace, err := os.ReadFile(serviceAccountNamespaceFile)
+	if err != nil {
+		return err
+	}
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return err
+	}
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return err
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"annotations": map[string]string{
+				utils.AnnotationRestorePhase:    phase,
+				utils.AnnotationRestoreProgress: progress,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = clientset.CoreV1().Pods(strings.TrimSpace(string(namespace))).Patch(context.TODO(), podName,
+		types.MergePatchType, patch, v1.PatchOptions{})
+	return err
+}
@@ -111,17 +111,35 @@ func (s *server) backupHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Not authenticated!", http.StatusForbidden)
 		return
 	}
+	if encryptionKeyInPlaintext(r, &requestBody) {
+		http.Error(w, "the encryption key must be sent over https", http.StatusBadRequest)
+		return
+	}
 
 	// /backup only handle S3 backup
 	if requestBody.BackupType == S3 {
 		// run pitr backup first? or later?
 		s.cfg.RunPitrBackupS3(&requestBody)
+		// Keep a copy of the xtrabackup_checkpoints to report the LSNs of the backup.
+		lsnDir, err := os.MkdirTemp("", "lsn-")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer os.RemoveAll(lsnDir)
+		requestBody.XtrabackupExtraArgs = append(requestBody.XtrabackupExtraArgs, "--extra-lsndir="+lsnDir)
 		backName, Datetime, backupSize, gtid, err := RunTakeS3BackupCommand(&requestBody)
 		log.Info("get backup result", "backName", backName, "gtid", gtid)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 		} else {
-			msg, _ := json.Marshal(utils.JsonResult{Status: backupSuccessful, BackupName: backName, Gtid: gtid, Date: Datetime, BackupSize: backupSize})
+			result := utils.JsonResult{Status: backupSuccessful, BackupName: backName, Gtid: gtid, Date: Datetime, BackupSize: backupSize}
+			if checkpoints, err := getXtrabackupCheckpoints(lsnDir); err != nil {
+				log.Error(err, "failed to get the checkpoints of the backup")
+			} else {
+				result.FromLSN, result.ToLSN = checkpoints.FromLSN, checkpoints.ToLSN
+			}
+			msg, _ := json.Marshal(result)
 			w.Write(msg)
 		}
 	}
@@ -141,12 +159,44 @@ func (s *server) backupDownloadHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The NFS backup job posts the encryption of the backup, the clone requests without body.
+	var requestBody BackupClientConfig
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if encryptionKeyInPlaintext(r, &requestBody) {
+		http.Error(w, "the encryption key must be sent over https", http.StatusBadRequest)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/octet-stream")
 	w.Header().Set("Connection", "keep-alive")
 	w.Header().Set("Trailer", backupStatusTrailer)
 
+	optionFile, err := writeOptionFile("xtrabackup", xtrabackupOptions(s.cfg.RootPassword))
+	if err != nil {
+		log.Error(err, "failed to write the option file")
+		http.Error(w, "xtrabackup failed", http.StatusInternalServerError)
+		return
+	}
+	defer os.Remove(optionFile)
+	encryptArgs, keyFile, err := requestBody.XtrabackupEncryptArgs()
+	if err != nil {
+		log.Error(err, "failed to encrypt the backup")
+		http.Error(w, "xtrabackup failed", http.StatusInternalServerError)
+		return
+	}
+	if len(keyFile) != 0 {
+		defer os.Remove(keyFile)
+	}
+
 	// nolint: gosec
-	xtrabackup := exec.Command(xtrabackupCommand, s.cfg.XtrabackupArgs()...)
+	args := append(s.cfg.XtrabackupArgs(optionFile), requestBody.XtrabackupTuningArgs()...)
+	args = append(args, requestBody.XtrabackupIncrementalArgs()...)
+	xtrabackup := exec.Command(xtrabackupCommand, append(args, encryptArgs...)...)
 	xtrabackup.Stderr = os.Stderr
 
 	stdout, err := xtrabackup.StdoutPipe()
@@ -185,6 +235,11 @@ func (s *server) backupDownloadHandler(w http.ResponseWriter, r *http.Request) {
 	flusher.Flush()
 }
 
+// encryptionKeyInPlaintext checks whether the request carries the encryption key of the backup over http.
+func encryptionKeyInPlaintext(r *http.Request, cfg *BackupClientConfig) bool {
+	return r.TLS == nil && (len(cfg.EncryptionAlgorithm) != 0 || len(cfg.EncryptionKey) != 0)
+}
+
 func (s *server) isAuthenticated(r *http.Request) bool {
 	user, pass, ok := r.BasicAuth()
 	return ok && user == s.cfg.BackupUser && pass == s.cfg.BackupPassword
@@ -203,15 +258,15 @@ func maxClients(h http.Handler, n int) http.Handler {
 	})
 }
 
-func prepareURL(svc string, endpoint string) string {
+func prepareURL(svc string, endpoint string, encrypt bool) string {
 	if !strings.Contains(svc, ":") {
 		svc = fmt.Sprintf("%s:%d", svc, serverPort)
 	}
-	return fmt.Sprintf("http://%s%s", svc, endpoint)
+	return fmt.Sprintf("%s://%s%s", serverScheme(encrypt), svc, endpoint)
 }
 
 // Set the timeout for HTTP.
-func transportWithTimeout(connectTimeout time.Duration) http.RoundTripper {
+func transportWithTimeout(connectTimeout time.Duration) *http.Transport {
 	return &http.Transport{
 		Proxy: http.ProxyFromEnvironment,
 		DialContext: (&net.Dialer{
@@ -0,0 +1,149 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecar
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"path"
+
+	"github.com/radondb/radondb-mysql-kubernetes/utils"
+)
+
+var (
+	// The certificates mounted from the tls secret of the cluster.
+	tlsCAPath   = path.Join(utils.TlsSecretMountPath, utils.TlsCAFile)
+	tlsCertPath = path.Join(utils.TlsSecretMountPath, utils.TlsCertFile)
+	tlsKeyPath  = path.Join(utils.TlsSecretMountPath, utils.TlsKeyFile)
+)
+
+// serverScheme returns the scheme of the backup http server.
+func serverScheme(encrypt bool) string {
+	if encrypt {
+		return "https"
+	}
+	return "http"
+}
+
+// curlTLSArgs returns the curl arguments to verify the backup https server with the CA of the cluster.
+func curlTLSArgs(encrypt bool) string {
+	if encrypt {
+		return fmt.Sprintf("--cacert %s ", tlsCAPath)
+	}
+	return ""
+}
+
+// cloneSSLOption returns the option of `CLONE INSTANCE` to require the encrypted connection.
+func cloneSSLOption(encrypt bool) string {
+	if encrypt {
+		return " REQUIRE SSL"
+	}
+	return ""
+}
+
+// loadCertificate loads the server certificate for each handshake, so that the renewed one
+// takes effect without restart.
+func loadCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cert, err := tls.LoadX509KeyPair(tlsCertPath, tlsKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	return &cert, nil
+}
+
+func loadCAPool() (*x509.CertPool, error) {
+	ca, err := os.ReadFile(tlsCAPath)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("failed to parse the CA in %s", tlsCAPath)
+	}
+	return pool, nil
+}
+
+// newServerTLSConfig returns the tls config of the https servers, the clients are required to
+// present the certificates issued by the CA of the cluster if verifyClient is set.
+func newServerTLSConfig(verifyClient bool) *tls.Config {
+	cfg := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: loadCertificate,
+	}
+	if verifyClient {
+		cfg.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			pool, err := loadCAPool()
+			if err != nil {
+				return nil, err
+			}
+			return &tls.Config{
+				MinVersion:     tls.VersionTLS12,
+				GetCertificate: loadCertificate,
+				ClientAuth:     tls.RequireAndVerifyClientCert,
+				ClientCAs:      pool,
+			}, nil
+		}
+	}
+	return cfg
+}
+
+// newTransport returns the transport to the backup http server, which trusts the CA of the cluster if encrypted.
+func newTransport(encrypt bool) (*http.Transport, error) {
+	transport := transportWithTimeout(serverConnectTimeout)
+	if !encrypt {
+		return transport, nil
+	}
+	pool, err := loadCAPool()
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		RootCAs:    pool,
+	}
+	return transport, nil
+}
+
+// newXenonProxy returns the server which proxies the xenon http apis with mTLS.
+func newXenonProxy(cfg *Config, stop <-chan struct{}) (*http.Server, error) {
+	// The xenon http apis only listen on the loopback, see xenonPeerAddress.
+	target, err := url.Parse(fmt.Sprintf("http://127.0.0.1:%d", utils.XenonPeerPort))
+	if err != nil {
+		return nil, err
+	}
+	srv := &http.Server{
+		Addr:      fmt.Sprintf(":%d", utils.XenonTLSPort),
+		Handler:   httputil.NewSingleHostReverseProxy(target),
+		TLSConfig: newServerTLSConfig(true),
+	}
+
+	// Shutdown gracefully the proxy server.
+	go func() {
+		<-stop // wait for stop signal
+		if err := srv.Shutdown(context.Background()); err != nil {
+			log.Error(err, "failed to stop xenon proxy server")
+		}
+	}()
+
+	return srv, nil
+}
@@ -17,8 +17,14 @@ limitations under the License.
 package sidecar
 
 import (
+	"bytes"
+	"fmt"
 	"io"
 	"os"
+	"os/user"
+	"sort"
+	"strconv"
+	"strings"
 
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
@@ -77,6 +83,15 @@ var (
 	// xcloudCommand is the upload tool file name.
 	xcloudCommand = "xbcloud"
 
+	// restoreEncryptionAlgorithm is the algorithm to decrypt the backup which restore from.
+	restoreEncryptionAlgorithm = "AES256"
+
+	// defaultTransferParallel is the default number of threads to transfer the backup by xbcloud.
+	defaultTransferParallel = 10
+
+	// compressedFileSuffixes are the suffixes of the files compressed by xtrabackup.
+	compressedFileSuffixes = []string{".qp", ".lz4", ".zst"}
+
 	// Restore Time Sample
 	RestoreTimeSample = "2006-01-02 15:04:05"
 )
@@ -111,6 +126,99 @@ func copyFile(src, dst string) error {
 	return nil
 }
 
+// optionValueEscaper escapes the quoted values in the option file.
+var optionValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// writeOptionFile writes the options of the group into a temporary option file only readable by
+// the owner, so that the credentials do not appear in the command line. The caller should remove it.
+func writeOptionFile(group string, options map[string]string) (string, error) {
+	keys := make([]string, 0, len(options))
+	for key := range options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "[%s]\n", group)
+	for _, key := range keys {
+		fmt.Fprintf(&buf, "%s=\"%s\"\n", key, optionValueEscaper.Replace(options[key]))
+	}
+
+	f, err := os.CreateTemp("", group+"-*.cnf")
+	if err != nil {
+		return "", err
+	}
+	if _, err = f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err = f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// writeMysqlOwnedFile writes the data into the file only readable by the mysql user.
+func writeMysqlOwnedFile(name string, data []byte) error {
+	mysqlUser, err := user.Lookup("mysql")
+	if err != nil {
+		return fmt.Errorf("failed to get mysql user: %s", err)
+	}
+	uid, err := strconv.Atoi(mysqlUser.Uid)
+	if err != nil {
+		return fmt.Errorf("failed to get mysql user uid: %s", err)
+	}
+	gid, err := strconv.Atoi(mysqlUser.Gid)
+	if err != nil {
+		return fmt.Errorf("failed to get mysql user gid: %s", err)
+	}
+	if err = os.WriteFile(name, data, 0600); err != nil {
+		return err
+	}
+	return os.Chown(name, uid, gid)
+}
+
+// writeEncryptionKeyFile writes the encryption key of the backup to a temporary file,
+// so that the key is kept out of the command line.
+func writeEncryptionKeyFile(key string) (string, error) {
+	f, err := os.CreateTemp("", "backup-key-*")
+	if err != nil {
+		return "", err
+	}
+	// The key file must not end with a newline.
+	if _, err = f.WriteString(strings.TrimSpace(key)); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err = f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// encryptionArgs returns the arguments to encrypt or decrypt the backup, the option is
+// encrypt or decrypt.
+func encryptionArgs(option, algorithm, keyFile string) []string {
+	return []string{
+		fmt.Sprintf("--%s=%s", option, algorithm),
+		fmt.Sprintf("--encrypt-key-file=%s", keyFile),
+	}
+}
+
+// xtrabackupOptions returns the credentials used by xtrabackup to connect mysql.
+func xtrabackupOptions(rootPassword string) map[string]string {
+	return map[string]string{"user": utils.RootUser, "password": rootPassword}
+}
+
+// xcloudOptions returns the credentials used by xbcloud to access s3.
+func xcloudOptions(accessKey, secretKey string) map[string]string {
+	return map[string]string{"s3-access-key": accessKey, "s3-secret-key": secretKey}
+}
+
 // getEnvValue get environment variable by the key.
 func getEnvValue(key string) string {
 	value := os.Getenv(key)
@@ -121,6 +229,33 @@ func getEnvValue(key string) string {
 	return value
 }
 
+// getEnvIntValue get the integer environment variable by the key, returns the default value if
+// it is not set or invalid.
+func getEnvIntValue(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvListValue get the comma separated environment variable by the key, returns nil if it is not set.
+func getEnvListValue(key string) []string {
+	value := os.Getenv(key)
+	if len(value) == 0 {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// xtrabackupCompressAlgorithm returns the compression algorithm of xtrabackup, qpress is named quicklz.
+func xtrabackupCompressAlgorithm(compression string) string {
+	if compression == "qpress" {
+		return "quicklz"
+	}
+	return compression
+}
+
 // checkIfPathExists check if the path exists.
 func checkIfPathExists(path string) (bool, error) {
 	f, err := os.Open(path)
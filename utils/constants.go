@@ -16,7 +16,10 @@ limitations under the License.
 
 package utils
 
-import "net/http"
+import (
+	"net/http"
+	"time"
+)
 
 var (
 	// MySQLDefaultVersion is the version for mysql that should be used
@@ -49,12 +52,17 @@ const (
 	ContainerErrorLogName  = "errorlog"
 	ContainerBackupName    = "backup"
 	ContainerBackupJobName = "backup-job"
+	// The container of the binlog archiver deployment.
+	ContainerBinlogArchiverName = "binlog-archiver"
 
 	// xtrabackup
 	XBackupPortName = "xtrabackup"
 	XBackupPort     = 8082
 	XtrabackupPV    = "backup"
 	XtrabckupLocal  = "/backup"
+	// The curl arguments to pass the backup user and password in the env by the config on stdin,
+	// so that they do not appear in the command line. It only works in bash.
+	CurlBackupAuth = `-K- <<< "user = \"$BACKUP_USER:$BACKUP_PASSWORD\""`
 
 	// MySQL port.
 	MysqlPortName = "mysql"
@@ -68,6 +76,13 @@ const (
 	XenonPortName = "xenon"
 	XenonPort     = 8801
 	XenonPeerPort = 6601
+	// The port of the mTLS proxy for the xenon http apis, enabled when encrypting in transit.
+	XenonTLSPortName = "xenon-tls"
+	XenonTLSPort     = 8802
+
+	// Binlog archiver metrics port.
+	BinlogArchiverPortName = "metrics"
+	BinlogArchiverPort     = 9105
 
 	// The name of the MySQL replication user.
 	ReplicationUser = "radondb_repl"
@@ -82,6 +97,8 @@ const (
 
 	// xtrabackup http server user
 	BackupUser = "sys_backup"
+	// The MySQL user used by the binlog archiver to stream and flush the binlogs of the leader.
+	BinlogArchiverUser = "radondb_archiver"
 
 	// volumes names.
 	MysqlConfVolumeName    = "mysql-conf"
@@ -138,6 +155,12 @@ const (
 	TlsVolumeName = "tls"
 	// TlsMountPath is the volume mount path for tls
 	TlsMountPath = "/etc/mysql-ssl"
+	// TlsSecretMountPath is the volume mount path for the tls secret, the files are copied to TlsMountPath.
+	TlsSecretMountPath = "/tmp/mysql-ssl"
+	// The file names of the CA, server cert and server private key for SSL.
+	TlsCAFile   = "ca.crt"
+	TlsCertFile = "tls.crt"
+	TlsKeyFile  = "tls.key"
 
 	// RemoteSource
 	RemoteSourceVolume = "rsrc"
@@ -183,8 +206,15 @@ const (
 	// XenonMetaData is the name of the configmap that contains xenon metadata.
 	XenonMetaData ResourceName = "xenon-metadata"
 
+	// TLSCASecret is the name of the secret that contains the CA generated by the operator.
+	TLSCASecret ResourceName = "tls-ca"
+	// TLSSecret is the name of the secret that contains the server certificate generated by the operator.
+	TLSSecret ResourceName = "tls"
+
 	// Remote Cluster info
 	RemoteCluster ResourceName = "remote-cluster"
+	// BinlogArchiver is the alias of the deployment which archives the binlogs.
+	BinlogArchiver ResourceName = "binlog-archiver"
 	// Job Annonations name
 	JobAnonationName = "backupName"
 	// Job Annonations date
@@ -195,6 +225,16 @@ const (
 	JobAnonationType = "backupType"
 	// Job Annonations size
 	JobAnonationSize = "backupSize"
+	// Job Annonations encryption key id
+	JobAnonationEncryptionKeyID = "encryptionKeyID"
+	// Job Annonations backups the incremental backup based on
+	JobAnonationBackupChain = "backupChain"
+	// Job Annonations from lsn
+	JobAnonationFromLSN = "fromLSN"
+	// Job Annonations to lsn
+	JobAnonationToLSN = "toLSN"
+	// Job Annonations the node the backup was taken from
+	JobAnonationBackupHost = "backupHost"
 )
 
 // JobType
@@ -215,6 +255,76 @@ const LabelRebuildFrom = "rebuild-from"
 const LabelMaintain = "maintain"
 const LabelTryLeader = "tryleader"
 
+// LabelFenced marks the leader made read-only by the operator before the switchover,
+// the readiness check does not make it writable again.
+const LabelFenced = "fenced"
+
+// AnnotationMysqlVersion records the MySQL version which the configs are generated for.
+const AnnotationMysqlVersion = "mysql.radondb.com/mysql-version"
+
+// AnnotationStaticConfigRev records the revision of the static configs, the pods restart when it changes.
+const AnnotationStaticConfigRev = "mysql.radondb.com/static-config-rev"
+
+// AnnotationTLSRev records the revision of the server certificate, the pods of mysql 5.7 restart when it changes.
+const AnnotationTLSRev = "mysql.radondb.com/tls-rev"
+
+// AnnotationCABundleLoaded records the hash of the CA bundle which is loaded by every node while
+// rotating the generated CA, the server certificate is issued by the new CA after that.
+const AnnotationCABundleLoaded = "mysql.radondb.com/ca-bundle-loaded"
+
+// AnnotationSecretRev records the revision of the secret except the internal credentials,
+// the pods restart when it changes.
+const AnnotationSecretRev = "mysql.radondb.com/secret-rev"
+
+// AnnotationRotateInternalCredentials triggers the rotation of the internal credentials when its value changes.
+const AnnotationRotateInternalCredentials = "mysql.radondb.com/rotate-internal-credentials"
+
+// AnnotationRetryCanary retries the failed canary of the rolling update when its value changes.
+const AnnotationRetryCanary = "mysql.radondb.com/retry-canary"
+
+// AnnotationRestorePhase records the phase of the restore reported by the init containers of the pod.
+const AnnotationRestorePhase = "mysql.radondb.com/restore-phase"
+
+// AnnotationRestoreProgress records the progress of the restore phase, such as "2/5".
+const AnnotationRestoreProgress = "mysql.radondb.com/restore-progress"
+
+// LabelRestore is the name of the MysqlRestore which created the cluster.
+const LabelRestore = "mysql.radondb.com/restore"
+
+const (
+	// DefaultCertValidityDays is the default validity of the generated server certificate.
+	DefaultCertValidityDays = 90
+	// DefaultCertRenewBeforeDays is the default days before the expiry to renew the generated server certificate.
+	DefaultCertRenewBeforeDays = 30
+	// CAValidityDays is the validity of the generated CA.
+	CAValidityDays = 3650
+)
+
+// DefaultPrepareMemory is the default memory used by xtrabackup to prepare the backup the cluster restore from.
+const DefaultPrepareMemory = "3072M"
+
+// DefaultPromoteCatchUpTimeout is the default time to wait for a candidate catching up the leader.
+const DefaultPromoteCatchUpTimeout = 30 * time.Second
+
+// MaxPromoteCatchUpTimeout is the max time to wait for a candidate catching up the leader,
+// the leader is read-only during the wait.
+const MaxPromoteCatchUpTimeout = 300 * time.Second
+
+// ReplicationMode is the replication mode between the leader and the followers.
+type ReplicationMode string
+
+const (
+	// SemiSync waits for the followers' ack, and degrades to async when they are unavailable.
+	SemiSync ReplicationMode = "semiSync"
+	// SemiSyncStrict waits for the followers' ack and never degrades.
+	SemiSyncStrict ReplicationMode = "semiSyncStrict"
+	// Async does not wait for the followers.
+	Async ReplicationMode = "async"
+)
+
+// SemiSyncNoTimeout is the rpl_semi_sync_master_timeout(ms) that makes the leader never time out.
+const SemiSyncNoTimeout = "1000000000000000000"
+
 // XenonHttpUrl is a http url corresponding to the xenon instruction.
 type XenonHttpUrl string
 
@@ -232,6 +342,8 @@ type JsonResult struct {
 	Gtid       string `json:"gtid"`
 	Date       string `json:"date"`
 	BackupSize int64  `json:"backupSize"`
+	FromLSN    string `json:"fromLSN"`
+	ToLSN      string `json:"toLSN"`
 }
 
 // MySQLDefaultVersionMap is a map of supported mysql version and their image
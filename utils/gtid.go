@@ -0,0 +1,182 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var uuidRegexp = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+// GtidInterval is the closed interval of the transaction numbers.
+type GtidInterval struct {
+	Start int64
+	End   int64
+}
+
+// GtidSet is the set of the transactions, the key is the server uuid in lower case and
+// the intervals are sorted and not overlapped.
+type GtidSet map[string][]GtidInterval
+
+// ParseGtid parses a single GTID like "3e11fa47-71ca-11e1-9e33-c80aa9429562:23".
+func ParseGtid(gtid string) (string, int64, error) {
+	parts := strings.Split(strings.TrimSpace(gtid), ":")
+	if len(parts) != 2 {
+		return "", 0, fmt.Errorf("invalid gtid %q", gtid)
+	}
+	uuid := strings.ToLower(parts[0])
+	if !uuidRegexp.MatchString(uuid) {
+		return "", 0, fmt.Errorf("invalid uuid of gtid %q", gtid)
+	}
+	gno, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || gno <= 0 {
+		return "", 0, fmt.Errorf("invalid number of gtid %q", gtid)
+	}
+	return uuid, gno, nil
+}
+
+// ParseGtidSet parses the GTID set in the format of gtid_executed, the empty string is an empty set.
+func ParseGtidSet(set string) (GtidSet, error) {
+	res := GtidSet{}
+	set = strings.Join(strings.Fields(set), "")
+	if len(set) == 0 {
+		return res, nil
+	}
+	for _, item := range strings.Split(set, ",") {
+		parts := strings.Split(item, ":")
+		uuid := strings.ToLower(parts[0])
+		if len(parts) < 2 || !uuidRegexp.MatchString(uuid) {
+			return nil, fmt.Errorf("invalid gtid set %q", item)
+		}
+		for _, interval := range parts[1:] {
+			bounds := strings.SplitN(interval, "-", 2)
+			start, err := strconv.ParseInt(bounds[0], 10, 64)
+			if err != nil || start <= 0 {
+				return nil, fmt.Errorf("invalid gtid interval %q", interval)
+			}
+			end := start
+			if len(bounds) == 2 {
+				if end, err = strconv.ParseInt(bounds[1], 10, 64); err != nil || end < start {
+					return nil, fmt.Errorf("invalid gtid interval %q", interval)
+				}
+			}
+			res.Add(uuid, start, end)
+		}
+	}
+	return res, nil
+}
+
+// Add adds the transactions from start to end of the server into the set.
+func (s GtidSet) Add(uuid string, start, end int64) {
+	uuid = strings.ToLower(uuid)
+	intervals := append(s[uuid], GtidInterval{Start: start, End: end})
+	sort.Slice(intervals, func(i, j int) bool {
+		return intervals[i].Start < intervals[j].Start
+	})
+	merged := intervals[:1]
+	for _, interval := range intervals[1:] {
+		last := &merged[len(merged)-1]
+		if interval.Start <= last.End+1 {
+			if interval.End > last.End {
+				last.End = interval.End
+			}
+			continue
+		}
+		merged = append(merged, interval)
+	}
+	s[uuid] = merged
+}
+
+// Union adds all the transactions of the other set into the set.
+func (s GtidSet) Union(other GtidSet) {
+	for uuid, intervals := range other {
+		for _, interval := range intervals {
+			s.Add(uuid, interval.Start, interval.End)
+		}
+	}
+}
+
+// Contains returns true if the transaction is in the set.
+func (s GtidSet) Contains(uuid string, gno int64) bool {
+	for _, interval := range s[strings.ToLower(uuid)] {
+		if gno >= interval.Start && gno <= interval.End {
+			return true
+		}
+	}
+	return false
+}
+
+// IsSubsetOf returns true if all the transactions of the set are in the other set.
+func (s GtidSet) IsSubsetOf(other GtidSet) bool {
+	for uuid, intervals := range s {
+		for _, interval := range intervals {
+			covered := false
+			for _, o := range other[uuid] {
+				if interval.Start >= o.Start && interval.End <= o.End {
+					covered = true
+					break
+				}
+			}
+			if !covered {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Intersects returns true if any transaction of the other set is in the set.
+func (s GtidSet) Intersects(other GtidSet) bool {
+	for uuid, intervals := range other {
+		for _, interval := range intervals {
+			for _, o := range s[uuid] {
+				if interval.Start <= o.End && o.Start <= interval.End {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// String returns the set in the format of gtid_executed, the servers are sorted.
+func (s GtidSet) String() string {
+	uuids := make([]string, 0, len(s))
+	for uuid, intervals := range s {
+		if len(intervals) != 0 {
+			uuids = append(uuids, uuid)
+		}
+	}
+	sort.Strings(uuids)
+	items := make([]string, 0, len(uuids))
+	for _, uuid := range uuids {
+		item := uuid
+		for _, interval := range s[uuid] {
+			if interval.Start == interval.End {
+				item += fmt.Sprintf(":%d", interval.Start)
+			} else {
+				item += fmt.Sprintf(":%d-%d", interval.Start, interval.End)
+			}
+		}
+		items = append(items, item)
+	}
+	return strings.Join(items, ",")
+}
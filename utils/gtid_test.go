@@ -0,0 +1,82 @@
+/*
+Copyright 2021 RadonDB.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testUUID1 = "3e11fa47-71ca-11e1-9e33-c80aa9429562"
+	testUUID2 = "4e11fa47-71ca-11e1-9e33-c80aa9429562"
+)
+
+func TestParseGtid(t *testing.T) {
+	uuid, gno, err := ParseGtid("3E11FA47-71CA-11E1-9E33-C80AA9429562:23")
+	assert.NoError(t, err)
+	assert.Equal(t, testUUID1, uuid)
+	assert.Equal(t, int64(23), gno)
+
+	for _, invalid := range []string{"", testUUID1, testUUID1 + ":0", testUUID1 + ":1-2", "abc:1"} {
+		_, _, err = ParseGtid(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestParseGtidSet(t *testing.T) {
+	set, err := ParseGtidSet("")
+	assert.NoError(t, err)
+	assert.Equal(t, "", set.String())
+
+	// The intervals are merged and the servers are sorted.
+	set, err = ParseGtidSet(testUUID2 + ":1-3,\n" + testUUID1 + ":5-7:1-4:10," + testUUID2 + ":4")
+	assert.NoError(t, err)
+	assert.Equal(t, testUUID1+":1-7:10,"+testUUID2+":1-4", set.String())
+	assert.True(t, set.Contains(testUUID1, 7))
+	assert.False(t, set.Contains(testUUID1, 8))
+	assert.False(t, set.Contains("5e11fa47-71ca-11e1-9e33-c80aa9429562", 1))
+
+	for _, invalid := range []string{testUUID1, testUUID1 + ":3-1", "abc:1-2", testUUID1 + ":a"} {
+		_, err = ParseGtidSet(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestGtidSetSubsetAndUnion(t *testing.T) {
+	set, _ := ParseGtidSet(testUUID1 + ":1-10")
+	sub, _ := ParseGtidSet(testUUID1 + ":2-5:7")
+	assert.True(t, sub.IsSubsetOf(set))
+	assert.False(t, set.IsSubsetOf(sub))
+	assert.True(t, GtidSet{}.IsSubsetOf(sub))
+
+	other, _ := ParseGtidSet(testUUID1 + ":11-12," + testUUID2 + ":1")
+	assert.False(t, other.IsSubsetOf(set))
+	set.Union(other)
+	assert.Equal(t, testUUID1+":1-12,"+testUUID2+":1", set.String())
+	assert.True(t, other.IsSubsetOf(set))
+}
+
+func TestGtidSetIntersects(t *testing.T) {
+	set, _ := ParseGtidSet(testUUID1 + ":1-5:10-12")
+	gap, _ := ParseGtidSet(testUUID1 + ":6-9," + testUUID2 + ":1-3")
+	assert.False(t, set.Intersects(gap))
+	edge, _ := ParseGtidSet(testUUID1 + ":9-10")
+	assert.True(t, set.Intersects(edge))
+	assert.False(t, set.Intersects(GtidSet{}))
+}
@@ -17,6 +17,9 @@ limitations under the License.
 package utils
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"hash/fnv"
 	"reflect"
@@ -63,3 +66,11 @@ func Hash(s string) (string, error) {
 	}
 	return rand.SafeEncodeString(fmt.Sprint(hash.Sum32())), nil
 }
+
+// HMAC calculates the HMAC-SHA256 of the string with the key, it identifies a secret
+// value without exposing it to the readers who do not have the key.
+func HMAC(key []byte, s string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(s))
+	return hex.EncodeToString(mac.Sum(nil))
+}